@@ -1,31 +1,79 @@
 package main
 
 import (
-	"encoding/hex"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
+
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/backend/pkg/protoresp"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // API handles HTTP requests for attester operations
 type API struct {
-	issuerService     *IssuerService
+	issuerService *IssuerService
+	// revocationService is this attester's own tree, served by the unscoped /revocation routes
 	revocationService *RevocationService
-	signer            *Signer
-	config            *Config
+	revocations       *RevocationRegistry
+	// attestations records signed attestations so revocation can invalidate them
+	attestations *AttestationLog
+	// revocationPublisher, when set, is notified of every revocation root change
+	revocationPublisher *RevocationPublisher
+	// nextID caches discovery of the next available attester ID, which queries the registry
+	nextID *NextIDCache
+	// hiroProbe, when set, tracks Hiro reachability for the hiro health check
+	hiroProbe *HiroProbe
+	signer    *Signer
+	config    *Config
 }
 
-// NewAPI creates a new API handler
-func NewAPI(signer *Signer) *API {
+// NewAPI creates a new API handler from a config main has validated
+func NewAPI(signer *Signer, config *Config) (*API, error) {
+	issuerService, err := NewIssuerService(signer, config)
+	if err != nil {
+		return nil, err
+	}
+	ownIssuer := strconv.FormatUint(uint64(signer.GetAttesterID()), 10)
+	revocations := NewRevocationRegistry(append([]string{ownIssuer}, config.RevocationIssuers...), config.HashDomain)
+	revocationService, _ := revocations.Tree(ownIssuer)
+	nextID := NewNextIDCache(func(ctx context.Context) (uint, error) {
+		return findAvailableAttesterID(ctx, config.StacksAPI(), config.AttesterRegistry, config.AttesterPubkeyFunction, signer.GetAttesterID(), config.DiscoveryMaxAttempts)
+	}, time.Duration(config.NextIDCacheSeconds)*time.Second, time.Duration(config.NextIDTimeoutSeconds)*time.Second)
+
 	return &API{
-		issuerService:     NewIssuerService(signer),
-		revocationService: NewRevocationService(),
+		issuerService:     issuerService,
+		revocationService: revocationService,
+		revocations:       revocations,
+		attestations:      NewAttestationLog(DefaultAttestationLogSize),
+		nextID:            nextID,
 		signer:            signer,
-		config:            LoadConfig(),
+		config:            config,
+	}, nil
+}
+
+// revocationTree returns the tree named by the :issuer path parameter, or this
+// attester's own tree on unscoped routes; an unknown issuer aborts with 404
+func (api *API) revocationTree(c *gin.Context) (*RevocationService, bool) {
+	issuer := c.Param("issuer")
+	if issuer == "" {
+		return api.revocationService, true
+	}
+	tree, ok := api.revocations.Tree(issuer)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown revocation issuer %q", issuer),
+		})
 	}
+	return tree, ok
 }
 
 // IssueCredential handles credential issuance requests
@@ -40,6 +88,20 @@ func (api *API) IssueCredential(c *gin.Context) {
 	}
 
 	credential, err := api.issuerService.IssueCredential(&req)
+	if errors.Is(err, ErrInvalidUserID) || errors.Is(err, ErrInvalidAttributes) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrCommitmentExists) || errors.Is(err, ErrCredentialExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -48,9 +110,10 @@ func (api *API) IssueCredential(c *gin.Context) {
 		return
 	}
 
+	// Attributes the client sent are not echoed back when configured as sensitive
 	c.JSON(http.StatusOK, gin.H{
 		"success":    true,
-		"credential": credential,
+		"credential": credential.redacted(api.config.RedactedAttributes),
 	})
 }
 
@@ -65,16 +128,107 @@ func (api *API) CreateAttestation(c *gin.Context) {
 		return
 	}
 
-	response, err := api.issuerService.CreateAttestation(&req)
+	if req.Bundle != nil {
+		if err := req.applyBundle(); err != nil {
+			c.JSON(http.StatusBadRequest, AttestationResponse{
+				Success: false,
+				Error:   "Invalid bundle: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	// The proof format may be given in the body or as a query parameter
+	if req.Format == "" {
+		req.Format = c.Query("format")
+	}
+
+	if !SupportsProofSystem(req.ProofSystem) {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeProofSystemNotAccepted,
+			fmt.Sprintf("Proof system %q is not accepted (expected %s)", req.ProofSystem, ProofSystemGroth16))
+		return
+	}
+
+	response, err := api.issuerService.CreateAttestation(c.Request.Context(), &req)
+	if errors.Is(err, ErrCheckFailed) {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeCheckFailed, response.Error)
+		return
+	}
+	if errors.Is(err, ErrUntrustedJurisdictionRoot) {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeUntrustedJurisdictionRoot, response.Error)
+		return
+	}
+	if errors.Is(err, ErrProofReplayed) {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeProofReplayed, response.Error)
+		return
+	}
+	if errors.Is(err, ErrReplayCacheFull) {
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeReplayCacheFull, response.Error)
+		return
+	}
+	if code := inputErrorCode(err); code != "" {
+		apierror.Abort(c, http.StatusBadRequest, code, response.Error)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, AttestationResponse{
+		// Rejected proofs and parameters are client errors; anything else is ours
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidAttestation) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	api.attestations.Record(response)
+	protoresp.OK(c, response)
+}
+
+// VerifyProof verifies a proof without signing it, so clients can debug proofs the attest
+// endpoint rejects; failed input checks report the offending input with a targeted code
+// POST /proof/verify
+func (api *API) VerifyProof(c *gin.Context) {
+	var req ProofVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ProofVerificationResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   "Invalid request: " + err.Error(),
 		})
 		return
 	}
+	if req.Format == "" {
+		req.Format = c.Query("format")
+	}
 
-	c.JSON(http.StatusOK, response)
+	verifier := api.issuerService.verifier
+	err := verifier.CheckPublicInputs(req.CircuitType, req.Commitment, req.PublicInputs)
+	if err == nil {
+		_, err = api.issuerService.VerifyProof(c.Request.Context(), req.Proof, req.Format, req.CircuitVersion, req.CircuitType, req.PublicInputs)
+	}
+	if code := inputErrorCode(err); code != "" {
+		apierror.Abort(c, http.StatusBadRequest, code, err.Error())
+		return
+	}
+	if errors.Is(err, ErrCheckFailed) {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeCheckFailed, err.Error())
+		return
+	}
+	if errors.Is(err, ErrVerifierUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, ProofVerificationResponse{
+			Success: false,
+			Error:   "Proof verifier unavailable",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ProofVerificationResponse{
+			Success: false,
+			Error:   "Proof verification failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ProofVerificationResponse{Success: true, Valid: true})
 }
 
 // RevokeCredential handles credential revocation requests
@@ -88,7 +242,12 @@ func (api *API) RevokeCredential(c *gin.Context) {
 		return
 	}
 
-	if err := api.revocationService.RevokeCredential(req.Commitment); err != nil {
+	tree, ok := api.revocationTree(c)
+	if !ok {
+		return
+	}
+
+	if err := tree.RevokeCredential(req.Commitment); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -96,18 +255,37 @@ func (api *API) RevokeCredential(c *gin.Context) {
 		return
 	}
 
+	// Attestations already signed for the commitment no longer hold
+	invalidated := api.attestations.Revoke(req.Commitment)
+	logger.Info("Revoked credential",
+		commitmentField("commitment", req.Commitment, api.config.LogCommitmentMode),
+		zap.String("issuer", c.Param("issuer")),
+		zap.Int("invalidated_attestations", invalidated),
+	)
+
+	// Only this attester's own root is published on-chain
+	root := tree.GetRevocationRoot()
+	if api.revocationPublisher != nil && tree == api.revocationService {
+		api.revocationPublisher.Notify(root)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Credential revoked",
-		"root":    api.revocationService.GetRevocationRoot(),
+		"root":    root,
 	})
 }
 
 // GetRevocationRoot returns the current revocation Merkle root
 func (api *API) GetRevocationRoot(c *gin.Context) {
+	tree, ok := api.revocationTree(c)
+	if !ok {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"root":  api.revocationService.GetRevocationRoot(),
-		"count": api.revocationService.GetRevokedCount(),
+		"root":  tree.GetRevocationRoot(),
+		"count": tree.GetRevokedCount(),
 	})
 }
 
@@ -122,13 +300,273 @@ func (api *API) CheckRevocationStatus(c *gin.Context) {
 		return
 	}
 
-	isRevoked := api.revocationService.IsRevoked(commitment)
+	tree, ok := api.revocationTree(c)
+	if !ok {
+		return
+	}
+
+	isRevoked := tree.IsRevoked(commitment)
 	c.JSON(http.StatusOK, gin.H{
 		"commitment": commitment,
 		"revoked":    isRevoked,
 	})
 }
 
+// VerifyRevocationProof checks a revocation tree Merkle proof against its root and the
+// tree's current root in one call
+// POST /revocation/verify-proof
+func (api *API) VerifyRevocationProof(c *gin.Context) {
+	var req RevocationProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	tree, ok := api.revocationTree(c)
+	if !ok {
+		return
+	}
+
+	valid, current := tree.VerifyProof(req.Commitment, req.Proof, req.Indices, req.Root)
+	c.JSON(http.StatusOK, RevocationProofResponse{
+		Valid:       valid,
+		RootCurrent: current,
+	})
+}
+
+// publisher returns the revocation root publisher, or aborts with 404 when publishing is off
+func (api *API) publisher(c *gin.Context) (*RevocationPublisher, bool) {
+	if api.revocationPublisher == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "revocation root publishing is not enabled",
+		})
+		return nil, false
+	}
+	return api.revocationPublisher, true
+}
+
+// GetPublishDeadLetters lists the revocation roots abandoned after exhausting retries
+// GET /admin/revocation/dead-letters
+func (api *API) GetPublishDeadLetters(c *gin.Context) {
+	publisher, ok := api.publisher(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": publisher.DeadLetters(),
+		"published":    publisher.Published(),
+	})
+}
+
+// RetryPublishDeadLetters clears the dead letters and publishes the latest root again
+// POST /admin/revocation/dead-letters/retry
+func (api *API) RetryPublishDeadLetters(c *gin.Context) {
+	publisher, ok := api.publisher(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"root":    publisher.Retry(),
+	})
+}
+
+// Reverify checks a batch of proof bundles against the verifying key of a target circuit
+// version, so operators can see which proofs survive a circuit upgrade before cutting over
+// POST /admin/reverify
+func (api *API) Reverify(c *gin.Context) {
+	var req ReverifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+	if len(req.Bundles) > api.config.ReverifyMaxBundles {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("at most %d bundles may be re-verified at once", api.config.ReverifyMaxBundles),
+		})
+		return
+	}
+
+	verifier := api.issuerService.verifier
+	if err := verifier.Initialize(); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "Proof verifier unavailable",
+		})
+		return
+	}
+	if !verifier.HasVersion(req.CircuitVersion) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("unknown circuit version %s", req.CircuitVersion),
+		})
+		return
+	}
+
+	response := ReverifyResponse{
+		Success:        true,
+		CircuitVersion: req.CircuitVersion,
+		Results:        make([]ReverifyResult, len(req.Bundles)),
+	}
+	for i, bundle := range req.Bundles {
+		result := ReverifyResult{Index: i}
+		var err error
+		switch {
+		case bundle == nil:
+			err = fmt.Errorf("missing bundle")
+		case bundle.Validate() != nil:
+			err = bundle.Validate()
+		case !SupportsProofSystem(bundle.ProofSystem):
+			err = fmt.Errorf("unsupported proof system %q", bundle.ProofSystem)
+		default:
+			result.Valid, err = verifier.VerifyProofWithVersion(bundle.EncodedProof(), proofformat.Base64, req.CircuitVersion, bundle.PublicInputs)
+		}
+		if err != nil {
+			result.Valid = false
+			result.Error = err.Error()
+		}
+		if result.Valid {
+			response.Passed++
+		} else {
+			response.Failed++
+		}
+		response.Results[i] = result
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RebuildRevocations replaces a revocation tree wholesale with a supplied revoked set,
+// for disaster recovery from an external copy of the list
+// POST /admin/revocation/rebuild
+func (api *API) RebuildRevocations(c *gin.Context) {
+	var req RevocationRebuildRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	tree, ok := api.revocationTree(c)
+	if !ok {
+		return
+	}
+
+	root, err := tree.Rebuild(req.Commitments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// Attestations follow the new set, including commitments the rebuild dropped
+	revoked, restored := api.attestations.Reconcile(api.revocations.IsRevoked)
+	logger.Info("Rebuilt revocation tree",
+		zap.String("issuer", c.Param("issuer")),
+		zap.Int("count", len(req.Commitments)),
+		zap.Int("invalidated_attestations", revoked),
+		zap.Int("restored_attestations", restored),
+	)
+	if api.revocationPublisher != nil && tree == api.revocationService {
+		api.revocationPublisher.Notify(root)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"root":    root,
+		"count":   len(req.Commitments),
+	})
+}
+
+// Page sizes for /revocation/export
+const (
+	revocationExportDefaultLimit = 1000
+	revocationExportMaxLimit     = 10000
+)
+
+// ExportRevocations serves the revoked commitments as a downloadable JSON file, one page
+// at a time; follow next_offset until it is omitted to fetch the whole set
+// GET /revocation/export?offset=0&limit=1000
+func (api *API) ExportRevocations(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "offset must be a non-negative integer",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(revocationExportDefaultLimit)))
+	if err != nil || limit < 1 || limit > revocationExportMaxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("limit must be between 1 and %d", revocationExportMaxLimit),
+		})
+		return
+	}
+
+	tree, ok := api.revocationTree(c)
+	if !ok {
+		return
+	}
+
+	commitments, total := tree.RevokedCommitments(offset, limit)
+	export := RevocationExport{
+		Commitments: commitments,
+		Root:        tree.GetRevocationRoot(),
+		Total:       total,
+		Offset:      offset,
+		ExportedAt:  time.Now().Unix(),
+	}
+	if next := offset + len(commitments); next < total {
+		export.NextOffset = next
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="revocations.json"`)
+	c.JSON(http.StatusOK, export)
+}
+
+// GetAttestationStatus reports the attestations signed for a commitment and whether
+// its revocation has invalidated them
+// GET /attestation/status?commitment=0x...
+func (api *API) GetAttestationStatus(c *gin.Context) {
+	commitment := c.Query("commitment")
+	if commitment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "commitment query parameter is required",
+		})
+		return
+	}
+
+	records := api.attestations.Lookup(commitment)
+	if len(records) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "no attestations recorded for commitment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"commitment":   commitment,
+		"status":       attestationStatus(records),
+		"attestations": records,
+	})
+}
+
 // HealthCheck returns service health status
 func (api *API) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -139,16 +577,47 @@ func (api *API) HealthCheck(c *gin.Context) {
 
 // GetAttesterInfo returns the attester ID and public key
 func (api *API) GetAttesterInfo(c *gin.Context) {
+	// public_key and hash_algo always describe the secp256k1 key the registry knows;
+	// signature_scheme says which key signs attestations
+	info := gin.H{
+		"attester_id":       api.signer.GetAttesterID(),
+		"public_key":        api.signer.GetPublicKey(),
+		"public_key_scheme": SignatureSchemeSecp256k1,
+		"issuer_name":       api.config.IssuerName,
+		"issuer_url":        api.config.IssuerURL,
+		"hash_algo":         api.config.SignHashAlgo,
+		"signature_scheme":  api.config.SignatureScheme,
+	}
+	// Attestations are signed with the EdDSA key, which circuits check them against
+	if eddsaSigner := api.issuerService.eddsa; eddsaSigner != nil {
+		info["eddsa_public_key"] = eddsaSigner.GetPublicKey()
+		info["eddsa_hash_algo"] = HashAlgoMiMC
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// GetClarityPublicKey returns the public key as the (buff 33) literal the attester
+// registry's add-attester call takes, ready to paste into a contract call
+func (api *API) GetClarityPublicKey(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"attester_id": api.signer.GetAttesterID(),
-		"public_key":  api.signer.GetPublicKey(),
+		"public_key":  "0x" + api.signer.GetPublicKey(),
+		"type":        "(buff 33)",
 	})
 }
 
 // GetNextAvailableID finds the next available attester ID by querying the contract
 // Starts from the backend's configured ID and increments until finding an available one
+// The result is cached briefly; if discovery fails or is slow, the last discovered ID
+// is returned with stale set
 func (api *API) GetNextAvailableID(c *gin.Context) {
-	nextID, err := api.findNextAvailableID()
+	nextID, stale, err := api.nextID.Get(c.Request.Context())
+	if errors.Is(err, errNextIDPending) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Failed to find next available ID: " + err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to find next available ID: " + err.Error(),
@@ -159,70 +628,6 @@ func (api *API) GetNextAvailableID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"next_available_id": nextID,
 		"suggested_id":      nextID,
+		"stale":             stale,
 	})
 }
-
-// findNextAvailableID queries the contract to find the next available attester ID
-func (api *API) findNextAvailableID() (uint, error) {
-	startID := api.signer.GetAttesterID()
-	maxAttempts := uint(100) // Limit search to prevent infinite loops
-
-	// Parse contract address
-	parts := strings.Split(api.config.AttesterRegistry, ".")
-	if len(parts) != 2 {
-		return 0, fmt.Errorf("invalid contract address format: %s", api.config.AttesterRegistry)
-	}
-	contractAddress := parts[0]
-	contractName := parts[1]
-
-	// Determine API URL based on network
-	apiURL := "https://api.testnet.hiro.so/v2"
-	if api.config.StacksNetwork == "mainnet" {
-		apiURL = "https://api.hiro.so/v2"
-	}
-
-	// Try IDs starting from the configured ID
-	for i := uint(0); i < maxAttempts; i++ {
-		testID := startID + i
-		
-		// Encode ID as Clarity uint (little-endian, 8 bytes)
-		idBytes := make([]byte, 8)
-		idBytes[0] = byte(testID)
-		idBytes[1] = byte(testID >> 8)
-		idBytes[2] = byte(testID >> 16)
-		idBytes[3] = byte(testID >> 24)
-		idBytes[4] = byte(testID >> 32)
-		idBytes[5] = byte(testID >> 40)
-		idBytes[6] = byte(testID >> 48)
-		idBytes[7] = byte(testID >> 56)
-		idHex := "0x01000000000000000000000000000000" + hex.EncodeToString(idBytes)
-
-		// Call contract read-only function
-		url := fmt.Sprintf("%s/contracts/call-read/%s/%s/get-attester-pubkey", apiURL, contractAddress, contractName)
-		payload := fmt.Sprintf(`{"sender": "%s", "arguments": ["%s"]}`, contractAddress, idHex)
-
-		resp, err := http.Post(url, "application/json", strings.NewReader(payload))
-		if err != nil {
-			return 0, fmt.Errorf("failed to query contract: %w", err)
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return 0, fmt.Errorf("failed to read response: %w", err)
-		}
-
-		// If response contains error (attester not found), this ID is available
-		bodyStr := string(body)
-		if strings.Contains(bodyStr, "ERR_ATTESTER_NOT_FOUND") || 
-		   strings.Contains(bodyStr, "u1003") ||
-		   !strings.Contains(bodyStr, `"okay":true`) {
-			// ID is not found, so it's available
-			return testID, nil
-		}
-	}
-
-	// If we've tried many IDs and all are taken, return an error
-	return 0, fmt.Errorf("could not find available ID after %d attempts", maxAttempts)
-}
-
@@ -0,0 +1,1099 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/apispec"
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/middleware"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/gin-gonic/gin"
+)
+
+// proofFixture holds a verifying key on disk and a valid proof for it
+type proofFixture struct {
+	vkPath       string
+	proof        string
+	publicInputs []string
+	commitment   string
+	// Compiled circuit and full witness, for tests that need further key pairs
+	ccs     constraint.ConstraintSystem
+	witness witness.Witness
+	// Proving key and assignment, for tests that prove other private inputs
+	pk         groth16.ProvingKey
+	assignment circuit.KYCCircuit
+}
+
+const testMerkleDepth = 20
+
+var (
+	testKeyDir  string
+	fixtureOnce sync.Once
+	fixture     *proofFixture
+	fixtureErr  error
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := logger.Initialize(logger.Config{Level: "error", Service: "attester-test"}); err != nil {
+		panic(err)
+	}
+
+	dir, err := os.MkdirTemp("", "attester-keys-")
+	if err != nil {
+		panic(err)
+	}
+	testKeyDir = dir
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testMiMC hashes the given field elements with MiMC as the circuit does
+func testMiMC(values ...*big.Int) *big.Int {
+	h := hash.MIMC_BN254.New()
+	for _, v := range values {
+		var e fr.Element
+		e.SetBigInt(v)
+		b := e.Bytes()
+		h.Write(b[:])
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// hexInput encodes a public input the way the prover does (even-length hex)
+func hexInput(v *big.Int) string {
+	return padHex(v.Text(16))
+}
+
+// newProofFixture compiles the KYC circuit, generates keys and a valid proof
+// Setup is expensive, so the fixture is shared by all tests in the package
+func newProofFixture(t *testing.T) *proofFixture {
+	t.Helper()
+	fixtureOnce.Do(func() {
+		fixture, fixtureErr = buildProofFixture()
+	})
+	if fixtureErr != nil {
+		t.Fatalf("Failed to build proof fixture: %v", fixtureErr)
+	}
+	return fixture
+}
+
+func buildProofFixture() (*proofFixture, error) {
+	field := ecc.BN254.ScalarField()
+	ccs, err := frontend.Compile(field, r1cs.NewBuilder, &circuit.KYCCircuit{
+		MerklePath:   make([]frontend.Variable, testMerkleDepth),
+		MerkleHelper: make([]frontend.Variable, testMerkleDepth),
+	})
+	if err != nil {
+		return nil, err
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return nil, err
+	}
+
+	vkPath := filepath.Join(testKeyDir, "verifying.key")
+	var vkBuf bytes.Buffer
+	if _, err := vk.WriteTo(&vkBuf); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(vkPath, vkBuf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+
+	// Jurisdiction 1 at index 0 of a tree whose siblings are all zero
+	jurisdiction := big.NewInt(1)
+	path := make([]frontend.Variable, testMerkleDepth)
+	helper := make([]frontend.Variable, testMerkleDepth)
+	root := testMiMC(jurisdiction)
+	for i := 0; i < testMerkleDepth; i++ {
+		path[i] = 0
+		helper[i] = 0
+		root = testMiMC(root, big.NewInt(0))
+	}
+
+	identityData, nonce := big.NewInt(12345), big.NewInt(67890)
+	commitment := testMiMC(identityData, nonce)
+
+	assignment := &circuit.KYCCircuit{
+		Age:                  25,
+		Jurisdiction:         jurisdiction,
+		IsAccredited:         1,
+		IdentityData:         identityData,
+		Nonce:                nonce,
+		MerklePath:           path,
+		MerkleHelper:         helper,
+		MinAge:               18,
+		JurisdictionRoot:     root,
+		RequireAccreditation: 1,
+		Commitment:           commitment,
+		RelyingPartyID:       0,
+	}
+	fullWitness, err := frontend.NewWitness(assignment, field)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		return nil, err
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		return nil, err
+	}
+
+	commitmentBytes := make([]byte, 32)
+	commitment.FillBytes(commitmentBytes)
+
+	return &proofFixture{
+		vkPath: vkPath,
+		proof:  base64.StdEncoding.EncodeToString(proofBuf.Bytes()),
+		publicInputs: []string{
+			hexInput(big.NewInt(18)),
+			hexInput(root),
+			hexInput(big.NewInt(1)),
+			hexInput(commitment),
+			hexInput(big.NewInt(0)),
+		},
+		commitment: hex.EncodeToString(commitmentBytes),
+		ccs:        ccs,
+		witness:    fullWitness,
+		pk:         pk,
+		assignment: *assignment,
+	}, nil
+}
+
+// newTestAPI creates an API backed by the fixture verifying key and a deterministic signer
+func newTestAPI(t *testing.T) *API {
+	t.Helper()
+	f := newProofFixture(t)
+	t.Setenv("VERIFYING_KEY_PATH", f.vkPath)
+
+	signer, err := NewSignerFromSeed([]byte("noah-api-test"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	api, err := NewAPI(signer, config)
+	if err != nil {
+		t.Fatalf("Failed to create API: %v", err)
+	}
+	return api
+}
+
+// doJSON performs a request against the handler and decodes the JSON response
+func doJSON(t *testing.T, handler http.Handler, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// TestIssuerMetadata tests that issuer metadata appears in attestations and attester info
+func TestIssuerMetadata(t *testing.T) {
+	f := newProofFixture(t)
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantName string
+		wantURL  string
+	}{
+		{"defaults", nil, "Noah Attester", ""},
+		{"configured", map[string]string{"ISSUER_NAME": "Acme KYC", "ISSUER_URL": "https://kyc.acme.example"}, "Acme KYC", "https://kyc.acme.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ISSUER_NAME", "")
+			t.Setenv("ISSUER_URL", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			api := newTestAPI(t)
+
+			router := gin.New()
+			router.GET("/info", api.GetAttesterInfo)
+			router.POST("/credential/attest", api.CreateAttestation)
+
+			var info map[string]interface{}
+			if code := doJSON(t, router, http.MethodGet, "/info", nil, &info); code != http.StatusOK {
+				t.Fatalf("Expected 200 from /info, got %d", code)
+			}
+			if info["issuer_name"] != tt.wantName || info["issuer_url"] != tt.wantURL {
+				t.Errorf("Expected info issuer %q/%q, got %v/%v", tt.wantName, tt.wantURL, info["issuer_name"], info["issuer_url"])
+			}
+
+			var resp AttestationResponse
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", AttestationRequest{
+				Commitment:   f.commitment,
+				PublicInputs: f.publicInputs,
+				Proof:        f.proof,
+			}, &resp)
+			if code != http.StatusOK || !resp.Success {
+				t.Fatalf("Expected successful attestation, got %d: %s", code, resp.Error)
+			}
+			if resp.IssuerName != tt.wantName || resp.IssuerURL != tt.wantURL {
+				t.Errorf("Expected attestation issuer %q/%q, got %q/%q", tt.wantName, tt.wantURL, resp.IssuerName, resp.IssuerURL)
+			}
+		})
+	}
+}
+
+// TestUnknownRouteAndWrongMethod tests the JSON error bodies for 404 and 405
+func TestUnknownRouteAndWrongMethod(t *testing.T) {
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	var notFound apierror.APIError
+	if code := doJSON(t, router, http.MethodGet, "/does-not-exist", nil, &notFound); code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", code)
+	}
+	if notFound.Code != apierror.CodeNotFound || notFound.Success {
+		t.Errorf("Expected %s error, got %+v", apierror.CodeNotFound, notFound)
+	}
+
+	var wrongMethod apierror.APIError
+	if code := doJSON(t, router, http.MethodGet, "/credential/attest", nil, &wrongMethod); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", code)
+	}
+	if wrongMethod.Code != apierror.CodeMethodNotAllowed {
+		t.Errorf("Expected %s error, got %+v", apierror.CodeMethodNotAllowed, wrongMethod)
+	}
+}
+
+// TestCreateAttestationErrorStatus tests the status code and message for each failure path
+func TestCreateAttestationErrorStatus(t *testing.T) {
+	f := newProofFixture(t)
+
+	tampered := append([]string{}, f.publicInputs...)
+	tampered[0] = "63"
+	otherCommitment := append([]string{}, f.publicInputs...)
+	otherCommitment[commitmentInputIndex] = "01"
+
+	tests := []struct {
+		name        string
+		hashAlgo    string
+		req         AttestationRequest
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:        "tampered public inputs",
+			req:         AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: tampered},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Proof verification failed: invalid proof",
+		},
+		{
+			name:        "proof of another commitment",
+			req:         AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: otherCommitment},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Proof verification failed: commitment mismatch",
+		},
+		{
+			name:        "malformed proof",
+			req:         AttestationRequest{Commitment: f.commitment, Proof: "not-a-proof", PublicInputs: f.publicInputs},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Proof verification failed: failed to decode proof",
+		},
+		{
+			name:        "invalid commitment",
+			req:         AttestationRequest{Commitment: "zz", Proof: f.proof, PublicInputs: f.publicInputs},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid commitment hex",
+		},
+		{
+			name:        "unknown commitment version",
+			req:         AttestationRequest{Commitment: "7f" + f.commitment, Proof: f.proof, PublicInputs: f.publicInputs},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "unknown commitment version",
+		},
+		{
+			name:        "validity over maximum",
+			req:         AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: f.publicInputs, ValiditySeconds: 1 << 40},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "validity_seconds",
+		},
+		{
+			name:        "signing failure",
+			hashAlgo:    "md5",
+			req:         AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: f.publicInputs},
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "Signature generation failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			if tt.hashAlgo != "" {
+				api.issuerService.config.SignHashAlgo = tt.hashAlgo
+			}
+			router := gin.New()
+			router.POST("/credential/attest", api.CreateAttestation)
+
+			var resp AttestationResponse
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", tt.req, &resp)
+			if code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, code, resp.Error)
+			}
+			if resp.Success {
+				t.Error("Expected success=false")
+			}
+			if !strings.HasPrefix(resp.Error, tt.wantMessage) {
+				t.Errorf("Expected error starting with %q, got %q", tt.wantMessage, resp.Error)
+			}
+			if strings.Count(strings.ToLower(resp.Error), "verification failed") > 1 {
+				t.Errorf("Expected a single verification failure message, got %q", resp.Error)
+			}
+		})
+	}
+}
+
+// TestCreateAttestationRelyingPartyBinding tests that a proof bound to one relying party
+// is refused when presented for another
+func TestCreateAttestationRelyingPartyBinding(t *testing.T) {
+	f := newProofFixture(t)
+
+	// Prove the fixture witness again, bound to party 7
+	assignment := f.assignment
+	assignment.RelyingPartyID = 7
+	fullWitness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("Failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(f.ccs, f.pk, fullWitness)
+	if err != nil {
+		t.Fatalf("Failed to prove: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		t.Fatalf("Failed to serialize proof: %v", err)
+	}
+	publicInputs := append([]string{}, f.publicInputs...)
+	publicInputs[relyingPartyInput] = hexInput(big.NewInt(7))
+
+	api := newTestAPI(t)
+	router := gin.New()
+	router.POST("/credential/attest", api.CreateAttestation)
+
+	tests := []struct {
+		name         string
+		relyingParty string
+		inputs       []string
+		wantStatus   int
+	}{
+		{"bound party", "7", publicInputs, http.StatusOK},
+		{"other party", "8", publicInputs, http.StatusBadRequest},
+		{"party omitted", "", publicInputs, http.StatusBadRequest},
+		{"inputs rebound to requesting party", "8", rebindInput(publicInputs, 8), http.StatusBadRequest},
+		{"invalid party", "party-a", publicInputs, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AttestationRequest{
+				Commitment:     f.commitment,
+				Proof:          base64.StdEncoding.EncodeToString(proofBuf.Bytes()),
+				PublicInputs:   tt.inputs,
+				RelyingPartyID: tt.relyingParty,
+			}
+			var resp AttestationResponse
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &resp)
+			if code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, code, resp.Error)
+			}
+		})
+	}
+}
+
+// rebindInput returns a copy of publicInputs claiming the given relying party
+func rebindInput(publicInputs []string, party int64) []string {
+	rebound := append([]string{}, publicInputs...)
+	rebound[relyingPartyInput] = hexInput(big.NewInt(party))
+	return rebound
+}
+
+// TestMetricsOnMainRouter tests that a standard Prometheus scrape of the service port
+// works, with or without a dedicated METRICS_PORT listener
+func TestMetricsOnMainRouter(t *testing.T) {
+	for _, metricsPort := range []string{"", "9091"} {
+		t.Run("METRICS_PORT="+metricsPort, func(t *testing.T) {
+			t.Setenv("METRICS_PORT", metricsPort)
+			api := newTestAPI(t)
+			router := setupRouter(api, api.config)
+
+			if code := doJSON(t, router, http.MethodGet, "/health", nil, nil); code != http.StatusOK {
+				t.Fatalf("Expected /health to answer 200, got %d", code)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected /metrics on the main router to answer 200, got %d", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), "http_requests_total") {
+				t.Error("Expected HTTP request metrics in /metrics")
+			}
+		})
+	}
+}
+
+// TestOpenAPIDocumentsEveryRoute tests that the served document describes every registered route
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	api := newTestAPI(t)
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	config.AdminAPIKey = "test-admin-key"
+	router := setupRouter(api, config)
+
+	var doc apispec.Document
+	if code := doJSON(t, router, http.MethodGet, "/openapi.json", nil, &doc); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+	if missing := doc.Missing(router.Routes()); len(missing) > 0 {
+		t.Errorf("Routes missing from OpenAPI document: %v", missing)
+	}
+}
+
+// TestCreateAttestationTrustedJurisdictionRoots tests that proofs are only attested
+// against a configured jurisdiction root
+func TestCreateAttestationTrustedJurisdictionRoots(t *testing.T) {
+	f := newProofFixture(t)
+	root := f.publicInputs[jurisdictionRootInput]
+
+	tests := []struct {
+		name       string
+		trusted    string
+		wantStatus int
+	}{
+		{"none configured", "", http.StatusOK},
+		{"trusted", "0x00" + root, http.StatusOK},
+		{"trusted among others", "0x1234, " + root, http.StatusOK},
+		{"untrusted", "0x1234", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_JURISDICTION_ROOTS", tt.trusted)
+			api := newTestAPI(t)
+			router := gin.New()
+			router.POST("/credential/attest", api.CreateAttestation)
+
+			req := AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: f.publicInputs}
+			var resp apierror.APIError
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &resp)
+			if code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %+v", tt.wantStatus, code, resp)
+			}
+			if code != http.StatusOK && resp.Code != apierror.CodeUntrustedJurisdictionRoot {
+				t.Errorf("Expected code %s, got %+v", apierror.CodeUntrustedJurisdictionRoot, resp)
+			}
+		})
+	}
+
+	if err := ValidateJurisdictionRoots([]string{"0xzz"}); err == nil {
+		t.Error("Expected an invalid root to be rejected")
+	}
+}
+
+// TestCreateAttestationProofSystem tests that only proofs of a system the verifier checks are attested
+func TestCreateAttestationProofSystem(t *testing.T) {
+	f := newProofFixture(t)
+	api := newTestAPI(t)
+	router := gin.New()
+	router.POST("/credential/attest", api.CreateAttestation)
+
+	tests := []struct {
+		name        string
+		proofSystem string
+		wantStatus  int
+	}{
+		{"undeclared defaults to groth16", "", http.StatusOK},
+		{"accepted", "groth16", http.StatusOK},
+		{"case-insensitive", "Groth16", http.StatusOK},
+		{"not verifiable", "plonk", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AttestationRequest{
+				Commitment:   f.commitment,
+				Proof:        f.proof,
+				PublicInputs: f.publicInputs,
+				ProofSystem:  tt.proofSystem,
+			}
+			var resp apierror.APIError
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &resp)
+			if code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %+v", tt.wantStatus, code, resp)
+			}
+			if code != http.StatusOK && resp.Code != apierror.CodeProofSystemNotAccepted {
+				t.Errorf("Expected code %s, got %+v", apierror.CodeProofSystemNotAccepted, resp)
+			}
+		})
+	}
+
+}
+
+// TestGetClarityPublicKey tests that the key is the 0x-prefixed 33-byte compressed key add-attester takes
+func TestGetClarityPublicKey(t *testing.T) {
+	api := newTestAPI(t)
+	router := gin.New()
+	router.GET("/info/public-key/clarity", api.GetClarityPublicKey)
+
+	var resp struct {
+		AttesterID uint   `json:"attester_id"`
+		PublicKey  string `json:"public_key"`
+		Type       string `json:"type"`
+	}
+	if code := doJSON(t, router, http.MethodGet, "/info/public-key/clarity", nil, &resp); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+
+	// (buff 33): 0x followed by 66 hex characters, starting with a compressed-point prefix
+	if len(resp.PublicKey) != 2+2*33 || !strings.HasPrefix(resp.PublicKey, "0x02") && !strings.HasPrefix(resp.PublicKey, "0x03") {
+		t.Errorf("Expected a 0x-prefixed compressed 33-byte key, got %q", resp.PublicKey)
+	}
+	if resp.PublicKey != "0x"+api.signer.GetPublicKey() || resp.Type != "(buff 33)" || resp.AttesterID != 1 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+}
+
+// TestIssueCredentialUserIDValidation tests that unsafe user IDs are rejected with 400
+func TestIssueCredentialUserIDValidation(t *testing.T) {
+	api := newTestAPI(t)
+	router := gin.New()
+	router.POST("/credential/issue", api.IssueCredential)
+
+	tests := []struct {
+		name       string
+		userID     string
+		wantStatus int
+	}{
+		{"valid", "user-123@example.com", http.StatusOK},
+		{"empty", "", http.StatusBadRequest},
+		{"over length", strings.Repeat("a", maxUserIDLength+1), http.StatusBadRequest},
+		{"newline", "alice\nlevel=error msg=forged", http.StatusBadRequest},
+		{"max length", strings.Repeat("b", maxUserIDLength), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				Success bool   `json:"success"`
+				Error   string `json:"error"`
+			}
+			code := doJSON(t, router, http.MethodPost, "/credential/issue", CredentialRequest{
+				UserID:     tt.userID,
+				Attributes: map[string]interface{}{"age": 30},
+			}, &resp)
+			if code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, code, resp.Error)
+			}
+			if tt.wantStatus == http.StatusBadRequest && !strings.Contains(resp.Error, "invalid user_id") {
+				t.Errorf("Expected an invalid user_id error, got %q", resp.Error)
+			}
+		})
+	}
+}
+
+func TestIssueCredentialRedactedAttributes(t *testing.T) {
+	t.Setenv("REDACTED_ATTRIBUTES", "ssn, dob")
+	api := newTestAPI(t)
+	router := gin.New()
+	router.POST("/credential/issue", api.IssueCredential)
+
+	req := CredentialRequest{
+		UserID:     "user-redacted",
+		Attributes: map[string]interface{}{"age": 30.0, "ssn": "123-45-6789", "dob": "1990-01-01"},
+	}
+	var resp struct {
+		Credential Credential `json:"credential"`
+	}
+	if code := doJSON(t, router, http.MethodPost, "/credential/issue", req, &resp); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	for _, key := range []string{"ssn", "dob"} {
+		if _, ok := resp.Credential.Attributes[key]; ok {
+			t.Errorf("Expected %s to be redacted from the response", key)
+		}
+	}
+	if resp.Credential.Attributes["age"] != 30.0 {
+		t.Errorf("Expected age to be returned, got %v", resp.Credential.Attributes)
+	}
+
+	// The commitment is over the full attributes, redacted ones included
+	nonce, err := hex.DecodeString(resp.Credential.Nonce)
+	if err != nil {
+		t.Fatalf("Invalid nonce: %v", err)
+	}
+	want, err := api.issuerService.generateCommitment(&req, nonce)
+	if err != nil {
+		t.Fatalf("generateCommitment failed: %v", err)
+	}
+	if resp.Credential.Commitment != want {
+		t.Errorf("Expected commitment %s over all attributes, got %s", want, resp.Credential.Commitment)
+	}
+	stored, err := api.issuerService.GetCredential(req.UserID)
+	if err != nil {
+		t.Fatalf("GetCredential failed: %v", err)
+	}
+	if stored.Attributes["ssn"] != "123-45-6789" {
+		t.Errorf("Expected the stored credential to keep redacted attributes, got %v", stored.Attributes)
+	}
+}
+
+// TestRevocationTreesPerIssuer tests that issuers keep independent revocation roots
+func TestRevocationTreesPerIssuer(t *testing.T) {
+	t.Setenv("REVOCATION_ISSUERS", "partner-a, partner-b")
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	type rootBody struct {
+		Root  string `json:"root"`
+		Count int    `json:"count"`
+	}
+	root := func(path string) rootBody {
+		var body rootBody
+		if code := doJSON(t, router, http.MethodGet, path, nil, &body); code != http.StatusOK {
+			t.Fatalf("Expected 200 from %s, got %d", path, code)
+		}
+		return body
+	}
+	revoke := func(path, commitment string) int {
+		return doJSON(t, router, http.MethodPost, path, RevocationRequest{Commitment: commitment}, nil)
+	}
+
+	empty := root("/revocation/partner-a/root")
+	if code := revoke("/revocation/partner-a/revoke", "aa"); code != http.StatusOK {
+		t.Fatalf("Expected revocation under partner-a to succeed, got %d", code)
+	}
+	if code := revoke("/revocation/partner-b/revoke", "bb"); code != http.StatusOK {
+		t.Fatalf("Expected revocation under partner-b to succeed, got %d", code)
+	}
+
+	a, b := root("/revocation/partner-a/root"), root("/revocation/partner-b/root")
+	if a.Count != 1 || b.Count != 1 || a.Root == b.Root || a.Root == empty.Root {
+		t.Errorf("Expected independent roots with one revocation each, got %+v and %+v", a, b)
+	}
+
+	// A commitment revoked by one issuer is not revoked by another, or by this attester
+	var status struct {
+		Revoked bool `json:"revoked"`
+	}
+	doJSON(t, router, http.MethodGet, "/revocation/partner-a/check?commitment=aa", nil, &status)
+	if !status.Revoked {
+		t.Error("Expected aa to be revoked by partner-a")
+	}
+	for _, path := range []string{"/revocation/partner-b/check?commitment=aa", "/revocation/check?commitment=aa"} {
+		status.Revoked = false
+		doJSON(t, router, http.MethodGet, path, nil, &status)
+		if status.Revoked {
+			t.Errorf("Expected aa not to be revoked at %s", path)
+		}
+	}
+	if code := revoke("/revocation/partner-b/revoke", "aa"); code != http.StatusOK {
+		t.Errorf("Expected partner-b to revoke a commitment partner-a already revoked, got %d", code)
+	}
+
+	// The unscoped routes are this attester's own tree, keyed by its ID
+	if code := revoke("/credential/revoke", "cc"); code != http.StatusOK {
+		t.Fatalf("Expected unscoped revocation to succeed, got %d", code)
+	}
+	if own, unscoped := root("/revocation/1/root"), root("/revocation/root"); own != unscoped || own.Count != 1 {
+		t.Errorf("Expected /revocation/1/root to match /revocation/root, got %+v and %+v", own, unscoped)
+	}
+
+	if code := revoke("/revocation/unknown/revoke", "dd"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unconfigured issuer, got %d", code)
+	}
+}
+
+// TestExportRevocations tests that the paginated export returns every revoked commitment
+func TestExportRevocations(t *testing.T) {
+	api := newTestAPI(t)
+	api.config.AdminAPIKey = "test-admin-key"
+	router := setupRouter(api, api.config)
+
+	revoked := make([]string, 5)
+	for i := range revoked {
+		revoked[i] = fmt.Sprintf("%064x", i+1)
+		if code := doJSON(t, router, http.MethodPost, "/credential/revoke", RevocationRequest{Commitment: revoked[i]}, nil); code != http.StatusOK {
+			t.Fatalf("Expected revocation of %s to succeed, got %d", revoked[i], code)
+		}
+	}
+
+	export := func(path, key string) (int, RevocationExport, http.Header) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set(middleware.APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body RevocationExport
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode export %q: %v", rec.Body.String(), err)
+			}
+		}
+		return rec.Code, body, rec.Header()
+	}
+
+	for _, key := range []string{"", "wrong-key"} {
+		if code, _, _ := export("/revocation/export", key); code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 with API key %q, got %d", key, code)
+		}
+	}
+
+	code, all, header := export("/revocation/export", "test-admin-key")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if !strings.HasPrefix(header.Get("Content-Disposition"), "attachment") {
+		t.Errorf("Expected an attachment, got Content-Disposition %q", header.Get("Content-Disposition"))
+	}
+	if fmt.Sprint(all.Commitments) != fmt.Sprint(revoked) || all.Total != len(revoked) || all.NextOffset != 0 {
+		t.Errorf("Expected all %d revoked commitments on one page, got %+v", len(revoked), all)
+	}
+	if all.Root != api.revocationService.GetRevocationRoot() || all.ExportedAt == 0 {
+		t.Errorf("Expected the current root and an export time, got %+v", all)
+	}
+
+	// Following next_offset pages through the same set
+	var paged []string
+	path := "/revocation/export?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > len(revoked) {
+			t.Fatal("Export did not terminate")
+		}
+		code, page, _ := export(path, "test-admin-key")
+		if code != http.StatusOK {
+			t.Fatalf("Expected 200 from %s, got %d", path, code)
+		}
+		paged = append(paged, page.Commitments...)
+		if page.NextOffset == 0 {
+			break
+		}
+		path = fmt.Sprintf("/revocation/export?limit=2&offset=%d", page.NextOffset)
+	}
+	if fmt.Sprint(paged) != fmt.Sprint(revoked) {
+		t.Errorf("Expected paged export %v, got %v", revoked, paged)
+	}
+
+	for _, path := range []string{"/revocation/export?limit=0", "/revocation/export?limit=10001", "/revocation/export?offset=-1"} {
+		if code, _, _ := export(path, "test-admin-key"); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", path, code)
+		}
+	}
+	if code, _, _ := export("/revocation/unknown/export", "test-admin-key"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unconfigured issuer, got %d", code)
+	}
+}
+
+// TestVerifyRevocationProof tests that a revocation tree proof is checked against its root
+// and that a root replaced by a later revocation is reported stale
+func TestVerifyRevocationProof(t *testing.T) {
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	for _, commitment := range []string{"aa", "bb", "cc"} {
+		if code := doJSON(t, router, http.MethodPost, "/credential/revoke", RevocationRequest{Commitment: commitment}, nil); code != http.StatusOK {
+			t.Fatalf("Expected revocation of %s to succeed, got %d", commitment, code)
+		}
+	}
+	proof, indices, err := api.revocationService.merkleTree.GenerateProof("bb")
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	req := RevocationProofRequest{
+		Commitment: "bb",
+		Proof:      proof,
+		Indices:    indices,
+		Root:       api.revocationService.GetRevocationRoot(),
+	}
+	verify := func(req RevocationProofRequest) RevocationProofResponse {
+		var resp RevocationProofResponse
+		if code := doJSON(t, router, http.MethodPost, "/revocation/verify-proof", req, &resp); code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", code)
+		}
+		return resp
+	}
+
+	if resp := verify(req); !resp.Valid || !resp.RootCurrent {
+		t.Errorf("Expected a valid proof against the current root, got %+v", resp)
+	}
+	other := req
+	other.Commitment = "dd"
+	if resp := verify(other); resp.Valid || !resp.RootCurrent {
+		t.Errorf("Expected the proof to fail for another commitment, got %+v", resp)
+	}
+
+	// A later revocation leaves the proof valid for its root, which is no longer current
+	if code := doJSON(t, router, http.MethodPost, "/credential/revoke", RevocationRequest{Commitment: "dd"}, nil); code != http.StatusOK {
+		t.Fatalf("Expected revocation of dd to succeed, got %d", code)
+	}
+	if resp := verify(req); !resp.Valid || resp.RootCurrent {
+		t.Errorf("Expected a valid proof against a stale root, got %+v", resp)
+	}
+
+	if code := doJSON(t, router, http.MethodPost, "/revocation/verify-proof", RevocationProofRequest{Commitment: "bb"}, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a root, got %d", code)
+	}
+	if code := doJSON(t, router, http.MethodPost, "/revocation/unknown/verify-proof", req, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unconfigured issuer, got %d", code)
+	}
+}
+
+// TestAttestationStatusAfterRevocation tests that revoking a commitment marks its logged attestations revoked
+func TestAttestationStatusAfterRevocation(t *testing.T) {
+	f := newProofFixture(t)
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	type statusBody struct {
+		Status       string              `json:"status"`
+		Attestations []AttestationRecord `json:"attestations"`
+	}
+	statusPath := "/attestation/status?commitment=" + f.commitment
+
+	if code := doJSON(t, router, http.MethodGet, statusPath, nil, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any attestation, got %d", code)
+	}
+
+	var attestation AttestationResponse
+	code := doJSON(t, router, http.MethodPost, "/credential/attest", AttestationRequest{
+		Commitment:   f.commitment,
+		PublicInputs: f.publicInputs,
+		Proof:        f.proof,
+	}, &attestation)
+	if code != http.StatusOK || !attestation.Success {
+		t.Fatalf("Expected successful attestation, got %d: %s", code, attestation.Error)
+	}
+
+	var status statusBody
+	if code := doJSON(t, router, http.MethodGet, statusPath, nil, &status); code != http.StatusOK {
+		t.Fatalf("Expected 200 from /attestation/status, got %d", code)
+	}
+	if status.Status != AttestationStatusValid || len(status.Attestations) != 1 || status.Attestations[0].Signature != attestation.Signature {
+		t.Fatalf("Expected one valid attestation, got %+v", status)
+	}
+
+	// Revoking the versioned form of the commitment reaches attestations of the bare form
+	if code := doJSON(t, router, http.MethodPost, "/credential/revoke", RevocationRequest{Commitment: "02" + f.commitment}, nil); code != http.StatusOK {
+		t.Fatalf("Expected revocation to succeed, got %d", code)
+	}
+
+	status = statusBody{}
+	doJSON(t, router, http.MethodGet, statusPath, nil, &status)
+	if status.Status != AttestationStatusRevoked || len(status.Attestations) != 1 {
+		t.Fatalf("Expected the attestation to be revoked, got %+v", status)
+	}
+	if record := status.Attestations[0]; record.Status != AttestationStatusRevoked || record.RevokedAt == 0 {
+		t.Errorf("Expected a revoked record with a revocation time, got %+v", record)
+	}
+}
+
+// TestAttestationSignatureFormat tests that attestations declare their signature encoding
+// and that the optional r/s/v components rebuild the signature hex
+func TestAttestationSignatureFormat(t *testing.T) {
+	f := newProofFixture(t)
+
+	tests := []struct {
+		algo       string
+		components bool
+		wantFormat string
+		wantLen    int
+	}{
+		{HashAlgoSHA256, false, SignatureFormatRS64, 128},
+		{HashAlgoSHA256, true, SignatureFormatRS64, 128},
+		{HashAlgoKeccak256, true, SignatureFormatRSV65, 130},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s components=%v", tt.algo, tt.components), func(t *testing.T) {
+			t.Setenv("SIGN_HASH_ALGO", tt.algo)
+			t.Setenv("ATTESTATION_SIGNATURE_COMPONENTS", strconv.FormatBool(tt.components))
+			api := newTestAPI(t)
+			router := gin.New()
+			router.POST("/credential/attest", api.CreateAttestation)
+
+			var resp AttestationResponse
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", AttestationRequest{
+				Commitment:   f.commitment,
+				PublicInputs: f.publicInputs,
+				Proof:        f.proof,
+			}, &resp)
+			if code != http.StatusOK || !resp.Success {
+				t.Fatalf("Expected successful attestation, got %d: %s", code, resp.Error)
+			}
+			if resp.SignatureFormat != tt.wantFormat || len(resp.Signature) != tt.wantLen {
+				t.Errorf("Expected a %d-character %s signature, got %d characters as %q", tt.wantLen, tt.wantFormat, len(resp.Signature), resp.SignatureFormat)
+			}
+
+			c := resp.SignatureComponents
+			if !tt.components {
+				if c != nil {
+					t.Errorf("Expected no signature components unless configured, got %+v", c)
+				}
+				return
+			}
+			if c == nil {
+				t.Fatal("Expected signature components")
+			}
+			if len(c.R) != 64 || len(c.S) != 64 || (c.V != nil) != (tt.wantFormat == SignatureFormatRSV65) {
+				t.Errorf("Unexpected components for %s: %+v", tt.wantFormat, c)
+			}
+			if c.Hex() != resp.Signature {
+				t.Errorf("Expected components to rebuild %s, got %s", resp.Signature, c.Hex())
+			}
+		})
+	}
+}
+
+// TestRebuildRevocations tests that a rebuild replaces the tree with exactly the supplied set
+func TestRebuildRevocations(t *testing.T) {
+	api := newTestAPI(t)
+	api.config.AdminAPIKey = "test-admin-key"
+	router := setupRouter(api, api.config)
+
+	old := fmt.Sprintf("%064x", 99)
+	api.attestations.Record(&AttestationResponse{Commitment: old, Signature: "old"})
+	api.attestations.Record(&AttestationResponse{Commitment: fmt.Sprintf("%064x", 2), Signature: "kept"})
+	if code := doJSON(t, router, http.MethodPost, "/credential/revoke", RevocationRequest{Commitment: old}, nil); code != http.StatusOK {
+		t.Fatalf("Expected revocation to succeed, got %d", code)
+	}
+
+	rebuild := func(commitments []string) (int, map[string]interface{}) {
+		body, _ := json.Marshal(RevocationRebuildRequest{Commitments: commitments})
+		req := httptest.NewRequest(http.MethodPost, "/admin/revocation/rebuild", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.APIKeyHeader, "test-admin-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	// Bare and versioned commitments are both accepted
+	commitments := []string{fmt.Sprintf("%064x", 1), fmt.Sprintf("%064x", 2), "01" + fmt.Sprintf("%064x", 3)}
+	code, resp := rebuild(commitments)
+	if code != http.StatusOK {
+		t.Fatalf("Expected rebuild to succeed, got %d: %v", code, resp)
+	}
+
+	// The root is the one a tree built from the same set has
+	want := NewRevocationService(api.config.HashDomain)
+	for _, commitment := range commitments {
+		want.RevokeCredential(commitment)
+	}
+	if resp["root"] != want.GetRevocationRoot() || api.revocationService.GetRevocationRoot() != want.GetRevocationRoot() {
+		t.Errorf("Expected root %s, got %v", want.GetRevocationRoot(), resp["root"])
+	}
+	if api.revocationService.IsRevoked(old) || !api.revocationService.IsRevoked(commitments[1]) {
+		t.Error("Expected the revoked set to be replaced")
+	}
+	if count := api.revocationService.GetRevokedCount(); count != 3 {
+		t.Errorf("Expected 3 revoked commitments, got %d", count)
+	}
+
+	// Attestations follow the rebuilt set: dropped commitments read as valid again
+	if status := attestationStatus(api.attestations.Lookup(old)); status != AttestationStatusValid {
+		t.Errorf("Expected the dropped commitment's attestation to be valid, got %s", status)
+	}
+	if status := attestationStatus(api.attestations.Lookup(commitments[1])); status != AttestationStatusRevoked {
+		t.Errorf("Expected the rebuilt commitment's attestation to be revoked, got %s", status)
+	}
+
+	// An invalid entry anywhere leaves the tree untouched
+	for _, bad := range [][]string{
+		{fmt.Sprintf("%064x", 4), "zz"},
+		{fmt.Sprintf("%064x", 4), fmt.Sprintf("%062x", 5)},
+		{fmt.Sprintf("%064x", 4), fmt.Sprintf("%064x", 4)},
+		{fmt.Sprintf("%064x", 4), "01" + fmt.Sprintf("%064x", 4)},
+	} {
+		if code, _ := rebuild(bad); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %v, got %d", bad, code)
+		}
+		if api.revocationService.GetRevocationRoot() != want.GetRevocationRoot() || api.revocationService.IsRevoked(bad[0]) {
+			t.Errorf("Expected the tree unchanged after rejecting %v", bad)
+		}
+	}
+}
+
+// TestRebuildRevocationsConcurrent tests that rebuilds can run alongside revocation reads
+// and writes; run with -race
+func TestRebuildRevocationsConcurrent(t *testing.T) {
+	rs := NewRevocationService(DefaultHashDomain)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				commitment := fmt.Sprintf("%062x%02x", i, j)
+				rs.RevokeCredential(commitment)
+				rs.IsRevoked(commitment)
+				rs.GetRevocationRoot()
+				rs.RevokedCommitments(0, 10)
+				if j%10 == 0 {
+					if _, err := rs.Rebuild([]string{commitment}); err != nil {
+						t.Errorf("Failed to rebuild: %v", err)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
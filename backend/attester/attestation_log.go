@@ -0,0 +1,164 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultAttestationLogSize is the default number of attestations the log keeps
+const DefaultAttestationLogSize = 100000
+
+// Attestation statuses reported by /attestation/status
+const (
+	AttestationStatusValid   = "valid"
+	AttestationStatusRevoked = "revoked"
+)
+
+// AttestationRecord is an issued attestation kept so it can be revoked with its commitment
+type AttestationRecord struct {
+	Signature  string `json:"signature"`
+	Expiry     uint64 `json:"expiry"`
+	ExpiryType string `json:"expiry_type"`
+	IssuedAt   int64  `json:"issued_at"`
+	Status     string `json:"status"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+}
+
+// AttestationLog records the attestations signed for each commitment. It keeps at most
+// capacity attestations; recording past that drops the oldest, so memory stays bounded
+// however many attestations are issued. Revocations live in the revocation tree, so a
+// dropped attestation is only missing from /attestation/status
+type AttestationLog struct {
+	mu       sync.RWMutex
+	capacity int
+	records  map[string][]*AttestationRecord
+	order    *list.List // front is the oldest attestation
+}
+
+// loggedAttestation is the value stored in the order list
+type loggedAttestation struct {
+	key    string
+	record *AttestationRecord
+}
+
+// NewAttestationLog creates an empty attestation log keeping up to capacity attestations
+func NewAttestationLog(capacity int) *AttestationLog {
+	if capacity <= 0 {
+		capacity = DefaultAttestationLogSize
+	}
+	return &AttestationLog{
+		capacity: capacity,
+		records:  make(map[string][]*AttestationRecord),
+		order:    list.New(),
+	}
+}
+
+// Record logs a signed attestation under its commitment, dropping the oldest logged
+// attestation when the log is full
+func (l *AttestationLog) Record(resp *AttestationResponse) {
+	key := commitmentKey(resp.Commitment)
+	record := &AttestationRecord{
+		Signature:  resp.Signature,
+		Expiry:     resp.Expiry,
+		ExpiryType: resp.ExpiryType,
+		IssuedAt:   time.Now().Unix(),
+		Status:     AttestationStatusValid,
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.order.Len() >= l.capacity {
+		l.evictOldest()
+	}
+	l.records[key] = append(l.records[key], record)
+	l.order.PushBack(&loggedAttestation{key: key, record: record})
+}
+
+// evictOldest drops the oldest logged attestation; callers hold mu
+func (l *AttestationLog) evictOldest() {
+	oldest := l.order.Remove(l.order.Front()).(*loggedAttestation)
+	records := l.records[oldest.key]
+	for i, record := range records {
+		if record == oldest.record {
+			records = append(records[:i], records[i+1:]...)
+			break
+		}
+	}
+	if len(records) == 0 {
+		delete(l.records, oldest.key)
+		return
+	}
+	l.records[oldest.key] = records
+}
+
+// Len returns how many attestations are logged
+func (l *AttestationLog) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.order.Len()
+}
+
+// Revoke marks every attestation logged for the commitment as revoked and returns how many changed
+func (l *AttestationLog) Revoke(commitment string) int {
+	key := commitmentKey(commitment)
+	now := time.Now().Unix()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	revoked := 0
+	for _, record := range l.records[key] {
+		if record.Status != AttestationStatusRevoked {
+			record.Status = AttestationStatusRevoked
+			record.RevokedAt = now
+			revoked++
+		}
+	}
+	return revoked
+}
+
+// Reconcile sets the status of every logged attestation from revoked, for when the revoked
+// set is replaced wholesale: attestations of commitments no longer revoked are valid again
+// It returns how many attestations were revoked and how many restored
+func (l *AttestationLog) Reconcile(revoked func(commitment string) bool) (int, int) {
+	now := time.Now().Unix()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	revokedCount, restored := 0, 0
+	for key, records := range l.records {
+		isRevoked := revoked(key)
+		for _, record := range records {
+			switch {
+			case isRevoked && record.Status != AttestationStatusRevoked:
+				record.Status = AttestationStatusRevoked
+				record.RevokedAt = now
+				revokedCount++
+			case !isRevoked && record.Status == AttestationStatusRevoked:
+				record.Status = AttestationStatusValid
+				record.RevokedAt = 0
+				restored++
+			}
+		}
+	}
+	return revokedCount, restored
+}
+
+// Lookup returns copies of the attestations logged for the commitment
+func (l *AttestationLog) Lookup(commitment string) []AttestationRecord {
+	key := commitmentKey(commitment)
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	records := make([]AttestationRecord, 0, len(l.records[key]))
+	for _, record := range l.records[key] {
+		records = append(records, *record)
+	}
+	return records
+}
+
+// attestationStatus summarizes logged attestations: revoked once any of them is
+func attestationStatus(records []AttestationRecord) string {
+	for _, record := range records {
+		if record.Status == AttestationStatusRevoked {
+			return AttestationStatusRevoked
+		}
+	}
+	return AttestationStatusValid
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestAttestationLogCapacity tests that a full log drops its oldest attestations first,
+// whichever commitment they belong to, and forgets commitments left with none
+func TestAttestationLogCapacity(t *testing.T) {
+	log := NewAttestationLog(3)
+	record := func(commitment, signature string) {
+		log.Record(&AttestationResponse{Commitment: commitment, Signature: signature})
+	}
+	record("0x01", "a1")
+	record("0x02", "b1")
+	record("0x01", "a2")
+	record("0x03", "c1")
+	record("0x03", "c2")
+
+	if n := log.Len(); n != 3 {
+		t.Fatalf("Expected 3 logged attestations, got %d", n)
+	}
+	if records := log.Lookup("0x02"); len(records) != 0 {
+		t.Errorf("Expected commitment 0x02 to be dropped, got %+v", records)
+	}
+	signatures := func(commitment string) string {
+		var got []string
+		for _, r := range log.Lookup(commitment) {
+			got = append(got, r.Signature)
+		}
+		return fmt.Sprint(got)
+	}
+	if got := signatures("0x01"); got != "[a2]" {
+		t.Errorf("Expected only the newer attestation of 0x01, got %s", got)
+	}
+	if got := signatures("0x03"); got != "[c1 c2]" {
+		t.Errorf("Expected both attestations of 0x03, got %s", got)
+	}
+
+	// Revocation still applies to what is kept
+	if revoked := log.Revoke("0x03"); revoked != 2 {
+		t.Errorf("Expected 2 revoked attestations, got %d", revoked)
+	}
+	record("0x04", "d1")
+	if got := attestationStatus(log.Lookup("0x03")); got != AttestationStatusRevoked {
+		t.Errorf("Expected 0x03 to stay revoked, got %s", got)
+	}
+}
+
+// TestAttestationLogDefaultCapacity tests that a non-positive capacity uses the default
+func TestAttestationLogDefaultCapacity(t *testing.T) {
+	if got := NewAttestationLog(0).capacity; got != DefaultAttestationLogSize {
+		t.Errorf("Expected capacity %d, got %d", DefaultAttestationLogSize, got)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// Messages an attestation signature can cover, selected by ATTESTATION_SIGNED_MESSAGE
+const (
+	// SignedMessageCommitment signs the 32-byte commitment hash alone
+	SignedMessageCommitment = "commitment"
+	// SignedMessageAttestation signs AttestationMessage, binding the attester ID and expiry
+	SignedMessageAttestation = "attestation"
+)
+
+// ValidateSignedMessage returns an error unless message is a supported signed message
+func ValidateSignedMessage(message string) error {
+	switch message {
+	case SignedMessageCommitment, SignedMessageAttestation:
+		return nil
+	}
+	return fmt.Errorf("unsupported signed message %q (expected %s or %s)", message, SignedMessageCommitment, SignedMessageAttestation)
+}
+
+// uint128Size is the width of a Clarity uint, which attester ID and expiry are encoded as
+const uint128Size = 16
+
+// AttestationMessage returns the canonical structured message for an attestation:
+// the 32-byte commitment hash || attester ID || expiry, each integer as a 16-byte
+// big-endian uint128, so a contract can rebuild it from its own values
+func AttestationMessage(commitment string, attesterID uint, expiry uint64) ([]byte, error) {
+	hash, err := decodeCommitment(commitment)
+	if err != nil {
+		return nil, err
+	}
+	// Both integers fit in the low 8 bytes of their uint128
+	message := make([]byte, commitmentSize+2*uint128Size)
+	copy(message, hash)
+	binary.BigEndian.PutUint64(message[commitmentSize+8:], uint64(attesterID))
+	binary.BigEndian.PutUint64(message[commitmentSize+uint128Size+8:], expiry)
+	return message, nil
+}
+
+// SignAttestation signs the structured attestation message for Clarity verification
+func (s *Signer) SignAttestation(commitment string, attesterID uint, expiry uint64) (string, error) {
+	return s.SignAttestationWith(commitment, attesterID, expiry, HashAlgoSHA256)
+}
+
+// SignAttestationWith signs the structured attestation message with the given hash
+// algorithm: a 64-byte signature over SHA256(message) for sha256, or a 65-byte one over
+// Keccak256(message) for keccak256
+func (s *Signer) SignAttestationWith(commitment string, attesterID uint, expiry uint64, algo string) (string, error) {
+	message, err := AttestationMessage(commitment, attesterID, expiry)
+	if err != nil {
+		return "", err
+	}
+	switch algo {
+	case HashAlgoSHA256:
+		digest := sha256.Sum256(message)
+		return s.SignWithSHA256(digest[:])
+	case HashAlgoKeccak256:
+		return s.Sign(message)
+	default:
+		return "", ValidateHashAlgo(algo)
+	}
+}
+
+// VerifyAttestationSignature verifies a signature made by SignAttestationWith against the
+// message rebuilt from commitment, attesterID and expiry
+func VerifyAttestationSignature(commitment string, attesterID uint, expiry uint64, signatureHex, publicKeyHex, algo string) (bool, error) {
+	message, err := AttestationMessage(commitment, attesterID, expiry)
+	if err != nil {
+		return false, err
+	}
+	if err := ValidateHashAlgo(algo); err != nil {
+		return false, err
+	}
+	if algo == HashAlgoKeccak256 {
+		return VerifySignatureWith(message, signatureHex, publicKeyHex, false)
+	}
+	// The SHA256 digest is signed directly, as a commitment hash is
+	digest := sha256.Sum256(message)
+	return VerifyCommitmentSignature(hex.EncodeToString(digest[:]), signatureHex, publicKeyHex, HashAlgoSHA256)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestAttestationMessageLayout tests the canonical encoding of the structured message
+func TestAttestationMessageLayout(t *testing.T) {
+	commitment := strings.Repeat("ab", 32)
+	message, err := AttestationMessage("02"+commitment, 7, 0x0102030405)
+	if err != nil {
+		t.Fatalf("Failed to build message: %v", err)
+	}
+	want := commitment +
+		"00000000000000000000000000000007" +
+		"00000000000000000000000102030405"
+	if got := hex.EncodeToString(message); got != want {
+		t.Errorf("Unexpected message:\n got %s\nwant %s", got, want)
+	}
+	if _, err := AttestationMessage("zz", 7, 1); err == nil {
+		t.Error("Expected an invalid commitment to be rejected")
+	}
+}
+
+// TestSignAttestation tests that the signature is bound to the attester ID and expiry
+func TestSignAttestation(t *testing.T) {
+	signer, err := NewSignerFromSeed([]byte("attestation message test"), 3)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	publicKey := signer.GetPublicKey()
+	commitment := strings.Repeat("cd", 32)
+
+	for _, algo := range []string{HashAlgoSHA256, HashAlgoKeccak256} {
+		t.Run(algo, func(t *testing.T) {
+			signature, err := signer.SignAttestationWith(commitment, 3, 1700000000, algo)
+			if err != nil {
+				t.Fatalf("Failed to sign: %v", err)
+			}
+			if valid, err := VerifyAttestationSignature(commitment, 3, 1700000000, signature, publicKey, algo); err != nil || !valid {
+				t.Fatalf("Expected the signature to verify, got %v, %v", valid, err)
+			}
+
+			changed := map[string]struct {
+				attesterID uint
+				expiry     uint64
+			}{
+				"attester ID": {4, 1700000000},
+				"expiry":      {3, 1700000001},
+			}
+			for name, c := range changed {
+				other, err := signer.SignAttestationWith(commitment, c.attesterID, c.expiry, algo)
+				if err != nil {
+					t.Fatalf("Failed to sign: %v", err)
+				}
+				if other == signature {
+					t.Errorf("Expected a changed %s to change the signature", name)
+				}
+				if valid, _ := VerifyAttestationSignature(commitment, c.attesterID, c.expiry, signature, publicKey, algo); valid {
+					t.Errorf("Expected the signature not to verify with a changed %s", name)
+				}
+			}
+
+			// The structured signature is not a bare commitment signature
+			if valid, _ := VerifyCommitmentSignature(commitment, signature, publicKey, algo); valid {
+				t.Error("Expected the attestation signature not to verify as a commitment signature")
+			}
+		})
+	}
+
+	if _, err := signer.SignAttestation(commitment, 3, 1); err != nil {
+		t.Errorf("Expected the sha256 default to sign, got %v", err)
+	}
+}
+
+// TestCreateAttestationSignedMessage tests that attestations sign the configured message
+func TestCreateAttestationSignedMessage(t *testing.T) {
+	f := newProofFixture(t)
+
+	for _, message := range []string{SignedMessageCommitment, SignedMessageAttestation} {
+		t.Run(message, func(t *testing.T) {
+			t.Setenv("ATTESTATION_SIGNED_MESSAGE", message)
+			api := newTestAPI(t)
+			publicKey := api.issuerService.signer.GetPublicKey()
+
+			resp, err := api.issuerService.CreateAttestation(context.Background(), &AttestationRequest{
+				Commitment:   f.commitment,
+				Proof:        f.proof,
+				PublicInputs: f.publicInputs,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create attestation: %v", err)
+			}
+			if resp.SignedMessage != message {
+				t.Errorf("Expected signed_message %q, got %q", message, resp.SignedMessage)
+			}
+
+			structured, _ := VerifyAttestationSignature(resp.Commitment, resp.AttesterID, resp.Expiry, resp.Signature, publicKey, resp.HashAlgo)
+			bare, _ := VerifyCommitmentSignature(resp.Commitment, resp.Signature, publicKey, resp.HashAlgo)
+			if structured != (message == SignedMessageAttestation) || bare != (message == SignedMessageCommitment) {
+				t.Errorf("Expected only the %s signature to verify, got structured %v and bare %v", message, structured, bare)
+			}
+		})
+	}
+
+	if err := ValidateSignedMessage("expiry"); err == nil {
+		t.Error("Expected an unknown signed message to be rejected")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// Circuit fields a credential attribute can be mapped to with ATTRIBUTE_FIELD_MAP
+const (
+	FieldIdentityData = "identity_data"
+	FieldAge          = "age"
+	FieldJurisdiction = "jurisdiction"
+)
+
+// ErrInvalidAttributes is returned when a credential's attributes cannot be encoded
+// into the circuit fields they are mapped to
+var ErrInvalidAttributes = errors.New("invalid attributes")
+
+// AttributeFields maps credential attributes to the private circuit inputs derived from them
+type AttributeFields struct {
+	// Identity lists the attributes hashed into IdentityData; empty hashes all of them
+	Identity []string
+	// Age and Jurisdiction name the attribute each is encoded from; empty derives nothing.
+	// Both are always hashed into IdentityData too, so the commitment covers them
+	Age          string
+	Jurisdiction string
+}
+
+// ParseAttributeFields parses "attribute=field" entries, where field is identity_data,
+// age or jurisdiction; age and jurisdiction take one attribute each
+func ParseAttributeFields(entries []string) (*AttributeFields, error) {
+	fields := &AttributeFields{}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		attribute, field, ok := strings.Cut(entry, "=")
+		attribute, field = strings.TrimSpace(attribute), strings.TrimSpace(field)
+		if !ok || attribute == "" {
+			return nil, fmt.Errorf("invalid entry %q (expected attribute=field)", entry)
+		}
+		if seen[attribute] {
+			return nil, fmt.Errorf("attribute %q is mapped more than once", attribute)
+		}
+		seen[attribute] = true
+
+		switch field {
+		case FieldIdentityData:
+			fields.Identity = append(fields.Identity, attribute)
+		case FieldAge, FieldJurisdiction:
+			target := &fields.Age
+			if field == FieldJurisdiction {
+				target = &fields.Jurisdiction
+			}
+			if *target != "" {
+				return nil, fmt.Errorf("%s is mapped from both %q and %q", field, *target, attribute)
+			}
+			*target = attribute
+		default:
+			return nil, fmt.Errorf("unknown circuit field %q (expected %s, %s or %s)", field, FieldIdentityData, FieldAge, FieldJurisdiction)
+		}
+	}
+	return fields, nil
+}
+
+// identityData derives the circuit's IdentityData from the credential deterministically:
+// SHA256 over the identity, age and jurisdiction attributes (JSON, keys sorted) and user ID,
+// reduced into the field. A nil f hashes all attributes
+func (f *AttributeFields) identityData(req *CredentialRequest) (*big.Int, error) {
+	attributes := req.Attributes
+	if f != nil && len(f.Identity) > 0 {
+		attributes = make(map[string]interface{}, len(f.Identity)+2)
+		for _, key := range f.committed() {
+			value, ok := req.Attributes[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: missing attribute %q mapped to %s", ErrInvalidAttributes, key, FieldIdentityData)
+			}
+			attributes[key] = value
+		}
+	}
+
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, []byte(req.UserID)...)
+	hash := sha256.Sum256(data)
+	return fieldElement(hash[:]), nil
+}
+
+// committed returns the attributes hashed into IdentityData when Identity is set: the
+// identity attributes plus those age and jurisdiction are derived from
+func (f *AttributeFields) committed() []string {
+	keys := append([]string{}, f.Identity...)
+	for _, key := range []string{f.Age, f.Jurisdiction} {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// derive fills the proof inputs derived from the mapped attributes; ages above maxAge
+// could not be proven and are rejected
+func (f *AttributeFields) derive(req *CredentialRequest, maxAge int, inputs *ProofInputs) error {
+	if f == nil {
+		return nil
+	}
+	if f.Age != "" {
+		age, err := attributeInteger(req.Attributes, f.Age, FieldAge)
+		if err != nil {
+			return err
+		}
+		if age.Cmp(big.NewInt(int64(maxAge))) > 0 {
+			return fmt.Errorf("%w: attribute %q is above the provable age %d", ErrInvalidAttributes, f.Age, maxAge)
+		}
+		inputs.Age = age.String()
+	}
+	if f.Jurisdiction != "" {
+		jurisdiction, err := attributeInteger(req.Attributes, f.Jurisdiction, FieldJurisdiction)
+		if err != nil {
+			return err
+		}
+		inputs.Jurisdiction = jurisdiction.String()
+	}
+	return nil
+}
+
+// attributeInteger encodes an attribute as a field element: it must be a non-negative
+// integer, given as a JSON number or a decimal string
+func attributeInteger(attributes map[string]interface{}, key, field string) (*big.Int, error) {
+	value, ok := attributes[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing attribute %q mapped to %s", ErrInvalidAttributes, key, field)
+	}
+
+	var n *big.Int
+	switch v := value.(type) {
+	case int:
+		n = big.NewInt(int64(v))
+	case int64:
+		n = big.NewInt(v)
+	case float64:
+		// JSON numbers decode as float64, which is exact up to 2^53
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			n = big.NewInt(int64(v))
+		}
+	case json.Number:
+		n, _ = new(big.Int).SetString(v.String(), 10)
+	case string:
+		n, _ = new(big.Int).SetString(v, 10)
+	}
+	if n == nil || n.Sign() < 0 || n.Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("%w: attribute %q mapped to %s must be a non-negative integer", ErrInvalidAttributes, key, field)
+	}
+	return n, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"noah-v2/backend/pkg/proofformat"
+)
+
+// commitmentInputIndex is the position of the commitment among the KYC circuit's public inputs
+const commitmentInputIndex = 3
+
+// applyBundle fills the proof fields of the request from its bundle, which may not be
+// combined with them; an omitted commitment defaults to the proof's commitment input
+func (r *AttestationRequest) applyBundle() error {
+	if err := r.Bundle.Validate(); err != nil {
+		return err
+	}
+	if r.Proof != "" || len(r.PublicInputs) != 0 || r.CircuitVersion != "" || r.ProofSystem != "" {
+		return fmt.Errorf("bundle cannot be combined with proof, public_inputs, circuit_version or proof_system")
+	}
+	r.Proof = r.Bundle.EncodedProof()
+	r.Format = proofformat.Base64
+	r.PublicInputs = r.Bundle.PublicInputs
+	r.CircuitVersion = r.Bundle.CircuitHash
+	r.ProofSystem = r.Bundle.ProofSystem
+
+	if r.Commitment == "" && len(r.PublicInputs) > commitmentInputIndex {
+		input := strings.TrimPrefix(r.PublicInputs[commitmentInputIndex], "0x")
+		if len(input) < 2*commitmentSize {
+			input = strings.Repeat("0", 2*commitmentSize-len(input)) + input
+		}
+		r.Commitment = input
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"noah-v2/backend/pkg/proofbundle"
+)
+
+// TestAttestProofBundle tests that a marshalled proof bundle is attested on its own, with
+// the commitment taken from its public inputs
+func TestAttestProofBundle(t *testing.T) {
+	f := newProofFixture(t)
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	proof, err := base64.StdEncoding.DecodeString(f.proof)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture proof: %v", err)
+	}
+	data, err := proofbundle.Marshal(proofbundle.New("", proofbundle.ProofSystemGroth16, proof, f.publicInputs))
+	if err != nil {
+		t.Fatalf("Failed to marshal bundle: %v", err)
+	}
+	bundle, err := proofbundle.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal bundle: %v", err)
+	}
+
+	var resp AttestationResponse
+	code := doJSON(t, router, http.MethodPost, "/credential/attest", AttestationRequest{Bundle: bundle}, &resp)
+	if code != http.StatusOK || !resp.Success {
+		t.Fatalf("Expected successful attestation, got %d: %s", code, resp.Error)
+	}
+	if resp.Commitment != f.commitment {
+		t.Errorf("Expected commitment %s from the bundle, got %s", f.commitment, resp.Commitment)
+	}
+
+	tampered := *bundle
+	tampered.PublicInputs = append([]string{"15"}, bundle.PublicInputs[1:]...)
+	if code := doJSON(t, router, http.MethodPost, "/credential/attest", AttestationRequest{Bundle: &tampered}, &resp); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a tampered bundle, got %d", code)
+	}
+
+	combined := AttestationRequest{Bundle: bundle, Proof: f.proof}
+	if code := doJSON(t, router, http.MethodPost, "/credential/attest", combined, &resp); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bundle combined with a proof, got %d", code)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"noah-v2/backend/pkg/metrics"
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Circuit types an attestation request can declare
+const (
+	// CircuitTypeKYC is the full KYC circuit (and its denylist variant), the default
+	CircuitTypeKYC = "kyc"
+	// CircuitTypeJurisdiction proves jurisdiction membership only; its one public input is JurisdictionRoot
+	CircuitTypeJurisdiction = "jurisdiction"
+	// CircuitTypeAge proves a minimum age only; its one public input is MinAge
+	CircuitTypeAge = "age"
+)
+
+// ErrUnknownCircuitType is returned for a circuit type the verifier has no key for
+var ErrUnknownCircuitType = errors.New("unknown circuit type")
+
+// ValidateCircuitType returns an error unless circuitType is empty or a known circuit type
+func ValidateCircuitType(circuitType string) error {
+	switch circuitType {
+	case "", CircuitTypeKYC, CircuitTypeJurisdiction, CircuitTypeAge:
+		return nil
+	}
+	return fmt.Errorf("%w %q (expected %s, %s or %s)", ErrUnknownCircuitType, circuitType, CircuitTypeKYC, CircuitTypeJurisdiction, CircuitTypeAge)
+}
+
+// isKYCCircuitType reports whether circuitType selects the KYC circuit
+func isKYCCircuitType(circuitType string) bool {
+	return circuitType == "" || circuitType == CircuitTypeKYC
+}
+
+// circuitRootInput returns the index of the JurisdictionRoot public input for circuitType,
+// or false if the circuit has none
+func circuitRootInput(circuitType string) (int, bool) {
+	switch {
+	case isKYCCircuitType(circuitType):
+		return jurisdictionRootInput, true
+	case circuitType == CircuitTypeJurisdiction:
+		return 0, true
+	}
+	return 0, false
+}
+
+// SetCircuitTypeKeyPath sets the verifying key file for a single-purpose circuit type;
+// the key is loaded on first use
+func (pv *ProofVerifier) SetCircuitTypeKeyPath(circuitType, path string) {
+	pv.typeMu.Lock()
+	defer pv.typeMu.Unlock()
+	pv.typeKeyPaths[circuitType] = path
+}
+
+// VerifyProofForCircuit verifies a proof against the verifying key for circuitType
+// An empty or "kyc" type verifies as VerifyProofWithVersion; version is only
+// supported for the KYC circuit. Inputs that are too long or not BN254 scalars are
+// rejected first
+func (pv *ProofVerifier) VerifyProofForCircuit(encodedProof, format, version, circuitType string, publicInputs []string) (bool, error) {
+	if err := ValidateCircuitType(circuitType); err != nil {
+		return false, err
+	}
+	if err := checkFieldInputs(circuitType, publicInputs, pv.inputByteLimit()); err != nil {
+		return false, err
+	}
+	if isKYCCircuitType(circuitType) {
+		return pv.VerifyProofWithVersion(encodedProof, format, version, publicInputs)
+	}
+	if version != "" {
+		return false, fmt.Errorf("circuit_version is not supported for %s proofs", circuitType)
+	}
+
+	vk, err := pv.circuitTypeKey(circuitType)
+	if err != nil {
+		return false, err
+	}
+
+	proofBytes, err := proofformat.Decode(encodedProof, format)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		return false, fmt.Errorf("failed to deserialize proof: %w", err)
+	}
+
+	assignment, err := reconstructCircuitTypeWitness(circuitType, publicInputs)
+	if err != nil {
+		return false, fmt.Errorf("failed to reconstruct public witness: %w", err)
+	}
+	publicWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		return false, fmt.Errorf("failed to create public witness: %w", err)
+	}
+
+	start := time.Now()
+	err = groth16.Verify(proof, vk, publicWitness)
+	metrics.RecordProofVerification(time.Since(start), err == nil)
+	if err != nil {
+		return false, fmt.Errorf("invalid proof: %w", err)
+	}
+	return true, nil
+}
+
+// circuitTypeKey returns the verifying key for a single-purpose circuit type, loading it
+// on first use; concurrent first uses load it once
+func (pv *ProofVerifier) circuitTypeKey(circuitType string) (groth16.VerifyingKey, error) {
+	pv.typeMu.Lock()
+	defer pv.typeMu.Unlock()
+	if vk, ok := pv.typeKeys[circuitType]; ok {
+		return vk, nil
+	}
+	path := pv.typeKeyPaths[circuitType]
+	if path == "" {
+		return nil, fmt.Errorf("%s proofs are not supported: no verifying key configured", circuitType)
+	}
+	vk, err := readVerifyingKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load %s verifying key: %w", ErrVerifierUnavailable, circuitType, err)
+	}
+	pv.typeKeys[circuitType] = vk
+	return vk, nil
+}
+
+// reconstructCircuitTypeWitness builds the public assignment of a single-purpose circuit
+// from its one public input
+func reconstructCircuitTypeWitness(circuitType string, publicInputs []string) (frontend.Circuit, error) {
+	if len(publicInputs) != 1 {
+		return nil, fmt.Errorf("invalid public inputs: expected 1 input for the %s circuit, got %d", circuitType, len(publicInputs))
+	}
+	value, err := hex.DecodeString(publicInputs[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid public input hex: %w", err)
+	}
+	input := new(big.Int).SetBytes(value)
+
+	if circuitType == CircuitTypeAge {
+		return &circuit.AgeCircuit{MinAge: input}, nil
+	}
+	return &circuit.JurisdictionCircuit{JurisdictionRoot: input}, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/gin-gonic/gin"
+)
+
+// buildJurisdictionProof proves the fixture's jurisdiction with the jurisdiction-only
+// circuit, returning the verifying key path and the encoded proof
+func buildJurisdictionProof(t *testing.T, f *proofFixture) (string, string) {
+	t.Helper()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit.JurisdictionCircuit{
+		MerklePath:   make([]frontend.Variable, testMerkleDepth),
+		MerkleHelper: make([]frontend.Variable, testMerkleDepth),
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile jurisdiction circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatalf("Failed to set up jurisdiction circuit: %v", err)
+	}
+
+	vkPath := filepath.Join(t.TempDir(), "jurisdiction_verifying.key")
+	var vkBuf bytes.Buffer
+	if _, err := vk.WriteTo(&vkBuf); err != nil {
+		t.Fatalf("Failed to serialize verifying key: %v", err)
+	}
+	if err := os.WriteFile(vkPath, vkBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write verifying key: %v", err)
+	}
+
+	witness, err := frontend.NewWitness(&circuit.JurisdictionCircuit{
+		Jurisdiction:     f.assignment.Jurisdiction,
+		MerklePath:       f.assignment.MerklePath,
+		MerkleHelper:     f.assignment.MerkleHelper,
+		JurisdictionRoot: f.assignment.JurisdictionRoot,
+	}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("Failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(ccs, pk, witness)
+	if err != nil {
+		t.Fatalf("Failed to prove: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		t.Fatalf("Failed to serialize proof: %v", err)
+	}
+	return vkPath, base64.StdEncoding.EncodeToString(proofBuf.Bytes())
+}
+
+// TestCreateAttestationCircuitType tests that each circuit type is verified with its own key
+func TestCreateAttestationCircuitType(t *testing.T) {
+	f := newProofFixture(t)
+	vkPath, jurisdictionProof := buildJurisdictionProof(t, f)
+	root := f.publicInputs[jurisdictionRootInput]
+
+	t.Setenv("JURISDICTION_VERIFYING_KEY_PATH", vkPath)
+	api := newTestAPI(t)
+	router := gin.New()
+	router.POST("/credential/attest", api.CreateAttestation)
+
+	kyc := AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: f.publicInputs}
+	jurisdiction := AttestationRequest{Commitment: f.commitment, Proof: jurisdictionProof, PublicInputs: []string{root}}
+
+	tests := []struct {
+		name        string
+		req         AttestationRequest
+		circuitType string
+		wantStatus  int
+	}{
+		{"kyc proof, default type", kyc, "", http.StatusOK},
+		{"kyc proof, kyc type", kyc, CircuitTypeKYC, http.StatusOK},
+		// Jurisdiction proofs verify but have no commitment to attest
+		{"jurisdiction proof, jurisdiction type", jurisdiction, CircuitTypeJurisdiction, http.StatusBadRequest},
+		{"jurisdiction proof, kyc type", jurisdiction, CircuitTypeKYC, http.StatusBadRequest},
+		{"kyc proof, jurisdiction type", kyc, CircuitTypeJurisdiction, http.StatusBadRequest},
+		{"no key for type", AttestationRequest{Commitment: f.commitment, Proof: jurisdictionProof, PublicInputs: []string{"12"}}, CircuitTypeAge, http.StatusBadRequest},
+		{"unknown type", kyc, "membership", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.CircuitType = tt.circuitType
+			var resp AttestationResponse
+			code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &resp)
+			if code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %+v", tt.wantStatus, code, resp)
+			}
+		})
+	}
+
+	// They still verify, without being attested
+	router.POST("/proof/verify", api.VerifyProof)
+	verify := ProofVerificationRequest{Proof: jurisdictionProof, PublicInputs: []string{root}, CircuitType: CircuitTypeJurisdiction}
+	var resp ProofVerificationResponse
+	if code := doJSON(t, router, http.MethodPost, "/proof/verify", verify, &resp); code != http.StatusOK || !resp.Valid {
+		t.Errorf("Expected the jurisdiction proof to verify, got %d %+v", code, resp)
+	}
+}
+
+// TestCircuitTypeKeyConcurrentFirstUse tests that parallel first requests for a circuit
+// type load its verifying key safely; run with -race
+func TestCircuitTypeKeyConcurrentFirstUse(t *testing.T) {
+	f := newProofFixture(t)
+	vkPath, jurisdictionProof := buildJurisdictionProof(t, f)
+	inputs := []string{f.publicInputs[jurisdictionRootInput]}
+
+	verifier := NewProofVerifier("")
+	verifier.SetCircuitTypeKeyPath(CircuitTypeJurisdiction, vkPath)
+
+	const requests = 8
+	errs := make(chan error, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.VerifyProofForCircuit(jurisdictionProof, "base64", "", CircuitTypeJurisdiction, inputs)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected the jurisdiction proof to verify, got %v", err)
+		}
+	}
+}
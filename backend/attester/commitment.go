@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/hash"
+)
+
+// Supported issuance commitment schemes
+const (
+	CommitmentSchemeSHA256 = "sha256" // SHA256(attributes || user ID || nonce); not provable in the circuit
+	CommitmentSchemeMiMC   = "mimc"   // MiMC(IdentityData, Nonce), the commitment the KYC circuit computes
+)
+
+// Commitment format versions, carried as a leading byte before the 32-byte hash so a
+// commitment records the scheme that produced it
+const (
+	CommitmentVersionSHA256 byte = 0x01
+	CommitmentVersionMiMC   byte = 0x02
+
+	// CommitmentVersionNone is reported for bare 32-byte commitments, which predate the
+	// version byte and are still produced by provers
+	CommitmentVersionNone byte = 0x00
+)
+
+// DefaultHashDomain is the HASH_DOMAIN used when none is configured: untagged, so
+// commitments and revocation roots match those made before domains were introduced
+const DefaultHashDomain = ""
+
+// Hash contexts combined with the configured domain, so an issuance commitment and a
+// revocation leaf hashed from the same bytes never produce the same digest
+const (
+	hashContextIssuance   = "issuance-commitment"
+	hashContextRevocation = "revocation-leaf"
+)
+
+// domainTag returns the prefix hashed ahead of data in a context: "domain/context" and a
+// NUL byte; an empty domain disables separation, as for commitments issued before it
+func domainTag(domain, context string) []byte {
+	if domain == "" {
+		return nil
+	}
+	return []byte(domain + "/" + context + "\x00")
+}
+
+// domainHash returns SHA256(domainTag(domain, context) || data)
+func domainHash(domain, context string, data []byte) [32]byte {
+	h := sha256.New()
+	h.Write(domainTag(domain, context))
+	h.Write(data)
+	return [32]byte(h.Sum(nil))
+}
+
+// commitmentSize is the length of a commitment hash without its version byte
+const commitmentSize = 32
+
+// ErrUnknownCommitmentVersion is returned for a versioned commitment this attester cannot interpret
+var ErrUnknownCommitmentVersion = errors.New("unknown commitment version")
+
+// commitmentVersions maps each issuance scheme to the version byte of its commitments
+var commitmentVersions = map[string]byte{
+	CommitmentSchemeSHA256: CommitmentVersionSHA256,
+	CommitmentSchemeMiMC:   CommitmentVersionMiMC,
+}
+
+// EncodeCommitment prefixes a 32-byte commitment hash with its format version, hex-encoded
+func EncodeCommitment(version byte, hash []byte) (string, error) {
+	if !knownCommitmentVersion(version) {
+		return "", fmt.Errorf("%w: 0x%02x", ErrUnknownCommitmentVersion, version)
+	}
+	if len(hash) != commitmentSize {
+		return "", fmt.Errorf("commitment must be %d bytes, got %d", commitmentSize, len(hash))
+	}
+	return hex.EncodeToString(append([]byte{version}, hash...)), nil
+}
+
+// DecodeCommitment splits a hex commitment into its format version and 32-byte hash
+// A bare 32-byte commitment is accepted with CommitmentVersionNone
+func DecodeCommitment(commitment string) (byte, []byte, error) {
+	b, err := hex.DecodeString(commitment)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid commitment hex: %w", err)
+	}
+
+	switch len(b) {
+	case commitmentSize:
+		return CommitmentVersionNone, b, nil
+	case commitmentSize + 1:
+		if !knownCommitmentVersion(b[0]) {
+			return 0, nil, fmt.Errorf("%w: 0x%02x", ErrUnknownCommitmentVersion, b[0])
+		}
+		return b[0], b[1:], nil
+	default:
+		return 0, nil, fmt.Errorf("commitment must be %d bytes, or %d with a version byte, got %d", commitmentSize, commitmentSize+1, len(b))
+	}
+}
+
+// commitmentKey normalizes a commitment to its bare hash, so the versioned, bare and
+// 0x-prefixed forms of one commitment are treated alike; other strings are used as given
+func commitmentKey(commitment string) string {
+	_, hash, err := DecodeCommitment(strings.ToLower(strings.TrimPrefix(commitment, "0x")))
+	if err != nil {
+		return commitment
+	}
+	return hex.EncodeToString(hash)
+}
+
+func knownCommitmentVersion(version byte) bool {
+	return version == CommitmentVersionSHA256 || version == CommitmentVersionMiMC
+}
+
+// ValidateCommitmentScheme returns an error if scheme is not a supported commitment scheme
+func ValidateCommitmentScheme(scheme string) error {
+	switch scheme {
+	case CommitmentSchemeSHA256, CommitmentSchemeMiMC:
+		return nil
+	default:
+		return fmt.Errorf("unsupported commitment scheme %q (expected %s or %s)", scheme, CommitmentSchemeSHA256, CommitmentSchemeMiMC)
+	}
+}
+
+// fieldElement reduces big-endian bytes modulo the BN254 scalar field
+func fieldElement(b []byte) *big.Int {
+	return new(big.Int).Mod(new(big.Int).SetBytes(b), fr.Modulus())
+}
+
+// mimcCommitment computes MiMC(identityData, nonce) exactly as the circuit and prover do
+func mimcCommitment(identityData, nonce *big.Int) []byte {
+	h := hash.MIMC_BN254.New()
+	for _, v := range []*big.Int{identityData, nonce} {
+		b := make([]byte, fr.Bytes)
+		v.FillBytes(b)
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
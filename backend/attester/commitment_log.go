@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// How commitments appear in logs, selected by LOG_COMMITMENT_MODE
+const (
+	// CommitmentLogFull logs commitments as given
+	CommitmentLogFull = "full"
+	// CommitmentLogTruncated logs the first commitmentLogPrefix hex characters of the hash
+	CommitmentLogTruncated = "truncated"
+	// CommitmentLogHashed logs a SHA256 digest of the hash, which still correlates entries
+	CommitmentLogHashed = "hashed"
+)
+
+// commitmentLogPrefix is how many hex characters of a hash truncated mode keeps
+const commitmentLogPrefix = 8
+
+// commitmentDigestSize is how many hex characters of the SHA256 digest hashed mode logs
+const commitmentDigestSize = 16
+
+// ValidateCommitmentLogMode returns an error unless mode is a supported commitment log mode
+func ValidateCommitmentLogMode(mode string) error {
+	switch mode {
+	case CommitmentLogFull, CommitmentLogTruncated, CommitmentLogHashed:
+		return nil
+	}
+	return fmt.Errorf("unsupported commitment log mode %q (expected %s, %s or %s)", mode, CommitmentLogFull, CommitmentLogTruncated, CommitmentLogHashed)
+}
+
+// commitmentField logs commitment under key as mode allows; the reduced modes work on
+// the bare hash, so every form of one commitment logs the same value
+func commitmentField(key, commitment, mode string) zap.Field {
+	switch mode {
+	case CommitmentLogTruncated:
+		hash := commitmentKey(commitment)
+		if len(hash) > commitmentLogPrefix {
+			hash = hash[:commitmentLogPrefix] + "..."
+		}
+		return zap.String(key, hash)
+	case CommitmentLogHashed:
+		digest := sha256.Sum256([]byte(commitmentKey(commitment)))
+		return zap.String(key, "sha256:"+hex.EncodeToString(digest[:])[:commitmentDigestSize])
+	default:
+		return zap.String(key, commitment)
+	}
+}
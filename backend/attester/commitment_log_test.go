@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"noah-v2/backend/pkg/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestRevocationLogsCommitmentByMode tests that revocation logs the full commitment, only
+// a prefix of it, or a digest that does not contain it, as LOG_COMMITMENT_MODE selects
+func TestRevocationLogsCommitmentByMode(t *testing.T) {
+	commitment := strings.Repeat("ab", 16) + strings.Repeat("cd", 16)
+	digest := sha256.Sum256([]byte(commitment))
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{CommitmentLogFull, commitment},
+		{CommitmentLogTruncated, "abababab..."},
+		{CommitmentLogHashed, "sha256:" + hex.EncodeToString(digest[:])[:commitmentDigestSize]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Setenv("LOG_COMMITMENT_MODE", tt.mode)
+			api := newTestAPI(t)
+			logs := observeLogs(t)
+
+			// The 0x-prefixed form logs the same as the bare hash in the reduced modes
+			request := RevocationRequest{Commitment: commitment}
+			if tt.mode != CommitmentLogFull {
+				request.Commitment = "0x" + commitment
+			}
+			if code := doJSON(t, setupRouter(api, api.config), http.MethodPost, "/credential/revoke", request, nil); code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", code)
+			}
+
+			entries := logs.FilterMessage("Revoked credential").All()
+			if len(entries) != 1 {
+				t.Fatalf("Expected one revocation log entry, got %d", len(entries))
+			}
+			got := entries[0].ContextMap()["commitment"]
+			if got != tt.want {
+				t.Errorf("Expected commitment logged as %q, got %q", tt.want, got)
+			}
+			if tt.mode != CommitmentLogFull && strings.Contains(got.(string), commitment[commitmentLogPrefix:]) {
+				t.Errorf("Expected the %s log to omit the rest of the commitment, got %q", tt.mode, got)
+			}
+		})
+	}
+
+	if err := ValidateCommitmentLogMode("redacted"); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
+
+// observeLogs replaces the global logger with one recording entries until the test ends
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.InfoLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = previous })
+	return logs
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestCommitmentEncodeDecode tests that versioned and bare commitments round-trip
+func TestCommitmentEncodeDecode(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xab}, 32)
+
+	for _, version := range []byte{CommitmentVersionSHA256, CommitmentVersionMiMC} {
+		encoded, err := EncodeCommitment(version, hash)
+		if err != nil {
+			t.Fatalf("Failed to encode version %d: %v", version, err)
+		}
+		if len(encoded) != 66 {
+			t.Errorf("Expected 33 hex-encoded bytes, got %d characters", len(encoded))
+		}
+
+		gotVersion, gotHash, err := DecodeCommitment(encoded)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", encoded, err)
+		}
+		if gotVersion != version || !bytes.Equal(gotHash, hash) {
+			t.Errorf("Expected version %d and the original hash, got version %d hash %x", version, gotVersion, gotHash)
+		}
+	}
+
+	version, gotHash, err := DecodeCommitment(strings.Repeat("ab", 32))
+	if err != nil || version != CommitmentVersionNone || !bytes.Equal(gotHash, hash) {
+		t.Errorf("Expected a bare commitment to decode unversioned, got version %d hash %x: %v", version, gotHash, err)
+	}
+
+	if _, err := EncodeCommitment(CommitmentVersionMiMC, hash[:31]); err == nil {
+		t.Error("Expected error for a short hash")
+	}
+	if _, _, err := DecodeCommitment(strings.Repeat("ab", 34)); err == nil {
+		t.Error("Expected error for an oversized commitment")
+	}
+}
+
+// TestCommitmentUnknownVersionRejected tests that unknown versions are refused when encoding, decoding and signing
+func TestCommitmentUnknownVersionRejected(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x01}, 32)
+	unknown := "7f" + strings.Repeat("01", 32)
+
+	if _, err := EncodeCommitment(0x7f, hash); !errors.Is(err, ErrUnknownCommitmentVersion) {
+		t.Errorf("Expected ErrUnknownCommitmentVersion encoding, got %v", err)
+	}
+	if _, _, err := DecodeCommitment(unknown); !errors.Is(err, ErrUnknownCommitmentVersion) {
+		t.Errorf("Expected ErrUnknownCommitmentVersion decoding, got %v", err)
+	}
+	// An explicit zero byte is not the implicit version of a bare commitment
+	if _, _, err := DecodeCommitment("00" + strings.Repeat("01", 32)); !errors.Is(err, ErrUnknownCommitmentVersion) {
+		t.Errorf("Expected ErrUnknownCommitmentVersion for a zero version byte, got %v", err)
+	}
+
+	signer, err := NewSignerFromSeed([]byte("noah-commitment-version-seed"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	if _, err := signer.SignCommitment(unknown); !errors.Is(err, ErrUnknownCommitmentVersion) {
+		t.Errorf("Expected SignCommitment to reject an unknown version, got %v", err)
+	}
+
+	// The version byte is not signed, so both forms of a commitment share a signature
+	versioned, _ := EncodeCommitment(CommitmentVersionMiMC, hash)
+	signature, err := signer.SignCommitment(versioned)
+	if err != nil {
+		t.Fatalf("Failed to sign versioned commitment: %v", err)
+	}
+	valid, err := VerifyCommitmentSignature(strings.Repeat("01", 32), signature, signer.GetPublicKey(), HashAlgoSHA256)
+	if err != nil || !valid {
+		t.Errorf("Expected the signature to verify against the bare hash: %v", err)
+	}
+}
+
+// TestCommitmentDomainSeparation tests that an issuance commitment and a revocation leaf
+// hashed from identical bytes differ, and coincide only with separation disabled
+func TestCommitmentDomainSeparation(t *testing.T) {
+	req := &CredentialRequest{UserID: "user-1", Attributes: map[string]interface{}{"age": 30}}
+	nonce := bytes.Repeat([]byte{0x42}, commitmentNonceSize)
+
+	// The exact bytes generateCommitment hashes
+	data, err := json.Marshal(req.Attributes)
+	if err != nil {
+		t.Fatalf("Failed to encode attributes: %v", err)
+	}
+	data = append(append(data, req.UserID...), nonce...)
+
+	issuedAndLeaf := func(domain string) (string, string) {
+		t.Helper()
+		is := &IssuerService{config: &Config{HashDomain: domain}}
+		commitment, err := is.generateCommitment(req, nonce)
+		if err != nil {
+			t.Fatalf("Failed to generate commitment: %v", err)
+		}
+		_, hash, err := DecodeCommitment(commitment)
+		if err != nil {
+			t.Fatalf("Failed to decode commitment: %v", err)
+		}
+		return hex.EncodeToString(hash), hashCommitment(domain, hex.EncodeToString(data))
+	}
+
+	if issued, leaf := issuedAndLeaf("noah-v2"); issued == leaf {
+		t.Errorf("Expected the issuance commitment and revocation leaf to differ, both are %s", issued)
+	}
+	if issued, leaf := issuedAndLeaf(""); issued != leaf {
+		t.Errorf("Expected untagged hashes to coincide, got %s and %s", issued, leaf)
+	}
+}
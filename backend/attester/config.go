@@ -1,46 +1,310 @@
 package main
 
 import (
-	"fmt"
-	"os"
+	"strings"
+	"time"
+
+	"noah-v2/backend/pkg/envconfig"
+	"noah-v2/backend/pkg/listeners"
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/middleware"
+	"noah-v2/backend/pkg/tlsconfig"
+	"noah-v2/circuit"
+
+	"go.uber.org/zap"
 )
 
 // Config holds the attester service configuration
 type Config struct {
-	Port             string
-	PrivateKey       string
+	Port string
+	// MetricsPort also serves /metrics on its own listener; empty serves it on Port only
+	MetricsPort string
+	PrivateKey  string
+	// PrivateKeyFile is a file (e.g. a mounted secret) holding the hex private key; it
+	// takes precedence over PrivateKey
+	PrivateKeyFile string
+	// EntropySource is a device or file read as the RNG for key and nonce generation
+	// (e.g. an HSM's RNG device); empty uses crypto/rand
+	EntropySource    string
 	AttesterID       uint
 	VerifyingKeyPath string
 	AttesterRegistry string
-	StacksNetwork    string
+	// AttesterPubkeyFunction is the registry's read-only function returning an attester's public key
+	AttesterPubkeyFunction string
+	StacksNetwork          string
+	// StacksAPIURL overrides the Hiro API base URL derived from StacksNetwork
+	StacksAPIURL string
+	IssuerName   string
+	IssuerURL    string
+	// RequireKeyManifest makes a missing or invalid verifying key manifest fatal at startup
+	RequireKeyManifest bool
+	RateLimitMaxIPs    int // Maximum number of per-IP rate limiters kept in memory
+	// CORSAllowedOrigins are the origins browsers may call the API from, with credentials
+	CORSAllowedOrigins []string
+	AdminAPIKey        string // Enables /admin endpoints behind the X-API-Key header when set
+	// DenylistVerifyingKeyPath is the key for proofs that include a denylist root
+	DenylistVerifyingKeyPath string
+	// JurisdictionVerifyingKeyPath and AgeVerifyingKeyPath are the keys for proofs declaring
+	// circuit_type "jurisdiction" or "age"; empty rejects that circuit type
+	JurisdictionVerifyingKeyPath string
+	AgeVerifyingKeyPath          string
+	// MaxAge is the largest provable age the KYC circuit is compiled with; it must match
+	// the prover's CIRCUIT_MAX_AGE (0 means circuit.MaxAge)
+	MaxAge int
+	// MaxPublicInputBytes bounds each public input once decoded, so an oversized hex
+	// string is rejected before it is allocated
+	MaxPublicInputBytes int
+	// VerifyingKeyDir optionally holds further verifying keys with manifests, selectable by
+	// circuit version while provers migrate between circuits
+	VerifyingKeyDir string
+	// SignHashAlgo selects how attestations are signed: "sha256" (Clarity) or "keccak256" (Ethereum)
+	SignHashAlgo string
+	// SignedMessage selects what attestations sign: "commitment" or "attestation"
+	// (commitment, attester ID and expiry; see AttestationMessage)
+	SignedMessage string
+	// SignatureScheme selects the attestation signature: "secp256k1" with the attester key,
+	// or "eddsa-babyjubjub" for signatures verifiable inside a circuit
+	SignatureScheme string
+	// SignatureComponents adds the signature split into r, s and v to attestation responses
+	SignatureComponents bool
+	// AttestationValiditySeconds is the default and maximum attestation lifetime
+	AttestationValiditySeconds int64
+	// ExpiryInBlocks expresses attestation expiry as a Stacks burn block height instead of a Unix timestamp
+	ExpiryInBlocks bool
+	// BlocksPerDay is the burn block rate block expiry is estimated with
+	BlocksPerDay int
+	// VerifyNbCPU caps the CPUs used for proof verification (0 uses all CPUs)
+	VerifyNbCPU int
+	// VerifyConcurrency caps concurrent proof verifications; further requests queue (0 is unlimited)
+	VerifyConcurrency int
+	// CommitmentScheme selects how issued commitments are computed: "sha256" or "mimc"
+	CommitmentScheme string
+	// LogCommitmentMode is how commitments appear in logs: "full", "truncated" or "hashed"
+	LogCommitmentMode string
+	// ReissuePolicy is what issuing to a user who holds a credential does: "reject",
+	// "overwrite" or "version"
+	ReissuePolicy string
+	// HashDomain tags issuance commitment and revocation leaf hashes with distinct prefixes
+	// so the same bytes never hash alike in both; empty restores the untagged hashes
+	HashDomain string
+	// RevocationPublishEnabled pushes revocation root changes on-chain through StacksSubmitterURL
+	RevocationPublishEnabled  bool
+	RevocationContract        string // "ADDRESS.contract-name" holding the revocation root
+	RevocationPublishFunction string // Public function taking the new root as (buff 32)
+	// RevocationPublishIntervalSeconds is the quiet period after the last revocation before
+	// publishing, and the delay between retries
+	RevocationPublishIntervalSeconds int
+	// RevocationPublishMaxRetries is how many times a failed submission is retried, with
+	// exponential backoff, before the root is dead-lettered
+	RevocationPublishMaxRetries int
+	// StacksSubmitterURL signs and broadcasts contract-calls on behalf of the contract owner
+	StacksSubmitterURL string
+	// RevocationIssuers names further issuers whose revocation trees this attester hosts
+	// under /revocation/:issuer, alongside its own tree keyed by AttesterID
+	RevocationIssuers []string
+	// RedactedAttributes lists credential attribute keys left out of issuance responses;
+	// they still feed the commitment and stay in the stored credential
+	RedactedAttributes []string
+	// AttributeFieldMap maps credential attributes to circuit fields as "attribute=field"
+	// entries (field: identity_data, age or jurisdiction), for mimc credentials
+	AttributeFieldMap []string
+	// TrustedJurisdictionRoots are the hex jurisdiction allow-list roots proofs must be
+	// against; empty accepts any root
+	TrustedJurisdictionRoots []string
+	// NextIDCacheSeconds is how long a discovered next available attester ID is served
+	// without querying the registry again (0 disables caching)
+	NextIDCacheSeconds int
+	// NextIDTimeoutSeconds bounds how long /info/next-available-id waits for discovery
+	// before answering with the last discovered ID
+	NextIDTimeoutSeconds int
+	// DiscoveryMaxAttempts bounds the registry lookups made when discovering a free attester ID
+	DiscoveryMaxAttempts uint
+	// AttesterAutodiscover looks up the next free attester ID at startup when ATTESTER_ID is unset
+	AttesterAutodiscover bool
+	// ReverifyMaxBundles bounds the proof bundles one /admin/reverify request may carry
+	ReverifyMaxBundles int
+	// HiroProbeInterval is how often Hiro reachability is probed for the hiro health
+	// check (0 disables both); the check degrades once the last success is older than
+	// HiroStaleThreshold
+	HiroProbeInterval  time.Duration
+	HiroStaleThreshold time.Duration
+	// ProofReplayTTL is how long attested proofs are remembered to detect replays (0
+	// disables); ProofReplayStrict rejects replays instead of only logging them
+	ProofReplayTTL    time.Duration
+	ProofReplayStrict bool
+	// SlowRequestThreshold logs requests taking longer at Warn (0 disables)
+	SlowRequestThreshold time.Duration
+	// OTLPEndpoint is the OTLP/HTTP collector base URL spans are exported to (empty disables tracing)
+	OTLPEndpoint string
+	// TLSCertFile and TLSKeyFile serve HTTPS when both are set
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSMinVersion string // Oldest TLS version accepted: 1.0, 1.1, 1.2 or 1.3
 }
 
 // LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
-	return &Config{
-		Port:             getEnv("ATTESTER_PORT", "8081"),
-		PrivateKey:       getEnv("ATTESTER_PRIVATE_KEY", ""),
-		AttesterID:       uint(getEnvUint("ATTESTER_ID", 1)),
-		VerifyingKeyPath: getEnv("VERIFYING_KEY_PATH", "../prover/keys/verifying.key"),
-		AttesterRegistry: getEnv("ATTESTER_REGISTRY", "ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J.attester-registry"),
-		StacksNetwork:    getEnv("STACKS_NETWORK", "testnet"),
+// Malformed numeric or boolean values are reported, one error per key, rather than
+// silently replaced by their defaults; the returned config still holds the defaults
+func LoadConfig() (*Config, error) {
+	var env envconfig.Parser
+	config := &Config{
+		Port:                         envconfig.Get("ATTESTER_PORT", "8081"),
+		MetricsPort:                  envconfig.Get("METRICS_PORT", ""),
+		PrivateKey:                   envconfig.Get("ATTESTER_PRIVATE_KEY", ""),
+		PrivateKeyFile:               envconfig.Get("ATTESTER_PRIVATE_KEY_FILE", ""),
+		EntropySource:                envconfig.Get("ENTROPY_SOURCE", ""),
+		AttesterID:                   env.Uint("ATTESTER_ID", 1),
+		VerifyingKeyPath:             envconfig.Get("VERIFYING_KEY_PATH", "../prover/keys/verifying.key"),
+		DenylistVerifyingKeyPath:     envconfig.Get("DENYLIST_VERIFYING_KEY_PATH", "../prover/keys/denylist_verifying.key"),
+		JurisdictionVerifyingKeyPath: envconfig.Get("JURISDICTION_VERIFYING_KEY_PATH", ""),
+		AgeVerifyingKeyPath:          envconfig.Get("AGE_VERIFYING_KEY_PATH", ""),
+		MaxAge:                       env.Int("CIRCUIT_MAX_AGE", circuit.MaxAge),
+		MaxPublicInputBytes:          env.Int("MAX_PUBLIC_INPUT_BYTES", DefaultMaxInputBytes),
+		VerifyingKeyDir:              envconfig.Get("VERIFYING_KEY_DIR", ""),
+		SignHashAlgo:                 envconfig.Get("SIGN_HASH_ALGO", HashAlgoSHA256),
+		SignedMessage:                envconfig.Get("ATTESTATION_SIGNED_MESSAGE", SignedMessageCommitment),
+		SignatureScheme:              envconfig.Get("ATTESTATION_SIGNATURE_SCHEME", SignatureSchemeSecp256k1),
+		SignatureComponents:          env.Bool("ATTESTATION_SIGNATURE_COMPONENTS", false),
+		AttestationValiditySeconds:   int64(env.Int("ATTESTATION_VALIDITY_SECONDS", 365*24*60*60)),
+		ExpiryInBlocks:               env.Bool("ATTESTATION_EXPIRY_IN_BLOCKS", false),
+		BlocksPerDay:                 env.Int("ATTESTATION_BLOCKS_PER_DAY", defaultBlocksPerDay),
+		AttesterRegistry:             envconfig.Get("ATTESTER_REGISTRY", "ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J.attester-registry"),
+		AttesterPubkeyFunction:       envconfig.Get("ATTESTER_PUBKEY_FUNCTION", "get-attester-pubkey"),
+		StacksNetwork:                envconfig.Get("STACKS_NETWORK", "testnet"),
+		StacksAPIURL:                 envconfig.Get("STACKS_API_URL", ""),
+		IssuerName:                   envconfig.Get("ISSUER_NAME", "Noah Attester"),
+		IssuerURL:                    envconfig.Get("ISSUER_URL", ""),
+		RequireKeyManifest:           env.Bool("REQUIRE_KEY_MANIFEST", false),
+		AdminAPIKey:                  envconfig.Get("ADMIN_API_KEY", ""),
+		RateLimitMaxIPs:              env.Int("RATE_LIMIT_MAX_IPS", middleware.DefaultMaxTrackedIPs),
+		CORSAllowedOrigins:           envconfig.GetList("CORS_ALLOWED_ORIGINS", middleware.DefaultCORSOrigins),
+		VerifyNbCPU:                  env.Int("VERIFY_NB_CPU", 0),
+		VerifyConcurrency:            env.Int("VERIFY_CONCURRENCY", 0),
+		CommitmentScheme:             envconfig.Get("COMMITMENT_SCHEME", CommitmentSchemeSHA256),
+		LogCommitmentMode:            envconfig.Get("LOG_COMMITMENT_MODE", CommitmentLogFull),
+		ReissuePolicy:                envconfig.Get("CREDENTIAL_REISSUE_POLICY", ReissueOverwrite),
+		HashDomain:                   envconfig.GetAllowEmpty("HASH_DOMAIN", DefaultHashDomain),
+
+		RevocationPublishEnabled:         env.Bool("REVOCATION_PUBLISH_ENABLED", false),
+		RevocationContract:               envconfig.Get("REVOCATION_CONTRACT", "ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J.revocation"),
+		RevocationPublishFunction:        envconfig.Get("REVOCATION_PUBLISH_FUNCTION", "update-revocation-root"),
+		RevocationPublishIntervalSeconds: env.Int("REVOCATION_PUBLISH_INTERVAL_SECONDS", 30),
+		RevocationPublishMaxRetries:      env.Int("REVOCATION_PUBLISH_MAX_RETRIES", 8),
+		StacksSubmitterURL:               envconfig.Get("STACKS_SUBMITTER_URL", ""),
+		RevocationIssuers:                envconfig.GetList("REVOCATION_ISSUERS", nil),
+
+		RedactedAttributes:       envconfig.GetList("REDACTED_ATTRIBUTES", nil),
+		AttributeFieldMap:        envconfig.GetList("ATTRIBUTE_FIELD_MAP", nil),
+		TrustedJurisdictionRoots: envconfig.GetList("TRUSTED_JURISDICTION_ROOTS", nil),
+
+		NextIDCacheSeconds:   env.Int("NEXT_ID_CACHE_SECONDS", 60),
+		NextIDTimeoutSeconds: env.Int("NEXT_ID_TIMEOUT_SECONDS", 5),
+		DiscoveryMaxAttempts: env.Uint("ATTESTER_DISCOVERY_MAX_ATTEMPTS", maxAttesterIDAttempts),
+		AttesterAutodiscover: env.Bool("ATTESTER_AUTODISCOVER", true),
+		ReverifyMaxBundles:   env.Int("REVERIFY_MAX_BUNDLES", 100),
+		HiroProbeInterval:    env.Duration("HIRO_PROBE_INTERVAL", 30*time.Second),
+		HiroStaleThreshold:   env.Duration("HIRO_STALE_THRESHOLD", 2*time.Minute),
+		ProofReplayTTL:       env.Duration("PROOF_REPLAY_TTL", 0),
+		ProofReplayStrict:    env.Bool("PROOF_REPLAY_STRICT", false),
+		SlowRequestThreshold: env.Duration("SLOW_REQUEST_THRESHOLD", 0),
+		OTLPEndpoint:         envconfig.Get("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+
+		TLSCertFile:   envconfig.Get("TLS_CERT_FILE", ""),
+		TLSKeyFile:    envconfig.Get("TLS_KEY_FILE", ""),
+		TLSMinVersion: envconfig.Get("TLS_MIN_VERSION", tlsconfig.DefaultMinVersion),
 	}
+	return config, env.Err()
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Listeners returns the ports the service listens on, by setting
+func (c *Config) Listeners() []listeners.Listener {
+	return []listeners.Listener{
+		{Name: "ATTESTER_PORT", Port: c.Port},
+		{Name: "METRICS_PORT", Port: c.MetricsPort},
 	}
-	return defaultValue
 }
 
-func getEnvUint(key string, defaultValue uint) uint {
-	if value := os.Getenv(key); value != "" {
-		var result uint
-		_, err := fmt.Sscanf(value, "%d", &result)
-		if err == nil {
-			return result
-		}
+// LogSafe logs the effective configuration with secrets redacted
+func (c *Config) LogSafe() {
+	logger.Info("Effective configuration", c.LogFields()...)
+}
+
+// LogFields returns every configuration value as a log field, with secrets redacted
+func (c *Config) LogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("port", c.Port),
+		zap.String("metrics_port", c.MetricsPort),
+		zap.String("private_key", envconfig.Redacted(c.PrivateKey)),
+		zap.String("private_key_file", c.PrivateKeyFile),
+		zap.String("entropy_source", c.EntropySource),
+		zap.Uint("attester_id", c.AttesterID),
+		zap.String("verifying_key_path", c.VerifyingKeyPath),
+		zap.String("denylist_verifying_key_path", c.DenylistVerifyingKeyPath),
+		zap.String("jurisdiction_verifying_key_path", c.JurisdictionVerifyingKeyPath),
+		zap.String("age_verifying_key_path", c.AgeVerifyingKeyPath),
+		zap.Int("max_age", c.MaxAge),
+		zap.Int("max_public_input_bytes", c.MaxPublicInputBytes),
+		zap.String("verifying_key_dir", c.VerifyingKeyDir),
+		zap.String("attester_registry", c.AttesterRegistry),
+		zap.String("attester_pubkey_function", c.AttesterPubkeyFunction),
+		zap.String("stacks_network", c.StacksNetwork),
+		zap.String("stacks_api_url", c.StacksAPIURL),
+		zap.String("issuer_name", c.IssuerName),
+		zap.String("issuer_url", c.IssuerURL),
+		zap.Bool("require_key_manifest", c.RequireKeyManifest),
+		zap.Int("rate_limit_max_ips", c.RateLimitMaxIPs),
+		zap.Strings("cors_allowed_origins", c.CORSAllowedOrigins),
+		zap.String("admin_api_key", envconfig.Redacted(c.AdminAPIKey)),
+		zap.String("sign_hash_algo", c.SignHashAlgo),
+		zap.String("signed_message", c.SignedMessage),
+		zap.String("signature_scheme", c.SignatureScheme),
+		zap.Bool("signature_components", c.SignatureComponents),
+		zap.String("commitment_scheme", c.CommitmentScheme),
+		zap.String("log_commitment_mode", c.LogCommitmentMode),
+		zap.String("reissue_policy", c.ReissuePolicy),
+		zap.String("hash_domain", c.HashDomain),
+		zap.Int64("attestation_validity_seconds", c.AttestationValiditySeconds),
+		zap.Bool("expiry_in_blocks", c.ExpiryInBlocks),
+		zap.Int("blocks_per_day", c.BlocksPerDay),
+		zap.Int("verify_nb_cpu", c.VerifyNbCPU),
+		zap.Int("verify_concurrency", c.VerifyConcurrency),
+		zap.Bool("revocation_publish_enabled", c.RevocationPublishEnabled),
+		zap.String("revocation_contract", c.RevocationContract),
+		zap.String("revocation_publish_function", c.RevocationPublishFunction),
+		zap.Int("revocation_publish_interval_seconds", c.RevocationPublishIntervalSeconds),
+		zap.Int("revocation_publish_max_retries", c.RevocationPublishMaxRetries),
+		zap.String("stacks_submitter_url", envconfig.Redacted(c.StacksSubmitterURL)),
+		zap.Strings("revocation_issuers", c.RevocationIssuers),
+		zap.Strings("redacted_attributes", c.RedactedAttributes),
+		zap.Strings("attribute_field_map", c.AttributeFieldMap),
+		zap.Strings("trusted_jurisdiction_roots", c.TrustedJurisdictionRoots),
+		zap.Int("next_id_cache_seconds", c.NextIDCacheSeconds),
+		zap.Int("next_id_timeout_seconds", c.NextIDTimeoutSeconds),
+		zap.Uint("discovery_max_attempts", c.DiscoveryMaxAttempts),
+		zap.Bool("attester_autodiscover", c.AttesterAutodiscover),
+		zap.Int("reverify_max_bundles", c.ReverifyMaxBundles),
+		zap.Duration("hiro_probe_interval", c.HiroProbeInterval),
+		zap.Duration("hiro_stale_threshold", c.HiroStaleThreshold),
+		zap.Duration("proof_replay_ttl", c.ProofReplayTTL),
+		zap.Bool("proof_replay_strict", c.ProofReplayStrict),
+		zap.Duration("slow_request_threshold", c.SlowRequestThreshold),
+		zap.String("otlp_endpoint", envconfig.RedactedURL(c.OTLPEndpoint)),
+		zap.String("tls_cert_file", c.TLSCertFile),
+		zap.String("tls_key_file", c.TLSKeyFile),
+		zap.String("tls_min_version", c.TLSMinVersion),
+	}
+}
+
+// StacksAPI returns the Hiro API base URL, StacksAPIURL when set
+func (c *Config) StacksAPI() string {
+	if c.StacksAPIURL != "" {
+		return strings.TrimSuffix(c.StacksAPIURL, "/")
 	}
-	return defaultValue
+	return stacksAPIURL(c.StacksNetwork)
+}
+
+// HiroProbeEnabled reports whether the hiro health check runs: HIRO_PROBE_INTERVAL must be
+// set, and autodiscovery or block-height expiry must depend on Hiro for it to matter
+func (c *Config) HiroProbeEnabled() bool {
+	return c.HiroProbeInterval > 0 && (c.AttesterAutodiscover || c.ExpiryInBlocks)
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Attestation signature schemes, selected by ATTESTATION_SIGNATURE_SCHEME
+const (
+	// SignatureSchemeSecp256k1 signs with the attester key, for Clarity and Ethereum
+	SignatureSchemeSecp256k1 = "secp256k1"
+	// SignatureSchemeEdDSA signs with an EdDSA BabyJubJub key, which
+	// circuit.AttestationSignatureCircuit can verify inside a proof
+	SignatureSchemeEdDSA = "eddsa-babyjubjub"
+)
+
+// HashAlgoMiMC is the hash EdDSA signatures are made with, reported as hash_algo
+const HashAlgoMiMC = "mimc"
+
+// SignatureFormatEdDSA64 is a compressed R || S EdDSA signature, reported as signature_format
+const SignatureFormatEdDSA64 = "eddsa-babyjubjub-64"
+
+// ValidateSignatureScheme returns an error unless scheme is a supported signature scheme
+// and works with the signed message and signature components settings
+func ValidateSignatureScheme(scheme, signedMessage string, components bool) error {
+	switch scheme {
+	case SignatureSchemeSecp256k1:
+		return nil
+	case SignatureSchemeEdDSA:
+		if signedMessage != SignedMessageCommitment {
+			return fmt.Errorf("%s signs the commitment only (ATTESTATION_SIGNED_MESSAGE=%s)", scheme, SignedMessageCommitment)
+		}
+		if components {
+			return fmt.Errorf("%s signatures have no r, s and v components", scheme)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported signature scheme %q (expected %s or %s)", scheme, SignatureSchemeSecp256k1, SignatureSchemeEdDSA)
+}
+
+// EdDSASigner signs commitments with an EdDSA key over BabyJubJub (gnark's BN254 twisted
+// Edwards curve) and MiMC, so signatures can be verified inside a BN254 circuit
+type EdDSASigner struct {
+	key *eddsa.PrivateKey
+}
+
+// NewEdDSASigner derives an EdDSA key from the attester's secp256k1 key using HKDF-SHA256,
+// so the attester has one secret to manage and the EdDSA key is stable across restarts
+func NewEdDSASigner(s *Signer) (*EdDSASigner, error) {
+	kdf := hkdf.New(sha256.New, s.privateKey.D.FillBytes(make([]byte, 32)), nil, []byte("noah-attester-eddsa-babyjubjub"))
+	key, err := eddsa.GenerateKey(kdf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive EdDSA key: %w", err)
+	}
+	return &EdDSASigner{key: key}, nil
+}
+
+// SignCommitment signs a commitment's 32-byte hash, which must be a BN254 scalar field
+// element as the circuit's Commitment input is, and returns the hex signature
+func (e *EdDSASigner) SignCommitment(commitment string) (string, error) {
+	message, err := eddsaMessage(commitment)
+	if err != nil {
+		return "", err
+	}
+	signature, err := e.key.Sign(message, mimc.NewMiMC())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign commitment: %w", err)
+	}
+	return hex.EncodeToString(signature), nil
+}
+
+// GetPublicKey returns the compressed EdDSA public key as hex
+func (e *EdDSASigner) GetPublicKey() string {
+	return hex.EncodeToString(e.key.Public().Bytes())
+}
+
+// VerifyEdDSACommitmentSignature verifies a signature made by EdDSASigner.SignCommitment
+func VerifyEdDSACommitmentSignature(commitment, signatureHex, publicKeyHex string) (bool, error) {
+	message, err := eddsaMessage(commitment)
+	if err != nil {
+		return false, err
+	}
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return false, fmt.Errorf("invalid signature hex: %w", err)
+	}
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return false, fmt.Errorf("invalid public key hex: %w", err)
+	}
+	var publicKey eddsa.PublicKey
+	if _, err := publicKey.SetBytes(publicKeyBytes); err != nil {
+		return false, fmt.Errorf("invalid public key: %w", err)
+	}
+	return publicKey.Verify(signature, message, mimc.NewMiMC())
+}
+
+// eddsaMessage returns the commitment hash as a canonical field element, which is what
+// MiMC hashes and what the circuit takes as its Commitment input. A hash at or above the
+// field modulus is rejected rather than reduced: h and h+r would reduce to one element,
+// so a signature would also cover a second commitment
+func eddsaMessage(commitment string) ([]byte, error) {
+	hash, err := decodeCommitment(commitment)
+	if err != nil {
+		return nil, err
+	}
+	var element fr.Element
+	if err := element.SetBytesCanonical(hash); err != nil {
+		return nil, fmt.Errorf("commitment hash is not a BN254 scalar field element, as %s signatures require: %w", SignatureSchemeEdDSA, err)
+	}
+	message := element.Bytes()
+	return message[:], nil
+}
+
+// signEdDSA signs a commitment with the issuer's EdDSA signer
+func (is *IssuerService) signEdDSA(commitment string) (string, error) {
+	if is.eddsa == nil {
+		return "", fmt.Errorf("EdDSA signer not initialized")
+	}
+	return is.eddsa.SignCommitment(commitment)
+}
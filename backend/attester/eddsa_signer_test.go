@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/test"
+	"github.com/gin-gonic/gin"
+)
+
+// TestEdDSAAttestationVerifiesInCircuit tests that an attestation signed under the
+// eddsa-babyjubjub scheme verifies natively and inside the attestation signature circuit
+func TestEdDSAAttestationVerifiesInCircuit(t *testing.T) {
+	f := newProofFixture(t)
+	t.Setenv("ATTESTATION_SIGNATURE_SCHEME", SignatureSchemeEdDSA)
+	api := newTestAPI(t)
+	router := gin.New()
+	router.POST("/credential/attest", api.CreateAttestation)
+	router.GET("/info", api.GetAttesterInfo)
+
+	var resp AttestationResponse
+	req := AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: f.publicInputs}
+	if code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &resp); code != http.StatusOK {
+		t.Fatalf("Expected successful attestation, got %d: %s", code, resp.Error)
+	}
+	if resp.HashAlgo != HashAlgoMiMC || resp.SignatureFormat != SignatureFormatEdDSA64 {
+		t.Errorf("Expected %s/%s, got %s/%s", HashAlgoMiMC, SignatureFormatEdDSA64, resp.HashAlgo, resp.SignatureFormat)
+	}
+
+	var info struct {
+		PublicKey       string `json:"public_key"`
+		PublicKeyScheme string `json:"public_key_scheme"`
+		HashAlgo        string `json:"hash_algo"`
+		EdDSAPublicKey  string `json:"eddsa_public_key"`
+		EdDSAHashAlgo   string `json:"eddsa_hash_algo"`
+	}
+	doJSON(t, router, http.MethodGet, "/info", nil, &info)
+	if info.EdDSAPublicKey == "" || info.EdDSAHashAlgo != HashAlgoMiMC {
+		t.Fatalf("Expected /info to publish the EdDSA public key and its hash, got %+v", info)
+	}
+	// The secp256k1 key keeps its own label and hash
+	if info.PublicKey != api.signer.GetPublicKey() || info.PublicKeyScheme != SignatureSchemeSecp256k1 || info.HashAlgo != api.config.SignHashAlgo {
+		t.Errorf("Expected public_key to be labelled as the secp256k1 key, got %+v", info)
+	}
+
+	valid, err := VerifyEdDSACommitmentSignature(f.commitment, resp.Signature, info.EdDSAPublicKey)
+	if err != nil || !valid {
+		t.Fatalf("Expected the signature to verify, got %v, %v", valid, err)
+	}
+
+	publicKey, _ := hex.DecodeString(info.EdDSAPublicKey)
+	signature, _ := hex.DecodeString(resp.Signature)
+	message, err := eddsaMessage(f.commitment)
+	if err != nil {
+		t.Fatalf("Failed to encode commitment: %v", err)
+	}
+	assign := func(message []byte) *circuit.AttestationSignatureCircuit {
+		assignment := &circuit.AttestationSignatureCircuit{Commitment: message}
+		assignment.PublicKey.Assign(tedwards.BN254, publicKey)
+		assignment.Signature.Assign(tedwards.BN254, signature)
+		return assignment
+	}
+
+	field := ecc.BN254.ScalarField()
+	if err := test.IsSolved(&circuit.AttestationSignatureCircuit{}, assign(message), field); err != nil {
+		t.Errorf("Expected the signature to verify in the circuit: %v", err)
+	}
+
+	// The signature does not cover another commitment
+	other := append([]byte{}, message...)
+	other[len(other)-1] ^= 1
+	if err := test.IsSolved(&circuit.AttestationSignatureCircuit{}, assign(other), field); err == nil {
+		t.Error("Expected the signature not to verify for another commitment")
+	}
+}
+
+// TestValidateSignatureScheme tests that EdDSA is only accepted for bare commitment signatures
+func TestValidateSignatureScheme(t *testing.T) {
+	tests := []struct {
+		name          string
+		scheme        string
+		signedMessage string
+		components    bool
+		wantErr       bool
+	}{
+		{"secp256k1", SignatureSchemeSecp256k1, SignedMessageAttestation, true, false},
+		{"eddsa", SignatureSchemeEdDSA, SignedMessageCommitment, false, false},
+		{"eddsa with attestation message", SignatureSchemeEdDSA, SignedMessageAttestation, false, true},
+		{"eddsa with components", SignatureSchemeEdDSA, SignedMessageCommitment, true, true},
+		{"unknown", "ed25519", SignedMessageCommitment, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSignatureScheme(tt.scheme, tt.signedMessage, tt.components)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestEdDSARejectsNonCanonicalCommitment tests that a commitment hash at or above the
+// field modulus is neither signed nor verified, so a signature of h cannot be passed
+// off as one of h+r, which reduces to the same field element
+func TestEdDSARejectsNonCanonicalCommitment(t *testing.T) {
+	t.Setenv("ATTESTATION_SIGNATURE_SCHEME", SignatureSchemeEdDSA)
+	api := newTestAPI(t)
+	signer := api.issuerService.eddsa
+
+	hash := big.NewInt(5)
+	commitment := hex.EncodeToString(hash.FillBytes(make([]byte, 32)))
+	aliased := hex.EncodeToString(new(big.Int).Add(hash, fr.Modulus()).FillBytes(make([]byte, 32)))
+
+	signature, err := signer.SignCommitment(commitment)
+	if err != nil {
+		t.Fatalf("Failed to sign commitment: %v", err)
+	}
+	if valid, err := VerifyEdDSACommitmentSignature(commitment, signature, signer.GetPublicKey()); err != nil || !valid {
+		t.Fatalf("Expected the signature to verify, got %v, %v", valid, err)
+	}
+	if valid, err := VerifyEdDSACommitmentSignature(aliased, signature, signer.GetPublicKey()); err == nil || valid {
+		t.Errorf("Expected the aliased commitment to be rejected, got %v, %v", valid, err)
+	}
+	if _, err := signer.SignCommitment(aliased); err == nil {
+		t.Error("Expected a commitment hash above the modulus not to be signed")
+	}
+
+	// An attestation request for it is refused before the proof is checked
+	_, err = api.issuerService.CreateAttestation(context.Background(), &AttestationRequest{Commitment: aliased, Proof: "proof", PublicInputs: []string{"0x01"}})
+	if !errors.Is(err, ErrInvalidAttestation) {
+		t.Errorf("Expected an invalid attestation, got %v", err)
+	}
+}
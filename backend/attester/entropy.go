@@ -0,0 +1,21 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+// OpenEntropySource returns the RNG for key and nonce generation: crypto/rand when path
+// is empty, otherwise the device or file at path, which stays open for the process
+func OpenEntropySource(path string) (io.Reader, error) {
+	if path == "" {
+		return rand.Reader, nil
+	}
+	source, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open entropy source: %w", err)
+	}
+	return source, nil
+}
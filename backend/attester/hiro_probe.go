@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// HiroProbe periodically reads from the Hiro API, which discovery and block-height expiry
+// depend on, and reports through a health check how long ago a read last succeeded
+type HiroProbe struct {
+	read      func(ctx context.Context) error
+	interval  time.Duration
+	threshold time.Duration
+
+	mu          sync.Mutex
+	lastSuccess time.Time // Zero until a read succeeds
+	lastErr     error     // Error of the last read, nil after a success
+}
+
+// NewHiroProbe creates a probe reading the node info of the Hiro API at apiURL every
+// interval; the check degrades once the last success is older than threshold
+func NewHiroProbe(apiURL string, interval, threshold time.Duration) *HiroProbe {
+	return &HiroProbe{
+		read: func(ctx context.Context) error {
+			_, err := fetchBurnBlockHeight(ctx, apiURL)
+			return err
+		},
+		interval:  interval,
+		threshold: threshold,
+	}
+}
+
+// Probe reads from Hiro once and records the outcome
+func (p *HiroProbe) Probe(ctx context.Context) error {
+	err := p.read(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = err
+	if err == nil {
+		p.lastSuccess = time.Now()
+	}
+	return err
+}
+
+// Run probes immediately and then every interval until ctx is cancelled
+func (p *HiroProbe) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Probe(ctx); err != nil {
+			logger.Warn("Hiro probe failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports healthy while the last successful read is within the threshold and
+// degraded otherwise, since the attester can still sign without Hiro
+func (p *HiroProbe) Check() health.CheckResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.lastSuccess.IsZero() {
+		return health.CheckResult{Status: "degraded", Message: withProbeError("no successful Hiro read yet", p.lastErr)}
+	}
+	age := time.Since(p.lastSuccess)
+	if age > p.threshold {
+		message := fmt.Sprintf("last successful Hiro read %s ago", age.Round(time.Second))
+		return health.CheckResult{Status: "degraded", Message: withProbeError(message, p.lastErr)}
+	}
+	return health.CheckResult{Status: "healthy"}
+}
+
+// withProbeError appends the last read error to message, if there is one
+func withProbeError(message string, err error) string {
+	if err == nil {
+		return message
+	}
+	return message + ": " + err.Error()
+}
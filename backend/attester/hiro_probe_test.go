@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/health"
+)
+
+// TestHiroHealthTransitions tests that the hiro health check degrades once Hiro has been
+// unreachable for longer than the threshold, and recovers on the next successful read
+func TestHiroHealthTransitions(t *testing.T) {
+	var available atomic.Bool
+	available.Store(true)
+	hiro := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"burn_block_height": 850000}`))
+	}))
+	defer hiro.Close()
+
+	const threshold = 500 * time.Millisecond
+	api := newTestAPI(t)
+	api.hiroProbe = NewHiroProbe(hiro.URL, time.Hour, threshold)
+	router := setupRouter(api, api.config)
+
+	hiroStatus := func() (int, string) {
+		var status health.Status
+		code := doJSON(t, router, http.MethodGet, "/health", nil, &status)
+		return code, status.Checks["hiro"].Status
+	}
+
+	// No read yet
+	if code, status := hiroStatus(); code != http.StatusOK || status != "degraded" {
+		t.Errorf("Expected degraded before the first read, got %d %s", code, status)
+	}
+
+	if err := api.hiroProbe.Probe(context.Background()); err != nil {
+		t.Fatalf("Expected the probe to succeed, got: %v", err)
+	}
+	if _, status := hiroStatus(); status != "healthy" {
+		t.Errorf("Expected healthy after a successful read, got %s", status)
+	}
+
+	// Failures within the threshold keep the last success
+	available.Store(false)
+	if err := api.hiroProbe.Probe(context.Background()); err == nil {
+		t.Fatal("Expected the probe to fail while Hiro is unavailable")
+	}
+	if _, status := hiroStatus(); status != "healthy" {
+		t.Errorf("Expected healthy within the threshold, got %s", status)
+	}
+
+	time.Sleep(threshold + 50*time.Millisecond)
+	var status health.Status
+	code := doJSON(t, router, http.MethodGet, "/health", nil, &status)
+	if code != http.StatusOK || status.Status != "degraded" || status.Checks["hiro"].Status != "degraded" {
+		t.Errorf("Expected a degraded 200 past the threshold, got %d %+v", code, status)
+	}
+
+	available.Store(true)
+	if err := api.hiroProbe.Probe(context.Background()); err != nil {
+		t.Fatalf("Expected the probe to succeed again, got: %v", err)
+	}
+	if _, status := hiroStatus(); status != "healthy" {
+		t.Errorf("Expected healthy after recovery, got %s", status)
+	}
+}
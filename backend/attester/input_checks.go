@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"noah-v2/backend/pkg/apierror"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// Errors from the checks run on public inputs before a proof is verified, so a client
+// learns which input is wrong instead of a bare pairing failure
+var (
+	// ErrMalformedInput is returned for a public input that is not even-length hex
+	ErrMalformedInput = errors.New("malformed public input")
+	// ErrInputOutOfField is returned for a public input not below the BN254 scalar modulus
+	ErrInputOutOfField = errors.New("public input out of field")
+	// ErrInputTooLarge is returned for a public input longer than the configured byte limit
+	ErrInputTooLarge = errors.New("public input too large")
+	// ErrCommitmentMismatch is returned when the proof's commitment input is not the supplied commitment
+	ErrCommitmentMismatch = errors.New("commitment mismatch")
+)
+
+// kycInputNames are the KYC circuit's public inputs in order; the sixth is the denylist variant's
+var kycInputNames = []string{"MinAge", "JurisdictionRoot", "RequireAccreditation", "Commitment", "RelyingPartyID", "DenylistRoot"}
+
+// publicInputName returns the circuit field behind public input i of circuitType
+func publicInputName(circuitType string, i int) string {
+	switch {
+	case circuitType == CircuitTypeJurisdiction && i == 0:
+		return "JurisdictionRoot"
+	case circuitType == CircuitTypeAge && i == 0:
+		return "MinAge"
+	case isKYCCircuitType(circuitType) && i < len(kycInputNames):
+		return kycInputNames[i]
+	}
+	return "unknown"
+}
+
+// DefaultMaxInputBytes is the default byte limit per public input: one BN254 field element
+const DefaultMaxInputBytes = fr.Bytes
+
+// ValidateMaxInputBytes rejects a limit below one field element, which would refuse valid inputs
+func ValidateMaxInputBytes(limit int) error {
+	if limit < fr.Bytes {
+		return fmt.Errorf("must be at least %d bytes, got %d", fr.Bytes, limit)
+	}
+	return nil
+}
+
+// checkInputLength returns ErrInputTooLarge, naming the input, if public input i is
+// longer than maxBytes once decoded; it runs before decoding so an oversized input is
+// never allocated
+func checkInputLength(circuitType string, i int, input string, maxBytes int) error {
+	if len(input) > 2*maxBytes {
+		return fmt.Errorf("%w: public input %d (%s) is %d hex characters, more than %d bytes",
+			ErrInputTooLarge, i, publicInputName(circuitType, i), len(input), maxBytes)
+	}
+	return nil
+}
+
+// parseFieldInput decodes public input i and returns ErrMalformedInput or
+// ErrInputOutOfField, naming the input, unless it is a BN254 scalar
+func parseFieldInput(circuitType string, i int, input string) (*big.Int, error) {
+	b, err := hex.DecodeString(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: public input %d (%s): %w", ErrMalformedInput, i, publicInputName(circuitType, i), err)
+	}
+	value := new(big.Int).SetBytes(b)
+	if value.Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("%w: public input %d (%s) is not below the BN254 scalar modulus", ErrInputOutOfField, i, publicInputName(circuitType, i))
+	}
+	return value, nil
+}
+
+// checkFieldInputs returns an error naming the first public input that is longer than
+// maxBytes or not a BN254 scalar
+// Out-of-field values would otherwise be reduced silently when building the witness
+func checkFieldInputs(circuitType string, publicInputs []string, maxBytes int) error {
+	for i, input := range publicInputs {
+		if err := checkInputLength(circuitType, i, input, maxBytes); err != nil {
+			return err
+		}
+		if _, err := parseFieldInput(circuitType, i, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckPublicInputs runs the pre-verification checks on publicInputs: every input must be
+// within the byte limit and a BN254 scalar and, when commitment is given and the circuit has a commitment input,
+// that input must equal the commitment's hash
+func (pv *ProofVerifier) CheckPublicInputs(circuitType, commitment string, publicInputs []string) error {
+	if err := ValidateCircuitType(circuitType); err != nil {
+		return err
+	}
+	if err := checkFieldInputs(circuitType, publicInputs, pv.inputByteLimit()); err != nil {
+		return err
+	}
+	if commitment == "" || !isKYCCircuitType(circuitType) || len(publicInputs) <= commitmentInputIndex {
+		return nil
+	}
+
+	_, hash, err := DecodeCommitment(commitment)
+	if err != nil {
+		return err
+	}
+	declared, _ := parseFieldInput(circuitType, commitmentInputIndex, publicInputs[commitmentInputIndex])
+	if declared.Cmp(new(big.Int).SetBytes(hash)) != 0 {
+		return fmt.Errorf("%w: public input %d (Commitment) is %x, the supplied commitment hash is %x",
+			ErrCommitmentMismatch, commitmentInputIndex, declared.FillBytes(make([]byte, commitmentSize)), hash)
+	}
+	return nil
+}
+
+// inputErrorCode returns the API error code for a failed pre-verification check, or ""
+// if err is not one
+func inputErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrMalformedInput):
+		return apierror.CodeMalformedInput
+	case errors.Is(err, ErrInputOutOfField):
+		return apierror.CodeInputOutOfField
+	case errors.Is(err, ErrInputTooLarge):
+		return apierror.CodeInputTooLarge
+	case errors.Is(err, ErrCommitmentMismatch):
+		return apierror.CodeCommitmentMismatch
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"noah-v2/backend/pkg/apierror"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// TestVerifyProofInputErrors tests that each failed input check is reported with its code and input
+func TestVerifyProofInputErrors(t *testing.T) {
+	f := newProofFixture(t)
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	withInput := func(i int, value string) []string {
+		inputs := append([]string{}, f.publicInputs...)
+		inputs[i] = value
+		return inputs
+	}
+	modulus := hexInput(fr.Modulus())
+
+	tests := []struct {
+		name        string
+		req         ProofVerificationRequest
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:       "valid proof",
+			req:        ProofVerificationRequest{Proof: f.proof, PublicInputs: f.publicInputs, Commitment: f.commitment},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "valid proof, versioned commitment",
+			req:        ProofVerificationRequest{Proof: f.proof, PublicInputs: f.publicInputs, Commitment: "02" + f.commitment},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "malformed input",
+			req:         ProofVerificationRequest{Proof: f.proof, PublicInputs: withInput(2, "0x01")},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    apierror.CodeMalformedInput,
+			wantMessage: "public input 2 (RequireAccreditation)",
+		},
+		{
+			name:        "input out of field",
+			req:         ProofVerificationRequest{Proof: f.proof, PublicInputs: withInput(1, modulus)},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    apierror.CodeInputOutOfField,
+			wantMessage: "public input 1 (JurisdictionRoot)",
+		},
+		{
+			name:        "input too large",
+			req:         ProofVerificationRequest{Proof: f.proof, PublicInputs: withInput(4, strings.Repeat("00", 64))},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    apierror.CodeInputTooLarge,
+			wantMessage: "public input 4 (RelyingPartyID)",
+		},
+		{
+			name:        "commitment mismatch",
+			req:         ProofVerificationRequest{Proof: f.proof, PublicInputs: f.publicInputs, Commitment: strings.Repeat("ab", 32)},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    apierror.CodeCommitmentMismatch,
+			wantMessage: "public input 3 (Commitment)",
+		},
+		{
+			name:        "tampered input",
+			req:         ProofVerificationRequest{Proof: f.proof, PublicInputs: withInput(0, "01")},
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Proof verification failed: invalid proof",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp apierror.APIError
+			code := doJSON(t, router, http.MethodPost, "/proof/verify", tt.req, &resp)
+			if code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %+v", tt.wantStatus, code, resp)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, resp.Code)
+			}
+			if !strings.Contains(resp.Error, tt.wantMessage) {
+				t.Errorf("Expected error containing %q, got %q", tt.wantMessage, resp.Error)
+			}
+		})
+	}
+
+	// The attest endpoint reports out-of-field inputs the same way
+	var resp apierror.APIError
+	req := AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: withInput(0, modulus)}
+	if code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &resp); code != http.StatusBadRequest || resp.Code != apierror.CodeInputOutOfField {
+		t.Errorf("Expected %s from attest, got %d %+v", apierror.CodeInputOutOfField, code, resp)
+	}
+}
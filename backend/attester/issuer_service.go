@@ -1,31 +1,108 @@
 package main
 
 import (
-	"crypto/sha256"
+	"context"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/circuit"
+
+	"go.uber.org/zap"
 )
 
+// ErrInvalidAttestation marks attestation failures caused by the request itself
+// (bad proof, inputs or parameters) rather than by the attester
+var ErrInvalidAttestation = errors.New("invalid attestation request")
+
+// ErrCommitmentExists is returned when an issued commitment is already held by another user
+var ErrCommitmentExists = errors.New("commitment already issued to another user")
+
+// ErrInvalidUserID is returned for a user ID that is empty, too long or contains control characters
+var ErrInvalidUserID = errors.New("invalid user_id")
+
+// maxUserIDLength bounds user IDs, which key the credential store and appear in logs
+const maxUserIDLength = 256
+
+// commitmentNonceSize is the number of random bytes mixed into each commitment
+const commitmentNonceSize = 32
+
 // IssuerService handles credential issuance
 type IssuerService struct {
-	signer      *Signer
+	signer *Signer
+	// mu guards credentials, history and commitments; GetCredential only needs the read lock
+	mu          sync.RWMutex
 	credentials map[string]*Credential
+	history     map[string][]*Credential // Previous credentials by user ID, under the version policy
+	commitments map[string]string        // commitment -> user ID
+	nonces      io.Reader                // Source of per-issuance nonces
 	verifier    *ProofVerifier
+	verifyLimit *verifyLimiter   // Bounds concurrent verifications; nil for no limit
+	fields      *AttributeFields // Attributes behind the derived proof inputs; nil derives none
 	config      *Config
+	// eddsa signs attestations under the eddsa-babyjubjub signature scheme; nil otherwise
+	eddsa *EdDSASigner
+	// blockHeight returns the current burn block height; nil uses wall-clock expiry
+	blockHeight func(ctx context.Context) (uint64, error)
 }
 
-// NewIssuerService creates a new issuer service
-func NewIssuerService(signer *Signer) *IssuerService {
-	config := LoadConfig()
-	verifier := NewProofVerifier(config.VerifyingKeyPath)
-	return &IssuerService{
+// NewIssuerService creates a new issuer service from a config main has validated
+// It fails if ATTRIBUTE_FIELD_MAP is invalid or the configured signature scheme's key
+// cannot be derived
+func NewIssuerService(signer *Signer, config *Config) (*IssuerService, error) {
+	fields, err := ParseAttributeFields(config.AttributeFieldMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATTRIBUTE_FIELD_MAP: %w", err)
+	}
+	verifier := newConfiguredVerifier(config)
+	is := &IssuerService{
 		signer:      signer,
 		credentials: make(map[string]*Credential),
+		history:     make(map[string][]*Credential),
+		commitments: make(map[string]string),
+		nonces:      rand.Reader,
 		verifier:    verifier,
+		verifyLimit: newVerifyLimiter(config.VerifyConcurrency),
+		fields:      fields,
 		config:      config,
 	}
+	if config.SignatureScheme == SignatureSchemeEdDSA {
+		if is.eddsa, err = NewEdDSASigner(signer); err != nil {
+			return nil, err
+		}
+	}
+	if config.ExpiryInBlocks {
+		is.blockHeight = NewBlockHeightCache(config.StacksAPI(), blockHeightCacheTTL).CurrentBlockHeight
+	}
+	return is, nil
+}
+
+// newConfiguredVerifier creates a proof verifier for the configured default, denylist
+// and versioned verifying keys
+func newConfiguredVerifier(config *Config) *ProofVerifier {
+	verifier := NewProofVerifierWithDenylist(config.VerifyingKeyPath, config.DenylistVerifyingKeyPath)
+	verifier.SetAgeLimit(config.MaxAge)
+	verifier.SetMaxInputBytes(config.MaxPublicInputBytes)
+	verifier.SetReplayProtection(config.ProofReplayTTL, config.ProofReplayStrict)
+	verifier.SetCircuitTypeKeyPath(CircuitTypeJurisdiction, config.JurisdictionVerifyingKeyPath)
+	verifier.SetCircuitTypeKeyPath(CircuitTypeAge, config.AgeVerifyingKeyPath)
+	if config.VerifyingKeyDir != "" {
+		loaded, err := verifier.LoadKeyDirectory(config.VerifyingKeyDir)
+		if err != nil {
+			logger.Warn("Failed to load verifying key directory", zap.String("dir", config.VerifyingKeyDir), zap.Error(err))
+		}
+		logger.Info("Loaded versioned verifying keys", zap.Int("count", loaded), zap.Strings("versions", verifier.Versions()))
+	}
+	return verifier
 }
 
 // IssueCredential issues a new credential to a user
@@ -34,31 +111,93 @@ func (is *IssuerService) IssueCredential(req *CredentialRequest) (*Credential, e
 	// 1. Verify user identity documents
 	// 2. Perform KYC checks
 	// 3. Generate a commitment from the credential data
+	if err := validateUserID(req.UserID); err != nil {
+		return nil, err
+	}
 
-	// Generate commitment from credential data
-	commitment, err := is.generateCommitment(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate commitment: %w", err)
+	// A fresh nonce keeps commitments unique even for identical attributes
+	nonce := make([]byte, commitmentNonceSize)
+	if _, err := io.ReadFull(is.nonces, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
 	// Create credential
 	credential := &Credential{
 		UserID:     req.UserID,
 		Attributes: req.Attributes,
-		Commitment: commitment,
 		IssuedAt:   time.Now().Unix(),
 		ExpiresAt:  time.Now().Add(365 * 24 * time.Hour).Unix(), // 1 year expiry
 		AttesterID: is.signer.GetAttesterID(),
 	}
 
+	// Generate commitment from credential data
+	var err error
+	if is.config.CommitmentScheme == CommitmentSchemeMiMC {
+		err = is.generateMiMCCommitment(req, nonce, credential)
+	} else {
+		credential.Commitment, err = is.generateCommitment(req, nonce)
+		credential.Nonce = hex.EncodeToString(nonce)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commitment: %w", err)
+	}
+	commitment := credential.Commitment
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	if owner, exists := is.commitments[commitment]; exists && owner != req.UserID {
+		return nil, ErrCommitmentExists
+	}
+
+	// Reissuing follows the reissue policy: reject, replace the previous credential and
+	// release its commitment, or replace it but keep it in the user's history
+	if previous, exists := is.credentials[req.UserID]; exists {
+		policy := is.config.ReissuePolicy
+		if policy == ReissueReject {
+			return nil, fmt.Errorf("%w: %s", ErrCredentialExists, req.UserID)
+		}
+		logger.Info("Replacing existing credential",
+			zap.String("user_id", req.UserID),
+			zap.String("reissue_policy", policy),
+			commitmentField("previous_commitment", previous.Commitment, is.config.LogCommitmentMode),
+		)
+		if policy == ReissueVersion {
+			is.history[req.UserID] = append(is.history[req.UserID], previous)
+		} else {
+			delete(is.commitments, previous.Commitment)
+		}
+	}
+
 	// Store credential
 	is.credentials[req.UserID] = credential
+	is.commitments[commitment] = req.UserID
 
 	return credential, nil
 }
 
+// redacted returns a copy of the credential without the given attribute keys, for
+// responses; the credential itself is left untouched
+func (c *Credential) redacted(keys []string) *Credential {
+	if len(keys) == 0 {
+		return c
+	}
+	copied := *c
+	copied.Attributes = make(map[string]interface{}, len(c.Attributes))
+	for k, v := range c.Attributes {
+		copied.Attributes[k] = v
+	}
+	for _, k := range keys {
+		delete(copied.Attributes, k)
+	}
+	return &copied
+}
+
 // GetCredential retrieves a credential by user ID
 func (is *IssuerService) GetCredential(userID string) (*Credential, error) {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
 	credential, exists := is.credentials[userID]
 	if !exists {
 		return nil, fmt.Errorf("credential not found for user: %s", userID)
@@ -66,46 +205,182 @@ func (is *IssuerService) GetCredential(userID string) (*Credential, error) {
 	return credential, nil
 }
 
-// generateCommitment generates a commitment hash from credential data
-func (is *IssuerService) generateCommitment(req *CredentialRequest) (string, error) {
+// validateUserID rejects user IDs that would be unsafe as map keys, commitment input or log fields
+func validateUserID(userID string) error {
+	switch {
+	case userID == "":
+		return fmt.Errorf("%w: must not be empty", ErrInvalidUserID)
+	case len(userID) > maxUserIDLength:
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidUserID, maxUserIDLength)
+	case !utf8.ValidString(userID):
+		return fmt.Errorf("%w: not valid UTF-8", ErrInvalidUserID)
+	}
+	for _, r := range userID {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: contains control characters", ErrInvalidUserID)
+		}
+	}
+	return nil
+}
+
+// generateCommitment generates a commitment hash from credential data and the issuance nonce
+func (is *IssuerService) generateCommitment(req *CredentialRequest, nonce []byte) (string, error) {
 	// Serialize credential data
 	data, err := json.Marshal(req.Attributes)
 	if err != nil {
 		return "", err
 	}
 
-	// Add user ID
+	// Add user ID and nonce
 	data = append(data, []byte(req.UserID)...)
+	data = append(data, nonce...)
 
-	// Hash the data
-	hash := sha256.Sum256(data)
-	return hex.EncodeToString(hash[:]), nil
+	// Hash the data, tagged so it cannot collide with a revocation leaf
+	hash := domainHash(is.config.HashDomain, hashContextIssuance, data)
+	return EncodeCommitment(CommitmentVersionSHA256, hash[:])
+}
+
+// generateMiMCCommitment sets the credential's commitment to MiMC(IdentityData, Nonce),
+// the value the KYC circuit commits to, along with the inputs a prover needs to match it:
+// IdentityData, Nonce and any Age and Jurisdiction mapped by ATTRIBUTE_FIELD_MAP
+// IdentityData is MiMC(CredentialData, ExpiryDays), binding the expiry day of ExpiresAt
+// so the expiry circuit can prove the credential has not expired
+func (is *IssuerService) generateMiMCCommitment(req *CredentialRequest, nonce []byte, credential *Credential) error {
+	inputs := &ProofInputs{}
+	if err := is.fields.derive(req, circuit.EffectiveAgeLimit(is.config.MaxAge), inputs); err != nil {
+		return err
+	}
+	credentialData, err := is.fields.identityData(req)
+	if err != nil {
+		return err
+	}
+	expiryDays := big.NewInt(credential.ExpiresAt / secondsPerDay)
+	identity := mimcHashElements(credentialData, expiryDays)
+	fieldNonce := fieldElement(nonce)
+
+	credential.Commitment, err = EncodeCommitment(CommitmentVersionMiMC, mimcCommitment(identity, fieldNonce))
+	if err != nil {
+		return err
+	}
+	credential.Nonce = hex.EncodeToString(fieldNonce.FillBytes(make([]byte, commitmentNonceSize)))
+	inputs.IdentityData = identity.String()
+	inputs.CredentialData = credentialData.String()
+	inputs.ExpiryDays = expiryDays.String()
+	inputs.Nonce = fieldNonce.String()
+	credential.ProofInputs = inputs
+	return nil
 }
 
 // VerifyProof verifies a ZK proof using groth16.Verify
-func (is *IssuerService) VerifyProof(proof string, publicInputs []string) (bool, error) {
+// format is the proof encoding ("base64" or "hex"); empty means base64
+// version selects the verifying key by circuit hash; empty uses the default key
+// circuitType selects the circuit (see ValidateCircuitType); empty means KYC
+// Beyond VERIFY_CONCURRENCY verifications at once, callers wait for a slot until ctx ends
+func (is *IssuerService) VerifyProof(ctx context.Context, proof, format, version, circuitType string, publicInputs []string) (bool, error) {
 	// Basic validation
 	if proof == "" || len(publicInputs) == 0 {
 		return false, fmt.Errorf("invalid proof or public inputs")
 	}
 
 	// Use the proof verifier to perform actual cryptographic verification
-	return is.verifier.VerifyProof(proof, publicInputs)
+	var verified bool
+	err := is.verifyLimit.do(ctx, func() (err error) {
+		verified, err = is.verifier.VerifyProofForCircuit(proof, format, version, circuitType, publicInputs)
+		return err
+	})
+	return verified, err
 }
 
 // CreateAttestation creates an attestation signature for a proof
-func (is *IssuerService) CreateAttestation(req *AttestationRequest) (*AttestationResponse, error) {
+func (is *IssuerService) CreateAttestation(ctx context.Context, req *AttestationRequest) (*AttestationResponse, error) {
+	validity, err := is.attestationValidity(req.ValiditySeconds)
+	if err != nil {
+		return invalidAttestation(err.Error())
+	}
+
+	if _, _, err := DecodeCommitment(req.Commitment); err != nil {
+		return invalidAttestation(err.Error())
+	}
+	// EdDSA signs the hash as a field element, so it must already be one
+	if is.config.SignatureScheme == SignatureSchemeEdDSA {
+		if _, err := eddsaMessage(req.Commitment); err != nil {
+			return invalidAttestation(err.Error())
+		}
+	}
+
+	if err := ValidateCircuitType(req.CircuitType); err != nil {
+		return invalidAttestation(err.Error())
+	}
+
+	// The signature covers req.Commitment, so only proofs with a Commitment input can be
+	// attested; jurisdiction and age proofs say nothing about any commitment
+	if !isKYCCircuitType(req.CircuitType) {
+		return invalidAttestation(fmt.Sprintf("%s proofs have no commitment input and cannot be attested; check them with /proof/verify", req.CircuitType))
+	}
+
+	if err := checkRelyingParty(req.RelyingPartyID, req.PublicInputs); err != nil {
+		return invalidAttestation(err.Error())
+	}
+
+	// The proof must be about the commitment being signed
+	if err := is.verifier.CheckPublicInputs(req.CircuitType, req.Commitment, req.PublicInputs); err != nil {
+		return &AttestationResponse{
+			Success: false,
+			Error:   "Proof verification failed: " + err.Error(),
+		}, fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
+	}
+
+	if err := checkJurisdictionRoot(is.config.TrustedJurisdictionRoots, req.CircuitType, req.PublicInputs); err != nil {
+		return &AttestationResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
+	}
+
 	// Verify the proof first
-	verified, err := is.VerifyProof(req.Proof, req.PublicInputs)
-	if !verified || err != nil {
+	verified, err := is.VerifyProof(ctx, req.Proof, req.Format, req.CircuitVersion, req.CircuitType, req.PublicInputs)
+	if errors.Is(err, ErrVerifierUnavailable) {
+		return &AttestationResponse{
+			Success: false,
+			Error:   "Proof verifier unavailable",
+		}, err
+	}
+	if errors.Is(err, ErrCheckFailed) || inputErrorCode(err) != "" {
+		return &AttestationResponse{
+			Success: false,
+			Error:   "Proof verification failed: " + err.Error(),
+		}, fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
+	}
+	if err != nil {
+		return invalidAttestation("Proof verification failed: " + err.Error())
+	}
+	if !verified {
+		return invalidAttestation("Proof verification failed")
+	}
+
+	// A proof already attested within the replay window is not signed for again
+	if err := is.verifier.CheckReplay(req.CircuitType, req.PublicInputs); err != nil {
 		return &AttestationResponse{
 			Success: false,
-			Error:   "Proof verification failed",
-		}, fmt.Errorf("proof verification failed: %w", err)
+			Error:   err.Error(),
+		}, fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
 	}
 
-	// Sign the commitment
-	signature, err := is.signer.SignCommitment(req.Commitment)
+	// The expiry comes first, as a structured signed message covers it
+	expiry, expiryType := is.computeExpiry(ctx, validity)
+
+	// Sign the commitment or attestation message with the configured scheme and hash algorithm
+	var signature string
+	hashAlgo, format := is.config.SignHashAlgo, signatureFormat(is.config.SignHashAlgo)
+	switch {
+	case is.config.SignatureScheme == SignatureSchemeEdDSA:
+		signature, err = is.signEdDSA(req.Commitment)
+		hashAlgo, format = HashAlgoMiMC, SignatureFormatEdDSA64
+	case is.config.SignedMessage == SignedMessageAttestation:
+		signature, err = is.signer.SignAttestationWith(req.Commitment, is.signer.GetAttesterID(), expiry, is.config.SignHashAlgo)
+	default:
+		signature, err = is.signer.SignCommitmentWith(req.Commitment, is.config.SignHashAlgo)
+	}
 	if err != nil {
 		return &AttestationResponse{
 			Success: false,
@@ -113,16 +388,111 @@ func (is *IssuerService) CreateAttestation(req *AttestationRequest) (*Attestatio
 		}, fmt.Errorf("failed to sign commitment: %w", err)
 	}
 
-	// Calculate expiry (1 year from now, in block height approximation)
-	// In production, use actual block height from Stacks
-	expiry := uint64(time.Now().Add(365 * 24 * time.Hour).Unix())
+	response := &AttestationResponse{
+		Commitment:      req.Commitment,
+		Signature:       signature,
+		HashAlgo:        hashAlgo,
+		SignatureFormat: format,
+		SignedMessage:   is.config.SignedMessage,
+		AttesterID:      is.signer.GetAttesterID(),
+		IssuerName:      is.config.IssuerName,
+		IssuerURL:       is.config.IssuerURL,
+		Expiry:          expiry,
+		ExpiryType:      expiryType,
+		Success:         true,
+	}
+	if is.config.SignatureComponents {
+		if response.SignatureComponents, err = splitSignature(signature); err != nil {
+			return &AttestationResponse{
+				Success: false,
+				Error:   "Signature generation failed",
+			}, fmt.Errorf("failed to split signature: %w", err)
+		}
+	}
 
+	// The receipt covers the finished response, so it is signed last
+	if response.ReceiptSignature, err = is.signer.SignReceipt(response); err != nil {
+		return &AttestationResponse{
+			Success: false,
+			Error:   "Signature generation failed",
+		}, fmt.Errorf("failed to sign receipt: %w", err)
+	}
+
+	logger.Info("Signed attestation",
+		commitmentField("commitment", req.Commitment, is.config.LogCommitmentMode),
+		zap.Uint("attester_id", response.AttesterID),
+		zap.Uint64("expiry", expiry),
+		zap.String("expiry_type", expiryType),
+	)
+	return response, nil
+}
+
+// invalidAttestation builds the response for a request the attester refuses to sign
+func invalidAttestation(message string) (*AttestationResponse, error) {
 	return &AttestationResponse{
-		Commitment: req.Commitment,
-		Signature:  signature,
-		AttesterID: is.signer.GetAttesterID(),
-		Expiry:     expiry,
-		Success:    true,
-	}, nil
+		Success: false,
+		Error:   message,
+	}, fmt.Errorf("%w: %s", ErrInvalidAttestation, message)
 }
 
+// relyingPartyInput is the index of the RelyingPartyID public input in every circuit variant
+const relyingPartyInput = 4
+
+// checkRelyingParty rejects a proof bound to a party other than the requesting one
+// An empty requested ID stands for 0, the ID of proofs bound to no party
+func checkRelyingParty(requested string, publicInputs []string) error {
+	want := new(big.Int)
+	if requested != "" {
+		if _, ok := want.SetString(requested, 10); !ok || want.Sign() < 0 {
+			return fmt.Errorf("invalid relying_party_id %q", requested)
+		}
+	}
+	if len(publicInputs) <= relyingPartyInput {
+		return fmt.Errorf("invalid public inputs: missing RelyingPartyID")
+	}
+	bound, err := hex.DecodeString(publicInputs[relyingPartyInput])
+	if err != nil {
+		return fmt.Errorf("invalid RelyingPartyID hex: %w", err)
+	}
+	got := new(big.Int).SetBytes(bound)
+	if got.Cmp(want) != 0 {
+		return fmt.Errorf("proof is bound to relying party %s, not %s", got, want)
+	}
+	return nil
+}
+
+// attestationValidity returns the lifetime for an attestation, applying an optional
+// per-request override that may only shorten the configured maximum
+func (is *IssuerService) attestationValidity(requested int64) (int64, error) {
+	max := is.config.AttestationValiditySeconds
+	switch {
+	case requested == 0:
+		return max, nil
+	case requested < 0:
+		return 0, fmt.Errorf("validity_seconds must be positive")
+	case requested > max:
+		return 0, fmt.Errorf("validity_seconds %d exceeds the maximum of %d", requested, max)
+	default:
+		return requested, nil
+	}
+}
+
+// computeExpiry converts a validity period into an expiry and its type
+// With block expiry configured, the Stacks burn block height is used, advanced by the
+// validity at ATTESTATION_BLOCKS_PER_DAY and rounded up; if it cannot be fetched,
+// expiry falls back to a wall-clock Unix timestamp
+func (is *IssuerService) computeExpiry(ctx context.Context, validitySeconds int64) (uint64, string) {
+	if is.blockHeight != nil {
+		height, err := is.blockHeight(ctx)
+		if err == nil {
+			blocksPerDay := int64(is.config.BlocksPerDay)
+			if blocksPerDay <= 0 {
+				blocksPerDay = defaultBlocksPerDay
+			}
+			blocks := (validitySeconds*blocksPerDay + secondsPerDay - 1) / secondsPerDay
+			return height + uint64(blocks), "block_height"
+		}
+		logger.Warn("Failed to fetch Stacks block height, using wall-clock expiry", zap.Error(err))
+	}
+	return uint64(time.Now().Add(time.Duration(validitySeconds) * time.Second).Unix()), "timestamp"
+}
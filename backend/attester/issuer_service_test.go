@@ -0,0 +1,569 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// TestAttestationExpiry tests the default, overridden and over-maximum attestation validity
+func TestAttestationExpiry(t *testing.T) {
+	f := newProofFixture(t)
+	t.Setenv("ATTESTATION_VALIDITY_SECONDS", "86400")
+	is := newTestAPI(t).issuerService
+
+	tests := []struct {
+		name     string
+		validity int64
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"default", 0, 24 * time.Hour, false},
+		{"override", 3600, time.Hour, false},
+		{"over max", 86401, 0, true},
+		{"negative", -1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			resp, err := is.CreateAttestation(context.Background(), &AttestationRequest{
+				Commitment:      f.commitment,
+				PublicInputs:    f.publicInputs,
+				Proof:           f.proof,
+				ValiditySeconds: tt.validity,
+			})
+			if tt.wantErr {
+				if err == nil || resp.Success {
+					t.Fatalf("Expected error for validity %d, got %+v", tt.validity, resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to create attestation: %v", err)
+			}
+
+			if resp.ExpiryType != "timestamp" {
+				t.Errorf("Expected timestamp expiry, got %q", resp.ExpiryType)
+			}
+			low := uint64(before.Add(tt.want).Unix())
+			high := uint64(time.Now().Add(tt.want).Unix())
+			if resp.Expiry < low || resp.Expiry > high {
+				t.Errorf("Expected expiry in [%d, %d], got %d", low, high, resp.Expiry)
+			}
+		})
+	}
+}
+
+// TestComputeExpiryBlockHeight tests block-height expiry and its wall-clock fallback
+func TestComputeExpiryBlockHeight(t *testing.T) {
+	is := &IssuerService{
+		config:      &Config{AttestationValiditySeconds: 86400},
+		blockHeight: func(context.Context) (uint64, error) { return 1000, nil },
+	}
+
+	expiry, expiryType := is.computeExpiry(context.Background(), 3601)
+	if expiryType != "block_height" || expiry != 1007 {
+		t.Errorf("Expected block height 1007, got %s %d", expiryType, expiry)
+	}
+
+	is.blockHeight = func(context.Context) (uint64, error) { return 0, fmt.Errorf("node unavailable") }
+	expiry, expiryType = is.computeExpiry(context.Background(), 3600)
+	if expiryType != "timestamp" || expiry < uint64(time.Now().Unix()) {
+		t.Errorf("Expected wall-clock fallback, got %s %d", expiryType, expiry)
+	}
+}
+
+// TestBlockHeightExpiryFromHiro tests that block expiry is computed from the height Hiro
+// reports, which is cached between attestations and falls back to wall-clock when Hiro fails
+func TestBlockHeightExpiryFromHiro(t *testing.T) {
+	var requests atomic.Int32
+	var available atomic.Bool
+	available.Store(true)
+	hiro := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/info" || !available.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"burn_block_height": 850000, "stacks_tip_height": 160000}`))
+	}))
+	defer hiro.Close()
+
+	cache := NewBlockHeightCache(hiro.URL, time.Hour)
+	is := &IssuerService{
+		config:      &Config{AttestationValiditySeconds: 7 * 86400, BlocksPerDay: 144},
+		blockHeight: cache.CurrentBlockHeight,
+	}
+
+	// One day is 144 blocks; a part block rounds up
+	if expiry, expiryType := is.computeExpiry(context.Background(), 86400); expiryType != "block_height" || expiry != 850144 {
+		t.Errorf("Expected block height 850144, got %s %d", expiryType, expiry)
+	}
+	if expiry, _ := is.computeExpiry(context.Background(), 86400+1); expiry != 850145 {
+		t.Errorf("Expected block height 850145, got %d", expiry)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected the height to be fetched once, got %d requests", n)
+	}
+
+	// A different estimate scales the block count
+	is.config.BlocksPerDay = 288
+	if expiry, _ := is.computeExpiry(context.Background(), 86400); expiry != 850288 {
+		t.Errorf("Expected block height 850288, got %d", expiry)
+	}
+
+	// Without a cached height, a failed fetch falls back to a timestamp
+	available.Store(false)
+	is.blockHeight = NewBlockHeightCache(hiro.URL, time.Hour).CurrentBlockHeight
+	expiry, expiryType := is.computeExpiry(context.Background(), 86400)
+	if expiryType != "timestamp" || expiry < uint64(time.Now().Unix()) {
+		t.Errorf("Expected wall-clock fallback, got %s %d", expiryType, expiry)
+	}
+}
+
+// TestBlockHeightCacheSharesFetches tests that concurrent callers share one fetch made
+// outside the lock, and that a failure is served from the cache for a short while
+func TestBlockHeightCacheSharesFetches(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cache := &BlockHeightCache{ttl: time.Hour, fetch: func(context.Context) (uint64, error) {
+		calls.Add(1)
+		<-release
+		return 850000, nil
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if height, err := cache.CurrentBlockHeight(context.Background()); err != nil || height != 850000 {
+				t.Errorf("Expected height 850000, got %d, %v", height, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected one shared fetch, got %d", n)
+	}
+
+	failing := &BlockHeightCache{ttl: time.Hour, fetch: func(context.Context) (uint64, error) {
+		calls.Add(1)
+		return 0, errors.New("hiro unavailable")
+	}}
+	calls.Store(0)
+	for i := 0; i < 3; i++ {
+		if _, err := failing.CurrentBlockHeight(context.Background()); err == nil {
+			t.Fatal("Expected the fetch error")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected the failure to be cached, got %d fetches", n)
+	}
+	failing.failed = time.Now().Add(-blockHeightFailureTTL)
+	failing.CurrentBlockHeight(context.Background())
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected a retry once the failure expires, got %d fetches", n)
+	}
+}
+
+// TestBlockHeightCacheUsesCallerContext tests that the fetch runs under the caller's context,
+// which carries its trace, without the caller's cancellation
+func TestBlockHeightCacheUsesCallerContext(t *testing.T) {
+	type traceKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "request-trace"))
+	cancel()
+
+	cache := &BlockHeightCache{ttl: time.Hour, fetch: func(ctx context.Context) (uint64, error) {
+		if ctx.Value(traceKey{}) != "request-trace" {
+			t.Error("Expected the fetch to run under the caller's context")
+		}
+		return 850000, ctx.Err()
+	}}
+	if _, err := cache.CurrentBlockHeight(ctx); err != nil {
+		t.Errorf("Expected the caller's cancellation not to fail the shared fetch, got %v", err)
+	}
+}
+
+// zeroReader yields an endless stream of zero bytes, giving a fixed issuance nonce
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// newTestIssuer creates an issuer service without a proof verifier
+func newTestIssuer(t *testing.T) *IssuerService {
+	t.Helper()
+	signer, err := NewSignerFromSeed([]byte("noah-issuer-test"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return &IssuerService{
+		signer:      signer,
+		credentials: make(map[string]*Credential),
+		history:     make(map[string][]*Credential),
+		commitments: make(map[string]string),
+		nonces:      zeroReader{},
+		config:      &Config{CommitmentScheme: CommitmentSchemeSHA256, ReissuePolicy: ReissueOverwrite},
+	}
+}
+
+// TestIssueCredentialRejectsCommitmentCollision tests that a commitment held by
+// another user is never reissued and the existing credential is kept
+func TestIssueCredentialRejectsCommitmentCollision(t *testing.T) {
+	is := newTestIssuer(t)
+	req := &CredentialRequest{UserID: "alice", Attributes: map[string]interface{}{"age": 30}}
+
+	first, err := is.IssueCredential(req)
+	if err != nil {
+		t.Fatalf("Failed to issue credential: %v", err)
+	}
+
+	// With a fixed nonce alice's next commitment is the same; pretend another user holds it
+	is.commitments[first.Commitment] = "mallory"
+	if _, err := is.IssueCredential(req); !errors.Is(err, ErrCommitmentExists) {
+		t.Fatalf("Expected ErrCommitmentExists, got %v", err)
+	}
+	if got, _ := is.GetCredential("alice"); got != first {
+		t.Error("Expected rejected issuance to leave the existing credential in place")
+	}
+
+	// Reissuing to the owner replaces the credential
+	is.commitments[first.Commitment] = "alice"
+	second, err := is.IssueCredential(req)
+	if err != nil {
+		t.Fatalf("Failed to reissue credential: %v", err)
+	}
+	if got, _ := is.GetCredential("alice"); got != second {
+		t.Error("Expected reissued credential to replace the previous one")
+	}
+}
+
+// TestIssuerServiceConcurrentAccess tests that concurrent issuance, reissuance and lookups
+// are safe; run with -race to detect unguarded map access
+func TestIssuerServiceConcurrentAccess(t *testing.T) {
+	is := newTestIssuer(t)
+	const workers, rounds = 8, 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				// Each worker reissues its own few users and reads everyone's
+				userID := fmt.Sprintf("user-%d-%d", w, i%5)
+				req := &CredentialRequest{UserID: userID, Attributes: map[string]interface{}{"round": i}}
+				if _, err := is.IssueCredential(req); err != nil {
+					t.Errorf("Failed to issue credential to %s: %v", userID, err)
+					return
+				}
+				if _, err := is.GetCredential(userID); err != nil {
+					t.Errorf("Failed to get credential just issued to %s: %v", userID, err)
+				}
+				is.GetCredential(fmt.Sprintf("user-%d-0", (w+1)%workers))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+	if len(is.credentials) != workers*5 || len(is.commitments) != workers*5 {
+		t.Errorf("Expected %d credentials and commitments, got %d and %d", workers*5, len(is.credentials), len(is.commitments))
+	}
+}
+
+// TestIssueCredentialInjectedNonceSource tests that nonces come from the injected
+// entropy source, so a deterministic source reproduces the commitment
+func TestIssueCredentialInjectedNonceSource(t *testing.T) {
+	req := &CredentialRequest{UserID: "alice", Attributes: map[string]interface{}{"age": 30}}
+	issue := func(nonce []byte) *Credential {
+		is := newTestIssuer(t)
+		is.nonces = bytes.NewReader(nonce)
+		credential, err := is.IssueCredential(req)
+		if err != nil {
+			t.Fatalf("Failed to issue credential: %v", err)
+		}
+		return credential
+	}
+
+	nonce := bytes.Repeat([]byte{0x5a}, commitmentNonceSize)
+	first, second := issue(nonce), issue(nonce)
+	if first.Nonce != hex.EncodeToString(nonce) {
+		t.Errorf("Expected nonce %x from the source, got %s", nonce, first.Nonce)
+	}
+	if first.Commitment != second.Commitment {
+		t.Errorf("Expected identical commitments, got %s and %s", first.Commitment, second.Commitment)
+	}
+
+	is := newTestIssuer(t)
+	is.nonces = bytes.NewReader(nonce[:8])
+	if _, err := is.IssueCredential(req); err == nil {
+		t.Error("Expected an error when the nonce source runs out")
+	}
+}
+
+// TestIssueCredentialNonceMakesCommitmentsUnique tests that identical attributes yield distinct commitments
+func TestIssueCredentialNonceMakesCommitmentsUnique(t *testing.T) {
+	is := newTestIssuer(t)
+	is.nonces = rand.Reader
+	attributes := map[string]interface{}{"age": 30, "jurisdiction": 840}
+
+	seen := make(map[string]bool)
+	for _, userID := range []string{"alice", "alice", "bob"} {
+		credential, err := is.IssueCredential(&CredentialRequest{UserID: userID, Attributes: attributes})
+		if err != nil {
+			t.Fatalf("Failed to issue credential: %v", err)
+		}
+		if len(credential.Nonce) != 2*commitmentNonceSize {
+			t.Errorf("Expected %d-byte hex nonce, got %q", commitmentNonceSize, credential.Nonce)
+		}
+		if seen[credential.Commitment] {
+			t.Errorf("Expected unique commitment, got duplicate %s", credential.Commitment)
+		}
+		seen[credential.Commitment] = true
+
+		// The commitment is reproducible from the stored nonce
+		nonce, _ := hex.DecodeString(credential.Nonce)
+		want, _ := is.generateCommitment(&CredentialRequest{UserID: userID, Attributes: attributes}, nonce)
+		if credential.Commitment != want {
+			t.Errorf("Expected commitment %s from stored nonce, got %s", want, credential.Commitment)
+		}
+	}
+
+	// The replaced credential's commitment is released
+	if len(is.commitments) != 2 {
+		t.Errorf("Expected 2 live commitments after reissuance, got %d", len(is.commitments))
+	}
+}
+
+// TestIssueCredentialMiMCCommitmentIsProvable tests that a mimc-scheme credential's
+// proof inputs yield a proof whose commitment matches the issued one and is attested
+func TestIssueCredentialMiMCCommitmentIsProvable(t *testing.T) {
+	f := newProofFixture(t)
+	t.Setenv("COMMITMENT_SCHEME", CommitmentSchemeMiMC)
+	is := newTestAPI(t).issuerService
+
+	credential, err := is.IssueCredential(&CredentialRequest{
+		UserID:     "alice",
+		Attributes: map[string]interface{}{"age": 25, "jurisdiction": 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to issue credential: %v", err)
+	}
+	if credential.ProofInputs == nil {
+		t.Fatal("Expected proof inputs for a mimc credential")
+	}
+
+	identity, _ := new(big.Int).SetString(credential.ProofInputs.IdentityData, 10)
+	nonce, _ := new(big.Int).SetString(credential.ProofInputs.Nonce, 10)
+	if identity == nil || nonce == nil {
+		t.Fatalf("Invalid proof inputs %+v", credential.ProofInputs)
+	}
+
+	// The circuit recomputes the commitment, so proving fails unless it matches
+	commitment := testMiMC(identity, nonce)
+	if want := "02" + hex.EncodeToString(commitment.FillBytes(make([]byte, 32))); credential.Commitment != want {
+		t.Fatalf("Expected commitment %s, got %s", want, credential.Commitment)
+	}
+	assignment := f.assignment
+	assignment.IdentityData = identity
+	assignment.Nonce = nonce
+	assignment.Commitment = commitment
+	fullWitness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("Failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(f.ccs, f.pk, fullWitness)
+	if err != nil {
+		t.Fatalf("Failed to prove the issued commitment: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		t.Fatalf("Failed to serialize proof: %v", err)
+	}
+
+	publicInputs := append([]string{}, f.publicInputs[:3]...)
+	publicInputs = append(publicInputs, hexInput(commitment), f.publicInputs[4])
+	resp, err := is.CreateAttestation(context.Background(), &AttestationRequest{
+		Commitment:   credential.Commitment,
+		PublicInputs: publicInputs,
+		Proof:        base64.StdEncoding.EncodeToString(proofBuf.Bytes()),
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("Expected the issued commitment to be attested, got %+v: %v", resp, err)
+	}
+
+	// The identity data commits to the expiry day
+	credentialData, _ := new(big.Int).SetString(credential.ProofInputs.CredentialData, 10)
+	expiryDays := big.NewInt(credential.ExpiresAt / secondsPerDay)
+	if credentialData == nil || credential.ProofInputs.ExpiryDays != expiryDays.String() {
+		t.Fatalf("Expected credential data and expiry day %s, got %+v", expiryDays, credential.ProofInputs)
+	}
+	if bound := mimcHashElements(credentialData, expiryDays); bound.Cmp(identity) != 0 {
+		t.Errorf("Expected identity data MiMC(credential_data, expiry_days) = %s, got %s", bound, identity)
+	}
+
+	// The credential data is derived from the credential, so it is stable across issuances
+	again, err := is.IssueCredential(&CredentialRequest{
+		UserID:     "alice",
+		Attributes: map[string]interface{}{"jurisdiction": 1, "age": 25},
+	})
+	if err != nil {
+		t.Fatalf("Failed to reissue credential: %v", err)
+	}
+	if again.ProofInputs.CredentialData != credential.ProofInputs.CredentialData {
+		t.Error("Expected credential data to be deterministic")
+	}
+	if again.Commitment == credential.Commitment {
+		t.Error("Expected a fresh nonce to change the commitment")
+	}
+}
+
+// TestIssueCredentialDerivedInputsAreProvable tests that the age, jurisdiction and identity
+// data derived from mapped attributes prove the issued commitment
+func TestIssueCredentialDerivedInputsAreProvable(t *testing.T) {
+	f := newProofFixture(t)
+	t.Setenv("COMMITMENT_SCHEME", CommitmentSchemeMiMC)
+	t.Setenv("ATTRIBUTE_FIELD_MAP", "name=identity_data,dob_years=age,country=jurisdiction")
+	is := newTestAPI(t).issuerService
+
+	// JSON numbers arrive as float64 and decimal strings are accepted
+	attributes := map[string]interface{}{"name": "Alice", "dob_years": float64(25), "country": "1", "email": "a@example.com"}
+	credential, err := is.IssueCredential(&CredentialRequest{UserID: "alice", Attributes: attributes})
+	if err != nil {
+		t.Fatalf("Failed to issue credential: %v", err)
+	}
+	inputs := credential.ProofInputs
+	if inputs == nil || inputs.Age != "25" || inputs.Jurisdiction != "1" {
+		t.Fatalf("Expected age 25 and jurisdiction 1, got %+v", inputs)
+	}
+
+	// Only the identity attributes feed the credential data
+	attributes["email"] = "b@example.com"
+	again, err := is.IssueCredential(&CredentialRequest{UserID: "alice", Attributes: attributes})
+	if err != nil {
+		t.Fatalf("Failed to reissue credential: %v", err)
+	}
+	if again.ProofInputs.CredentialData != inputs.CredentialData {
+		t.Error("Expected an unmapped attribute not to change the credential data")
+	}
+
+	// The age and jurisdiction attributes are committed along with the identity ones
+	for key, value := range map[string]interface{}{"dob_years": 30, "country": 2} {
+		changed := make(map[string]interface{}, len(attributes))
+		for k, v := range attributes {
+			changed[k] = v
+		}
+		changed[key] = value
+		other, err := is.IssueCredential(&CredentialRequest{UserID: "alice", Attributes: changed})
+		if err != nil {
+			t.Fatalf("Failed to reissue credential: %v", err)
+		}
+		if other.ProofInputs.CredentialData == inputs.CredentialData {
+			t.Errorf("Expected changing %q to change the credential data", key)
+		}
+	}
+
+	assignment := f.assignment
+	assignment.IdentityData, _ = new(big.Int).SetString(inputs.IdentityData, 10)
+	assignment.Nonce, _ = new(big.Int).SetString(inputs.Nonce, 10)
+	assignment.Age, _ = new(big.Int).SetString(inputs.Age, 10)
+	assignment.Jurisdiction, _ = new(big.Int).SetString(inputs.Jurisdiction, 10)
+	commitment := testMiMC(assignment.IdentityData.(*big.Int), assignment.Nonce.(*big.Int))
+	assignment.Commitment = commitment
+	fullWitness, err := frontend.NewWitness(&assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("Failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(f.ccs, f.pk, fullWitness)
+	if err != nil {
+		t.Fatalf("Failed to prove the derived inputs: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		t.Fatalf("Failed to serialize proof: %v", err)
+	}
+
+	publicInputs := append([]string{}, f.publicInputs[:3]...)
+	publicInputs = append(publicInputs, hexInput(commitment), f.publicInputs[4])
+	resp, err := is.CreateAttestation(context.Background(), &AttestationRequest{
+		Commitment:   credential.Commitment,
+		PublicInputs: publicInputs,
+		Proof:        base64.StdEncoding.EncodeToString(proofBuf.Bytes()),
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("Expected the derived commitment to be attested, got %+v: %v", resp, err)
+	}
+
+	invalid := map[string]map[string]interface{}{
+		"missing age":         {"name": "Alice", "country": 1},
+		"missing identity":    {"dob_years": 25, "country": 1},
+		"fractional age":      {"name": "Alice", "dob_years": 25.5, "country": 1},
+		"negative":            {"name": "Alice", "dob_years": 25, "country": -1},
+		"non-numeric":         {"name": "Alice", "dob_years": 25, "country": "US"},
+		"above the age limit": {"name": "Alice", "dob_years": 200, "country": 1},
+	}
+	for name, attributes := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := is.IssueCredential(&CredentialRequest{UserID: "bob", Attributes: attributes})
+			if !errors.Is(err, ErrInvalidAttributes) {
+				t.Errorf("Expected ErrInvalidAttributes, got %v", err)
+			}
+		})
+	}
+}
+
+// TestParseAttributeFields tests the ATTRIBUTE_FIELD_MAP syntax
+func TestParseAttributeFields(t *testing.T) {
+	fields, err := ParseAttributeFields([]string{"name=identity_data", " dob = age ", "country=jurisdiction", "passport=identity_data"})
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if fields.Age != "dob" || fields.Jurisdiction != "country" || len(fields.Identity) != 2 {
+		t.Errorf("Unexpected fields %+v", fields)
+	}
+
+	for _, entries := range [][]string{
+		{"name"},
+		{"=age"},
+		{"name=nationality"},
+		{"a=age", "b=age"},
+		{"a=age", "a=jurisdiction"},
+	} {
+		if _, err := ParseAttributeFields(entries); err == nil {
+			t.Errorf("Expected %q to be rejected", entries)
+		}
+	}
+	// The issuer service refuses an invalid map rather than deriving no proof inputs
+	signer, err := NewSignerFromSeed([]byte("noah-attribute-fields-test"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	if _, err := NewIssuerService(signer, &Config{AttributeFieldMap: []string{"name"}}); err == nil {
+		t.Error("Expected NewIssuerService to reject an invalid ATTRIBUTE_FIELD_MAP")
+	}
+}
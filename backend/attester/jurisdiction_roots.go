@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// jurisdictionRootInput is the index of the JurisdictionRoot public input in every KYC circuit variant
+const jurisdictionRootInput = 1
+
+// ErrUntrustedJurisdictionRoot is returned for proofs of membership in a jurisdiction
+// tree other than the trusted allow-lists
+var ErrUntrustedJurisdictionRoot = errors.New("untrusted jurisdiction root")
+
+// parseRoot parses a hex field element, with or without a 0x prefix
+func parseRoot(root string) (*big.Int, error) {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(root, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jurisdiction root %q: %w", root, err)
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+// ValidateJurisdictionRoots returns an error if a trusted root is not hex
+func ValidateJurisdictionRoots(roots []string) error {
+	for _, root := range roots {
+		if _, err := parseRoot(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkJurisdictionRoot rejects a proof whose JurisdictionRoot is not a trusted root
+// Roots compare as field elements, so leading zeros do not matter; with no trusted
+// roots configured, or for a circuit type without one, any proof is accepted
+func checkJurisdictionRoot(trusted []string, circuitType string, publicInputs []string) error {
+	index, ok := circuitRootInput(circuitType)
+	if len(trusted) == 0 || !ok {
+		return nil
+	}
+	if len(publicInputs) <= index {
+		return fmt.Errorf("invalid public inputs: missing JurisdictionRoot")
+	}
+	got, err := parseRoot(publicInputs[index])
+	if err != nil {
+		return err
+	}
+	for _, root := range trusted {
+		if want, err := parseRoot(root); err == nil && want.Cmp(got) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: proof is against jurisdiction root %s", ErrUntrustedJurisdictionRoot, publicInputs[index])
+}
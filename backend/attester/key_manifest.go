@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+
+	"noah-v2/backend/pkg/keymanifest"
+)
+
+// LoadAndVerifyManifest loads the manifest distributed with the verifying key
+// and checks that the key on disk matches it. If a public key is given and a
+// detached signature exists, the signature over the verifying key is checked too.
+// With requireSignature, a missing signature is an error: the manifest itself is
+// unsigned, so anyone able to swap the key could rewrite it and delete the signature.
+func LoadAndVerifyManifest(verifyingKeyPath string, publicKey *ecdsa.PublicKey, requireSignature bool) (*keymanifest.Manifest, error) {
+	manifest, err := keymanifest.Read(keymanifest.ManifestPath(verifyingKeyPath))
+	if err != nil {
+		return nil, err
+	}
+
+	vkBytes, err := os.ReadFile(verifyingKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verifying key: %w", err)
+	}
+
+	if err := manifest.VerifyKey(vkBytes); err != nil {
+		return nil, err
+	}
+
+	if publicKey == nil {
+		return manifest, nil
+	}
+
+	signature, err := os.ReadFile(keymanifest.SignaturePath(verifyingKeyPath))
+	if os.IsNotExist(err) {
+		if requireSignature {
+			return nil, fmt.Errorf("verifying key signature %s is missing", keymanifest.SignaturePath(verifyingKeyPath))
+		}
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verifying key signature: %w", err)
+	}
+
+	if err := keymanifest.VerifySignature(publicKey, vkBytes, string(signature)); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
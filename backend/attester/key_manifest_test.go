@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"noah-v2/backend/pkg/keymanifest"
+)
+
+// TestLoadAndVerifyManifest tests that a signed manifest verifies and a tampered key is rejected
+func TestLoadAndVerifyManifest(t *testing.T) {
+	signer, err := NewSignerFromSeed([]byte("noah-manifest-test"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	vkPath := filepath.Join(t.TempDir(), "verifying.key")
+	vkBytes := []byte("verifying-key-bytes")
+	if err := os.WriteFile(vkPath, vkBytes, 0644); err != nil {
+		t.Fatalf("Failed to write verifying key: %v", err)
+	}
+
+	manifest := keymanifest.New([]byte("circuit-bytes"), vkBytes, "groth16", "bn254")
+	if err := keymanifest.Write(keymanifest.ManifestPath(vkPath), manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	signature, err := keymanifest.Sign(signer.privateKey, vkBytes)
+	if err != nil {
+		t.Fatalf("Failed to sign verifying key: %v", err)
+	}
+	if err := os.WriteFile(keymanifest.SignaturePath(vkPath), []byte(signature), 0644); err != nil {
+		t.Fatalf("Failed to write signature: %v", err)
+	}
+
+	loaded, err := LoadAndVerifyManifest(vkPath, signer.publicKey, true)
+	if err != nil {
+		t.Fatalf("Expected manifest to verify, got: %v", err)
+	}
+	if loaded.CircuitHash != manifest.CircuitHash {
+		t.Errorf("Expected circuit hash %s, got %s", manifest.CircuitHash, loaded.CircuitHash)
+	}
+
+	// Tamper with the verifying key
+	if err := os.WriteFile(vkPath, []byte("tampered-key-bytes"), 0644); err != nil {
+		t.Fatalf("Failed to tamper verifying key: %v", err)
+	}
+	if _, err := LoadAndVerifyManifest(vkPath, signer.publicKey, true); err == nil {
+		t.Error("Expected error for tampered verifying key, got nil")
+	}
+}
+
+// TestLoadAndVerifyManifestMissingSignature tests that a swapped key with a rewritten
+// manifest and no signature is rejected when the manifest is required
+func TestLoadAndVerifyManifestMissingSignature(t *testing.T) {
+	signer, err := NewSignerFromSeed([]byte("noah-manifest-test"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	vkPath := filepath.Join(t.TempDir(), "verifying.key")
+	vkBytes := []byte("verifying-key-bytes")
+	if err := os.WriteFile(vkPath, vkBytes, 0644); err != nil {
+		t.Fatalf("Failed to write verifying key: %v", err)
+	}
+	signature, err := keymanifest.Sign(signer.privateKey, vkBytes)
+	if err != nil {
+		t.Fatalf("Failed to sign verifying key: %v", err)
+	}
+	if err := os.WriteFile(keymanifest.SignaturePath(vkPath), []byte(signature), 0644); err != nil {
+		t.Fatalf("Failed to write signature: %v", err)
+	}
+
+	// Swap the key, rewrite the unsigned manifest to match and remove the signature
+	tampered := []byte("tampered-key-bytes")
+	if err := os.WriteFile(vkPath, tampered, 0644); err != nil {
+		t.Fatalf("Failed to tamper verifying key: %v", err)
+	}
+	manifest := keymanifest.New([]byte("circuit-bytes"), tampered, "groth16", "bn254")
+	if err := keymanifest.Write(keymanifest.ManifestPath(vkPath), manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if err := os.Remove(keymanifest.SignaturePath(vkPath)); err != nil {
+		t.Fatalf("Failed to remove signature: %v", err)
+	}
+
+	if _, err := LoadAndVerifyManifest(vkPath, signer.publicKey, true); err == nil {
+		t.Error("Expected error for a missing signature when required, got nil")
+	}
+	// Without the requirement, the key is only checked against its manifest
+	if _, err := LoadAndVerifyManifest(vkPath, signer.publicKey, false); err != nil {
+		t.Errorf("Expected manifest to verify without a required signature, got: %v", err)
+	}
+}
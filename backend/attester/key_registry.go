@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"noah-v2/backend/pkg/keymanifest"
+	"noah-v2/backend/pkg/logger"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"go.uber.org/zap"
+)
+
+// RegisterKey makes a verifying key selectable by circuit version
+func (pv *ProofVerifier) RegisterKey(version string, vk groth16.VerifyingKey) {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	pv.keys[version] = vk
+}
+
+// RegisterOutputs declares the public inputs of a circuit version that are check
+// outputs; proofs are only accepted when each of them equals 1
+func (pv *ProofVerifier) RegisterOutputs(version string, indices ...int) {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
+	pv.outputs[version] = indices
+}
+
+// HasVersion reports whether a verifying key is registered for the circuit version
+func (pv *ProofVerifier) HasVersion(version string) bool {
+	pv.mu.RLock()
+	defer pv.mu.RUnlock()
+	_, ok := pv.keys[version]
+	return ok
+}
+
+// Versions returns the registered circuit versions in sorted order
+func (pv *ProofVerifier) Versions() []string {
+	pv.mu.RLock()
+	defer pv.mu.RUnlock()
+	versions := make([]string, 0, len(pv.keys))
+	for version := range pv.keys {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// LoadKeyDirectory registers every *.key file in dir under the circuit hash from its manifest
+// Keys without a manifest are skipped, since their version is unknown; a key that does
+// not match its manifest is an error
+func (pv *ProofVerifier) LoadKeyDirectory(dir string) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.key"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list verifying keys: %w", err)
+	}
+
+	loaded := 0
+	for _, path := range paths {
+		manifest, err := keymanifest.Read(keymanifest.ManifestPath(path))
+		if err != nil {
+			logger.Warn("Skipping verifying key without manifest", zap.String("path", path), zap.Error(err))
+			continue
+		}
+
+		vkBytes, err := os.ReadFile(path)
+		if err != nil {
+			return loaded, fmt.Errorf("failed to read verifying key %s: %w", path, err)
+		}
+		if err := manifest.VerifyKey(vkBytes); err != nil {
+			return loaded, fmt.Errorf("verifying key %s: %w", path, err)
+		}
+
+		vk, err := readVerifyingKey(path)
+		if err != nil {
+			return loaded, err
+		}
+		pv.RegisterKey(manifest.CircuitHash, vk)
+		loaded++
+	}
+	return loaded, nil
+}
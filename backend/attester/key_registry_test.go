@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"noah-v2/backend/pkg/keymanifest"
+	"noah-v2/backend/pkg/middleware"
+	"noah-v2/backend/pkg/proofbundle"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// writeVersionedKey writes a verifying key and a manifest declaring the given circuit bytes
+func writeVersionedKey(t *testing.T, dir, name string, vk groth16.VerifyingKey, circuitBytes []byte) string {
+	t.Helper()
+	var vkBuf bytes.Buffer
+	if _, err := vk.WriteTo(&vkBuf); err != nil {
+		t.Fatalf("Failed to serialize verifying key: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, vkBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write verifying key: %v", err)
+	}
+	manifest := keymanifest.New(circuitBytes, vkBuf.Bytes(), "groth16", "bn254")
+	if err := keymanifest.Write(keymanifest.ManifestPath(path), manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	return manifest.CircuitHash
+}
+
+// TestVerifyProofByCircuitVersion tests that proofs from two key pairs verify only under their declared version
+func TestVerifyProofByCircuitVersion(t *testing.T) {
+	f := newProofFixture(t)
+
+	// A second key pair stands in for an upgraded circuit
+	pk2, vk2, err := groth16.Setup(f.ccs)
+	if err != nil {
+		t.Fatalf("Failed to set up second key pair: %v", err)
+	}
+	proof2, err := groth16.Prove(f.ccs, pk2, f.witness)
+	if err != nil {
+		t.Fatalf("Failed to prove with second key pair: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof2.WriteTo(&proofBuf); err != nil {
+		t.Fatalf("Failed to serialize proof: %v", err)
+	}
+	encodedProof2 := base64.StdEncoding.EncodeToString(proofBuf.Bytes())
+
+	vk1, err := readVerifyingKey(f.vkPath)
+	if err != nil {
+		t.Fatalf("Failed to read fixture key: %v", err)
+	}
+
+	dir := t.TempDir()
+	v1 := writeVersionedKey(t, dir, "v1.key", vk1, []byte("circuit-v1"))
+	v2 := writeVersionedKey(t, dir, "v2.key", vk2, []byte("circuit-v2"))
+	// Keys without a manifest have no version and are skipped
+	if err := os.WriteFile(filepath.Join(dir, "unversioned.key"), []byte("ignored"), 0644); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	pv := NewProofVerifier(f.vkPath)
+	loaded, err := pv.LoadKeyDirectory(dir)
+	if err != nil {
+		t.Fatalf("Failed to load key directory: %v", err)
+	}
+	if loaded != 2 {
+		t.Fatalf("Expected 2 versioned keys, got %d", loaded)
+	}
+
+	tests := []struct {
+		name    string
+		proof   string
+		version string
+		want    bool
+	}{
+		{"v1 proof, v1 key", f.proof, v1, true},
+		{"v2 proof, v2 key", encodedProof2, v2, true},
+		{"v1 proof, v2 key", f.proof, v2, false},
+		{"v2 proof, v1 key", encodedProof2, v1, false},
+		{"v1 proof, default key", f.proof, "", true},
+		{"unknown version", f.proof, "deadbeef", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := pv.VerifyProofWithVersion(tt.proof, "", tt.version, f.publicInputs)
+			if valid != tt.want {
+				t.Errorf("Expected valid=%v, got %v (err: %v)", tt.want, valid, err)
+			}
+			if !tt.want && err == nil {
+				t.Error("Expected an error for a rejected proof")
+			}
+		})
+	}
+}
+
+// TestLoadKeyDirectoryRejectsMismatchedKey tests that a key differing from its manifest is not registered
+func TestLoadKeyDirectoryRejectsMismatchedKey(t *testing.T) {
+	f := newProofFixture(t)
+	vk, err := readVerifyingKey(f.vkPath)
+	if err != nil {
+		t.Fatalf("Failed to read fixture key: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "v1.key")
+	writeVersionedKey(t, dir, "v1.key", vk, []byte("circuit-v1"))
+	if err := os.WriteFile(path, []byte("tampered"), 0644); err != nil {
+		t.Fatalf("Failed to overwrite key: %v", err)
+	}
+
+	pv := NewProofVerifier(f.vkPath)
+	if _, err := pv.LoadKeyDirectory(dir); err == nil {
+		t.Error("Expected error for a key that does not match its manifest")
+	}
+	if len(pv.Versions()) != 0 {
+		t.Errorf("Expected no registered versions, got %v", pv.Versions())
+	}
+}
+
+// TestReverify tests re-verifying a proof against its own key and an upgraded circuit's key
+func TestReverify(t *testing.T) {
+	f := newProofFixture(t)
+	vk1, err := readVerifyingKey(f.vkPath)
+	if err != nil {
+		t.Fatalf("Failed to read fixture key: %v", err)
+	}
+	_, vk2, err := groth16.Setup(f.ccs)
+	if err != nil {
+		t.Fatalf("Failed to set up second key pair: %v", err)
+	}
+	dir := t.TempDir()
+	v1 := writeVersionedKey(t, dir, "v1.key", vk1, []byte("circuit-v1"))
+	v2 := writeVersionedKey(t, dir, "v2.key", vk2, []byte("circuit-v2"))
+
+	t.Setenv("VERIFYING_KEY_DIR", dir)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	t.Setenv("REVERIFY_MAX_BUNDLES", "2")
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	proof, err := base64.StdEncoding.DecodeString(f.proof)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture proof: %v", err)
+	}
+	bundle := proofbundle.New(v1, proofbundle.ProofSystemGroth16, proof, f.publicInputs)
+	reverify := func(version string, bundles ...*proofbundle.Bundle) (int, ReverifyResponse) {
+		body, _ := json.Marshal(ReverifyRequest{CircuitVersion: version, Bundles: bundles})
+		req := httptest.NewRequest(http.MethodPost, "/admin/reverify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.APIKeyHeader, "admin-secret")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var resp ReverifyResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	code, resp := reverify(v1, bundle)
+	if code != http.StatusOK || resp.Passed != 1 || resp.Failed != 0 || !resp.Results[0].Valid {
+		t.Errorf("Expected the proof to pass under its own key, got %d %+v", code, resp)
+	}
+
+	// The bundle's own circuit hash does not pick the key
+	code, resp = reverify(v2, bundle, &proofbundle.Bundle{Version: 2})
+	if code != http.StatusOK || resp.Passed != 0 || resp.Failed != 2 {
+		t.Fatalf("Expected both bundles to fail under the new key, got %d %+v", code, resp)
+	}
+	for i, result := range resp.Results {
+		if result.Index != i || result.Valid || result.Error == "" {
+			t.Errorf("Expected failed result %d with an error, got %+v", i, result)
+		}
+	}
+
+	if code, _ := reverify("deadbeef", bundle); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown version, got %d", code)
+	}
+	if code, _ := reverify(v1, bundle, bundle, bundle); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 above REVERIFY_MAX_BUNDLES, got %d", code)
+	}
+}
+
+// TestVerifierConcurrentInitialize tests that requests initializing the verifier race
+// neither each other nor keys being registered and looked up; run with -race
+func TestVerifierConcurrentInitialize(t *testing.T) {
+	f := newProofFixture(t)
+	pv := NewProofVerifier(f.vkPath)
+	vk, err := readVerifyingKey(f.vkPath)
+	if err != nil {
+		t.Fatalf("Failed to read verifying key: %v", err)
+	}
+
+	const requests = 8
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		version := "v" + strconv.Itoa(i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if valid, err := pv.VerifyProof(f.proof, f.publicInputs); err != nil || !valid {
+				t.Errorf("Expected the proof to verify, got %v, %v", valid, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := pv.Initialize(); err != nil {
+				t.Errorf("Failed to initialize verifier: %v", err)
+			}
+			pv.RegisterKey(version, vk)
+			if !pv.HasVersion(version) {
+				t.Errorf("Expected version %s to be registered", version)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := len(pv.Versions()); got < requests {
+		t.Errorf("Expected at least %d versions, got %d", requests, got)
+	}
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
-	"encoding/hex"
+	"context"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"strings"
+	"runtime"
+	"time"
 
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/apispec"
 	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/listeners"
 	"noah-v2/backend/pkg/logger"
 	"noah-v2/backend/pkg/metrics"
 	"noah-v2/backend/pkg/middleware"
+	"noah-v2/backend/pkg/tlsconfig"
+	"noah-v2/backend/pkg/tracing"
+	"noah-v2/circuit"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -21,71 +27,39 @@ import (
 // discoverNextAvailableID queries the contract to find the next available attester ID
 // Starts from ID 1 and increments until finding an available one
 func discoverNextAvailableID(config *Config) (uint, error) {
-	startID := uint(1)
-	maxAttempts := uint(100) // Limit search to prevent infinite loops
-
-	// Parse contract address
-	parts := strings.Split(config.AttesterRegistry, ".")
-	if len(parts) != 2 {
-		return 0, fmt.Errorf("invalid contract address format: %s", config.AttesterRegistry)
-	}
-	contractAddress := parts[0]
-	contractName := parts[1]
-
-	// Determine API URL based on network
-	apiURL := "https://api.testnet.hiro.so/v2"
-	if config.StacksNetwork == "mainnet" {
-		apiURL = "https://api.hiro.so/v2"
-	}
-
-	// Try IDs starting from 1
-	for i := uint(0); i < maxAttempts; i++ {
-		testID := startID + i
-
-		// Encode ID as Clarity uint (little-endian, 8 bytes)
-		idBytes := make([]byte, 8)
-		idBytes[0] = byte(testID)
-		idBytes[1] = byte(testID >> 8)
-		idBytes[2] = byte(testID >> 16)
-		idBytes[3] = byte(testID >> 24)
-		idBytes[4] = byte(testID >> 32)
-		idBytes[5] = byte(testID >> 40)
-		idBytes[6] = byte(testID >> 48)
-		idBytes[7] = byte(testID >> 56)
-		idHex := "0x01000000000000000000000000000000" + hex.EncodeToString(idBytes)
-
-		// Call contract read-only function
-		url := fmt.Sprintf("%s/contracts/call-read/%s/%s/get-attester-pubkey", apiURL, contractAddress, contractName)
-		payload := fmt.Sprintf(`{"sender": "%s", "arguments": ["%s"]}`, contractAddress, idHex)
-
-		resp, err := http.Post(url, "application/json", strings.NewReader(payload))
-		if err != nil {
-			return 0, fmt.Errorf("failed to query contract: %w", err)
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return 0, fmt.Errorf("failed to read response: %w", err)
-		}
+	return findAvailableAttesterID(context.Background(), config.StacksAPI(), config.AttesterRegistry, config.AttesterPubkeyFunction, 1, config.DiscoveryMaxAttempts)
+}
 
-		// If response contains error (attester not found), this ID is available
-		bodyStr := string(body)
-		if strings.Contains(bodyStr, "ERR_ATTESTER_NOT_FOUND") ||
-			strings.Contains(bodyStr, "u1003") ||
-			!strings.Contains(bodyStr, `"okay":true`) {
-			// ID is not found, so it's available
-			return testID, nil
-		}
+// resolveAttesterID returns the attester ID to sign with: the configured one when it was
+// set explicitly or ATTESTER_AUTODISCOVER is off, otherwise the one discover finds,
+// falling back to the configured ID if discovery fails
+func resolveAttesterID(config *Config, explicit bool, discover func() (uint, error)) uint {
+	if explicit {
+		logger.Info("Using explicitly configured Attester ID", zap.Uint("id", config.AttesterID))
+		return config.AttesterID
+	}
+	if !config.AttesterAutodiscover {
+		logger.Info("Attester ID auto-discovery disabled, using configured ID", zap.Uint("id", config.AttesterID))
+		return config.AttesterID
 	}
 
-	// If we've tried many IDs and all are taken, return an error
-	return 0, fmt.Errorf("could not find available ID after %d attempts", maxAttempts)
+	nextID, err := discover()
+	if err != nil {
+		logger.Warn("Could not discover next available ID, using configured ID",
+			zap.Uint("id", config.AttesterID),
+			zap.Error(err))
+		return config.AttesterID
+	}
+	logger.Info("Auto-discovered next available Attester ID", zap.Uint("id", nextID))
+	return nextID
 }
 
 func main() {
+	verifyFile := flag.String("verify-file", "", "verify the JSON {proof, public_inputs} in this file, print the result and exit")
+	flag.Parse()
+
 	// Load configuration
-	config := LoadConfig()
+	config, configErr := LoadConfig()
 
 	// Initialize logger
 	err := logger.Initialize(logger.Config{
@@ -100,35 +74,99 @@ func main() {
 	}
 	defer logger.Sync()
 
+	if configErr != nil {
+		logger.Fatal("Invalid configuration", zap.Error(configErr))
+	}
+
+	// Verify a single proof offline without starting the server
+	if *verifyFile != "" {
+		runVerifyFile(*verifyFile, config)
+	}
+	config.LogSafe()
+	if err := middleware.ValidateCORSOrigins(config.CORSAllowedOrigins, corsAllowCredentials); err != nil {
+		logger.Fatal("Invalid CORS_ALLOWED_ORIGINS", zap.Error(err))
+	}
+	if err := listeners.Validate(config.Listeners()...); err != nil {
+		logger.Fatal("Conflicting listener ports", zap.Error(err))
+	}
+
+	// Trace requests when an OTLP collector is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), "attester", config.OTLPEndpoint)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", zap.Error(err))
+	}
+	defer shutdownTracing(context.Background())
+
 	// Initialize metrics
 	metrics.Initialize(metrics.Config{
 		ServiceName: "attester",
 	})
 
-	// Discover next available ID dynamically (unless explicitly set via env var)
-	attesterID := config.AttesterID
-	if os.Getenv("ATTESTER_ID") == "" {
-		// Only auto-discover if ATTESTER_ID is not explicitly set
-		nextID, err := discoverNextAvailableID(config)
-		if err == nil {
-			attesterID = nextID
-			logger.Info("Auto-discovered next available Attester ID", zap.Uint("id", attesterID))
-		} else {
-			logger.Warn("Could not discover next available ID, using configured ID",
-				zap.Uint("id", config.AttesterID),
-				zap.Error(err))
-		}
-	} else {
-		logger.Info("Using explicitly configured Attester ID", zap.Uint("id", attesterID))
+	// gnark's verifier has no per-call CPU option, so bound it process-wide
+	if config.VerifyNbCPU > 0 {
+		previous := runtime.GOMAXPROCS(config.VerifyNbCPU)
+		logger.Info("Limited verification CPUs", zap.Int("cpus", config.VerifyNbCPU), zap.Int("available", previous))
+	}
+
+	// Discover next available ID dynamically (unless explicitly set via env var or disabled)
+	attesterID := resolveAttesterID(config, os.Getenv("ATTESTER_ID") != "", func() (uint, error) {
+		return discoverNextAvailableID(config)
+	})
+
+	if err := ValidateHashAlgo(config.SignHashAlgo); err != nil {
+		logger.Fatal("Invalid SIGN_HASH_ALGO", zap.Error(err))
+	}
+	if err := ValidateSignedMessage(config.SignedMessage); err != nil {
+		logger.Fatal("Invalid ATTESTATION_SIGNED_MESSAGE", zap.Error(err))
+	}
+	if err := ValidateSignatureScheme(config.SignatureScheme, config.SignedMessage, config.SignatureComponents); err != nil {
+		logger.Fatal("Invalid ATTESTATION_SIGNATURE_SCHEME", zap.Error(err))
+	}
+	if err := ValidateCommitmentScheme(config.CommitmentScheme); err != nil {
+		logger.Fatal("Invalid COMMITMENT_SCHEME", zap.Error(err))
+	}
+	if err := ValidateCommitmentLogMode(config.LogCommitmentMode); err != nil {
+		logger.Fatal("Invalid LOG_COMMITMENT_MODE", zap.Error(err))
+	}
+	if err := ValidateReissuePolicy(config.ReissuePolicy); err != nil {
+		logger.Fatal("Invalid CREDENTIAL_REISSUE_POLICY", zap.Error(err))
+	}
+	if err := circuit.ValidateAgeLimit(config.MaxAge); err != nil {
+		logger.Fatal("Invalid CIRCUIT_MAX_AGE", zap.Error(err))
+	}
+	if err := ValidateMaxInputBytes(config.MaxPublicInputBytes); err != nil {
+		logger.Fatal("Invalid MAX_PUBLIC_INPUT_BYTES", zap.Error(err))
+	}
+	if config.BlocksPerDay <= 0 {
+		logger.Fatal("Invalid ATTESTATION_BLOCKS_PER_DAY", zap.Int("blocks_per_day", config.BlocksPerDay))
+	}
+	if config.NextIDTimeoutSeconds <= 0 {
+		// A zero timeout would answer every uncached /info/next-available-id with 503
+		logger.Fatal("Invalid NEXT_ID_TIMEOUT_SECONDS", zap.Int("next_id_timeout_seconds", config.NextIDTimeoutSeconds))
+	}
+	if err := ValidateJurisdictionRoots(config.TrustedJurisdictionRoots); err != nil {
+		logger.Fatal("Invalid TRUSTED_JURISDICTION_ROOTS", zap.Error(err))
+	}
+	if _, err := ParseAttributeFields(config.AttributeFieldMap); err != nil {
+		logger.Fatal("Invalid ATTRIBUTE_FIELD_MAP", zap.Error(err))
+	}
+
+	// Keys and nonces come from the configured RNG
+	entropy, err := OpenEntropySource(config.EntropySource)
+	if err != nil {
+		logger.Fatal("Invalid ENTROPY_SOURCE", zap.Error(err))
 	}
 
 	// Generate or load signer
 	var signer *Signer
-	var privateKeyHex string
+	privateKeyHex, err := config.ResolvePrivateKey()
+	if err != nil {
+		logger.Fatal("Invalid attester private key", zap.Error(err))
+	}
 
-	if config.PrivateKey == "" {
+	if privateKeyHex == "" {
 		// Generate new key pair for development
-		privateKey, publicKey, err := GenerateKeyPair()
+		privateKey, publicKey, err := GenerateKeyPairFrom(entropy)
 		if err != nil {
 			logger.Fatal("Failed to generate key pair", zap.Error(err))
 		}
@@ -137,8 +175,6 @@ func main() {
 			zap.String("public_key", publicKey),
 		)
 		privateKeyHex = privateKey
-	} else {
-		privateKeyHex = config.PrivateKey
 	}
 
 	signer, err = NewSigner(privateKeyHex, attesterID)
@@ -146,34 +182,121 @@ func main() {
 		logger.Fatal("Failed to create signer", zap.Error(err))
 	}
 
+	// Check the verifying key against the manifest distributed with it
+	if manifest, err := LoadAndVerifyManifest(config.VerifyingKeyPath, signer.publicKey, config.RequireKeyManifest); err != nil {
+		if config.RequireKeyManifest {
+			logger.Fatal("Verifying key manifest check failed", zap.Error(err))
+		}
+		logger.Warn("Verifying key manifest check failed", zap.Error(err))
+	} else {
+		logger.Info("Verifying key manifest verified",
+			zap.String("circuit_hash", manifest.CircuitHash),
+			zap.Int64("created_at", manifest.CreatedAt),
+		)
+	}
+
 	logger.Info("Attester started",
 		zap.Uint("attester_id", signer.GetAttesterID()),
 		zap.String("public_key", signer.GetPublicKey()),
 	)
 
 	// Create API
-	api := NewAPI(signer)
+	api, err := NewAPI(signer, config)
+	if err != nil {
+		logger.Fatal("Failed to create attestation signer", zap.Error(err))
+	}
+	api.issuerService.nonces = entropy
+
+	// Publish revocation root changes on-chain in the background
+	if config.RevocationPublishEnabled {
+		if config.StacksSubmitterURL == "" {
+			logger.Fatal("REVOCATION_PUBLISH_ENABLED requires STACKS_SUBMITTER_URL")
+		}
+		publisher, err := NewRevocationPublisher(
+			NewHTTPContractCallSubmitter(config.StacksSubmitterURL),
+			config.RevocationContract,
+			config.RevocationPublishFunction,
+			time.Duration(config.RevocationPublishIntervalSeconds)*time.Second,
+			config.RevocationPublishMaxRetries,
+		)
+		if err != nil {
+			logger.Fatal("Invalid revocation publisher configuration", zap.Error(err))
+		}
+		api.revocationPublisher = publisher
+		go publisher.Run(context.Background())
+		logger.Info("Publishing revocation roots",
+			zap.String("contract", config.RevocationContract),
+			zap.String("function", config.RevocationPublishFunction),
+		)
+	}
+
+	// Track Hiro reachability in the background for the hiro health check
+	if config.HiroProbeEnabled() {
+		api.hiroProbe = NewHiroProbe(config.StacksAPI(), config.HiroProbeInterval, config.HiroStaleThreshold)
+		go api.hiroProbe.Run(context.Background())
+	}
 
 	// Setup routes
+	router := setupRouter(api, config)
+
+	// Serve metrics on their own listener when configured
+	if config.MetricsPort != "" {
+		logger.Info("Starting metrics server", zap.String("metrics_port", config.MetricsPort))
+		go func() {
+			if err := metrics.ListenAndServe(":" + config.MetricsPort); err != nil {
+				logger.Fatal("Failed to start metrics server", zap.Error(err))
+			}
+		}()
+	}
+
+	// Start server
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+			logger.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		logger.Info("Starting attester service with TLS",
+			zap.String("port", config.Port),
+			zap.String("tls_min_version", config.TLSMinVersion),
+		)
+		if err := tlsconfig.ListenAndServe(":"+config.Port, router, config.TLSCertFile, config.TLSKeyFile, config.TLSMinVersion); err != nil {
+			logger.Fatal("Failed to start server", zap.Error(err))
+		}
+		return
+	}
+	logger.Info("Starting attester service", zap.String("port", config.Port))
+	if err := router.Run(":" + config.Port); err != nil {
+		logger.Fatal("Failed to start server", zap.Error(err))
+	}
+}
+
+// corsAllowCredentials lets browsers send cookies and auth headers cross-origin, which
+// rules out a wildcard in CORS_ALLOWED_ORIGINS
+const corsAllowCredentials = true
+
+// setupRouter creates the gin engine with middleware and all attester routes
+func setupRouter(api *API, config *Config) *gin.Engine {
 	router := gin.New() // Use gin.New() to add middleware manually
+	router.HandleMethodNotAllowed = true
 
 	// Add standard middleware
-	router.Use(logger.GinLogger())
+	router.Use(tracing.Middleware("attester"))
+	router.Use(logger.GinLogger(config.SlowRequestThreshold))
 	router.Use(logger.GinRecovery())
 	router.Use(middleware.Security())
+	router.Use(middleware.ValidateContentType()) // Only checks POST and PUT bodies
 	router.Use(metrics.HTTPMiddleware())
 
 	// Rate limiting (100 requests per second, burst of 20)
-	limiter := middleware.NewRateLimiter(100, 20)
+	limiter := middleware.NewBoundedRateLimiter(100, 20, config.RateLimitMaxIPs)
 	router.Use(limiter.Middleware())
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174", "http://localhost:3000"},
+		AllowOrigins:     config.CORSAllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
+		AllowCredentials: corsAllowCredentials,
 	}))
 
 	// Health check
@@ -182,36 +305,70 @@ func main() {
 		Version:     "1.0.0",
 		Checks: map[string]health.Checker{
 			"signer": func() health.CheckResult {
-				if signer != nil {
+				if api.signer != nil {
 					return health.CheckResult{Status: "healthy"}
 				}
 				return health.CheckResult{Status: "unhealthy", Message: "Signer not initialized"}
 			},
 		},
 	}
+	if api.hiroProbe != nil {
+		healthConfig.Checks["hiro"] = api.hiroProbe.Check
+	}
 	router.GET("/health", health.Handler(healthConfig))
-	router.GET("/health/ready", health.ReadinessHandler())
+	// The attester is ready as soon as its signer exists
+	readiness := health.NewReadiness()
+	readiness.SetReady(api.signer != nil)
+	router.GET("/health/ready", health.ReadinessHandler(readiness))
+	router.GET("/ready", health.ReadinessHandler(readiness))
 	router.GET("/health/live", health.LivenessHandler())
 
 	// Attester info
 	router.GET("/info", api.GetAttesterInfo)
 	router.GET("/info/next-available-id", api.GetNextAvailableID)
+	router.GET("/info/public-key/clarity", api.GetClarityPublicKey)
 
-	// Metrics
+	// Metrics, also served on their own METRICS_PORT listener when one is set
 	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Credential operations
 	router.POST("/credential/issue", api.IssueCredential)
 	router.POST("/credential/attest", api.CreateAttestation)
 	router.POST("/credential/revoke", api.RevokeCredential)
+	router.GET("/attestation/status", api.GetAttestationStatus)
+	router.POST("/proof/verify", api.VerifyProof)
 
 	// Revocation
 	router.GET("/revocation/root", api.GetRevocationRoot)
 	router.GET("/revocation/check", api.CheckRevocationStatus)
-
-	// Start server
-	logger.Info("Starting attester service", zap.String("port", config.Port))
-	if err := router.Run(":" + config.Port); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+	router.POST("/revocation/verify-proof", api.VerifyRevocationProof)
+	router.POST("/revocation/:issuer/revoke", api.RevokeCredential)
+	router.GET("/revocation/:issuer/root", api.GetRevocationRoot)
+	router.GET("/revocation/:issuer/check", api.CheckRevocationStatus)
+	router.POST("/revocation/:issuer/verify-proof", api.VerifyRevocationProof)
+
+	// API description
+	router.GET("/openapi.json", apispec.Handler(openAPISpec()))
+
+	// Admin endpoints, only registered when an API key is configured
+	if config.AdminAPIKey != "" {
+		admin := router.Group("/admin", middleware.APIKey(config.AdminAPIKey))
+		admin.GET("/ratelimit", limiter.AdminHandler())
+		admin.GET("/revocation/dead-letters", api.GetPublishDeadLetters)
+		admin.POST("/revocation/dead-letters/retry", api.RetryPublishDeadLetters)
+		admin.POST("/reverify", api.Reverify)
+		admin.POST("/revocation/rebuild", api.RebuildRevocations)
+		admin.POST("/revocation/:issuer/rebuild", api.RebuildRevocations)
+
+		// Bulk export of revoked commitments for auditors and peer attesters
+		exportAuth := middleware.APIKey(config.AdminAPIKey)
+		router.GET("/revocation/export", exportAuth, api.ExportRevocations)
+		router.GET("/revocation/:issuer/export", exportAuth, api.ExportRevocations)
 	}
+
+	// Consistent JSON errors for unknown routes and wrong methods
+	router.NoRoute(apierror.NotFoundHandler())
+	router.NoMethod(apierror.MethodNotAllowedHandler())
+
+	return router
 }
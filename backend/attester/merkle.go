@@ -10,21 +10,24 @@ import (
 type MerkleTree struct {
 	leaves []string
 	root   string
+	domain string // Hash domain whose revocation tag prefixes every leaf hash
 }
 
-// NewMerkleTree creates a new Merkle tree from a list of commitments
-func NewMerkleTree(commitments []string) *MerkleTree {
+// NewMerkleTree creates a new Merkle tree from a list of commitments, hashing leaves in
+// the given hash domain
+func NewMerkleTree(domain string, commitments []string) *MerkleTree {
 	if len(commitments) == 0 {
 		return &MerkleTree{
 			leaves: []string{},
 			root:   "0x0000000000000000000000000000000000000000000000000000000000000000",
+			domain: domain,
 		}
 	}
 
 	// Hash all leaves
 	hashedLeaves := make([]string, len(commitments))
 	for i, commitment := range commitments {
-		hashedLeaves[i] = hashCommitment(commitment)
+		hashedLeaves[i] = hashCommitment(domain, commitment)
 	}
 
 	// Build tree
@@ -33,6 +36,7 @@ func NewMerkleTree(commitments []string) *MerkleTree {
 	return &MerkleTree{
 		leaves: commitments,
 		root:   root,
+		domain: domain,
 	}
 }
 
@@ -46,21 +50,20 @@ func (mt *MerkleTree) AddCommitment(commitment string) {
 	mt.leaves = append(mt.leaves, commitment)
 	hashedLeaves := make([]string, len(mt.leaves))
 	for i, c := range mt.leaves {
-		hashedLeaves[i] = hashCommitment(c)
+		hashedLeaves[i] = hashCommitment(mt.domain, c)
 	}
 	mt.root = buildMerkleTree(hashedLeaves)
 }
 
 // GenerateProof generates a Merkle proof for a commitment
 func (mt *MerkleTree) GenerateProof(commitment string) ([]string, []bool, error) {
-	_ = hashCommitment(commitment) // Hash the commitment for lookup
 
 	// Find index of commitment
 	index := -1
 	hashedLeaves := make([]string, len(mt.leaves))
 	for i, c := range mt.leaves {
-		hashedLeaves[i] = hashCommitment(c)
-		if c == commitment {
+		hashedLeaves[i] = hashCommitment(mt.domain, c)
+		if commitmentKey(c) == commitmentKey(commitment) {
 			index = i
 		}
 	}
@@ -76,39 +79,30 @@ func (mt *MerkleTree) GenerateProof(commitment string) ([]string, []bool, error)
 	currentIndex := index
 
 	for len(currentLevel) > 1 {
+		// The last node of an odd level is paired with itself, as nextLevel builds it
 		siblingIndex := currentIndex ^ 1
-		if siblingIndex < len(currentLevel) {
-			proof = append(proof, currentLevel[siblingIndex])
-			proofIndices = append(proofIndices, currentIndex%2 == 0)
-		} else {
-			// Odd number of nodes, duplicate the last one
-			proof = append(proof, currentLevel[len(currentLevel)-1])
-			proofIndices = append(proofIndices, true)
+		if siblingIndex >= len(currentLevel) {
+			siblingIndex = currentIndex
 		}
+		proof = append(proof, currentLevel[siblingIndex])
+		// true when the sibling is on the right, i.e. the node is a left child
+		proofIndices = append(proofIndices, currentIndex%2 == 0)
 
 		// Move to next level
-		nextLevel := []string{}
-		for i := 0; i < len(currentLevel); i += 2 {
-			if i+1 < len(currentLevel) {
-				nextLevel = append(nextLevel, hashPair(currentLevel[i], currentLevel[i+1]))
-			} else {
-				nextLevel = append(nextLevel, hashPair(currentLevel[i], currentLevel[i]))
-			}
-		}
-		currentLevel = nextLevel
+		currentLevel = nextLevel(currentLevel)
 		currentIndex = currentIndex / 2
 	}
 
 	return proof, proofIndices, nil
 }
 
-// VerifyProof verifies a Merkle proof
-func VerifyProof(commitment string, proof []string, proofIndices []bool, root string) bool {
+// VerifyProof verifies a Merkle proof for a tree hashed in the given domain
+func VerifyProof(domain, commitment string, proof []string, proofIndices []bool, root string) bool {
 	if len(proof) != len(proofIndices) {
 		return false
 	}
 
-	currentHash := hashCommitment(commitment)
+	currentHash := hashCommitment(domain, commitment)
 
 	for i, siblingHash := range proof {
 		if proofIndices[i] {
@@ -123,20 +117,16 @@ func VerifyProof(commitment string, proof []string, proofIndices []bool, root st
 	return currentHash == root
 }
 
-// hashCommitment hashes a commitment
-func hashCommitment(commitment string) string {
-	// Remove 0x prefix if present
-	if len(commitment) > 2 && commitment[:2] == "0x" {
-		commitment = commitment[2:]
-	}
-
-	bytes, err := hex.DecodeString(commitment)
+// hashCommitment hashes a commitment as a revocation leaf of the given domain
+// The leaf is the bare 32-byte hash, so every form of a commitment hashes alike
+func hashCommitment(domain, commitment string) string {
+	bytes, err := hex.DecodeString(commitmentKey(commitment))
 	if err != nil {
-		// If not hex, treat as string
+		// If not a commitment, treat as string
 		bytes = []byte(commitment)
 	}
 
-	hash := sha256.Sum256(bytes)
+	hash := domainHash(domain, hashContextRevocation, bytes)
 	return hex.EncodeToString(hash[:])
 }
 
@@ -162,18 +152,23 @@ func buildMerkleTree(leaves []string) string {
 
 	currentLevel := leaves
 	for len(currentLevel) > 1 {
-		nextLevel := []string{}
-		for i := 0; i < len(currentLevel); i += 2 {
-			if i+1 < len(currentLevel) {
-				nextLevel = append(nextLevel, hashPair(currentLevel[i], currentLevel[i+1]))
-			} else {
-				// Odd number, duplicate last node
-				nextLevel = append(nextLevel, hashPair(currentLevel[i], currentLevel[i]))
-			}
-		}
-		currentLevel = nextLevel
+		currentLevel = nextLevel(currentLevel)
 	}
 
 	return currentLevel[0]
 }
 
+// nextLevel hashes a level's nodes in pairs; an odd last node is hashed with itself
+// Proof generation and root building share it so their paths cannot drift apart
+func nextLevel(level []string) []string {
+	next := make([]string, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 < len(level) {
+			next = append(next, hashPair(level[i], level[i+1]))
+		} else {
+			// Odd number, duplicate last node
+			next = append(next, hashPair(level[i], level[i]))
+		}
+	}
+	return next
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/hash"
+)
+
+// The MiMC tree mirrors gnark's merkle.MerkleProof with a MiMC hasher, so its proofs verify
+// inside a circuit: leaves are hashed once, each node hashes (left, right) as field elements,
+// and bit i of the leaf index is 1 when the node at level i is a right child
+
+// mimcHashElements hashes field elements with MiMC over BN254, as the circuit's hasher does
+func mimcHashElements(values ...*big.Int) *big.Int {
+	h := hash.MIMC_BN254.New()
+	for _, v := range values {
+		b := make([]byte, fr.Bytes)
+		v.FillBytes(b)
+		h.Write(b)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// mimcLeafHash hashes a leaf value, matching merkle.MerkleProof's leaf hashing
+func mimcLeafHash(leaf *big.Int) *big.Int {
+	return mimcHashElements(leaf)
+}
+
+// mimcHashPair hashes two child nodes, left first, matching merkle.MerkleProof's node hashing
+func mimcHashPair(left, right *big.Int) *big.Int {
+	return mimcHashElements(left, right)
+}
+
+// mimcMerkleProof builds the MiMC tree over leaves and returns its root and the
+// merkle.MerkleProof path for leaves[index]: the leaf followed by one sibling per level
+// An odd node at the end of a level is paired with itself, as in the SHA256 tree
+func mimcMerkleProof(leaves []*big.Int, index int) (*big.Int, []*big.Int, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, nil, fmt.Errorf("leaf index %d out of range for %d leaves", index, len(leaves))
+	}
+
+	level := make([]*big.Int, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = mimcLeafHash(leaf)
+	}
+	path := []*big.Int{leaves[index]}
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index
+		}
+		path = append(path, level[sibling])
+
+		next := make([]*big.Int, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, mimcHashPair(level[i], right))
+		}
+		level = next
+		index /= 2
+	}
+	return level[0], path, nil
+}
+
+// verifyMiMCMerkleProof recomputes the root from a merkle.MerkleProof path and leaf index
+func verifyMiMCMerkleProof(root *big.Int, path []*big.Int, index uint64) bool {
+	if len(path) == 0 {
+		return false
+	}
+	sum := mimcLeafHash(path[0])
+	for _, sibling := range path[1:] {
+		if index&1 == 1 {
+			sum = mimcHashPair(sibling, sum)
+		} else {
+			sum = mimcHashPair(sum, sibling)
+		}
+		index >>= 1
+	}
+	return sum.Cmp(root) == 0
+}
+
+// MiMCProof returns a revocation proof for a commitment in the tree that a circuit can
+// check with merkle.MerkleProof: the MiMC root, the path and the commitment's leaf index
+// Commitments are read as field elements, reducing hashes above the BN254 modulus
+func (mt *MerkleTree) MiMCProof(commitment string) (*big.Int, []*big.Int, int, error) {
+	index := -1
+	leaves := make([]*big.Int, len(mt.leaves))
+	for i, c := range mt.leaves {
+		leaf, err := commitmentFieldElement(c)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		leaves[i] = leaf
+		if commitmentKey(c) == commitmentKey(commitment) {
+			index = i
+		}
+	}
+	if index == -1 {
+		return nil, nil, 0, fmt.Errorf("commitment not found in tree")
+	}
+
+	root, path, err := mimcMerkleProof(leaves, index)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return root, path, index, nil
+}
+
+// commitmentFieldElement reads a bare, versioned or 0x-prefixed hex commitment as a field element
+func commitmentFieldElement(commitment string) (*big.Int, error) {
+	if len(commitment) > 2 && commitment[:2] == "0x" {
+		commitment = commitment[2:]
+	}
+	_, hash, err := DecodeCommitment(commitment)
+	if err != nil {
+		return nil, err
+	}
+	return fieldElement(hash), nil
+}
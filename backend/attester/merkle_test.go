@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestMerkleProofOddSizes tests that every leaf of trees whose levels need a duplicated
+// last node proves and verifies against the root, and that its path has the tree's depth
+func TestMerkleProofOddSizes(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 4, 5, 6, 7, 8, 9} {
+		commitments := make([]string, size)
+		for i := range commitments {
+			commitments[i] = fmt.Sprintf("%064x", i+1)
+		}
+		tree := NewMerkleTree(DefaultHashDomain, commitments)
+
+		depth := 0
+		for n := size; n > 1; n = (n + 1) / 2 {
+			depth++
+		}
+
+		for i, commitment := range commitments {
+			t.Run(fmt.Sprintf("size %d leaf %d", size, i), func(t *testing.T) {
+				proof, indices, err := tree.GenerateProof(commitment)
+				if err != nil {
+					t.Fatalf("Failed to generate proof: %v", err)
+				}
+				if len(proof) != depth || len(indices) != depth {
+					t.Fatalf("Expected a path of %d nodes, got %d siblings and %d indices", depth, len(proof), len(indices))
+				}
+				if !VerifyProof(DefaultHashDomain, commitment, proof, indices, tree.GetRoot()) {
+					t.Fatal("Expected the proof to verify")
+				}
+
+				// Each direction bit matters unless the sibling is the node itself
+				for level := range indices {
+					flipped := append([]bool{}, indices...)
+					flipped[level] = !flipped[level]
+					if VerifyProof(DefaultHashDomain, commitment, proof, flipped, tree.GetRoot()) && !duplicatedAt(size, i, level) {
+						t.Errorf("Expected a flipped direction at level %d to fail", level)
+					}
+				}
+			})
+		}
+	}
+}
+
+// duplicatedAt reports whether leaf i's node at level is paired with itself, as the
+// last node of an odd level is
+func duplicatedAt(size, i, level int) bool {
+	n, index := size, i
+	for l := 0; l < level; l++ {
+		n, index = (n+1)/2, index/2
+	}
+	return index == n-1 && n%2 == 1
+}
+
+// TestRevocationCommitmentForms tests that the bare, versioned, 0x-prefixed and upper-case
+// forms of one commitment are revoked, checked and proven as the same leaf
+func TestRevocationCommitmentForms(t *testing.T) {
+	bare := fmt.Sprintf("%064x", 0xabc)
+	versioned, err := EncodeCommitment(CommitmentVersionMiMC, mustDecodeHex(t, bare))
+	if err != nil {
+		t.Fatalf("Failed to encode commitment: %v", err)
+	}
+	forms := []string{bare, versioned, "0x" + versioned, strings.ToUpper(bare)}
+
+	rs := NewRevocationService(DefaultHashDomain)
+	if err := rs.RevokeCredential(versioned); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+	root := rs.GetRevocationRoot()
+	if root != NewMerkleTree(DefaultHashDomain, []string{bare}).GetRoot() {
+		t.Error("Expected the leaf to hash the bare commitment")
+	}
+
+	for _, form := range forms {
+		if !rs.IsRevoked(form) {
+			t.Errorf("Expected %s to be revoked", form)
+		}
+		if err := rs.RevokeCredential(form); err == nil {
+			t.Errorf("Expected %s to be already revoked", form)
+		}
+		proof, indices, err := rs.merkleTree.GenerateProof(form)
+		if err != nil {
+			t.Fatalf("Failed to generate proof for %s: %v", form, err)
+		}
+		if !VerifyProof(DefaultHashDomain, form, proof, indices, root) {
+			t.Errorf("Expected the proof for %s to verify", form)
+		}
+	}
+	if rs.GetRevokedCount() != 1 {
+		t.Errorf("Expected one revocation, got %d", rs.GetRevokedCount())
+	}
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("Invalid hex %q: %v", s, err)
+	}
+	return b
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// nextIDDiscoveryTimeout bounds a background discovery that callers stopped waiting for
+const nextIDDiscoveryTimeout = time.Minute
+
+// errNextIDPending is returned when discovery is still running and no ID was found before
+var errNextIDPending = errors.New("next available ID discovery is still running")
+
+// NextIDCache serves the next available attester ID from a short-lived cache
+// Discovery runs in the background, one at a time; a caller waits for it up to the
+// timeout and otherwise gets the last discovered ID, even if it has expired
+type NextIDCache struct {
+	discover func(ctx context.Context) (uint, error)
+	ttl      time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	id       uint
+	found    time.Time     // When id was discovered; zero until a discovery succeeds
+	err      error         // Error of the last finished discovery
+	inflight chan struct{} // Closed when the running discovery finishes
+}
+
+// NewNextIDCache creates a cache around discover
+func NewNextIDCache(discover func(ctx context.Context) (uint, error), ttl, timeout time.Duration) *NextIDCache {
+	return &NextIDCache{discover: discover, ttl: ttl, timeout: timeout}
+}
+
+// Get returns the next available ID and whether it is stale (older than the TTL,
+// served because discovery failed or did not finish in time)
+// Discovery runs under ctx without its cancellation, so its calls are traced with the
+// request that started it and it can finish for later callers after that one gives up
+func (c *NextIDCache) Get(ctx context.Context) (uint, bool, error) {
+	c.mu.Lock()
+	if c.fresh() {
+		id := c.id
+		c.mu.Unlock()
+		return id, false, nil
+	}
+	done := c.inflight
+	if done == nil {
+		done = make(chan struct{})
+		c.inflight = done
+		go c.refresh(context.WithoutCancel(ctx), done)
+	}
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	finished := true
+	select {
+	case <-done:
+	case <-timer.C:
+		finished = false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.fresh(), finished && c.err == nil:
+		return c.id, false, nil
+	case !c.found.IsZero():
+		return c.id, true, nil
+	case finished:
+		return 0, false, c.err
+	default:
+		return 0, false, errNextIDPending
+	}
+}
+
+// fresh reports whether the cached ID is within its TTL; c.mu must be held
+func (c *NextIDCache) fresh() bool {
+	return !c.found.IsZero() && time.Since(c.found) < c.ttl
+}
+
+// refresh runs one discovery and records its result
+func (c *NextIDCache) refresh(ctx context.Context, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, nextIDDiscoveryTimeout)
+	defer cancel()
+	id, err := c.discover(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+	if err == nil {
+		c.id = id
+		c.found = time.Now()
+	}
+	c.inflight = nil
+	close(done)
+}
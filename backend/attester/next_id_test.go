@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mockRegistry is a Hiro read-only call endpoint where the first taken IDs are registered
+type mockRegistry struct {
+	taken int32
+	calls int32
+
+	mu    sync.Mutex
+	paths []string
+}
+
+func (m *mockRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.paths = append(m.paths, r.URL.Path)
+	m.mu.Unlock()
+	if atomic.AddInt32(&m.calls, 1) <= m.taken {
+		w.Write([]byte(`{"okay":true,"result":"0x0a0200000021"}`))
+		return
+	}
+	w.Write([]byte(`{"okay":true,"result":"(err u1003)"}`))
+}
+
+func (m *mockRegistry) Calls() int {
+	return int(atomic.LoadInt32(&m.calls))
+}
+
+func (m *mockRegistry) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.paths...)
+}
+
+func TestGetNextAvailableIDCached(t *testing.T) {
+	mock := &mockRegistry{taken: 2}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	t.Setenv("STACKS_API_URL", server.URL)
+
+	api := newTestAPI(t)
+	router := setupRouter(api, api.config)
+
+	var first, second struct {
+		NextAvailableID uint `json:"next_available_id"`
+		Stale           bool `json:"stale"`
+	}
+	if code := doJSON(t, router, http.MethodGet, "/info/next-available-id", nil, &first); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if first.NextAvailableID != 3 || first.Stale {
+		t.Errorf("Expected fresh next ID 3, got %+v", first)
+	}
+	calls := mock.Calls()
+	if calls != 3 {
+		t.Errorf("Expected 3 registry calls, got %d", calls)
+	}
+
+	if code := doJSON(t, router, http.MethodGet, "/info/next-available-id", nil, &second); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if second != first {
+		t.Errorf("Expected cached %+v, got %+v", first, second)
+	}
+	if mock.Calls() != calls {
+		t.Errorf("Expected no registry calls for a cached ID, got %d more", mock.Calls()-calls)
+	}
+}
+
+func TestGetNextAvailableIDFunctionName(t *testing.T) {
+	mock := &mockRegistry{}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	t.Setenv("STACKS_API_URL", server.URL)
+	t.Setenv("ATTESTER_REGISTRY", "ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J.attester-registry-v2")
+	t.Setenv("ATTESTER_PUBKEY_FUNCTION", "get-attester-key")
+
+	api := newTestAPI(t)
+	if code := doJSON(t, setupRouter(api, api.config), http.MethodGet, "/info/next-available-id", nil, nil); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	want := "/contracts/call-read/ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J/attester-registry-v2/get-attester-key"
+	if paths := mock.Paths(); len(paths) != 1 || paths[0] != want {
+		t.Errorf("Expected a single call to %s, got %v", want, paths)
+	}
+}
+
+func TestDiscoveryMaxAttempts(t *testing.T) {
+	mock := &mockRegistry{taken: 1000}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+	t.Setenv("STACKS_API_URL", server.URL)
+	t.Setenv("ATTESTER_DISCOVERY_MAX_ATTEMPTS", "3")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	_, err = discoverNextAvailableID(config)
+	if err == nil || err.Error() != "could not find available ID after 3 attempts" {
+		t.Errorf("Expected failure after 3 attempts, got %v", err)
+	}
+	if mock.Calls() != 3 {
+		t.Errorf("Expected 3 registry calls, got %d", mock.Calls())
+	}
+
+	// The next-available-id endpoint uses the same bound
+	api := newTestAPI(t)
+	if code := doJSON(t, setupRouter(api, api.config), http.MethodGet, "/info/next-available-id", nil, nil); code == http.StatusOK {
+		t.Errorf("Expected discovery to fail, got %d", code)
+	}
+	if mock.Calls() != 6 {
+		t.Errorf("Expected 3 more registry calls, got %d", mock.Calls()-3)
+	}
+}
+
+func TestNextIDCacheStaleOnTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var calls int32
+	cache := NewNextIDCache(func(ctx context.Context) (uint, error) {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			<-release
+		}
+		return 7, nil
+	}, time.Nanosecond, 50*time.Millisecond)
+
+	if id, stale, err := cache.Get(context.Background()); err != nil || id != 7 || stale {
+		t.Fatalf("Expected fresh ID 7, got %d, %v, %v", id, stale, err)
+	}
+
+	// The ID has expired and rediscovery hangs: the last ID is served as stale
+	start := time.Now()
+	id, stale, err := cache.Get(context.Background())
+	if err != nil || id != 7 || !stale {
+		t.Errorf("Expected stale ID 7, got %d, %v, %v", id, stale, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Get to give up after the timeout, took %v", elapsed)
+	}
+
+	// Callers share the running discovery rather than starting another
+	cache.Get(context.Background())
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 discoveries, got %d", n)
+	}
+}
+
+func TestNextIDCacheWithoutID(t *testing.T) {
+	failing := NewNextIDCache(func(ctx context.Context) (uint, error) {
+		return 0, errors.New("registry unreachable")
+	}, time.Minute, time.Second)
+	if _, _, err := failing.Get(context.Background()); err == nil || err.Error() != "registry unreachable" {
+		t.Errorf("Expected discovery error, got %v", err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	hanging := NewNextIDCache(func(ctx context.Context) (uint, error) {
+		<-release
+		return 1, nil
+	}, time.Minute, 10*time.Millisecond)
+	if _, _, err := hanging.Get(context.Background()); !errors.Is(err, errNextIDPending) {
+		t.Errorf("Expected errNextIDPending, got %v", err)
+	}
+}
+
+// TestResolveAttesterIDAutodiscover tests that startup only queries the registry when
+// ATTESTER_ID is unset and auto-discovery is enabled
+func TestResolveAttesterIDAutodiscover(t *testing.T) {
+	tests := []struct {
+		name         string
+		autodiscover string
+		explicit     bool
+		wantID       uint
+		wantCalls    bool
+	}{
+		{"default discovers", "", false, 3, true},
+		{"disabled", "false", false, 1, false},
+		{"explicit ID", "", true, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockRegistry{taken: 2}
+			server := httptest.NewServer(mock)
+			defer server.Close()
+			t.Setenv("STACKS_API_URL", server.URL)
+			t.Setenv("ATTESTER_AUTODISCOVER", tt.autodiscover)
+
+			config, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			discoveries := 0
+			id := resolveAttesterID(config, tt.explicit, func() (uint, error) {
+				discoveries++
+				return discoverNextAvailableID(config)
+			})
+
+			if id != tt.wantID {
+				t.Errorf("Expected attester ID %d, got %d", tt.wantID, id)
+			}
+			if got := mock.Calls() > 0; got != tt.wantCalls || (discoveries > 0) != tt.wantCalls {
+				t.Errorf("Expected registry calls %v, got %d calls from %d discoveries", tt.wantCalls, mock.Calls(), discoveries)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"net/http"
+
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/apispec"
+	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/middleware"
+)
+
+// Response bodies the handlers build with gin.H, described as structs for the spec
+type (
+	errorBody struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	issueCredentialBody struct {
+		Success    bool       `json:"success"`
+		Credential Credential `json:"credential"`
+	}
+	revokeCredentialBody struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Root    string `json:"root"`
+	}
+	revocationRootBody struct {
+		Root  string `json:"root"`
+		Count int    `json:"count"`
+	}
+	deadLettersBody struct {
+		DeadLetters []DeadLetter `json:"dead_letters"`
+		Published   string       `json:"published"`
+	}
+	rebuildBody struct {
+		Success bool   `json:"success"`
+		Root    string `json:"root"`
+		Count   int    `json:"count"`
+	}
+	retryDeadLettersBody struct {
+		Success bool   `json:"success"`
+		Root    string `json:"root"`
+	}
+	revocationStatusBody struct {
+		Commitment string `json:"commitment"`
+		Revoked    bool   `json:"revoked"`
+	}
+	attestationStatusBody struct {
+		Commitment   string              `json:"commitment"`
+		Status       string              `json:"status"`
+		Attestations []AttestationRecord `json:"attestations"`
+	}
+	attesterInfoBody struct {
+		AttesterID      uint   `json:"attester_id"`
+		PublicKey       string `json:"public_key"`        // secp256k1 registry key
+		PublicKeyScheme string `json:"public_key_scheme"` // Always secp256k1
+		IssuerName      string `json:"issuer_name"`
+		IssuerURL       string `json:"issuer_url"`
+		HashAlgo        string `json:"hash_algo"` // Hash public_key signs with
+		SignatureScheme string `json:"signature_scheme"`
+		EdDSAPublicKey  string `json:"eddsa_public_key,omitempty"` // Set with eddsa-babyjubjub
+		EdDSAHashAlgo   string `json:"eddsa_hash_algo,omitempty"`
+	}
+	clarityPublicKeyBody struct {
+		AttesterID uint   `json:"attester_id"`
+		PublicKey  string `json:"public_key"`
+		Type       string `json:"type"`
+	}
+	nextAvailableIDBody struct {
+		NextAvailableID uint `json:"next_available_id"`
+		SuggestedID     uint `json:"suggested_id"`
+		Stale           bool `json:"stale"` // Cache expired and rediscovery failed or timed out
+	}
+	readinessBody struct {
+		Status string `json:"status"`
+	}
+)
+
+// openAPISpec describes the attester routes registered by setupRouter
+// Schemas are derived from the request and response types, so keep the route list in step with setupRouter
+func openAPISpec() *apispec.Document {
+	doc := apispec.New("Noah Attester", "1.0.0", "Verifies KYC proofs, signs attestations and manages revocations")
+
+	doc.Add(http.MethodPost, "/credential/issue", &apispec.Operation{
+		Summary:     "Issue a credential",
+		RequestBody: apispec.JSONBody(CredentialRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Credential issued", issueCredentialBody{}),
+			"400": apispec.JSONResponse("Invalid request or user_id", errorBody{}),
+			"409": apispec.JSONResponse("Commitment already issued to another user", errorBody{}),
+			"500": apispec.JSONResponse("Issuance failed", errorBody{}),
+		},
+	})
+	doc.Add(http.MethodPost, "/credential/attest", &apispec.Operation{
+		Summary:     "Verify a proof and sign its commitment",
+		Parameters:  []apispec.Parameter{apispec.QueryParam("format", "Proof encoding when not given in the body: base64 or hex", false)},
+		RequestBody: apispec.JSONBody(AttestationRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Attestation signed", AttestationResponse{}),
+			"400": apispec.JSONResponse("Proof rejected, proof attests a failed check (ERR_CHECK_FAILED), untrusted jurisdiction root (ERR_UNTRUSTED_JURISDICTION_ROOT), malformed or out-of-field public input (ERR_MALFORMED_INPUT, ERR_INPUT_OUT_OF_FIELD), proof system not accepted (ERR_PROOF_SYSTEM_NOT_ACCEPTED), unknown circuit type or invalid parameters", AttestationResponse{}),
+			"500": apispec.JSONResponse("Attester failure", AttestationResponse{}),
+		},
+	})
+	doc.Add(http.MethodPost, "/proof/verify", &apispec.Operation{
+		Summary:     "Verify a proof without signing it, reporting the public input behind a rejection",
+		Parameters:  []apispec.Parameter{apispec.QueryParam("format", "Proof encoding when not given in the body: base64 or hex", false)},
+		RequestBody: apispec.JSONBody(ProofVerificationRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Proof verified", ProofVerificationResponse{}),
+			"400": apispec.JSONResponse("Malformed input (ERR_MALFORMED_INPUT), input out of field (ERR_INPUT_OUT_OF_FIELD), commitment mismatch (ERR_COMMITMENT_MISMATCH), failed check (ERR_CHECK_FAILED), or proof rejected", apierror.APIError{}),
+			"503": apispec.JSONResponse("Proof verifier unavailable", ProofVerificationResponse{}),
+		},
+	})
+	doc.Add(http.MethodPost, "/credential/revoke", &apispec.Operation{
+		Summary:     "Revoke a credential",
+		RequestBody: apispec.JSONBody(RevocationRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Credential revoked", revokeCredentialBody{}),
+			"400": apispec.JSONResponse("Invalid request or already revoked", errorBody{}),
+		},
+	})
+	doc.Add(http.MethodGet, "/attestation/status", &apispec.Operation{
+		Summary:    "Attestations signed for a commitment and whether revocation invalidated them",
+		Parameters: []apispec.Parameter{apispec.QueryParam("commitment", "Commitment the attestations were signed for", true)},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Attestation status: valid or revoked", attestationStatusBody{}),
+			"400": apispec.JSONResponse("Missing commitment", errorBody{}),
+			"404": apispec.JSONResponse("No attestations recorded", errorBody{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/revocation/root", &apispec.Operation{
+		Summary:   "Current revocation Merkle root",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Revocation root", revocationRootBody{})},
+	})
+	doc.Add(http.MethodGet, "/revocation/check", &apispec.Operation{
+		Summary:    "Check whether a commitment is revoked",
+		Parameters: []apispec.Parameter{apispec.QueryParam("commitment", "Commitment to check", true)},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Revocation status", revocationStatusBody{}),
+			"400": apispec.JSONResponse("Missing commitment", errorBody{}),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/revocation/verify-proof", &apispec.Operation{
+		Summary:     "Verify a revocation tree Merkle proof and whether its root is current",
+		RequestBody: apispec.JSONBody(RevocationProofRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Proof verdict", RevocationProofResponse{}),
+			"400": apispec.JSONResponse("Invalid request", errorBody{}),
+		},
+	})
+
+	issuerParam := apispec.PathParam("issuer", "This attester's ID or an issuer listed in REVOCATION_ISSUERS")
+	doc.Add(http.MethodPost, "/revocation/:issuer/revoke", &apispec.Operation{
+		Summary:     "Revoke a credential in an issuer's revocation tree",
+		Parameters:  []apispec.Parameter{issuerParam},
+		RequestBody: apispec.JSONBody(RevocationRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Credential revoked", revokeCredentialBody{}),
+			"400": apispec.JSONResponse("Invalid request or already revoked", errorBody{}),
+			"404": apispec.JSONResponse("Unknown issuer", errorBody{}),
+		},
+	})
+	doc.Add(http.MethodGet, "/revocation/:issuer/root", &apispec.Operation{
+		Summary:    "Revocation Merkle root of an issuer",
+		Parameters: []apispec.Parameter{issuerParam},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Revocation root", revocationRootBody{}),
+			"404": apispec.JSONResponse("Unknown issuer", errorBody{}),
+		},
+	})
+	doc.Add(http.MethodGet, "/revocation/:issuer/check", &apispec.Operation{
+		Summary:    "Check whether a commitment is revoked by an issuer",
+		Parameters: []apispec.Parameter{issuerParam, apispec.QueryParam("commitment", "Commitment to check", true)},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Revocation status", revocationStatusBody{}),
+			"400": apispec.JSONResponse("Missing commitment", errorBody{}),
+			"404": apispec.JSONResponse("Unknown issuer", errorBody{}),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/revocation/:issuer/verify-proof", &apispec.Operation{
+		Summary:     "Verify a Merkle proof against an issuer's revocation tree",
+		Parameters:  []apispec.Parameter{issuerParam},
+		RequestBody: apispec.JSONBody(RevocationProofRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Proof verdict", RevocationProofResponse{}),
+			"400": apispec.JSONResponse("Invalid request", errorBody{}),
+			"404": apispec.JSONResponse("Unknown issuer", errorBody{}),
+		},
+	})
+
+	exportParams := []apispec.Parameter{
+		apispec.HeaderParam(middleware.APIKeyHeader, "Admin API key"),
+		apispec.QueryParam("offset", "Index of the first commitment to return (default 0)", false),
+		apispec.QueryParam("limit", "Maximum commitments to return, 1 to 10000 (default 1000)", false),
+	}
+	doc.Add(http.MethodGet, "/revocation/export", &apispec.Operation{
+		Summary:    "Download the revoked commitments (only registered when ADMIN_API_KEY is set)",
+		Parameters: exportParams,
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Page of revoked commitments", RevocationExport{}),
+			"400": apispec.JSONResponse("Invalid offset or limit", errorBody{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+		},
+	})
+	doc.Add(http.MethodGet, "/revocation/:issuer/export", &apispec.Operation{
+		Summary:    "Download an issuer's revoked commitments (only registered when ADMIN_API_KEY is set)",
+		Parameters: append([]apispec.Parameter{issuerParam}, exportParams...),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Page of revoked commitments", RevocationExport{}),
+			"400": apispec.JSONResponse("Invalid offset or limit", errorBody{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+			"404": apispec.JSONResponse("Unknown issuer", errorBody{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/info", &apispec.Operation{
+		Summary:   "Attester identity and signing settings",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Attester info", attesterInfoBody{})},
+	})
+	doc.Add(http.MethodGet, "/info/public-key/clarity", &apispec.Operation{
+		Summary:   "Public key as a Clarity (buff 33) literal for add-attester",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Clarity public key", clarityPublicKeyBody{})},
+	})
+	doc.Add(http.MethodGet, "/info/next-available-id", &apispec.Operation{
+		Summary: "Next attester ID not yet registered on-chain",
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Next available ID", nextAvailableIDBody{}),
+			"500": apispec.JSONResponse("Registry query failed", errorBody{}),
+			"503": apispec.JSONResponse("Discovery still running and no ID cached", errorBody{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/health", &apispec.Operation{
+		Summary:   "Detailed health status",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Health status", health.Status{})},
+	})
+	for _, path := range []string{"/health/ready", "/ready"} {
+		doc.Add(http.MethodGet, path, &apispec.Operation{
+			Summary: "Readiness probe",
+			Responses: map[string]apispec.Response{
+				"200": apispec.JSONResponse("Signer initialized", readinessBody{}),
+				"503": apispec.JSONResponse("Not ready", readinessBody{}),
+			},
+		})
+	}
+	doc.Add(http.MethodGet, "/health/live", &apispec.Operation{
+		Summary:   "Liveness probe",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Alive", readinessBody{})},
+	})
+
+	doc.Add(http.MethodGet, "/metrics", &apispec.Operation{
+		Summary:   "Prometheus metrics",
+		Responses: map[string]apispec.Response{"200": apispec.TextResponse("Metrics in Prometheus text format")},
+	})
+
+	doc.Add(http.MethodGet, "/admin/ratelimit", &apispec.Operation{
+		Summary: "Rate limiter state (only registered when ADMIN_API_KEY is set)",
+		Parameters: []apispec.Parameter{
+			apispec.HeaderParam(middleware.APIKeyHeader, "Admin API key"),
+			apispec.QueryParam("ip", "Report the remaining tokens for this IP", false),
+		},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Rate limiter state", map[string]interface{}{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+		},
+	})
+
+	adminKey := apispec.HeaderParam(middleware.APIKeyHeader, "Admin API key")
+	doc.Add(http.MethodGet, "/admin/revocation/dead-letters", &apispec.Operation{
+		Summary:    "Revocation roots abandoned after exhausting publish retries (only registered when ADMIN_API_KEY is set)",
+		Parameters: []apispec.Parameter{adminKey},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Dead letters", deadLettersBody{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+			"404": apispec.JSONResponse("Revocation publishing is not enabled", errorBody{}),
+		},
+	})
+	doc.Add(http.MethodPost, "/admin/revocation/dead-letters/retry", &apispec.Operation{
+		Summary:    "Clear the dead letters and publish the latest revocation root again",
+		Parameters: []apispec.Parameter{adminKey},
+		Responses: map[string]apispec.Response{
+			"202": apispec.JSONResponse("Retry scheduled", retryDeadLettersBody{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+			"404": apispec.JSONResponse("Revocation publishing is not enabled", errorBody{}),
+		},
+	})
+	rebuildResponses := map[string]apispec.Response{
+		"200": apispec.JSONResponse("New root", rebuildBody{}),
+		"400": apispec.JSONResponse("Invalid or duplicate commitment; the tree is unchanged", errorBody{}),
+		"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+		"404": apispec.JSONResponse("Unknown issuer", errorBody{}),
+	}
+	doc.Add(http.MethodPost, "/admin/revocation/rebuild", &apispec.Operation{
+		Summary:     "Replace this attester's revocation tree with the supplied revoked commitments",
+		Parameters:  []apispec.Parameter{adminKey},
+		RequestBody: apispec.JSONBody(RevocationRebuildRequest{}),
+		Responses:   rebuildResponses,
+	})
+	doc.Add(http.MethodPost, "/admin/revocation/:issuer/rebuild", &apispec.Operation{
+		Summary:     "Replace an issuer's revocation tree with the supplied revoked commitments",
+		Parameters:  []apispec.Parameter{adminKey, issuerParam},
+		RequestBody: apispec.JSONBody(RevocationRebuildRequest{}),
+		Responses:   rebuildResponses,
+	})
+	doc.Add(http.MethodPost, "/admin/reverify", &apispec.Operation{
+		Summary:     "Re-verify proof bundles against the verifying key of a target circuit version",
+		Parameters:  []apispec.Parameter{adminKey},
+		RequestBody: apispec.JSONBody(ReverifyRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Per-bundle results", ReverifyResponse{}),
+			"400": apispec.JSONResponse("Invalid request or too many bundles", errorBody{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+			"404": apispec.JSONResponse("Unknown circuit version", errorBody{}),
+			"503": apispec.JSONResponse("Proof verifier unavailable", errorBody{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/openapi.json", &apispec.Operation{
+		Summary:   "This OpenAPI document",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("OpenAPI 3 document", map[string]interface{}{})},
+	})
+
+	return doc
+}
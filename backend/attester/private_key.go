@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ResolvePrivateKey returns the attester private key as hex: the contents of
+// PrivateKeyFile, with surrounding whitespace trimmed, when it is set, otherwise
+// PrivateKey; empty when neither is set, meaning a key is generated
+func (c *Config) ResolvePrivateKey() (string, error) {
+	key, source := c.PrivateKey, "ATTESTER_PRIVATE_KEY"
+	if c.PrivateKeyFile != "" {
+		contents, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read private key file: %w", err)
+		}
+		key, source = strings.TrimSpace(string(contents)), c.PrivateKeyFile
+		if key == "" {
+			return "", fmt.Errorf("private key file %s is empty", c.PrivateKeyFile)
+		}
+	}
+	if key == "" {
+		return "", nil
+	}
+	// Report a malformed key without echoing it
+	if _, err := crypto.HexToECDSA(key); err != nil {
+		return "", fmt.Errorf("invalid private key in %s: expected 64 hex characters", source)
+	}
+	return key, nil
+}
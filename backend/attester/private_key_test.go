@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestResolvePrivateKey tests loading the attester key from the env var and from a file
+func TestResolvePrivateKey(t *testing.T) {
+	const envKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	const fileKey = "1111111111111111111111111111111111111111111111111111111111111111"
+	dir := t.TempDir()
+	writeKey := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("Failed to write key file: %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		env     string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{"neither", "", "", "", false},
+		{"env", envKey, "", envKey, false},
+		{"file with whitespace", "", writeKey("key", "  "+fileKey+"\n"), fileKey, false},
+		{"file takes precedence", envKey, writeKey("precedence", fileKey), fileKey, false},
+		{"invalid file contents", envKey, writeKey("invalid", "not-a-key\n"), "", true},
+		{"empty file", envKey, writeKey("empty", "\n"), "", true},
+		{"missing file", envKey, filepath.Join(dir, "missing"), "", true},
+		{"invalid env", "zz", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ATTESTER_PRIVATE_KEY", tt.env)
+			t.Setenv("ATTESTER_PRIVATE_KEY_FILE", tt.file)
+			config, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+
+			got, err := config.ResolvePrivateKey()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected key %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
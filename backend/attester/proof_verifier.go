@@ -2,13 +2,18 @@ package main
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"noah-v2/backend/pkg/keymanifest"
+	"noah-v2/backend/pkg/metrics"
+	"noah-v2/backend/pkg/proofformat"
 	"noah-v2/circuit"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -18,24 +23,105 @@ import (
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 )
 
+// ProofSystemGroth16 is the proof system the verifier checks (Groth16 over BN254)
+const ProofSystemGroth16 = "groth16"
+
+// SupportsProofSystem reports whether the verifier can check proofs of the declared
+// system; an undeclared system means groth16
+func SupportsProofSystem(system string) bool {
+	return system == "" || strings.EqualFold(system, ProofSystemGroth16)
+}
+
+// ErrVerifierUnavailable marks failures to set up the verifier itself, as opposed to
+// problems with the proof being verified
+var ErrVerifierUnavailable = errors.New("verifier unavailable")
+
+// ErrCheckFailed marks a valid proof whose public outputs attest a failed check
+var ErrCheckFailed = errors.New("proof attests a failed check")
+
 // ProofVerifier handles proof verification using the verification key
 type ProofVerifier struct {
+	// mu guards initialization and the keys and outputs registered by version, as
+	// Initialize runs on the request path
+	mu          sync.RWMutex
 	ccs         constraint.ConstraintSystem
 	vk          groth16.VerifyingKey
 	initialized bool
 	keyPath     string
+
+	// Verifying key for the KYC + denylist circuit variant (six public inputs), loaded on
+	// first use under denylistMu
+	denylistMu      sync.Mutex
+	denylistVK      groth16.VerifyingKey
+	denylistKeyPath string
+
+	// Verifying keys by circuit version (circuit hash), for proofs that declare one
+	keys map[string]groth16.VerifyingKey
+
+	// Public input indices of check outputs by circuit version; each must equal 1
+	outputs map[string][]int
+
+	// Largest provable age the KYC circuit is compiled with (0 means circuit.MaxAge)
+	ageLimit int
+
+	// Largest public input accepted in bytes (0 means DefaultMaxInputBytes)
+	maxInputBytes int
+
+	// Proofs attested within the replay TTL (nil disables replay protection); strict
+	// rejects a replay instead of logging it
+	replay       *replayCache
+	replayStrict bool
+
+	// Verifying keys for single-purpose circuit types, loaded on first use from typeKeyPaths
+	// Requests load them concurrently, so both maps are guarded by typeMu
+	typeMu       sync.Mutex
+	typeKeys     map[string]groth16.VerifyingKey
+	typeKeyPaths map[string]string
 }
 
 // NewProofVerifier creates a new proof verifier
 func NewProofVerifier(verifyingKeyPath string) *ProofVerifier {
+	return NewProofVerifierWithDenylist(verifyingKeyPath, "")
+}
+
+// NewProofVerifierWithDenylist creates a proof verifier that also accepts
+// denylist variant proofs checked against the second verifying key
+func NewProofVerifierWithDenylist(verifyingKeyPath, denylistVerifyingKeyPath string) *ProofVerifier {
 	return &ProofVerifier{
-		initialized: false,
-		keyPath:     verifyingKeyPath,
+		initialized:     false,
+		keyPath:         verifyingKeyPath,
+		denylistKeyPath: denylistVerifyingKeyPath,
+		keys:            make(map[string]groth16.VerifyingKey),
+		outputs:         make(map[string][]int),
+		typeKeys:        make(map[string]groth16.VerifyingKey),
+		typeKeyPaths:    make(map[string]string),
 	}
 }
 
-// Initialize compiles the circuit and loads the verification key
+// SetAgeLimit sets the age limit the KYC circuit is compiled with, which must match the
+// prover's; call it before the verifier is initialized
+func (pv *ProofVerifier) SetAgeLimit(limit int) {
+	pv.ageLimit = limit
+}
+
+// SetMaxInputBytes sets the largest public input accepted, in decoded bytes
+func (pv *ProofVerifier) SetMaxInputBytes(limit int) {
+	pv.maxInputBytes = limit
+}
+
+// inputByteLimit returns the largest public input accepted in bytes
+func (pv *ProofVerifier) inputByteLimit() int {
+	if pv.maxInputBytes > 0 {
+		return pv.maxInputBytes
+	}
+	return DefaultMaxInputBytes
+}
+
+// Initialize compiles the circuit and loads the verification key; it is safe to call
+// concurrently, and only the first successful call does the work
 func (pv *ProofVerifier) Initialize() error {
+	pv.mu.Lock()
+	defer pv.mu.Unlock()
 	if pv.initialized {
 		return nil
 	}
@@ -60,6 +146,9 @@ func (pv *ProofVerifier) Initialize() error {
 		JurisdictionRoot:     0,
 		RequireAccreditation: 0,
 		Commitment:           0,
+		RelyingPartyID:       0,
+		// Must match the prover's CIRCUIT_MAX_AGE
+		AgeLimit: pv.ageLimit,
 	}
 
 	field := ecc.BN254.ScalarField()
@@ -74,43 +163,88 @@ func (pv *ProofVerifier) Initialize() error {
 		return fmt.Errorf("failed to load verifying key: %w", err)
 	}
 
+	// The default key is also selectable by version when its manifest is present
+	if manifest, err := keymanifest.Read(keymanifest.ManifestPath(pv.keyPath)); err == nil {
+		if _, exists := pv.keys[manifest.CircuitHash]; !exists {
+			pv.keys[manifest.CircuitHash] = pv.vk
+		}
+	}
+
 	pv.initialized = true
 	return nil
 }
 
 // loadVerifyingKey loads the verification key from file
 func (pv *ProofVerifier) loadVerifyingKey() error {
+	vk, err := readVerifyingKey(pv.keyPath)
+	if err != nil {
+		return err
+	}
+	pv.vk = vk
+	return nil
+}
+
+// denylistKey returns the verifying key for the denylist circuit variant, loading it on
+// first use; concurrent first uses load it once
+func (pv *ProofVerifier) denylistKey() (groth16.VerifyingKey, error) {
+	pv.denylistMu.Lock()
+	defer pv.denylistMu.Unlock()
+	if pv.denylistVK != nil {
+		return pv.denylistVK, nil
+	}
+	if pv.denylistKeyPath == "" {
+		return nil, fmt.Errorf("denylist proofs are not supported: no denylist verifying key configured")
+	}
+	vk, err := readVerifyingKey(pv.denylistKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load denylist verifying key: %w", ErrVerifierUnavailable, err)
+	}
+	pv.denylistVK = vk
+	return vk, nil
+}
+
+// readVerifyingKey reads a Groth16 BN254 verifying key from file
+func readVerifyingKey(path string) (groth16.VerifyingKey, error) {
 	// Check if key file exists
-	if _, err := os.Stat(pv.keyPath); os.IsNotExist(err) {
-		return fmt.Errorf("verifying key file does not exist at %s", pv.keyPath)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("verifying key file does not exist at %s", path)
 	}
 
 	// Load verifying key
-	vkFile, err := os.Open(pv.keyPath)
+	vkFile, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to open verifying key file: %w", err)
+		return nil, fmt.Errorf("failed to open verifying key file: %w", err)
 	}
 	defer vkFile.Close()
 
-	pv.vk = groth16.NewVerifyingKey(ecc.BN254)
-	if _, err := pv.vk.ReadFrom(vkFile); err != nil {
-		return fmt.Errorf("failed to read verifying key: %w", err)
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(vkFile); err != nil {
+		return nil, fmt.Errorf("failed to read verifying key: %w", err)
 	}
 
-	return nil
+	return vk, nil
 }
 
 // VerifyProof verifies a base64-encoded proof with public inputs
 func (pv *ProofVerifier) VerifyProof(proofBase64 string, publicInputs []string) (bool, error) {
+	return pv.VerifyProofWithFormat(proofBase64, proofformat.Base64, publicInputs)
+}
+
+// VerifyProofWithFormat verifies a proof encoded as "base64" or "hex" with public inputs
+func (pv *ProofVerifier) VerifyProofWithFormat(encodedProof, format string, publicInputs []string) (bool, error) {
+	return pv.VerifyProofWithVersion(encodedProof, format, "", publicInputs)
+}
+
+// VerifyProofWithVersion verifies a proof against the verifying key registered for the
+// declared circuit version; an empty version uses the configured default keys
+func (pv *ProofVerifier) VerifyProofWithVersion(encodedProof, format, version string, publicInputs []string) (bool, error) {
 	// Initialize if not already done
-	if !pv.initialized {
-		if err := pv.Initialize(); err != nil {
-			return false, fmt.Errorf("failed to initialize verifier: %w", err)
-		}
+	if err := pv.Initialize(); err != nil {
+		return false, fmt.Errorf("%w: failed to initialize verifier: %w", ErrVerifierUnavailable, err)
 	}
 
-	// Decode base64 proof
-	proofBytes, err := base64.StdEncoding.DecodeString(proofBase64)
+	// Decode proof, checking the content matches the declared format
+	proofBytes, err := proofformat.Decode(encodedProof, format)
 	if err != nil {
 		return false, fmt.Errorf("failed to decode proof: %w", err)
 	}
@@ -122,7 +256,30 @@ func (pv *ProofVerifier) VerifyProof(proofBase64 string, publicInputs []string)
 	}
 
 	// Reconstruct public witness from public inputs
-	publicWitnessData, err := pv.reconstructPublicWitness(publicInputs)
+	// A sixth public input (DenylistRoot) selects the denylist circuit variant
+	var publicWitnessData frontend.Circuit
+	pv.mu.RLock()
+	vk := pv.vk
+	registered, ok := pv.keys[version]
+	outputs := pv.outputs[version]
+	pv.mu.RUnlock()
+	if version != "" {
+		if !ok {
+			return false, fmt.Errorf("unknown circuit version %s", version)
+		}
+		vk = registered
+	}
+	if len(publicInputs) == denylistPublicInputs && version == "" {
+		vk, err = pv.denylistKey()
+		if err != nil {
+			return false, err
+		}
+	}
+	if len(publicInputs) == denylistPublicInputs {
+		publicWitnessData, err = pv.reconstructDenylistWitness(publicInputs)
+	} else {
+		publicWitnessData, err = pv.reconstructPublicWitness(publicInputs)
+	}
 	if err != nil {
 		return false, fmt.Errorf("failed to reconstruct public witness: %w", err)
 	}
@@ -148,7 +305,9 @@ func (pv *ProofVerifier) VerifyProof(proofBase64 string, publicInputs []string)
 	// #endregion agent log
 
 	// Verify the proof
-	err = groth16.Verify(proof, pv.vk, pubWitness)
+	start := time.Now()
+	err = groth16.Verify(proof, vk, pubWitness)
+	metrics.RecordProofVerification(time.Since(start), err == nil)
 	if err != nil {
 		// #region agent log
 		logFile3, _ := os.OpenFile("/Users/machine/Documents/Noah-v2/.cursor/debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -156,14 +315,36 @@ func (pv *ProofVerifier) VerifyProof(proofBase64 string, publicInputs []string)
 		logFile3.WriteString(logEntryErr)
 		logFile3.Close()
 		// #endregion agent log
-		return false, fmt.Errorf("proof verification failed: %w", err)
+		return false, fmt.Errorf("invalid proof: %w", err)
+	}
+
+	// A proof of a circuit with outputs is valid even when a check failed
+	if err := checkOutputs(publicInputs, outputs); err != nil {
+		return false, err
 	}
 
 	return true, nil
 }
 
+// checkOutputs returns ErrCheckFailed unless every public input at indices equals 1
+func checkOutputs(publicInputs []string, indices []int) error {
+	for _, i := range indices {
+		if i < 0 || i >= len(publicInputs) {
+			return fmt.Errorf("output index %d out of range for %d public inputs", i, len(publicInputs))
+		}
+		value, err := hex.DecodeString(publicInputs[i])
+		if err != nil {
+			return fmt.Errorf("invalid output %d hex: %w", i, err)
+		}
+		if new(big.Int).SetBytes(value).Cmp(big.NewInt(1)) != 0 {
+			return fmt.Errorf("%w: output %d is not 1", ErrCheckFailed, i)
+		}
+	}
+	return nil
+}
+
 // reconstructPublicWitness reconstructs the circuit structure from public inputs
-// Public inputs order: MinAge, JurisdictionRoot, RequireAccreditation, Commitment
+// Public inputs order: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID
 func (pv *ProofVerifier) reconstructPublicWitness(publicInputs []string) (*circuit.KYCCircuit, error) {
 	// #region agent log
 	logFile, _ := os.OpenFile("/Users/machine/Documents/Noah-v2/.cursor/debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -172,11 +353,17 @@ func (pv *ProofVerifier) reconstructPublicWitness(publicInputs []string) (*circu
 	// #endregion agent log
 
 	// New optimized circuit structure:
-	// Public inputs: [MinAge, JurisdictionRoot, RequireAccreditation, Commitment]
-	expectedInputs := 4
+	// Public inputs: [MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID]
+	expectedInputs := 5
 	if len(publicInputs) != expectedInputs {
 		logFile.Close()
-		return nil, fmt.Errorf("invalid public inputs: expected %d inputs (MinAge, JurisdictionRoot, RequireAccreditation, Commitment), got %d", expectedInputs, len(publicInputs))
+		return nil, fmt.Errorf("invalid public inputs: expected %d inputs (MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID), got %d", expectedInputs, len(publicInputs))
+	}
+	for i, input := range publicInputs {
+		if err := checkInputLength(CircuitTypeKYC, i, input, pv.inputByteLimit()); err != nil {
+			logFile.Close()
+			return nil, err
+		}
 	}
 
 	// Parse MinAge (first input)
@@ -211,6 +398,14 @@ func (pv *ProofVerifier) reconstructPublicWitness(publicInputs []string) (*circu
 	}
 	commitment := new(big.Int).SetBytes(commitmentBytes)
 
+	// Parse RelyingPartyID (fifth input)
+	relyingPartyBytes, err := hex.DecodeString(publicInputs[4])
+	if err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("invalid RelyingPartyID hex: %w", err)
+	}
+	relyingParty := new(big.Int).SetBytes(relyingPartyBytes)
+
 	// #region agent log
 	logEntry2 := fmt.Sprintf(`{"sessionId":"debug-session","runId":"run1","hypothesisId":"C","location":"proof_verifier.go:218","message":"Parsed all public inputs","data":{"minAge":"%s","jurisdictionRoot":"%s","requireAccred":"%s","commitment":"%s"},"timestamp":%d}`+"\n", minAge.String(), jurisdictionRoot.String(), requireAccred.String(), commitment.String(), time.Now().UnixMilli())
 	logFile.WriteString(logEntry2)
@@ -224,5 +419,36 @@ func (pv *ProofVerifier) reconstructPublicWitness(publicInputs []string) (*circu
 		JurisdictionRoot:     jurisdictionRoot,
 		RequireAccreditation: requireAccred,
 		Commitment:           commitment,
+		RelyingPartyID:       relyingParty,
+	}, nil
+}
+
+// denylistPublicInputs is the public input count of circuit.KYCDenylistCircuit
+const denylistPublicInputs = 6
+
+// reconstructDenylistWitness reconstructs the denylist variant from public inputs
+// Public inputs order: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID, DenylistRoot
+func (pv *ProofVerifier) reconstructDenylistWitness(publicInputs []string) (*circuit.KYCDenylistCircuit, error) {
+	if len(publicInputs) != denylistPublicInputs {
+		return nil, fmt.Errorf("invalid public inputs: expected %d inputs for the denylist circuit, got %d", denylistPublicInputs, len(publicInputs))
+	}
+
+	kyc, err := pv.reconstructPublicWitness(publicInputs[:5])
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse DenylistRoot (sixth input)
+	if err := checkInputLength(CircuitTypeKYC, 5, publicInputs[5], pv.inputByteLimit()); err != nil {
+		return nil, err
+	}
+	denylistRootBytes, err := hex.DecodeString(publicInputs[5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid DenylistRoot hex: %w", err)
+	}
+
+	return &circuit.KYCDenylistCircuit{
+		KYCCircuit:   *kyc,
+		DenylistRoot: new(big.Int).SetBytes(denylistRootBytes),
 	}, nil
 }
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
 	"math/big"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
 )
 
 // padHex ensures hex string is even length by padding with leading zero if needed
@@ -14,16 +19,17 @@ func padHex(s string) string {
 }
 
 // TestReconstructPublicWitnessOptimized tests the optimized circuit structure
-// with Merkle proofs (4 public inputs instead of 258)
+// with Merkle proofs (5 public inputs instead of 258)
 func TestReconstructPublicWitnessOptimized(t *testing.T) {
 	pv := NewProofVerifier("../prover/keys/verifying.key")
 
-	// New optimized structure: [MinAge, JurisdictionRoot, RequireAccreditation, Commitment]
+	// New optimized structure: [MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID]
 	publicInputs := []string{
 		padHex(big.NewInt(18).Text(16)),    // MinAge
 		padHex(big.NewInt(12345).Text(16)), // JurisdictionRoot (Merkle root)
 		padHex(big.NewInt(0).Text(16)),     // RequireAccreditation
 		padHex(big.NewInt(67890).Text(16)), // Commitment
+		padHex(big.NewInt(7).Text(16)),     // RelyingPartyID
 	}
 
 	// Reconstruct witness
@@ -67,6 +73,15 @@ func TestReconstructPublicWitnessOptimized(t *testing.T) {
 	if commitment.Int64() != 67890 {
 		t.Errorf("Expected Commitment to be 67890, got %d", commitment.Int64())
 	}
+
+	// Verify RelyingPartyID
+	relyingParty, ok := witness.RelyingPartyID.(*big.Int)
+	if !ok {
+		t.Fatal("Failed to cast RelyingPartyID to *big.Int")
+	}
+	if relyingParty.Int64() != 7 {
+		t.Errorf("Expected RelyingPartyID to be 7, got %d", relyingParty.Int64())
+	}
 }
 
 // TestReconstructPublicWitnessInvalidInputCount tests error handling for wrong number of inputs
@@ -89,6 +104,7 @@ func TestReconstructPublicWitnessInvalidInputCount(t *testing.T) {
 		padHex(big.NewInt(12345).Text(16)),
 		padHex(big.NewInt(0).Text(16)),
 		padHex(big.NewInt(67890).Text(16)),
+		padHex(big.NewInt(0).Text(16)),
 		padHex(big.NewInt(999).Text(16)), // Extra input
 	}
 
@@ -108,6 +124,7 @@ func TestReconstructPublicWitnessInvalidHex(t *testing.T) {
 		padHex(big.NewInt(12345).Text(16)), // JurisdictionRoot
 		padHex(big.NewInt(0).Text(16)),     // RequireAccreditation
 		padHex(big.NewInt(67890).Text(16)), // Commitment
+		padHex(big.NewInt(0).Text(16)),     // RelyingPartyID
 	}
 
 	_, err := pv.reconstructPublicWitness(publicInputs)
@@ -116,6 +133,36 @@ func TestReconstructPublicWitnessInvalidHex(t *testing.T) {
 	}
 }
 
+// TestReconstructPublicWitnessInputLength tests that a 32-byte input is accepted and a
+// 64-byte one is rejected before it is decoded, unless the limit is raised
+func TestReconstructPublicWitnessInputLength(t *testing.T) {
+	pv := NewProofVerifier("../prover/keys/verifying.key")
+	withRelyingParty := func(input string) []string {
+		return []string{
+			padHex(big.NewInt(18).Text(16)),
+			padHex(big.NewInt(12345).Text(16)),
+			padHex(big.NewInt(0).Text(16)),
+			padHex(big.NewInt(67890).Text(16)),
+			input,
+		}
+	}
+
+	if _, err := pv.reconstructPublicWitness(withRelyingParty(strings.Repeat("00", 31) + "07")); err != nil {
+		t.Errorf("Expected a 32-byte input to be accepted, got: %v", err)
+	}
+
+	long := strings.Repeat("00", 63) + "07"
+	_, err := pv.reconstructPublicWitness(withRelyingParty(long))
+	if !errors.Is(err, ErrInputTooLarge) || !strings.Contains(err.Error(), "public input 4 (RelyingPartyID)") {
+		t.Errorf("Expected a too-large error naming RelyingPartyID, got %v", err)
+	}
+
+	pv.SetMaxInputBytes(64)
+	if _, err := pv.reconstructPublicWitness(withRelyingParty(long)); err != nil {
+		t.Errorf("Expected a 64-byte input within a 64-byte limit, got: %v", err)
+	}
+}
+
 // TestReconstructPublicWitnessLargeValues tests handling of large BigInt values
 func TestReconstructPublicWitnessLargeValues(t *testing.T) {
 	pv := NewProofVerifier("../prover/keys/verifying.key")
@@ -129,6 +176,7 @@ func TestReconstructPublicWitnessLargeValues(t *testing.T) {
 		padHex(largeValue.Text(16)),     // JurisdictionRoot (large value)
 		padHex(big.NewInt(1).Text(16)),  // RequireAccreditation
 		padHex(largeValue.Text(16)),     // Commitment (large value)
+		padHex(big.NewInt(0).Text(16)),  // RelyingPartyID
 	}
 
 	witness, err := pv.reconstructPublicWitness(publicInputs)
@@ -154,3 +202,113 @@ func TestReconstructPublicWitnessLargeValues(t *testing.T) {
 		t.Errorf("Expected Commitment to be %s, got %s", largeValue.String(), commitment.String())
 	}
 }
+
+// TestReconstructDenylistWitness tests that a sixth public input is parsed as the denylist root
+func TestReconstructDenylistWitness(t *testing.T) {
+	pv := NewProofVerifierWithDenylist("../prover/keys/verifying.key", "../prover/keys/denylist_verifying.key")
+
+	publicInputs := []string{
+		padHex(big.NewInt(18).Text(16)),     // MinAge
+		padHex(big.NewInt(12345).Text(16)),  // JurisdictionRoot
+		padHex(big.NewInt(1).Text(16)),      // RequireAccreditation
+		padHex(big.NewInt(67890).Text(16)),  // Commitment
+		padHex(big.NewInt(0).Text(16)),      // RelyingPartyID
+		padHex(big.NewInt(424242).Text(16)), // DenylistRoot
+	}
+
+	witness, err := pv.reconstructDenylistWitness(publicInputs)
+	if err != nil {
+		t.Fatalf("Failed to reconstruct denylist witness: %v", err)
+	}
+
+	denylistRoot, ok := witness.DenylistRoot.(*big.Int)
+	if !ok {
+		t.Fatal("Failed to cast DenylistRoot to *big.Int")
+	}
+	if denylistRoot.Int64() != 424242 {
+		t.Errorf("Expected DenylistRoot to be 424242, got %s", denylistRoot.String())
+	}
+	if witness.MinAge.(*big.Int).Int64() != 18 {
+		t.Errorf("Expected MinAge to be 18, got %v", witness.MinAge)
+	}
+
+	// The standard circuit still rejects six inputs
+	if _, err := pv.reconstructPublicWitness(publicInputs); err == nil {
+		t.Error("Expected error for six inputs on the standard circuit, got nil")
+	}
+}
+
+// TestVerifyDenylistProofWithoutKey tests that denylist proofs are refused when no key is configured
+func TestVerifyDenylistProofWithoutKey(t *testing.T) {
+	f := newProofFixture(t)
+	pv := NewProofVerifier(f.vkPath)
+
+	publicInputs := append(append([]string{}, f.publicInputs...), hexInput(big.NewInt(424242)))
+	valid, err := pv.VerifyProof(f.proof, publicInputs)
+	if err == nil || valid {
+		t.Fatal("Expected error for denylist proof without denylist key, got nil")
+	}
+	if !strings.Contains(err.Error(), "denylist") {
+		t.Errorf("Expected denylist error, got: %v", err)
+	}
+}
+
+// TestCheckOutputs tests that check outputs must all equal 1
+func TestCheckOutputs(t *testing.T) {
+	// MinAge, JurisdictionRoot, Commitment, then the OverallVerified and AgeVerified outputs
+	inputs := func(overall, age int64) []string {
+		return []string{
+			padHex(big.NewInt(18).Text(16)),
+			padHex(big.NewInt(12345).Text(16)),
+			padHex(big.NewInt(67890).Text(16)),
+			padHex(big.NewInt(overall).Text(16)),
+			padHex(big.NewInt(age).Text(16)),
+		}
+	}
+
+	if err := checkOutputs(inputs(1, 1), []int{3, 4}); err != nil {
+		t.Errorf("Expected outputs of 1 to pass, got %v", err)
+	}
+	if err := checkOutputs(inputs(0, 1), []int{3, 4}); !errors.Is(err, ErrCheckFailed) {
+		t.Errorf("Expected ErrCheckFailed for an output of 0, got %v", err)
+	}
+	if err := checkOutputs(inputs(1, 0), []int{3, 4}); !errors.Is(err, ErrCheckFailed) {
+		t.Errorf("Expected ErrCheckFailed for an output of 0, got %v", err)
+	}
+	if err := checkOutputs(inputs(0, 0), nil); err != nil {
+		t.Errorf("Expected no check without outputs, got %v", err)
+	}
+	if err := checkOutputs(inputs(1, 1), []int{5}); err == nil || errors.Is(err, ErrCheckFailed) {
+		t.Errorf("Expected an out of range error, got %v", err)
+	}
+}
+
+// TestDenylistKeyConcurrentFirstUse tests that parallel first denylist proofs load the
+// denylist verifying key once; run with -race
+func TestDenylistKeyConcurrentFirstUse(t *testing.T) {
+	f := newProofFixture(t)
+	// Any verifying key will do, as only the loading is under test
+	pv := NewProofVerifierWithDenylist(f.vkPath, f.vkPath)
+
+	const requests = 8
+	keys := make(chan groth16.VerifyingKey, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vk, err := pv.denylistKey()
+			if err != nil {
+				t.Errorf("Failed to load denylist key: %v", err)
+			}
+			keys <- vk
+		}()
+	}
+	wg.Wait()
+	close(keys)
+	for vk := range keys {
+		if vk != pv.denylistVK {
+			t.Error("Expected every request to share the one loaded denylist key")
+		}
+	}
+}
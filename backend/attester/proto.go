@@ -0,0 +1,120 @@
+package main
+
+import (
+	"noah-v2/backend/pkg/protoresp"
+)
+
+// AttestationResponse field numbers in backend/proto/noah.proto
+const (
+	attestationFieldCommitment          = 1
+	attestationFieldSignature           = 2
+	attestationFieldHashAlgo            = 3
+	attestationFieldSignatureFormat     = 4
+	attestationFieldSignatureComponents = 5
+	attestationFieldAttesterID          = 6
+	attestationFieldIssuerName          = 7
+	attestationFieldIssuerURL           = 8
+	attestationFieldExpiry              = 9
+	attestationFieldExpiryType          = 10
+	attestationFieldSuccess             = 11
+	attestationFieldError               = 12
+	attestationFieldReceiptSignature    = 13
+	attestationFieldSignedMessage       = 14
+)
+
+// SignatureComponents field numbers in backend/proto/noah.proto
+const (
+	componentsFieldR = 1
+	componentsFieldS = 2
+	componentsFieldV = 3
+)
+
+// MarshalProto encodes the response as the noah.v1.AttestationResponse message
+func (r *AttestationResponse) MarshalProto() []byte {
+	var e protoresp.Encoder
+	e.String(attestationFieldCommitment, r.Commitment)
+	e.String(attestationFieldSignature, r.Signature)
+	e.String(attestationFieldHashAlgo, r.HashAlgo)
+	e.String(attestationFieldSignatureFormat, r.SignatureFormat)
+	if r.SignatureComponents != nil {
+		e.Message(attestationFieldSignatureComponents, r.SignatureComponents)
+	}
+	e.Uint64(attestationFieldAttesterID, uint64(r.AttesterID))
+	e.String(attestationFieldIssuerName, r.IssuerName)
+	e.String(attestationFieldIssuerURL, r.IssuerURL)
+	e.Uint64(attestationFieldExpiry, r.Expiry)
+	e.String(attestationFieldExpiryType, r.ExpiryType)
+	e.Bool(attestationFieldSuccess, r.Success)
+	e.String(attestationFieldError, r.Error)
+	e.String(attestationFieldReceiptSignature, r.ReceiptSignature)
+	e.String(attestationFieldSignedMessage, r.SignedMessage)
+	return e.Bytes()
+}
+
+// UnmarshalProto decodes a noah.v1.AttestationResponse message into r
+func (r *AttestationResponse) UnmarshalProto(b []byte) error {
+	*r = AttestationResponse{}
+	return protoresp.Decode(b, func(f protoresp.Field) error {
+		switch f.Number {
+		case attestationFieldCommitment:
+			r.Commitment = string(f.Bytes)
+		case attestationFieldSignature:
+			r.Signature = string(f.Bytes)
+		case attestationFieldHashAlgo:
+			r.HashAlgo = string(f.Bytes)
+		case attestationFieldSignatureFormat:
+			r.SignatureFormat = string(f.Bytes)
+		case attestationFieldSignatureComponents:
+			r.SignatureComponents = &SignatureComponents{}
+			return r.SignatureComponents.UnmarshalProto(f.Bytes)
+		case attestationFieldAttesterID:
+			r.AttesterID = uint(f.Varint)
+		case attestationFieldIssuerName:
+			r.IssuerName = string(f.Bytes)
+		case attestationFieldIssuerURL:
+			r.IssuerURL = string(f.Bytes)
+		case attestationFieldExpiry:
+			r.Expiry = f.Varint
+		case attestationFieldExpiryType:
+			r.ExpiryType = string(f.Bytes)
+		case attestationFieldSuccess:
+			r.Success = f.Varint != 0
+		case attestationFieldError:
+			r.Error = string(f.Bytes)
+		case attestationFieldReceiptSignature:
+			r.ReceiptSignature = string(f.Bytes)
+		case attestationFieldSignedMessage:
+			r.SignedMessage = string(f.Bytes)
+		}
+		return nil
+	})
+}
+
+// MarshalProto encodes the components as the noah.v1.SignatureComponents message
+func (c *SignatureComponents) MarshalProto() []byte {
+	var e protoresp.Encoder
+	e.String(componentsFieldR, c.R)
+	e.String(componentsFieldS, c.S)
+	if c.V != nil {
+		v := uint64(*c.V)
+		e.OptionalUint64(componentsFieldV, &v)
+	}
+	return e.Bytes()
+}
+
+// UnmarshalProto decodes a noah.v1.SignatureComponents message into c
+func (c *SignatureComponents) UnmarshalProto(b []byte) error {
+	*c = SignatureComponents{}
+	return protoresp.Decode(b, func(f protoresp.Field) error {
+		switch f.Number {
+		case componentsFieldR:
+			c.R = string(f.Bytes)
+		case componentsFieldS:
+			c.S = string(f.Bytes)
+		case componentsFieldV:
+			v := byte(f.Varint)
+			c.V = &v
+		}
+		return nil
+	})
+}
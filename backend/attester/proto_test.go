@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"noah-v2/backend/pkg/protoresp"
+	"noah-v2/backend/pkg/protoresp/prototest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCreateAttestationProtobuf tests that a protobuf attestation decodes to the same
+// AttestationResponse as the JSON one for the same proof
+func TestCreateAttestationProtobuf(t *testing.T) {
+	f := newProofFixture(t)
+	t.Setenv("SIGN_HASH_ALGO", HashAlgoKeccak256)
+	t.Setenv("ATTESTATION_SIGNATURE_COMPONENTS", "true")
+	api := newTestAPI(t)
+	// A fixed block height keeps the expiry, and so the signature, the same across requests
+	api.issuerService.blockHeight = func(context.Context) (uint64, error) { return 150000, nil }
+	router := gin.New()
+	router.POST("/credential/attest", api.CreateAttestation)
+
+	body, err := json.Marshal(AttestationRequest{
+		Commitment:   f.commitment,
+		PublicInputs: f.publicInputs,
+		Proof:        f.proof,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	post := func(accept string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/credential/attest", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for Accept %q, got %d: %s", accept, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	var fromJSON AttestationResponse
+	if err := json.Unmarshal(post("application/json").Body.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("Failed to decode JSON response: %v", err)
+	}
+
+	rec := post(protoresp.MIMEType)
+	if ct := rec.Header().Get("Content-Type"); ct != protoresp.MIMEType {
+		t.Errorf("Expected %s, got %q", protoresp.MIMEType, ct)
+	}
+	var fromProto AttestationResponse
+	if err := fromProto.UnmarshalProto(rec.Body.Bytes()); err != nil {
+		t.Fatalf("Failed to decode protobuf response: %v", err)
+	}
+
+	if fromJSON.SignatureComponents == nil || fromJSON.SignatureComponents.V == nil {
+		t.Fatalf("Expected r, s and v components, got %+v", fromJSON.SignatureComponents)
+	}
+	if !reflect.DeepEqual(fromJSON, fromProto) {
+		t.Errorf("Expected equal responses\njson:  %+v\nproto: %+v", fromJSON, fromProto)
+	}
+}
+
+// TestAttestationResponseMatchesSchema tests that an AttestationResponse with every field
+// set encodes as the noah.v1.AttestationResponse in backend/proto/noah.proto
+func TestAttestationResponseMatchesSchema(t *testing.T) {
+	fd := prototest.Schema(t, "../proto/noah.proto")
+	v := byte(0)
+	resp := AttestationResponse{
+		Commitment:          "0x01",
+		Signature:           "0x02",
+		HashAlgo:            HashAlgoKeccak256,
+		SignatureFormat:     "secp256k1-rsv-65",
+		SignedMessage:       "attestation",
+		SignatureComponents: &SignatureComponents{R: "0x03", S: "0x04", V: &v},
+		AttesterID:          1,
+		IssuerName:          "issuer",
+		IssuerURL:           "https://issuer.example",
+		Expiry:              1 << 40,
+		ExpiryType:          "block_height",
+		ReceiptSignature:    "0x05",
+		Success:             true,
+		Error:               "error",
+	}
+
+	msg := prototest.RoundTrip(t, fd, "AttestationResponse", resp.MarshalProto())
+	if unset := prototest.Unset(msg); len(unset) > 0 {
+		t.Errorf("Expected every field on the wire, missing %v", unset)
+	}
+	components := msg.Get(msg.Descriptor().Fields().ByName("signature_components")).Message()
+	if unset := prototest.Unset(components); len(unset) > 0 {
+		t.Errorf("Expected r, s and a zero v on the wire, missing %v", unset)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// receiptVersion names the receipt serialization, so it can change without old
+// receipts verifying under a new layout
+const receiptVersion = "noah-attestation-receipt-v1"
+
+// receiptPayload is the signed content of an attestation receipt, in serialization order
+type receiptPayload struct {
+	Version         string `json:"version"`
+	Commitment      string `json:"commitment"`
+	Signature       string `json:"signature"`
+	HashAlgo        string `json:"hash_algo"`
+	SignatureFormat string `json:"signature_format"`
+	AttesterID      uint   `json:"attester_id"`
+	IssuerName      string `json:"issuer_name"`
+	IssuerURL       string `json:"issuer_url"`
+	Expiry          uint64 `json:"expiry"`
+	ExpiryType      string `json:"expiry_type"`
+}
+
+// canonicalReceipt serializes the attested fields of r as compact JSON with a fixed key
+// order, lowercase hex and no HTML escaping; SignatureComponents (derived from
+// Signature), Success, Error and the receipt signature itself are not covered
+func canonicalReceipt(r *AttestationResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(receiptPayload{
+		Version:         receiptVersion,
+		Commitment:      strings.ToLower(r.Commitment),
+		Signature:       strings.ToLower(r.Signature),
+		HashAlgo:        r.HashAlgo,
+		SignatureFormat: r.SignatureFormat,
+		AttesterID:      r.AttesterID,
+		IssuerName:      r.IssuerName,
+		IssuerURL:       r.IssuerURL,
+		Expiry:          r.Expiry,
+		ExpiryType:      r.ExpiryType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize receipt: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// SignReceipt signs the canonical serialization of r with the attester key, returning
+// a 65-byte r || s || v hex signature over its Keccak256 hash
+func (s *Signer) SignReceipt(r *AttestationResponse) (string, error) {
+	payload, err := canonicalReceipt(r)
+	if err != nil {
+		return "", err
+	}
+	return s.Sign(payload)
+}
+
+// VerifyReceipt reports whether r's ReceiptSignature was made by the attester key
+// publicKeyHex over r's current fields, so any change to them invalidates it
+func VerifyReceipt(r *AttestationResponse, publicKeyHex string) (bool, error) {
+	if r.ReceiptSignature == "" {
+		return false, fmt.Errorf("attestation has no receipt signature")
+	}
+	payload, err := canonicalReceipt(r)
+	if err != nil {
+		return false, err
+	}
+	return VerifySignatureWith(payload, r.ReceiptSignature, publicKeyHex, true)
+}
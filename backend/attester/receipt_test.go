@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestAttestationReceipt tests that the receipt verifies and that changing any attested field invalidates it
+func TestAttestationReceipt(t *testing.T) {
+	f := newProofFixture(t)
+	api := newTestAPI(t)
+	publicKey := api.issuerService.signer.GetPublicKey()
+
+	resp, err := api.issuerService.CreateAttestation(context.Background(), &AttestationRequest{
+		Commitment:   f.commitment,
+		Proof:        f.proof,
+		PublicInputs: f.publicInputs,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create attestation: %v", err)
+	}
+	if valid, err := VerifyReceipt(resp, publicKey); err != nil || !valid {
+		t.Fatalf("Expected the receipt to verify, got %v, %v", valid, err)
+	}
+
+	// Hex case is canonicalized away
+	upper := *resp
+	upper.Commitment = strings.ToUpper(resp.Commitment)
+	if valid, err := VerifyReceipt(&upper, publicKey); err != nil || !valid {
+		t.Errorf("Expected the receipt to verify with an uppercase commitment, got %v, %v", valid, err)
+	}
+
+	mutations := map[string]func(r *AttestationResponse){
+		"commitment":       func(r *AttestationResponse) { r.Commitment = strings.Repeat("ab", 32) },
+		"signature":        func(r *AttestationResponse) { r.Signature = strings.Repeat("00", 64) },
+		"hash_algo":        func(r *AttestationResponse) { r.HashAlgo = HashAlgoKeccak256 },
+		"signature_format": func(r *AttestationResponse) { r.SignatureFormat = "other" },
+		"attester_id":      func(r *AttestationResponse) { r.AttesterID++ },
+		"issuer_name":      func(r *AttestationResponse) { r.IssuerName += "x" },
+		"issuer_url":       func(r *AttestationResponse) { r.IssuerURL += "x" },
+		"expiry":           func(r *AttestationResponse) { r.Expiry++ },
+		"expiry_type":      func(r *AttestationResponse) { r.ExpiryType = "block_height" },
+	}
+	for field, mutate := range mutations {
+		t.Run(field, func(t *testing.T) {
+			mutated := *resp
+			mutate(&mutated)
+			if valid, _ := VerifyReceipt(&mutated, publicKey); valid {
+				t.Errorf("Expected a changed %s to invalidate the receipt", field)
+			}
+		})
+	}
+
+	other, err := NewSignerFromSeed([]byte("another attester"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	if valid, _ := VerifyReceipt(resp, other.GetPublicKey()); valid {
+		t.Error("Expected the receipt not to verify under another attester's key")
+	}
+}
+
+// TestCanonicalReceiptStable tests that the receipt serialization is fixed for given fields
+func TestCanonicalReceiptStable(t *testing.T) {
+	r := &AttestationResponse{
+		Commitment:      "ABCD",
+		Signature:       "EF01",
+		HashAlgo:        HashAlgoSHA256,
+		SignatureFormat: "secp256k1-rs-64",
+		AttesterID:      3,
+		IssuerName:      "Noah <KYC> & Co",
+		IssuerURL:       "https://issuer.example",
+		Expiry:          1700000000,
+		ExpiryType:      "timestamp",
+		Success:         true,
+	}
+	got, err := canonicalReceipt(r)
+	if err != nil {
+		t.Fatalf("Failed to serialize receipt: %v", err)
+	}
+	want := `{"version":"noah-attestation-receipt-v1","commitment":"abcd","signature":"ef01","hash_algo":"sha256",` +
+		`"signature_format":"secp256k1-rs-64","attester_id":3,"issuer_name":"Noah <KYC> & Co",` +
+		`"issuer_url":"https://issuer.example","expiry":1700000000,"expiry_type":"timestamp"}`
+	if string(got) != want {
+		t.Errorf("Unexpected canonical receipt:\n got %s\nwant %s", got, want)
+	}
+
+	// Response-only fields are not covered
+	r.Success, r.Error, r.ReceiptSignature = false, "ignored", "ignored"
+	if again, _ := canonicalReceipt(r); string(again) != want {
+		t.Errorf("Expected success, error and receipt_signature to be excluded, got %s", again)
+	}
+}
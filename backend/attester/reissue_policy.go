@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// What issuing to a user who already holds a credential does, selected by
+// CREDENTIAL_REISSUE_POLICY
+const (
+	// ReissueReject refuses the issuance with ErrCredentialExists
+	ReissueReject = "reject"
+	// ReissueOverwrite replaces the credential and releases its commitment
+	ReissueOverwrite = "overwrite"
+	// ReissueVersion replaces the credential but keeps the previous ones, and their
+	// commitments, in the user's credential history
+	ReissueVersion = "version"
+)
+
+// ErrCredentialExists is returned under the reject policy when the user already holds a credential
+var ErrCredentialExists = errors.New("credential already issued to user")
+
+// ValidateReissuePolicy returns an error unless policy is a supported reissue policy
+func ValidateReissuePolicy(policy string) error {
+	switch policy {
+	case ReissueReject, ReissueOverwrite, ReissueVersion:
+		return nil
+	}
+	return fmt.Errorf("unsupported reissue policy %q (expected %s, %s or %s)", policy, ReissueReject, ReissueOverwrite, ReissueVersion)
+}
+
+// CredentialHistory returns the credentials the user held before their current one,
+// oldest first; only the version policy keeps them
+func (is *IssuerService) CredentialHistory(userID string) []*Credential {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+	return append([]*Credential(nil), is.history[userID]...)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestIssueCredentialReissuePolicy tests that reissuing to a user rejects, replaces or
+// versions their credential as the policy says
+func TestIssueCredentialReissuePolicy(t *testing.T) {
+	first := &CredentialRequest{UserID: "alice", Attributes: map[string]interface{}{"age": 30}}
+	second := &CredentialRequest{UserID: "alice", Attributes: map[string]interface{}{"age": 31}}
+
+	t.Run("reject", func(t *testing.T) {
+		is := newTestIssuer(t)
+		is.config.ReissuePolicy = ReissueReject
+		issued, err := is.IssueCredential(first)
+		if err != nil {
+			t.Fatalf("Failed to issue credential: %v", err)
+		}
+		if _, err := is.IssueCredential(second); !errors.Is(err, ErrCredentialExists) {
+			t.Fatalf("Expected ErrCredentialExists, got %v", err)
+		}
+		if got, _ := is.GetCredential("alice"); got != issued {
+			t.Error("Expected the rejected reissuance to keep the existing credential")
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		is := newTestIssuer(t)
+		is.config.ReissuePolicy = ReissueOverwrite
+		previous, _ := is.IssueCredential(first)
+		reissued, err := is.IssueCredential(second)
+		if err != nil {
+			t.Fatalf("Failed to reissue credential: %v", err)
+		}
+		if got, _ := is.GetCredential("alice"); got != reissued {
+			t.Error("Expected the reissued credential to replace the previous one")
+		}
+		if _, held := is.commitments[previous.Commitment]; held {
+			t.Error("Expected the previous commitment to be released")
+		}
+		if history := is.CredentialHistory("alice"); len(history) != 0 {
+			t.Errorf("Expected no history under overwrite, got %d entries", len(history))
+		}
+	})
+
+	t.Run("version", func(t *testing.T) {
+		is := newTestIssuer(t)
+		is.config.ReissuePolicy = ReissueVersion
+		previous, _ := is.IssueCredential(first)
+		reissued, err := is.IssueCredential(second)
+		if err != nil {
+			t.Fatalf("Failed to reissue credential: %v", err)
+		}
+		if got, _ := is.GetCredential("alice"); got != reissued {
+			t.Error("Expected the reissued credential to be the current one")
+		}
+		history := is.CredentialHistory("alice")
+		if len(history) != 1 || history[0] != previous {
+			t.Fatalf("Expected the previous credential in the history, got %v", history)
+		}
+		if owner := is.commitments[previous.Commitment]; owner != "alice" {
+			t.Errorf("Expected the previous commitment to stay with alice, held by %q", owner)
+		}
+	})
+
+	if err := ValidateReissuePolicy("append"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
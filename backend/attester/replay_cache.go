@@ -0,0 +1,147 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"noah-v2/backend/pkg/logger"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"go.uber.org/zap"
+)
+
+// DefaultReplayCacheSize is the default number of proofs the replay cache remembers
+const DefaultReplayCacheSize = 100000
+
+var (
+	// ErrProofReplayed is returned under strict replay protection for a proof of public
+	// inputs already attested within PROOF_REPLAY_TTL
+	ErrProofReplayed = errors.New("proof replayed")
+	// ErrReplayCacheFull is returned under strict replay protection when the replay cache
+	// holds DefaultReplayCacheSize live entries and cannot record another proof
+	ErrReplayCacheFull = errors.New("replay cache full")
+)
+
+// replayCache remembers the proofs seen within a TTL, keyed by a SHA256 of their public
+// inputs; entries are kept in first-seen order, which with a fixed TTL is also expiry
+// order, so expired ones are dropped from the front
+type replayCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	seen     map[[sha256.Size]byte]*list.Element
+	order    *list.List // front is the oldest entry
+	now      func() time.Time
+}
+
+// replayEntry is the value stored in the order list
+type replayEntry struct {
+	key    [sha256.Size]byte
+	seenAt time.Time
+}
+
+// newReplayCache returns a cache remembering up to capacity proofs for ttl
+func newReplayCache(ttl time.Duration, capacity int) *replayCache {
+	if capacity <= 0 {
+		capacity = DefaultReplayCacheSize
+	}
+	return &replayCache{
+		ttl:      ttl,
+		capacity: capacity,
+		seen:     make(map[[sha256.Size]byte]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// claim records key as seen and reports whether it was already seen within the TTL;
+// a replayed key keeps its first-seen time, so the window is not extended by retries
+// A new key is refused with ErrReplayCacheFull while the cache is full, as evicting a
+// live entry would let its proof be replayed
+func (rc *replayCache) claim(key [sha256.Size]byte) (bool, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := rc.now()
+	rc.expire(now)
+	if _, exists := rc.seen[key]; exists {
+		return true, nil
+	}
+	if rc.order.Len() >= rc.capacity {
+		return false, ErrReplayCacheFull
+	}
+	rc.seen[key] = rc.order.PushBack(&replayEntry{key: key, seenAt: now})
+	return false, nil
+}
+
+// expire drops the entries first seen a TTL or more before now
+func (rc *replayCache) expire(now time.Time) {
+	for front := rc.order.Front(); front != nil; front = rc.order.Front() {
+		entry := front.Value.(*replayEntry)
+		if now.Sub(entry.seenAt) < rc.ttl {
+			return
+		}
+		rc.order.Remove(front)
+		delete(rc.seen, entry.key)
+	}
+}
+
+// replayKey hashes the circuit type and the public inputs as canonical field elements
+// Groth16 proofs are malleable, so a replay can re-randomize the proof bytes but not what
+// they prove, which for the KYC circuits includes the commitment and relying party
+func replayKey(circuitType string, publicInputs []string) ([sha256.Size]byte, error) {
+	if isKYCCircuitType(circuitType) {
+		circuitType = CircuitTypeKYC
+	}
+	h := sha256.New()
+	h.Write([]byte(circuitType))
+	h.Write([]byte{0})
+	for i, input := range publicInputs {
+		value, err := parseFieldInput(circuitType, i, input)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		h.Write(value.FillBytes(make([]byte, fr.Bytes)))
+	}
+	return [sha256.Size]byte(h.Sum(nil)), nil
+}
+
+// SetReplayProtection remembers attested proofs for ttl (0 disables); a proof seen again
+// within it is rejected with ErrProofReplayed when strict, and only logged otherwise
+func (pv *ProofVerifier) SetReplayProtection(ttl time.Duration, strict bool) {
+	pv.replay = nil
+	if ttl > 0 {
+		pv.replay = newReplayCache(ttl, DefaultReplayCacheSize)
+	}
+	pv.replayStrict = strict
+}
+
+// CheckReplay records a verified proof by its public inputs and returns ErrProofReplayed
+// if they were already recorded within the replay TTL, or ErrReplayCacheFull if they
+// cannot be recorded, when replay protection is strict
+func (pv *ProofVerifier) CheckReplay(circuitType string, publicInputs []string) error {
+	if pv.replay == nil {
+		return nil
+	}
+	key, err := replayKey(circuitType, publicInputs)
+	if err != nil {
+		return err
+	}
+	replayed, err := pv.replay.claim(key)
+	switch {
+	case err != nil && pv.replayStrict:
+		return fmt.Errorf("%w: try again once older proofs expire", err)
+	case err != nil:
+		logger.Warn("Replay cache full, proof not recorded", zap.String("proof_hash", hex.EncodeToString(key[:8])))
+	case replayed && pv.replayStrict:
+		return fmt.Errorf("%w: a proof of the same public inputs was already attested within %s", ErrProofReplayed, pv.replay.ttl)
+	case replayed:
+		logger.Warn("Replayed proof accepted", zap.String("proof_hash", hex.EncodeToString(key[:8])))
+	}
+	return nil
+}
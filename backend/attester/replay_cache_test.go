@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/apierror"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/gin-gonic/gin"
+)
+
+// TestCreateAttestationProofReplay tests that strict replay protection rejects a second
+// attestation of the same public inputs, even under a freshly randomized proof, and
+// non-strict only logs it
+func TestCreateAttestationProofReplay(t *testing.T) {
+	f := newProofFixture(t)
+
+	// Proving the fixture witness again gives different proof bytes for the same inputs
+	fullWitness, err := frontend.NewWitness(&f.assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("Failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(f.ccs, f.pk, fullWitness)
+	if err != nil {
+		t.Fatalf("Failed to prove: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		t.Fatalf("Failed to serialize proof: %v", err)
+	}
+	reproved := base64.StdEncoding.EncodeToString(proofBuf.Bytes())
+	if reproved == f.proof {
+		t.Fatal("Expected a new proof to differ from the fixture's")
+	}
+
+	tests := []struct {
+		name       string
+		strict     string
+		wantStatus int
+	}{
+		{"strict", "true", http.StatusConflict},
+		{"not strict", "false", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROOF_REPLAY_TTL", "1m")
+			t.Setenv("PROOF_REPLAY_STRICT", tt.strict)
+			api := newTestAPI(t)
+			router := gin.New()
+			router.POST("/credential/attest", api.CreateAttestation)
+
+			req := AttestationRequest{Commitment: f.commitment, Proof: f.proof, PublicInputs: f.publicInputs}
+			var first apierror.APIError
+			if code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &first); code != http.StatusOK {
+				t.Fatalf("Expected the first attestation to succeed, got %d: %+v", code, first)
+			}
+
+			for name, encoded := range map[string]string{"same proof": f.proof, "new proof": reproved} {
+				req.Proof = encoded
+				var replay apierror.APIError
+				code := doJSON(t, router, http.MethodPost, "/credential/attest", req, &replay)
+				if code != tt.wantStatus {
+					t.Fatalf("Expected status %d for the %s, got %d: %+v", tt.wantStatus, name, code, replay)
+				}
+				if code != http.StatusOK && replay.Code != apierror.CodeProofReplayed {
+					t.Errorf("Expected code %s for the %s, got %+v", apierror.CodeProofReplayed, name, replay)
+				}
+			}
+		})
+	}
+}
+
+// TestReplayCacheExpiry tests that proofs are forgotten after the TTL and that a full
+// cache refuses new proofs rather than evicting live ones
+func TestReplayCacheExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache := newReplayCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+	a, b, c := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b")), sha256.Sum256([]byte("c"))
+
+	if replayed, _ := cache.claim(a); replayed {
+		t.Fatal("Expected a new proof not to be a replay")
+	}
+	now = now.Add(30 * time.Second)
+	if replayed, _ := cache.claim(a); !replayed {
+		t.Error("Expected a proof within the TTL to be a replay")
+	}
+	now = now.Add(30 * time.Second)
+	if replayed, _ := cache.claim(a); replayed {
+		t.Error("Expected a proof to be forgotten after the TTL")
+	}
+
+	cache.claim(b)
+	if _, err := cache.claim(c); !errors.Is(err, ErrReplayCacheFull) {
+		t.Errorf("Expected ErrReplayCacheFull, got %v", err)
+	}
+	if replayed, _ := cache.claim(a); !replayed {
+		t.Error("Expected a full cache to keep its live entries")
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := cache.claim(c); err != nil {
+		t.Errorf("Expected room once entries expire, got %v", err)
+	}
+}
+
+// TestCheckReplayKeysOnPublicInputs tests that the replay key ignores input encoding and
+// treats the empty and explicit KYC circuit types alike, and that a full cache fails
+// strict checks only
+func TestCheckReplayKeysOnPublicInputs(t *testing.T) {
+	inputs := []string{"12", "00ab"}
+	key, err := replayKey("", inputs)
+	if err != nil {
+		t.Fatalf("Failed to compute key: %v", err)
+	}
+	if same, _ := replayKey(CircuitTypeKYC, []string{"0012", "ab"}); same != key {
+		t.Error("Expected equal field elements to share a key")
+	}
+	if other, _ := replayKey(CircuitTypeAge, inputs); other == key {
+		t.Error("Expected another circuit type to change the key")
+	}
+
+	pv := &ProofVerifier{}
+	pv.SetReplayProtection(time.Minute, true)
+	pv.replay.capacity = 1
+	if err := pv.CheckReplay("", inputs); err != nil {
+		t.Fatalf("Expected the first proof to be recorded, got %v", err)
+	}
+	if err := pv.CheckReplay("", []string{"13"}); !errors.Is(err, ErrReplayCacheFull) || !strings.Contains(err.Error(), "try again") {
+		t.Errorf("Expected ErrReplayCacheFull, got %v", err)
+	}
+	pv.replayStrict = false
+	if err := pv.CheckReplay("", []string{"13"}); err != nil {
+		t.Errorf("Expected a full cache only to be logged when not strict, got %v", err)
+	}
+}
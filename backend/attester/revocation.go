@@ -2,29 +2,36 @@ package main
 
 import (
 	"fmt"
+	"sync"
 )
 
 // RevocationService manages credential revocation
+// mu guards both fields, which Rebuild replaces together
 type RevocationService struct {
+	mu         sync.RWMutex
 	merkleTree *MerkleTree
 	revoked    map[string]bool
 }
 
-// NewRevocationService creates a new revocation service
-func NewRevocationService() *RevocationService {
+// NewRevocationService creates a new revocation service whose leaves are hashed in domain
+func NewRevocationService(domain string) *RevocationService {
 	return &RevocationService{
-		merkleTree: NewMerkleTree([]string{}),
+		merkleTree: NewMerkleTree(domain, []string{}),
 		revoked:    make(map[string]bool),
 	}
 }
 
 // RevokeCredential revokes a credential by adding it to the revocation tree
+// Commitments are keyed by their bare hash, so any form of one is revoked once
 func (rs *RevocationService) RevokeCredential(commitment string) error {
-	if rs.revoked[commitment] {
+	key := commitmentKey(commitment)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.revoked[key] {
 		return fmt.Errorf("credential already revoked")
 	}
 
-	rs.revoked[commitment] = true
+	rs.revoked[key] = true
 	rs.merkleTree.AddCommitment(commitment)
 
 	return nil
@@ -32,18 +39,25 @@ func (rs *RevocationService) RevokeCredential(commitment string) error {
 
 // IsRevoked checks if a commitment is revoked
 func (rs *RevocationService) IsRevoked(commitment string) bool {
-	return rs.revoked[commitment]
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.revoked[commitmentKey(commitment)]
 }
 
 // GetRevocationRoot returns the current Merkle root of revoked credentials
 func (rs *RevocationService) GetRevocationRoot() string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
 	return rs.merkleTree.GetRoot()
 }
 
 // GenerateNonRevocationProof generates a proof that a commitment is NOT in the revocation tree
 func (rs *RevocationService) GenerateNonRevocationProof(commitment string) ([]string, []bool, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
 	// If the commitment is revoked, we can't generate a non-revocation proof
-	if rs.IsRevoked(commitment) {
+	if rs.revoked[commitmentKey(commitment)] {
 		return nil, nil, fmt.Errorf("credential is revoked")
 	}
 
@@ -59,8 +73,91 @@ func (rs *RevocationService) GenerateNonRevocationProof(commitment string) ([]st
 	return proof, path, nil
 }
 
+// VerifyProof checks a Merkle proof for commitment against root, and whether root is
+// this tree's current root; a proof for an older root may be outdated by later revocations
+func (rs *RevocationService) VerifyProof(commitment string, proof []string, indices []bool, root string) (bool, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	valid := VerifyProof(rs.merkleTree.domain, commitment, proof, indices, root)
+	return valid, root == rs.merkleTree.GetRoot()
+}
+
+// RevokedCommitments returns up to limit revoked commitments starting at offset, in
+// revocation order, along with the total number revoked; limit 0 returns the rest
+func (rs *RevocationService) RevokedCommitments(offset, limit int) ([]string, int) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	leaves := rs.merkleTree.leaves
+	total := len(leaves)
+	if offset >= total {
+		return []string{}, total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return append([]string{}, leaves[offset:end]...), total
+}
+
+// Rebuild replaces the revoked set with commitments, in the given order, and returns the
+// new root. Every entry is validated first, so an invalid list leaves the tree unchanged
+func (rs *RevocationService) Rebuild(commitments []string) (string, error) {
+	revoked := make(map[string]bool, len(commitments))
+	for i, commitment := range commitments {
+		if _, _, err := DecodeCommitment(commitment); err != nil {
+			return "", fmt.Errorf("commitment %d: %w", i, err)
+		}
+		key := commitmentKey(commitment)
+		if revoked[key] {
+			return "", fmt.Errorf("commitment %d: duplicate %s", i, commitment)
+		}
+		revoked[key] = true
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	tree := NewMerkleTree(rs.merkleTree.domain, append([]string{}, commitments...))
+	rs.merkleTree, rs.revoked = tree, revoked
+	return tree.GetRoot(), nil
+}
+
 // GetRevokedCount returns the number of revoked credentials
 func (rs *RevocationService) GetRevokedCount() int {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
 	return len(rs.revoked)
 }
 
+// RevocationRegistry keeps a separate revocation tree per issuer, so revocations from
+// different issuers hosted by one attester never share a root
+// The issuer set is fixed at construction, so lookups need no locking
+type RevocationRegistry struct {
+	trees map[string]*RevocationService
+}
+
+// NewRevocationRegistry creates an empty revocation tree for each issuer, hashed in domain
+func NewRevocationRegistry(issuers []string, domain string) *RevocationRegistry {
+	trees := make(map[string]*RevocationService, len(issuers))
+	for _, issuer := range issuers {
+		if _, exists := trees[issuer]; !exists {
+			trees[issuer] = NewRevocationService(domain)
+		}
+	}
+	return &RevocationRegistry{trees: trees}
+}
+
+// Tree returns the issuer's revocation tree, or false if the issuer is not configured
+func (rr *RevocationRegistry) Tree(issuer string) (*RevocationService, bool) {
+	tree, ok := rr.trees[issuer]
+	return tree, ok
+}
+
+// IsRevoked reports whether any issuer's tree revokes the commitment
+func (rr *RevocationRegistry) IsRevoked(commitment string) bool {
+	for _, tree := range rr.trees {
+		if tree.IsRevoked(commitment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"noah-v2/backend/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxPublishBackoff caps the delay between retries of a failed submission
+const maxPublishBackoff = time.Hour
+
+// maxDeadLetters bounds the abandoned roots kept for operators
+const maxDeadLetters = 100
+
+// RevocationPublisher pushes the revocation root on-chain in the background
+// Changes are debounced so a burst of revocations produces one transaction. A failed
+// submission is retried with exponential backoff until it succeeds, a newer root
+// replaces it, or maxRetries is exhausted and the root is dead-lettered
+type RevocationPublisher struct {
+	submitter  ContractCallSubmitter
+	address    string
+	contract   string
+	function   string
+	interval   time.Duration // Quiet period after the last change, and the first retry delay
+	maxRetries int
+
+	mu          sync.Mutex
+	pending     string // Latest root to publish
+	published   string // Last root confirmed submitted
+	deadLetters []DeadLetter
+	changed     chan struct{}
+}
+
+// DeadLetter is a root whose publication was abandoned after exhausting retries
+type DeadLetter struct {
+	Root      string `json:"root"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error"`
+	FailedAt  int64  `json:"failed_at"` // Unix seconds
+}
+
+// NewRevocationPublisher creates a publisher calling function on contract ("ADDRESS.name"),
+// giving up on a root after maxRetries failed retries
+func NewRevocationPublisher(submitter ContractCallSubmitter, contract, function string, interval time.Duration, maxRetries int) (*RevocationPublisher, error) {
+	address, name, err := splitContractID(contract)
+	if err != nil {
+		return nil, err
+	}
+	if function == "" {
+		return nil, fmt.Errorf("revocation publish function is required")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("revocation publish interval must be positive")
+	}
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("revocation publish max retries cannot be negative")
+	}
+	return &RevocationPublisher{
+		submitter:  submitter,
+		address:    address,
+		contract:   name,
+		function:   function,
+		interval:   interval,
+		maxRetries: maxRetries,
+		changed:    make(chan struct{}, 1),
+	}, nil
+}
+
+// Notify records a new revocation root; it never blocks the caller
+func (p *RevocationPublisher) Notify(root string) {
+	p.mu.Lock()
+	p.pending = root
+	p.mu.Unlock()
+
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// DeadLetters returns the roots abandoned after exhausting retries, oldest first
+func (p *RevocationPublisher) DeadLetters() []DeadLetter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]DeadLetter{}, p.deadLetters...)
+}
+
+// Retry clears the dead letters and publishes the latest root again with a fresh retry
+// budget, returning that root
+func (p *RevocationPublisher) Retry() string {
+	p.mu.Lock()
+	p.deadLetters = nil
+	root := p.pending
+	p.mu.Unlock()
+
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+	return root
+}
+
+// Published returns the last root submitted on-chain
+func (p *RevocationPublisher) Published() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.published
+}
+
+// Run publishes root changes until ctx is done
+func (p *RevocationPublisher) Run(ctx context.Context) {
+	var timer *time.Timer
+	var fire <-chan time.Time
+	reset := func(delay time.Duration) {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.NewTimer(delay)
+		fire = timer.C
+	}
+	failures := 0 // Failed attempts for the pending root
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-p.changed:
+			// Every change restarts the quiet period and the retry budget
+			failures = 0
+			reset(p.interval)
+		case <-fire:
+			fire = nil
+			err := p.publish(ctx)
+			if err == nil {
+				failures = 0
+				continue
+			}
+			failures++
+			if failures > p.maxRetries {
+				p.deadLetter(failures, err)
+				failures = 0
+				continue
+			}
+			delay := p.backoff(failures)
+			logger.Warn("Failed to publish revocation root, will retry",
+				zap.Int("attempt", failures),
+				zap.Duration("retry_in", delay),
+				zap.Error(err),
+			)
+			reset(delay)
+		}
+	}
+}
+
+// backoff returns the delay before the retry following the given number of failures:
+// the interval, doubled for each further failure up to maxPublishBackoff
+func (p *RevocationPublisher) backoff(failures int) time.Duration {
+	delay := p.interval
+	for i := 1; i < failures && delay < maxPublishBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxPublishBackoff {
+		delay = maxPublishBackoff
+	}
+	return delay
+}
+
+// deadLetter records the pending root as abandoned; it is not retried until the root
+// changes or an operator calls Retry
+func (p *RevocationPublisher) deadLetter(attempts int, err error) {
+	p.mu.Lock()
+	letter := DeadLetter{
+		Root:      p.pending,
+		Attempts:  attempts,
+		LastError: err.Error(),
+		FailedAt:  time.Now().Unix(),
+	}
+	p.deadLetters = append(p.deadLetters, letter)
+	if len(p.deadLetters) > maxDeadLetters {
+		p.deadLetters = p.deadLetters[len(p.deadLetters)-maxDeadLetters:]
+	}
+	p.mu.Unlock()
+
+	logger.Error("Giving up publishing revocation root",
+		zap.String("root", letter.Root),
+		zap.Int("attempts", attempts),
+		zap.Error(err),
+	)
+}
+
+// publish submits the pending root if it differs from the last published one
+func (p *RevocationPublisher) publish(ctx context.Context) error {
+	p.mu.Lock()
+	root := p.pending
+	unchanged := root == p.published
+	p.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	rootBytes, err := hex.DecodeString(strings.TrimPrefix(root, "0x"))
+	if err != nil || len(rootBytes) != 32 {
+		// Retrying cannot fix a malformed root, so drop it until the next change
+		logger.Error("Not publishing malformed revocation root", zap.String("root", root))
+		return nil
+	}
+
+	txID, err := p.submitter.SubmitContractCall(ctx, ContractCall{
+		ContractAddress: p.address,
+		ContractName:    p.contract,
+		FunctionName:    p.function,
+		FunctionArgs:    []string{clarityBuffer(rootBytes)},
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.published = root
+	p.mu.Unlock()
+	logger.Info("Published revocation root",
+		zap.String("root", root),
+		zap.String("txid", txID),
+	)
+	return nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/middleware"
+)
+
+// mockSubmitter is a Stacks submitter endpoint recording contract-calls
+type mockSubmitter struct {
+	mu    sync.Mutex
+	calls []ContractCall
+	// failures is the number of upcoming calls answered with 503
+	failures int
+}
+
+func (m *mockSubmitter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var call ContractCall
+	if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+	if m.failures > 0 {
+		m.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"txid": "0xabc"})
+}
+
+func (m *mockSubmitter) Calls() []ContractCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ContractCall{}, m.calls...)
+}
+
+// startTestPublisher runs a publisher against a mock submitter until the test ends
+func startTestPublisher(t *testing.T, mock *mockSubmitter, interval time.Duration, maxRetries int) *RevocationPublisher {
+	t.Helper()
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	publisher, err := NewRevocationPublisher(NewHTTPContractCallSubmitter(server.URL),
+		"ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J.revocation", "update-revocation-root", interval, maxRetries)
+	if err != nil {
+		t.Fatalf("Failed to create publisher: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go publisher.Run(ctx)
+	return publisher
+}
+
+// waitPublished waits until root is the published root
+func waitPublished(t *testing.T, p *RevocationPublisher, root string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Published() != root {
+		if time.Now().After(deadline) {
+			t.Fatalf("Root %s not published, last published %q", root, p.Published())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testRoot(b byte) string {
+	return "0x" + strings.Repeat(string("0123456789abcdef"[b%16]), 64)
+}
+
+// TestRevocationPublisherPublishesOnChange tests that a root change is submitted as a
+// contract-call with the root as a Clarity buffer, and an unchanged root is not resubmitted
+func TestRevocationPublisherPublishesOnChange(t *testing.T) {
+	mock := &mockSubmitter{}
+	p := startTestPublisher(t, mock, 20*time.Millisecond, 5)
+
+	root := testRoot(1)
+	p.Notify(root)
+	waitPublished(t, p, root)
+
+	calls := mock.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected 1 contract call, got %d", len(calls))
+	}
+	want := ContractCall{
+		ContractAddress: "ST2N04CYE3CQ1S354MZX4KHYJYD4QW25ZW37GQY7J",
+		ContractName:    "revocation",
+		FunctionName:    "update-revocation-root",
+		FunctionArgs:    []string{"0x0200000020" + strings.TrimPrefix(root, "0x")},
+	}
+	got := calls[0]
+	if got.ContractAddress != want.ContractAddress || got.ContractName != want.ContractName ||
+		got.FunctionName != want.FunctionName || len(got.FunctionArgs) != 1 || got.FunctionArgs[0] != want.FunctionArgs[0] {
+		t.Errorf("Expected call %+v, got %+v", want, got)
+	}
+
+	p.Notify(root)
+	time.Sleep(100 * time.Millisecond)
+	if n := len(mock.Calls()); n != 1 {
+		t.Errorf("Expected an unchanged root not to be resubmitted, got %d calls", n)
+	}
+}
+
+// TestRevocationPublisherDebounces tests that a burst of changes publishes only the final root
+func TestRevocationPublisherDebounces(t *testing.T) {
+	mock := &mockSubmitter{}
+	p := startTestPublisher(t, mock, 100*time.Millisecond, 5)
+
+	for i := byte(1); i <= 5; i++ {
+		p.Notify(testRoot(i))
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitPublished(t, p, testRoot(5))
+
+	calls := mock.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected the burst to produce 1 contract call, got %d", len(calls))
+	}
+	if !strings.HasSuffix(calls[0].FunctionArgs[0], strings.TrimPrefix(testRoot(5), "0x")) {
+		t.Errorf("Expected the final root to be published, got %s", calls[0].FunctionArgs[0])
+	}
+}
+
+// TestRevocationPublisherRetries tests that a failed submission is retried until it succeeds
+func TestRevocationPublisherRetries(t *testing.T) {
+	mock := &mockSubmitter{failures: 2}
+	p := startTestPublisher(t, mock, 20*time.Millisecond, 5)
+
+	root := testRoot(7)
+	p.Notify(root)
+	waitPublished(t, p, root)
+
+	if n := len(mock.Calls()); n != 3 {
+		t.Errorf("Expected 2 failed attempts and 1 success, got %d calls", n)
+	}
+}
+
+// waitDeadLetters waits until the publisher has dead-lettered n roots
+func waitDeadLetters(t *testing.T, p *RevocationPublisher, n int) []DeadLetter {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		letters := p.DeadLetters()
+		if len(letters) >= n {
+			return letters
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d dead letters, got %+v", n, letters)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRevocationPublisherDeadLetters tests that a root that keeps failing is given up
+// after the retry budget, recorded as a dead letter, and published again on Retry
+func TestRevocationPublisherDeadLetters(t *testing.T) {
+	mock := &mockSubmitter{failures: 1000}
+	p := startTestPublisher(t, mock, 10*time.Millisecond, 2)
+
+	root := testRoot(3)
+	p.Notify(root)
+	letters := waitDeadLetters(t, p, 1)
+	if letters[0].Root != root || letters[0].Attempts != 3 || !strings.Contains(letters[0].LastError, "503") {
+		t.Errorf("Expected root %s dead-lettered after 3 attempts, got %+v", root, letters[0])
+	}
+
+	// Nothing is retried once the budget is spent
+	time.Sleep(150 * time.Millisecond)
+	if n := len(mock.Calls()); n != 3 {
+		t.Errorf("Expected 3 contract calls, got %d", n)
+	}
+	if p.Published() != "" {
+		t.Errorf("Expected nothing published, got %s", p.Published())
+	}
+
+	mock.mu.Lock()
+	mock.failures = 0
+	mock.mu.Unlock()
+	if retried := p.Retry(); retried != root {
+		t.Errorf("Expected Retry to republish %s, got %s", root, retried)
+	}
+	waitPublished(t, p, root)
+	if letters := p.DeadLetters(); len(letters) != 0 {
+		t.Errorf("Expected Retry to clear the dead letters, got %+v", letters)
+	}
+}
+
+// TestRevocationPublisherBackoff tests that retry delays double up to the cap
+func TestRevocationPublisherBackoff(t *testing.T) {
+	p := &RevocationPublisher{interval: 30 * time.Second}
+	for failures, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		20: maxPublishBackoff,
+	} {
+		if got := p.backoff(failures); got != want {
+			t.Errorf("Expected backoff %v after %d failures, got %v", want, failures, got)
+		}
+	}
+}
+
+// TestPublishDeadLettersEndpoints tests the admin endpoints listing and retrying dead letters
+func TestPublishDeadLettersEndpoints(t *testing.T) {
+	api := newTestAPI(t)
+	api.config.AdminAPIKey = "test-admin-key"
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(middleware.APIKeyHeader, "test-admin-key")
+		rec := httptest.NewRecorder()
+		setupRouter(api, api.config).ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodGet, "/admin/revocation/dead-letters"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 with publishing disabled, got %d", rec.Code)
+	}
+
+	mock := &mockSubmitter{failures: 1000}
+	api.revocationPublisher = startTestPublisher(t, mock, 10*time.Millisecond, 0)
+	root := testRoot(9)
+	api.revocationPublisher.Notify(root)
+	waitDeadLetters(t, api.revocationPublisher, 1)
+
+	rec := request(http.MethodGet, "/admin/revocation/dead-letters")
+	var body struct {
+		DeadLetters []DeadLetter `json:"dead_letters"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 with JSON, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(body.DeadLetters) != 1 || body.DeadLetters[0].Root != root || body.DeadLetters[0].Attempts != 1 {
+		t.Errorf("Expected %s dead-lettered after 1 attempt, got %+v", root, body.DeadLetters)
+	}
+
+	if rec := request(http.MethodPost, "/admin/revocation/dead-letters/retry"); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 from retry, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if letters := api.revocationPublisher.DeadLetters(); len(letters) > 1 {
+		t.Errorf("Expected retry to clear the dead letters, got %+v", letters)
+	}
+}
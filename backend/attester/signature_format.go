@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// Attestation signature encodings, reported as signature_format
+const (
+	SignatureFormatRS64  = "secp256k1-rs-64"  // r || s with low-S, for Clarity secp256k1-verify
+	SignatureFormatRSV65 = "secp256k1-rsv-65" // r || s || v with recovery ID v of 0 or 1, for Ethereum
+)
+
+// SignatureComponents is an attestation signature split into hex r and s and, for
+// recoverable signatures, the recovery ID v
+type SignatureComponents struct {
+	R string `json:"r"`
+	S string `json:"s"`
+	V *byte  `json:"v,omitempty"`
+}
+
+// signatureFormat returns the encoding of signatures produced with the given hash algorithm
+func signatureFormat(algo string) string {
+	if algo == HashAlgoKeccak256 {
+		return SignatureFormatRSV65
+	}
+	return SignatureFormatRS64
+}
+
+// splitSignature splits a 64-byte r || s or 65-byte r || s || v hex signature
+func splitSignature(signature string) (*SignatureComponents, error) {
+	b, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature hex: %w", err)
+	}
+	if len(b) != 64 && len(b) != 65 {
+		return nil, fmt.Errorf("signature must be 64 or 65 bytes, got %d", len(b))
+	}
+
+	components := &SignatureComponents{
+		R: hex.EncodeToString(b[:32]),
+		S: hex.EncodeToString(b[32:64]),
+	}
+	if len(b) == 65 {
+		v := b[64]
+		components.V = &v
+	}
+	return components, nil
+}
+
+// Hex joins the components back into the signature hex they were split from
+func (c *SignatureComponents) Hex() string {
+	if c.V == nil {
+		return c.R + c.S
+	}
+	return c.R + c.S + hex.EncodeToString([]byte{*c.V})
+}
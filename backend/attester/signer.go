@@ -3,13 +3,17 @@ package main
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"os"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"golang.org/x/crypto/hkdf"
 )
 
 // Signer handles ECDSA signature generation using secp256k1
@@ -35,11 +39,51 @@ func NewSigner(privateKeyHex string, attesterID uint) (*Signer, error) {
 	}, nil
 }
 
+// NewSignerFromSeed deterministically derives a signer from a seed using HKDF-SHA256
+// INSECURE: intended only for tests that need reproducible keys and signatures.
+// Never use this for a production attester key.
+func NewSignerFromSeed(seed []byte, attesterID uint) (*Signer, error) {
+	if len(seed) == 0 {
+		return nil, fmt.Errorf("seed must not be empty")
+	}
+
+	kdf := hkdf.New(sha256.New, seed, nil, []byte("noah-attester-test-signer"))
+	keyBytes := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(kdf, keyBytes); err != nil {
+			return nil, fmt.Errorf("failed to derive key from seed: %w", err)
+		}
+		// Retry on the (negligible) chance the bytes are not a valid scalar
+		privateKey, err := crypto.ToECDSA(keyBytes)
+		if err == nil {
+			return &Signer{
+				privateKey: privateKey,
+				publicKey:  &privateKey.PublicKey,
+				attesterID: attesterID,
+			}, nil
+		}
+	}
+}
+
 // GenerateKeyPair generates a new secp256k1 key pair
 func GenerateKeyPair() (string, string, error) {
-	privateKey, err := ecdsa.GenerateKey(secp256k1.S256(), rand.Reader)
-	if err != nil {
-		return "", "", err
+	return GenerateKeyPairFrom(rand.Reader)
+}
+
+// GenerateKeyPairFrom generates a new secp256k1 key pair from the given entropy source
+// The key is read directly from entropy, so a given stream always yields the same key
+func GenerateKeyPairFrom(entropy io.Reader) (string, string, error) {
+	keyBytes := make([]byte, 32)
+	var privateKey *ecdsa.PrivateKey
+	for {
+		if _, err := io.ReadFull(entropy, keyBytes); err != nil {
+			return "", "", fmt.Errorf("failed to read entropy: %w", err)
+		}
+		// Retry on the (negligible) chance the bytes are not a valid scalar
+		var err error
+		if privateKey, err = crypto.ToECDSA(keyBytes); err == nil {
+			break
+		}
 	}
 
 	privateKeyHex := hex.EncodeToString(crypto.FromECDSA(privateKey))
@@ -78,7 +122,7 @@ func (s *Signer) SignWithSHA256(messageHash []byte) (string, error) {
 	logEntry1 := fmt.Sprintf(`{"sessionId":"debug-session","runId":"run1","hypothesisId":"A","location":"signer.go:75","message":"SignWithSHA256 entry","data":{"messageHashLen":%d,"messageHashHex":"%s"},"timestamp":%d}`+"\n", len(messageHash), hex.EncodeToString(messageHash), 0)
 	logFile.WriteString(logEntry1)
 	// #endregion agent log
-	
+
 	// Use crypto.Sign from go-ethereum (similar to Ethereum Sign function)
 	// crypto.Sign returns 65 bytes: r || s || v, but we need 64 bytes for Clarity
 	// crypto.Sign signs the hash directly (doesn't hash again)
@@ -96,19 +140,19 @@ func (s *Signer) SignWithSHA256(messageHash []byte) (string, error) {
 
 	// Extract r and s (first 64 bytes, discard recovery ID v)
 	sigBytes := signature[:64]
-	
+
 	// Extract r and s components for low-S normalization
 	rBytes := sigBytes[:32]
 	sBytes := sigBytes[32:64]
-	
+
 	// Get curve order for secp256k1
 	curve := secp256k1.S256()
 	curveOrder := curve.N
 	halfOrder := new(big.Int).Div(curveOrder, big.NewInt(2))
-	
+
 	// Parse s value
 	sValue := new(big.Int).SetBytes(sBytes)
-	
+
 	// Normalize to low-S: if s > curveOrder/2, use curveOrder - s
 	var normalizedSBytes []byte
 	if sValue.Cmp(halfOrder) > 0 {
@@ -120,10 +164,10 @@ func (s *Signer) SignWithSHA256(messageHash []byte) (string, error) {
 		// Already low-S
 		normalizedSBytes = sBytes
 	}
-	
+
 	// Reconstruct signature with normalized s
 	normalizedSig := append(rBytes, normalizedSBytes...)
-	
+
 	// #region agent log
 	logEntry3 := fmt.Sprintf(`{"sessionId":"debug-session","runId":"run1","hypothesisId":"C","location":"signer.go:95","message":"Signature normalization","data":{"originalSLen":%d,"normalizedSLen":%d,"wasHighS":%t,"sHex":"%s","normalizedSHex":"%s"},"timestamp":%d}`+"\n", len(sBytes), len(normalizedSBytes), sValue.Cmp(halfOrder) > 0, hex.EncodeToString(sBytes), hex.EncodeToString(normalizedSBytes), 0)
 	logFile.WriteString(logEntry3)
@@ -131,32 +175,63 @@ func (s *Signer) SignWithSHA256(messageHash []byte) (string, error) {
 
 	// Clarity accepts 64-byte signatures (r || s, no recovery ID) with low-S normalization
 	sigHex := hex.EncodeToString(normalizedSig)
-	
+
 	// #region agent log
 	logEntry4 := fmt.Sprintf(`{"sessionId":"debug-session","runId":"run1","hypothesisId":"D","location":"signer.go:102","message":"Final signature","data":{"sigLen":%d,"sigHex":"%s"},"timestamp":%d}`+"\n", len(normalizedSig), sigHex, 0)
 	logFile.WriteString(logEntry4)
 	logFile.Close()
 	// #endregion agent log
-	
+
 	// Return 64-byte signature (Clarity accepts this format)
 	return sigHex, nil
 }
 
+// Signing hash algorithms for attestations
+const (
+	HashAlgoSHA256    = "sha256"    // Clarity secp256k1-verify: 64-byte low-S signature over the commitment
+	HashAlgoKeccak256 = "keccak256" // Ethereum: 65-byte signature over Keccak256(commitment)
+)
+
+// ValidateHashAlgo returns an error if algo is not a supported signing hash algorithm
+func ValidateHashAlgo(algo string) error {
+	switch algo {
+	case HashAlgoSHA256, HashAlgoKeccak256:
+		return nil
+	default:
+		return fmt.Errorf("unsupported signing hash algorithm %q (expected %s or %s)", algo, HashAlgoSHA256, HashAlgoKeccak256)
+	}
+}
+
 // SignCommitment signs a commitment hash for Clarity verification
 // The commitment is already a 32-byte hash, and Clarity's secp256k1-verify expects
 // a signature over the message hash (which it hashes internally with SHA256)
 func (s *Signer) SignCommitment(commitment string) (string, error) {
-	commitmentBytes, err := hex.DecodeString(commitment)
+	return s.SignCommitmentWith(commitment, HashAlgoSHA256)
+}
+
+// SignCommitmentWith signs a commitment's 32-byte hash using the given hash algorithm
+func (s *Signer) SignCommitmentWith(commitment, algo string) (string, error) {
+	commitmentBytes, err := decodeCommitment(commitment)
 	if err != nil {
-		return "", fmt.Errorf("invalid commitment hex: %w", err)
+		return "", err
 	}
 
-	if len(commitmentBytes) != 32 {
-		return "", fmt.Errorf("commitment must be 32 bytes, got %d", len(commitmentBytes))
+	switch algo {
+	case HashAlgoSHA256:
+		// Use SHA256 to match Clarity's secp256k1-verify
+		return s.SignWithSHA256(commitmentBytes)
+	case HashAlgoKeccak256:
+		return s.Sign(commitmentBytes)
+	default:
+		return "", ValidateHashAlgo(algo)
 	}
+}
 
-	// Use SHA256 to match Clarity's secp256k1-verify
-	return s.SignWithSHA256(commitmentBytes)
+// decodeCommitment returns the 32-byte hash of a bare or versioned hex commitment
+// The signature covers the hash alone, which is what the registry contract stores
+func decodeCommitment(commitment string) ([]byte, error) {
+	_, hash, err := DecodeCommitment(commitment)
+	return hash, err
 }
 
 // GetPublicKey returns the compressed public key as hex
@@ -170,8 +245,26 @@ func (s *Signer) GetAttesterID() uint {
 	return s.attesterID
 }
 
+// ErrHighS is returned by strict verification for a signature whose s is in the upper
+// half of the curve order, the malleated twin of a valid low-S signature
+var ErrHighS = errors.New("signature is not low-S")
+
+// checkLowS returns ErrHighS if s is above half the secp256k1 curve order
+func checkLowS(s *big.Int) error {
+	halfOrder := new(big.Int).Rsh(secp256k1.S256().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		return ErrHighS
+	}
+	return nil
+}
+
 // VerifySignature verifies a signature (for testing)
 func VerifySignature(message []byte, signatureHex string, publicKeyHex string) (bool, error) {
+	return VerifySignatureWith(message, signatureHex, publicKeyHex, false)
+}
+
+// VerifySignatureWith verifies a signature, rejecting high-S signatures when requireLowS is set
+func VerifySignatureWith(message []byte, signatureHex string, publicKeyHex string, requireLowS bool) (bool, error) {
 	hash := crypto.Keccak256Hash(message)
 
 	signature, err := hex.DecodeString(signatureHex)
@@ -203,7 +296,60 @@ func VerifySignature(message []byte, signatureHex string, publicKeyHex string) (
 	// Verify signature
 	r := new(big.Int).SetBytes(sigWithoutRecovery[:32])
 	s := new(big.Int).SetBytes(sigWithoutRecovery[32:64])
+	if requireLowS {
+		if err := checkLowS(s); err != nil {
+			return false, err
+		}
+	}
 
 	return ecdsa.Verify(publicKey, hash.Bytes(), r, s), nil
 }
 
+// VerifyCommitmentSignature verifies a commitment signature produced with the given hash algorithm
+func VerifyCommitmentSignature(commitment, signatureHex, publicKeyHex, algo string) (bool, error) {
+	return VerifyCommitmentSignatureWith(commitment, signatureHex, publicKeyHex, algo, false)
+}
+
+// VerifyCommitmentSignatureWith verifies a commitment signature; with requireLowS a high-S
+// signature is rejected with ErrHighS, matching verifiers that only accept low-S
+// signatures, such as Clarity's secp256k1-verify
+func VerifyCommitmentSignatureWith(commitment, signatureHex, publicKeyHex, algo string, requireLowS bool) (bool, error) {
+	commitmentBytes, err := decodeCommitment(commitment)
+	if err != nil {
+		return false, err
+	}
+
+	if err := ValidateHashAlgo(algo); err != nil {
+		return false, err
+	}
+	if algo == HashAlgoKeccak256 {
+		return VerifySignatureWith(commitmentBytes, signatureHex, publicKeyHex, requireLowS)
+	}
+
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return false, fmt.Errorf("invalid signature hex: %w", err)
+	}
+	if len(signature) != 64 {
+		return false, fmt.Errorf("invalid signature length: expected 64, got %d", len(signature))
+	}
+
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return false, fmt.Errorf("invalid public key hex: %w", err)
+	}
+	publicKey, err := crypto.DecompressPubkey(publicKeyBytes)
+	if err != nil {
+		return false, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	// The commitment is signed directly, as Clarity's secp256k1-verify expects
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if requireLowS {
+		if err := checkLowS(s); err != nil {
+			return false, err
+		}
+	}
+	return ecdsa.Verify(publicKey, commitmentBytes, r, s), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+)
+
+// TestNewSignerFromSeedDeterministic tests that a seed always yields the same key and signatures
+func TestNewSignerFromSeedDeterministic(t *testing.T) {
+	seed := []byte("noah-test-seed")
+	commitment := "0000000000000000000000000000000000000000000000000000000000000001"
+
+	first, err := NewSignerFromSeed(seed, 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	second, err := NewSignerFromSeed(seed, 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	if first.GetPublicKey() != second.GetPublicKey() {
+		t.Errorf("Expected identical public keys, got %s and %s", first.GetPublicKey(), second.GetPublicKey())
+	}
+
+	sig1, err := first.SignCommitment(commitment)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	sig2, err := second.SignCommitment(commitment)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if sig1 != sig2 {
+		t.Errorf("Expected identical signatures, got %s and %s", sig1, sig2)
+	}
+
+	other, err := NewSignerFromSeed([]byte("another-seed"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	if other.GetPublicKey() == first.GetPublicKey() {
+		t.Error("Expected different seeds to yield different public keys")
+	}
+
+	if _, err := NewSignerFromSeed(nil, 1); err == nil {
+		t.Error("Expected error for empty seed, got nil")
+	}
+}
+
+// TestGenerateKeyPairFromEntropy tests that an injected entropy source yields
+// reproducible keys and that a file can serve as the source
+func TestGenerateKeyPairFromEntropy(t *testing.T) {
+	entropy := func(b byte) []byte {
+		sum := sha256.Sum256([]byte{b})
+		return sum[:]
+	}
+
+	priv1, pub1, err := GenerateKeyPairFrom(bytes.NewReader(entropy(1)))
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	priv2, pub2, err := GenerateKeyPairFrom(bytes.NewReader(entropy(1)))
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	if priv1 != priv2 || pub1 != pub2 {
+		t.Errorf("Expected identical key pairs for the same entropy, got %s and %s", pub1, pub2)
+	}
+	if _, err := NewSigner(priv1, 1); err != nil {
+		t.Errorf("Expected a usable private key, got %v", err)
+	}
+
+	_, other, err := GenerateKeyPairFrom(bytes.NewReader(entropy(2)))
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	if other == pub1 {
+		t.Error("Expected different entropy to yield a different key pair")
+	}
+
+	if _, _, err := GenerateKeyPairFrom(bytes.NewReader(make([]byte, 16))); err == nil {
+		t.Error("Expected an error when entropy runs out")
+	}
+
+	path := filepath.Join(t.TempDir(), "rng")
+	if err := os.WriteFile(path, entropy(1), 0600); err != nil {
+		t.Fatalf("Failed to write entropy file: %v", err)
+	}
+	source, err := OpenEntropySource(path)
+	if err != nil {
+		t.Fatalf("Failed to open entropy source: %v", err)
+	}
+	if _, fromFile, err := GenerateKeyPairFrom(source); err != nil || fromFile != pub1 {
+		t.Errorf("Expected the file source to yield %s, got %s, %v", pub1, fromFile, err)
+	}
+	if _, err := OpenEntropySource(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing entropy source")
+	}
+}
+
+// TestSignCommitmentHashAlgos tests that each algorithm verifies only under its own verifier
+func TestSignCommitmentHashAlgos(t *testing.T) {
+	signer, err := NewSignerFromSeed([]byte("noah-hash-algo-seed"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	commitment := "00000000000000000000000000000000000000000000000000000000000000ff"
+	publicKey := signer.GetPublicKey()
+
+	algos := []string{HashAlgoSHA256, HashAlgoKeccak256}
+	for _, signAlgo := range algos {
+		signature, err := signer.SignCommitmentWith(commitment, signAlgo)
+		if err != nil {
+			t.Fatalf("Failed to sign with %s: %v", signAlgo, err)
+		}
+
+		for _, verifyAlgo := range algos {
+			valid, _ := VerifyCommitmentSignature(commitment, signature, publicKey, verifyAlgo)
+			if want := signAlgo == verifyAlgo; valid != want {
+				t.Errorf("Signed with %s, verified with %s: expected %v, got %v", signAlgo, verifyAlgo, want, valid)
+			}
+		}
+	}
+
+	if _, err := signer.SignCommitmentWith(commitment, "md5"); err == nil {
+		t.Error("Expected error for unsupported hash algorithm, got nil")
+	}
+}
+
+// TestVerifyRejectsHighS tests that a malleated high-S signature verifies only in lenient mode
+func TestVerifyRejectsHighS(t *testing.T) {
+	signer, err := NewSignerFromSeed([]byte("noah-low-s-seed"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	commitment := "00000000000000000000000000000000000000000000000000000000000000ff"
+	publicKey := signer.GetPublicKey()
+
+	for _, algo := range []string{HashAlgoSHA256, HashAlgoKeccak256} {
+		t.Run(algo, func(t *testing.T) {
+			signature, err := signer.SignCommitmentWith(commitment, algo)
+			if err != nil {
+				t.Fatalf("Failed to sign: %v", err)
+			}
+			if valid, err := VerifyCommitmentSignatureWith(commitment, signature, publicKey, algo, true); !valid || err != nil {
+				t.Fatalf("Expected the low-S signature to pass strict verification, got %v, %v", valid, err)
+			}
+
+			// Replace s with N - s: the same signature, malleated to high-S
+			sig, _ := hex.DecodeString(signature)
+			s := new(big.Int).SetBytes(sig[32:64])
+			new(big.Int).Sub(secp256k1.S256().N, s).FillBytes(sig[32:64])
+			malleated := hex.EncodeToString(sig)
+
+			if valid, err := VerifyCommitmentSignatureWith(commitment, malleated, publicKey, algo, true); valid || !errors.Is(err, ErrHighS) {
+				t.Errorf("Expected strict verification to reject high-S, got %v, %v", valid, err)
+			}
+			if valid, err := VerifyCommitmentSignature(commitment, malleated, publicKey, algo); !valid || err != nil {
+				t.Errorf("Expected lenient verification to accept high-S, got %v, %v", valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"noah-v2/backend/pkg/tracing"
+)
+
+// defaultBlocksPerDay estimates the burn (Bitcoin) blocks mined per day, one every ~600s
+const defaultBlocksPerDay = 144
+
+// secondsPerDay converts Unix timestamps to days since the epoch
+const secondsPerDay = 24 * 60 * 60
+
+// blockHeightCacheTTL is how long a fetched block height is reused; burn blocks are
+// minutes apart, so a short cache spares Hiro a request per attestation
+const blockHeightCacheTTL = 30 * time.Second
+
+// maxAttesterIDAttempts is the default bound on the registry lookups made when
+// discovering a free attester ID
+const maxAttesterIDAttempts = 100
+
+// stacksAPIURL returns the Hiro API base URL for the given Stacks network
+func stacksAPIURL(network string) string {
+	if network == "mainnet" {
+		return "https://api.hiro.so/v2"
+	}
+	return "https://api.testnet.hiro.so/v2"
+}
+
+// findAvailableAttesterID returns the first ID from startID up whose public key is not
+// registered in registry ("ADDRESS.contract-name"), read with the given function and
+// checking at most maxAttempts IDs
+func findAvailableAttesterID(ctx context.Context, apiURL, registry, function string, startID, maxAttempts uint) (uint, error) {
+	contractAddress, contractName, err := splitContractID(registry)
+	if err != nil {
+		return 0, err
+	}
+	url := fmt.Sprintf("%s/contracts/call-read/%s/%s/%s", apiURL, contractAddress, contractName, function)
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
+
+	for i := uint(0); i < maxAttempts; i++ {
+		testID := startID + i
+
+		// Encode ID as Clarity uint (little-endian, 8 bytes)
+		idBytes := make([]byte, 8)
+		binary.LittleEndian.PutUint64(idBytes, uint64(testID))
+		idHex := "0x01000000000000000000000000000000" + hex.EncodeToString(idBytes)
+
+		// Call contract read-only function
+		payload := fmt.Sprintf(`{"sender": "%s", "arguments": ["%s"]}`, contractAddress, idHex)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(payload))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("failed to query contract: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		// If response contains error (attester not found), this ID is available
+		bodyStr := string(body)
+		if strings.Contains(bodyStr, "ERR_ATTESTER_NOT_FOUND") ||
+			strings.Contains(bodyStr, "u1003") ||
+			!strings.Contains(bodyStr, `"okay":true`) {
+			return testID, nil
+		}
+	}
+
+	// If we've tried many IDs and all are taken, return an error
+	return 0, fmt.Errorf("could not find available ID after %d attempts", maxAttempts)
+}
+
+// fetchBurnBlockHeight returns the current burn block height reported by the Hiro node info endpoint
+// The request is made under ctx, so its span joins the caller's trace
+func fetchBurnBlockHeight(ctx context.Context, apiURL string) (uint64, error) {
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"/info", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query node info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to query node info: unexpected status %d", resp.StatusCode)
+	}
+
+	var info struct {
+		BurnBlockHeight uint64 `json:"burn_block_height"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return 0, fmt.Errorf("failed to parse node info: %w", err)
+	}
+	if info.BurnBlockHeight == 0 {
+		return 0, fmt.Errorf("node info has no burn_block_height")
+	}
+	return info.BurnBlockHeight, nil
+}
+
+// blockHeightFailureTTL is how long a failed fetch is remembered, so while Hiro is down
+// callers fall back to wall-clock expiry at once instead of each waiting on a request
+const blockHeightFailureTTL = 5 * time.Second
+
+// BlockHeightCache serves the current burn block height from the Hiro API, fetching it
+// at most once per ttl; concurrent callers share one fetch, and a failed fetch is
+// returned to callers for blockHeightFailureTTL
+type BlockHeightCache struct {
+	fetch func(ctx context.Context) (uint64, error)
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	height   uint64
+	fetched  time.Time     // Zero until a fetch succeeds
+	err      error         // Error of the last fetch, if it failed
+	failed   time.Time     // When the last fetch failed
+	inflight chan struct{} // Closed when the running fetch finishes
+}
+
+// NewBlockHeightCache creates a cache reading the node info of the Hiro API at apiURL
+func NewBlockHeightCache(apiURL string, ttl time.Duration) *BlockHeightCache {
+	return &BlockHeightCache{
+		fetch: func(ctx context.Context) (uint64, error) { return fetchBurnBlockHeight(ctx, apiURL) },
+		ttl:   ttl,
+	}
+}
+
+// CurrentBlockHeight returns the cached height while it is within the ttl, and fetches
+// it from Hiro otherwise; the lock is not held during the fetch
+// The fetch runs under the first caller's ctx without its cancellation, so it is traced
+// with that request and a disconnecting client does not fail it for the others
+func (c *BlockHeightCache) CurrentBlockHeight(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	switch {
+	case !c.fetched.IsZero() && time.Since(c.fetched) < c.ttl:
+		height := c.height
+		c.mu.Unlock()
+		return height, nil
+	case c.err != nil && time.Since(c.failed) < blockHeightFailureTTL:
+		err := c.err
+		c.mu.Unlock()
+		return 0, err
+	}
+	done := c.inflight
+	if done == nil {
+		done = make(chan struct{})
+		c.inflight = done
+		c.mu.Unlock()
+		c.refresh(context.WithoutCancel(ctx), done)
+	} else {
+		c.mu.Unlock()
+		<-done
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.height, nil
+}
+
+// refresh runs one fetch, records its result and closes done
+func (c *BlockHeightCache) refresh(ctx context.Context, done chan struct{}) {
+	height, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.err, c.failed = err, time.Now()
+	} else {
+		c.height, c.fetched, c.err = height, time.Now(), nil
+	}
+	c.inflight = nil
+	close(done)
+}
+
+// ContractCall is a Stacks contract-call with Clarity arguments serialized as 0x-prefixed hex
+type ContractCall struct {
+	ContractAddress string   `json:"contract_address"`
+	ContractName    string   `json:"contract_name"`
+	FunctionName    string   `json:"function_name"`
+	FunctionArgs    []string `json:"function_args"`
+}
+
+// ContractCallSubmitter signs and broadcasts contract-calls, returning the transaction ID
+type ContractCallSubmitter interface {
+	SubmitContractCall(ctx context.Context, call ContractCall) (string, error)
+}
+
+// HTTPContractCallSubmitter posts contract-calls to a submitter service that holds the
+// sending key (for update-revocation-root, the contract owner) and broadcasts the transaction
+type HTTPContractCallSubmitter struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPContractCallSubmitter creates a submitter for the given endpoint
+func NewHTTPContractCallSubmitter(url string) *HTTPContractCallSubmitter {
+	return &HTTPContractCallSubmitter{URL: url, Client: &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(nil)}}
+}
+
+// SubmitContractCall posts the call as JSON and expects {"txid": "..."} back
+func (s *HTTPContractCallSubmitter) SubmitContractCall(ctx context.Context, call ContractCall) (string, error) {
+	body, err := json.Marshal(call)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to submit contract call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to submit contract call: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		TxID string `json:"txid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse submitter response: %w", err)
+	}
+	if result.TxID == "" {
+		return "", fmt.Errorf("submitter response has no txid")
+	}
+	return result.TxID, nil
+}
+
+// splitContractID splits "ADDRESS.contract-name" into its parts
+func splitContractID(contract string) (string, string, error) {
+	parts := strings.Split(contract, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid contract address format: %s", contract)
+	}
+	return parts[0], parts[1], nil
+}
+
+// clarityBuffer serializes bytes as a Clarity (buff N) value
+func clarityBuffer(b []byte) string {
+	encoded := make([]byte, 5, 5+len(b))
+	encoded[0] = 0x02 // Clarity buffer type ID
+	binary.BigEndian.PutUint32(encoded[1:], uint32(len(b)))
+	return "0x" + hex.EncodeToString(append(encoded, b...))
+}
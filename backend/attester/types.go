@@ -1,38 +1,97 @@
 package main
 
+import (
+	"noah-v2/backend/pkg/proofbundle"
+)
+
 // CredentialRequest represents a request to issue a credential
 type CredentialRequest struct {
-	UserID      string                 `json:"user_id"`
-	Attributes  map[string]interface{} `json:"attributes"`
-	Documents   []string                `json:"documents"` // Document hashes or IDs
+	UserID     string                 `json:"user_id"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Documents  []string               `json:"documents"` // Document hashes or IDs
 }
 
 // Credential represents an issued credential
 type Credential struct {
-	UserID        string                 `json:"user_id"`
-	Attributes    map[string]interface{} `json:"attributes"`
-	Commitment    string                 `json:"commitment"`
-	IssuedAt      int64                  `json:"issued_at"`
-	ExpiresAt     int64                  `json:"expires_at"`
-	AttesterID    uint                   `json:"attester_id"`
+	UserID      string                 `json:"user_id"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Commitment  string                 `json:"commitment"`
+	Nonce       string                 `json:"nonce"`                  // Random per-issuance value mixed into the commitment
+	ProofInputs *ProofInputs           `json:"proof_inputs,omitempty"` // Private circuit inputs, mimc scheme only
+	IssuedAt    int64                  `json:"issued_at"`
+	ExpiresAt   int64                  `json:"expires_at"`
+	AttesterID  uint                   `json:"attester_id"`
+}
+
+// ProofInputs are the private inputs that reproduce a mimc commitment in the circuit,
+// as decimal strings ready for the prover's identity_data, nonce, age and jurisdiction
+type ProofInputs struct {
+	IdentityData string `json:"identity_data"`
+	Nonce        string `json:"nonce"`
+	// CredentialData and ExpiryDays are the values hashed into IdentityData, for the
+	// prover's expiry.credential_data and expiry.expiry_days
+	CredentialData string `json:"credential_data"`
+	ExpiryDays     string `json:"expiry_days"`
+	// Age and Jurisdiction are derived from the attributes mapped by ATTRIBUTE_FIELD_MAP
+	Age          string `json:"age,omitempty"`
+	Jurisdiction string `json:"jurisdiction,omitempty"`
 }
 
 // AttestationRequest represents a request to sign a commitment
 type AttestationRequest struct {
-	Commitment    string   `json:"commitment"`
-	PublicInputs  []string `json:"public_inputs"`
-	Proof         string   `json:"proof"` // Serialized proof
-	UserID        string   `json:"user_id"`
+	Commitment   string   `json:"commitment"`
+	PublicInputs []string `json:"public_inputs"`
+	Proof        string   `json:"proof"`            // Serialized proof
+	Format       string   `json:"format,omitempty"` // Proof encoding: "base64" (default) or "hex"
+	// CircuitVersion selects the verifying key by circuit hash; empty uses the default key
+	CircuitVersion string `json:"circuit_version,omitempty"`
+	// CircuitType selects the circuit and its verifying key: "kyc" (default), "jurisdiction" or "age"
+	CircuitType string `json:"circuit_type,omitempty"`
+	// ProofSystem declares the proving system; empty means groth16
+	ProofSystem string `json:"proof_system,omitempty"`
+	// ValiditySeconds optionally shortens the attestation lifetime (bounded by ATTESTATION_VALIDITY_SECONDS)
+	ValiditySeconds int64 `json:"validity_seconds,omitempty"`
+	// RelyingPartyID is the decimal ID of the requesting party, which must match the one the
+	// proof is bound to; omitted, only proofs bound to no party (ID 0) are accepted
+	RelyingPartyID string `json:"relying_party_id,omitempty"`
+	// Bundle carries proof, public inputs, circuit version and proof system in one
+	// document, in place of those fields; see applyBundle
+	Bundle *proofbundle.Bundle `json:"bundle,omitempty"`
+	UserID string              `json:"user_id"`
 }
 
 // AttestationResponse contains the signed attestation
 type AttestationResponse struct {
-	Commitment    string `json:"commitment"`
-	Signature     string `json:"signature"` // 64-byte signature (r || s) for Clarity compatibility
-	AttesterID    uint   `json:"attester_id"`
-	Expiry        uint64 `json:"expiry"`
-	Success       bool   `json:"success"`
-	Error         string `json:"error,omitempty"`
+	Commitment string `json:"commitment"`
+	Signature  string `json:"signature"` // Hex signature, encoded as given by SignatureFormat
+	HashAlgo   string `json:"hash_algo"` // Signing hash algorithm: "sha256" or "keccak256"
+	// SignatureFormat is "secp256k1-rs-64" (r || s, sha256) or "secp256k1-rsv-65" (r || s || v, keccak256)
+	SignatureFormat string `json:"signature_format"`
+	// SignedMessage is what Signature covers: "commitment" or "attestation" (see AttestationMessage)
+	SignedMessage string `json:"signed_message"`
+	// SignatureComponents splits Signature into r, s and v when ATTESTATION_SIGNATURE_COMPONENTS is set
+	SignatureComponents *SignatureComponents `json:"signature_components,omitempty"`
+	AttesterID          uint                 `json:"attester_id"`
+	IssuerName          string               `json:"issuer_name,omitempty"`
+	IssuerURL           string               `json:"issuer_url,omitempty"`
+	Expiry              uint64               `json:"expiry"`
+	ExpiryType          string               `json:"expiry_type"` // "timestamp" (Unix seconds) or "block_height" (burn block)
+	// ReceiptSignature signs the response's attested fields with the attester key, so the
+	// response can be archived as a tamper-evident receipt; see VerifyReceipt
+	ReceiptSignature string `json:"receipt_signature,omitempty"`
+	Success          bool   `json:"success"`
+	Error            string `json:"error,omitempty"`
+}
+
+// RevocationExport is one page of the revoked commitments served by /revocation/export
+type RevocationExport struct {
+	Commitments []string `json:"commitments"`
+	Root        string   `json:"root"`  // Root over every revoked commitment, not just this page
+	Total       int      `json:"total"` // Number of revoked commitments
+	Offset      int      `json:"offset"`
+	// NextOffset is the offset of the following page, omitted on the last one
+	NextOffset int   `json:"next_offset,omitempty"`
+	ExportedAt int64 `json:"exported_at"` // Unix seconds
 }
 
 // RevocationRequest represents a request to revoke a credential
@@ -41,3 +100,64 @@ type RevocationRequest struct {
 	Reason     string `json:"reason,omitempty"`
 }
 
+// RevocationRebuildRequest is the complete revoked set to replace a revocation tree with
+type RevocationRebuildRequest struct {
+	Commitments []string `json:"commitments" binding:"required"`
+}
+
+// RevocationProofRequest is a revocation tree Merkle proof to check, as returned by
+// GenerateNonRevocationProof: sibling hashes and whether each sibling is on the right
+type RevocationProofRequest struct {
+	Commitment string   `json:"commitment" binding:"required"`
+	Proof      []string `json:"proof"`
+	Indices    []bool   `json:"indices"`
+	Root       string   `json:"root" binding:"required"`
+}
+
+// ReverifyRequest re-verifies proofs against one verifying key, to decide whether they
+// still hold under an upgraded circuit
+type ReverifyRequest struct {
+	CircuitVersion string                `json:"circuit_version" binding:"required"` // Target key's circuit hash
+	Bundles        []*proofbundle.Bundle `json:"bundles" binding:"required"`         // Each bundle's own circuit_hash is ignored
+}
+
+// ReverifyResult is the outcome for the bundle at Index
+type ReverifyResult struct {
+	Index int    `json:"index"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// ReverifyResponse reports per-bundle results against the target key
+type ReverifyResponse struct {
+	Success        bool             `json:"success"`
+	CircuitVersion string           `json:"circuit_version"`
+	Passed         int              `json:"passed"`
+	Failed         int              `json:"failed"`
+	Results        []ReverifyResult `json:"results"`
+}
+
+// ProofVerificationRequest asks the attester to verify a proof without signing anything
+type ProofVerificationRequest struct {
+	Proof          string   `json:"proof" binding:"required"`         // Serialized proof
+	PublicInputs   []string `json:"public_inputs" binding:"required"` // Hex public inputs in circuit order
+	Format         string   `json:"format,omitempty"`                 // Proof encoding: "base64" (default) or "hex"
+	CircuitVersion string   `json:"circuit_version,omitempty"`        // Verifying key by circuit hash; empty uses the default key
+	CircuitType    string   `json:"circuit_type,omitempty"`           // "kyc" (default), "jurisdiction" or "age"
+	// Commitment, when given, must equal the proof's Commitment public input
+	Commitment string `json:"commitment,omitempty"`
+}
+
+// ProofVerificationResponse reports whether the proof verified
+type ProofVerificationResponse struct {
+	Success bool   `json:"success"`
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RevocationProofResponse reports whether the proof leads to the given root, and
+// whether that root is the tree's current one
+type RevocationProofResponse struct {
+	Valid       bool `json:"valid"`
+	RootCurrent bool `json:"root_current"`
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// proofFile is the document read by --verify-file, the same fields as an attestation request
+type proofFile struct {
+	Proof          string   `json:"proof"`
+	Format         string   `json:"format,omitempty"`
+	CircuitVersion string   `json:"circuit_version,omitempty"`
+	PublicInputs   []string `json:"public_inputs"`
+}
+
+// verifyProofFile verifies the proof stored at path and writes the verdict to out
+// A rejected proof is reported as (false, nil); errors are reserved for an unreadable
+// file or an unavailable verifier, so callers can tell the two apart
+func verifyProofFile(path string, verifier *ProofVerifier, out io.Writer) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read proof file: %w", err)
+	}
+	var pf proofFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return false, fmt.Errorf("failed to parse proof file: %w", err)
+	}
+	if pf.Proof == "" || len(pf.PublicInputs) == 0 {
+		return false, fmt.Errorf("proof file must contain proof and public_inputs")
+	}
+
+	verified, err := verifier.VerifyProofWithVersion(pf.Proof, pf.Format, pf.CircuitVersion, pf.PublicInputs)
+	if errors.Is(err, ErrVerifierUnavailable) {
+		return false, err
+	}
+	if err != nil || !verified {
+		reason := "verification failed"
+		if err != nil {
+			reason = err.Error()
+		}
+		fmt.Fprintf(out, "%s: proof is INVALID: %s\n", path, reason)
+		return false, nil
+	}
+	fmt.Fprintf(out, "%s: proof is valid\n", path)
+	return true, nil
+}
+
+// runVerifyFile verifies a proof file with the configured verifying keys and exits:
+// 0 for a valid proof, 1 for an invalid one and 2 when verification could not run
+func runVerifyFile(path string, config *Config) {
+	verified, err := verifyProofFile(path, newConfiguredVerifier(config), os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		os.Exit(2)
+	}
+	if !verified {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestVerifyProofFile tests offline verification of a generated proof file
+func TestVerifyProofFile(t *testing.T) {
+	f := newProofFixture(t)
+	dir := t.TempDir()
+	writeProofFile := func(name string, pf proofFile) string {
+		data, err := json.Marshal(pf)
+		if err != nil {
+			t.Fatalf("Failed to encode proof file: %v", err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to write proof file: %v", err)
+		}
+		return path
+	}
+
+	tampered := append([]string(nil), f.publicInputs...)
+	tampered[0] = hexInput(big.NewInt(21)) // the proof was made for MinAge 18
+
+	tests := []struct {
+		name      string
+		path      string
+		wantValid bool
+		wantErr   bool
+		wantOut   string
+	}{
+		{"valid proof", writeProofFile("valid.json", proofFile{Proof: f.proof, PublicInputs: f.publicInputs}), true, false, "proof is valid"},
+		{"tampered public input", writeProofFile("tampered.json", proofFile{Proof: f.proof, PublicInputs: tampered}), false, false, "proof is INVALID"},
+		{"missing public inputs", writeProofFile("empty.json", proofFile{Proof: f.proof}), false, true, ""},
+		{"missing file", filepath.Join(dir, "missing.json"), false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			valid, err := verifyProofFile(tt.path, NewProofVerifier(f.vkPath), &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if valid != tt.wantValid {
+				t.Errorf("Expected valid=%v, got %v", tt.wantValid, valid)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("Expected output containing %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+}
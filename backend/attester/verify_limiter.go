@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"noah-v2/backend/pkg/metrics"
+)
+
+// verifyLimiter bounds the number of concurrent proof verifications, which are CPU-bound;
+// callers over the limit wait in line until a slot frees or their context ends
+type verifyLimiter struct {
+	slots chan struct{}
+}
+
+// newVerifyLimiter returns a limiter allowing n concurrent verifications, or nil, which
+// does not limit, for n <= 0
+func newVerifyLimiter(n int) *verifyLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return &verifyLimiter{slots: make(chan struct{}, n)}
+}
+
+// do runs fn once a verification slot is free; if ctx ends while waiting, fn is not run
+// and an ErrVerifierUnavailable error is returned
+func (l *verifyLimiter) do(ctx context.Context, fn func() error) error {
+	if l == nil {
+		return fn()
+	}
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		if err := l.wait(ctx); err != nil {
+			return err
+		}
+	}
+	defer func() { <-l.slots }()
+	return fn()
+}
+
+// wait queues for a slot, counting the caller in the queue depth metric meanwhile
+func (l *verifyLimiter) wait(ctx context.Context) error {
+	metrics.AddProofVerificationQueueDepth(1)
+	defer metrics.AddProofVerificationQueueDepth(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: gave up waiting for a verification slot: %w", ErrVerifierUnavailable, ctx.Err())
+	}
+}
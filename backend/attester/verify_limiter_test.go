@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/metrics"
+)
+
+// TestVerifyLimiterSerializes tests that verifications beyond the limit wait for a free slot
+func TestVerifyLimiterSerializes(t *testing.T) {
+	const limit = 2
+	limiter := newVerifyLimiter(limit)
+
+	var inFlight, maxInFlight atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := limiter.do(context.Background(), func() error {
+				n := inFlight.Add(1)
+				for {
+					seen := maxInFlight.Load()
+					if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				inFlight.Add(-1)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got != limit {
+		t.Errorf("Expected at most %d verifications in flight, observed %d", limit, got)
+	}
+
+	// No limit runs everything at once
+	if err := newVerifyLimiter(0).do(context.Background(), func() error { return nil }); err != nil {
+		t.Errorf("Expected an unlimited limiter to run fn, got %v", err)
+	}
+}
+
+// TestVerifyLimiterCancelledWhileQueued tests that a queued caller gives up when its context ends
+func TestVerifyLimiterCancelledWhileQueued(t *testing.T) {
+	limiter := newVerifyLimiter(1)
+	release := make(chan struct{})
+	holding := make(chan struct{})
+	go limiter.do(context.Background(), func() error {
+		close(holding)
+		<-release
+		return nil
+	})
+	<-holding
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	ran := false
+	go func() {
+		done <- limiter.do(ctx, func() error { ran = true; return nil })
+	}()
+
+	// The waiting caller is counted in the queue depth
+	deadline := time.Now().Add(5 * time.Second)
+	for !queueDepthIs(t, 1) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the queue depth metric to reach 1")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	err := <-done
+	if !errors.Is(err, ErrVerifierUnavailable) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled verifier-unavailable error, got %v", err)
+	}
+	if ran {
+		t.Error("Expected fn not to run after the context ended")
+	}
+	if !queueDepthIs(t, 0) {
+		t.Error("Expected the queue depth to return to 0")
+	}
+}
+
+// TestVerifyProofConcurrencyLimit tests that proofs still verify through a limit of one
+func TestVerifyProofConcurrencyLimit(t *testing.T) {
+	f := newProofFixture(t)
+	t.Setenv("VERIFY_CONCURRENCY", "1")
+	api := newTestAPI(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			valid, err := api.issuerService.VerifyProof(context.Background(), f.proof, "", "", "", f.publicInputs)
+			if err != nil || !valid {
+				t.Errorf("Expected the proof to verify, got %v, %v", valid, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// scrapeMetrics returns the metrics endpoint body
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+// queueDepthIs reports whether the verification queue depth gauge reads depth
+func queueDepthIs(t *testing.T, depth int) bool {
+	t.Helper()
+	for _, line := range strings.Split(scrapeMetrics(t), "\n") {
+		if strings.HasPrefix(line, "proof_verification_queue_depth{") {
+			return strings.HasSuffix(line, " "+strconv.Itoa(depth))
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes shared by the services
+const (
+	CodeNotFound             = "NOT_FOUND"
+	CodeMethodNotAllowed     = "METHOD_NOT_ALLOWED"
+	CodeUnauthorized         = "UNAUTHORIZED"
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	CodeLowEntropyInput      = "ERR_LOW_ENTROPY_INPUT"
+	CodeInternal             = "INTERNAL_ERROR"
+
+	CodeProofSystemNotAccepted    = "ERR_PROOF_SYSTEM_NOT_ACCEPTED"
+	CodeQueueFull                 = "ERR_QUEUE_FULL"
+	CodeMerkleDepthMismatch       = "ERR_MERKLE_DEPTH_MISMATCH"
+	CodeCheckFailed               = "ERR_CHECK_FAILED"
+	CodeUntrustedJurisdictionRoot = "ERR_UNTRUSTED_JURISDICTION_ROOT"
+	CodeMalformedInput            = "ERR_MALFORMED_INPUT"
+	CodeInputOutOfField           = "ERR_INPUT_OUT_OF_FIELD"
+	CodeInputTooLarge             = "ERR_INPUT_TOO_LARGE"
+	CodeCommitmentMismatch        = "ERR_COMMITMENT_MISMATCH"
+	CodeProofReplayed             = "ERR_PROOF_REPLAYED"
+	CodeReplayCacheFull           = "ERR_REPLAY_CACHE_FULL"
+)
+
+// APIError is the JSON error body returned by both services
+type APIError struct {
+	Success bool   `json:"success"`
+	Code    string `json:"code"`
+	Error   string `json:"error"`
+	// RequestID identifies the request in the service logs, for support; set on internal errors
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Abort writes an APIError with the given status and stops the handler chain
+func Abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, APIError{
+		Success: false,
+		Code:    code,
+		Error:   message,
+	})
+}
+
+// NotFoundHandler returns a handler for unknown routes (use with router.NoRoute)
+func NotFoundHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Abort(c, http.StatusNotFound, CodeNotFound, "Route "+c.Request.URL.Path+" not found")
+	}
+}
+
+// MethodNotAllowedHandler returns a handler for known routes called with the wrong method
+// (use with router.NoMethod; requires router.HandleMethodNotAllowed = true)
+func MethodNotAllowedHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Abort(c, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method "+c.Request.Method+" not allowed on "+c.Request.URL.Path)
+	}
+}
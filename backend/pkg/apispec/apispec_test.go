@@ -0,0 +1,78 @@
+package apispec
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testAmount struct{ *big.Int }
+
+func (a testAmount) MarshalJSON() ([]byte, error) { return []byte(`"0"`), nil }
+
+type testBase struct {
+	ID uint `json:"id"`
+}
+
+type testRequest struct {
+	testBase
+	Name     string                 `json:"name"`
+	Amount   testAmount             `json:"amount"`
+	Tags     []string               `json:"tags,omitempty"`
+	Extra    map[string]interface{} `json:"extra"`
+	Nested   *struct{ OK bool }     `json:"nested"`
+	Internal string                 `json:"-"`
+	hidden   string
+}
+
+// TestSchemaOf tests that schemas follow json tags and field types
+func TestSchemaOf(t *testing.T) {
+	s := SchemaOf(testRequest{})
+	if s.Type != "object" {
+		t.Fatalf("Expected object schema, got %q", s.Type)
+	}
+
+	want := map[string]string{
+		"id":     "integer",
+		"name":   "string",
+		"amount": "string",
+		"tags":   "array",
+		"extra":  "object",
+		"nested": "object",
+	}
+	got := make(map[string]string)
+	for name, prop := range s.Properties {
+		got[name] = prop.Type
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected properties %v, got %v", want, got)
+	}
+	if s.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Expected string items, got %+v", s.Properties["tags"].Items)
+	}
+	if s.Properties["nested"].Properties["OK"].Type != "boolean" {
+		t.Errorf("Expected untagged field to use its Go name, got %+v", s.Properties["nested"])
+	}
+}
+
+// TestMissing tests that undocumented routes are reported, with gin parameters mapped to templates
+func TestMissing(t *testing.T) {
+	doc := New("test", "1.0.0", "")
+	doc.Add("GET", "/items/:id", &Operation{Summary: "Get item"})
+
+	routes := gin.RoutesInfo{
+		{Method: "GET", Path: "/items/:id"},
+		{Method: "DELETE", Path: "/items/:id"},
+		{Method: "POST", Path: "/items"},
+	}
+	missing := doc.Missing(routes)
+	want := []string{"DELETE /items/:id", "POST /items"}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("Expected missing %v, got %v", want, missing)
+	}
+	if _, ok := doc.Paths["/items/{id}"]["get"]; !ok {
+		t.Errorf("Expected /items/{id} path, got %v", doc.Paths)
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"noah-v2/backend/pkg/proofbundle"
+)
+
+// AttestationRequest is the body of POST /credential/attest
+type AttestationRequest struct {
+	Commitment      string   `json:"commitment"`
+	PublicInputs    []string `json:"public_inputs"`
+	Proof           string   `json:"proof"`
+	Format          string   `json:"format,omitempty"`
+	CircuitVersion  string   `json:"circuit_version,omitempty"`
+	CircuitType     string   `json:"circuit_type,omitempty"`
+	ProofSystem     string   `json:"proof_system,omitempty"`
+	ValiditySeconds int64    `json:"validity_seconds,omitempty"`
+	RelyingPartyID  string   `json:"relying_party_id,omitempty"`
+	UserID          string   `json:"user_id"`
+	// Bundle replaces Proof, PublicInputs, CircuitVersion and ProofSystem
+	Bundle *proofbundle.Bundle `json:"bundle,omitempty"`
+}
+
+// AttestationResponse is the signed attestation
+type AttestationResponse struct {
+	Commitment          string               `json:"commitment"`
+	Signature           string               `json:"signature"`
+	HashAlgo            string               `json:"hash_algo"`
+	SignatureFormat     string               `json:"signature_format"`
+	SignedMessage       string               `json:"signed_message"`
+	SignatureComponents *SignatureComponents `json:"signature_components,omitempty"`
+	AttesterID          uint                 `json:"attester_id"`
+	IssuerName          string               `json:"issuer_name,omitempty"`
+	IssuerURL           string               `json:"issuer_url,omitempty"`
+	Expiry              uint64               `json:"expiry"`
+	ExpiryType          string               `json:"expiry_type"`
+	ReceiptSignature    string               `json:"receipt_signature,omitempty"`
+	Success             bool                 `json:"success"`
+	Error               string               `json:"error,omitempty"`
+}
+
+// SignatureComponents is the attestation signature split into r, s and, when recoverable, v
+type SignatureComponents struct {
+	R string `json:"r"`
+	S string `json:"s"`
+	V *byte  `json:"v,omitempty"`
+}
+
+// RevocationRequest is the body of POST /credential/revoke
+type RevocationRequest struct {
+	Commitment string `json:"commitment"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// RevocationResponse carries the revocation root after the revocation
+type RevocationResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Root    string `json:"root,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AttesterClient calls the attester service
+type AttesterClient struct {
+	base
+}
+
+// NewAttesterClient creates a client for the attester at baseURL; a nil httpClient uses
+// a default client that propagates trace context
+func NewAttesterClient(baseURL string, httpClient *http.Client) *AttesterClient {
+	return &AttesterClient{base: newBase(baseURL, httpClient)}
+}
+
+// CreateAttestation verifies a proof and returns the signed commitment
+func (c *AttesterClient) CreateAttestation(ctx context.Context, req *AttestationRequest) (*AttestationResponse, error) {
+	var resp AttestationResponse
+	err := c.do(ctx, http.MethodPost, "/credential/attest", req, &resp)
+	return &resp, err
+}
+
+// Revoke revokes a credential by commitment
+func (c *AttesterClient) Revoke(ctx context.Context, req *RevocationRequest) (*RevocationResponse, error) {
+	var resp RevocationResponse
+	err := c.do(ctx, http.MethodPost, "/credential/revoke", req, &resp)
+	return &resp, err
+}
@@ -0,0 +1,100 @@
+// Package client provides typed Go clients for the prover and attester HTTP APIs
+// The services are main packages, so the request and response types here mirror
+// their JSON bodies field for field
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"noah-v2/backend/pkg/tracing"
+)
+
+// Error is a non-2xx response from a service
+type Error struct {
+	StatusCode int
+	Code       string // apierror code, when the service sent one
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("status %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// base holds what both clients share
+type base struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func newBase(baseURL string, httpClient *http.Client) base {
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: tracing.Transport(nil)}
+	}
+	return base{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+// do sends body as JSON (when not nil) and decodes a 2xx response into out
+// Error responses are returned as *Error; out is still decoded when the body fits it,
+// so callers can inspect fields such as success and error
+func (b *base) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Code  string `json:"code"`
+			Error string `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		if out != nil {
+			json.Unmarshal(data, out)
+		}
+		message := apiErr.Error
+		if message == "" {
+			message = strings.TrimSpace(string(data))
+		}
+		return &Error{StatusCode: resp.StatusCode, Code: apiErr.Code, Message: message}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// jsonServer answers path with status and body, decoding each request into a generic map
+func jsonServer(t *testing.T, path string, status int, body interface{}, got *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("Expected POST %s, got %s %s", path, r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+}
+
+// TestGenerateProof tests the request wire format and response decoding of GenerateProof
+func TestGenerateProof(t *testing.T) {
+	var got map[string]interface{}
+	srv := jsonServer(t, "/proof/generate", http.StatusOK, map[string]interface{}{
+		"proof":           "cHJvb2Y=",
+		"proof_format":    "base64",
+		"public_inputs":   []string{"18", "5", "0", "42"},
+		"commitment":      "42",
+		"circuit_version": "v1",
+		"success":         true,
+	}, &got)
+	defer srv.Close()
+
+	c := NewProverClient(srv.URL+"/", srv.Client())
+	resp, err := c.GenerateProof(context.Background(), &ProofRequest{
+		Age: "25", Jurisdiction: "1", IsAccredited: "1", IdentityData: "7", Nonce: "9",
+		MinAge: "18", RequireAccreditation: "0", Commitment: "42",
+	})
+	if err != nil {
+		t.Fatalf("GenerateProof failed: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"age": "25", "jurisdiction": "1", "is_accredited": "1", "identity_data": "7", "nonce": "9",
+		"min_age": "18", "require_accreditation": "0", "commitment": "42",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected request body:\n got %v\nwant %v", got, want)
+	}
+	if !resp.Success || resp.Proof != "cHJvb2Y=" || resp.CircuitVersion != "v1" || len(resp.PublicInputs) != 4 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+// TestVerifyProof tests that public inputs are sent by circuit field name
+func TestVerifyProof(t *testing.T) {
+	var got map[string]interface{}
+	srv := jsonServer(t, "/proof/verify-witness", http.StatusOK, map[string]interface{}{"valid": false, "success": true}, &got)
+	defer srv.Close()
+
+	resp, err := NewProverClient(srv.URL, nil).VerifyProof(context.Background(), &VerifyProofRequest{
+		Proof:  "abcd",
+		Format: "hex",
+		PublicWitness: PublicWitness{
+			MinAge: "18", JurisdictionRoot: "5", RequireAccreditation: "0", Commitment: "42",
+		},
+	})
+	if err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+	if resp.Valid || !resp.Success {
+		t.Errorf("Expected an invalid proof reported without error, got %+v", resp)
+	}
+
+	witness, _ := got["public_witness"].(map[string]interface{})
+	if got["proof"] != "abcd" || got["format"] != "hex" || witness["MinAge"] != "18" || witness["Commitment"] != "42" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+}
+
+// TestCreateAttestation tests the request wire format and response decoding of CreateAttestation
+func TestCreateAttestation(t *testing.T) {
+	var got map[string]interface{}
+	srv := jsonServer(t, "/credential/attest", http.StatusOK, map[string]interface{}{
+		"commitment":  "0xab",
+		"signature":   "0xsig",
+		"hash_algo":   "sha256",
+		"attester_id": 3,
+		"expiry":      1700000000,
+		"expiry_type": "timestamp",
+		"success":     true,
+	}, &got)
+	defer srv.Close()
+
+	resp, err := NewAttesterClient(srv.URL, nil).CreateAttestation(context.Background(), &AttestationRequest{
+		Commitment:      "0xab",
+		PublicInputs:    []string{"18", "5", "0", "42"},
+		Proof:           "cHJvb2Y=",
+		ValiditySeconds: 60,
+		UserID:          "user-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateAttestation failed: %v", err)
+	}
+
+	if got["user_id"] != "user-1" || got["validity_seconds"] != float64(60) || len(got["public_inputs"].([]interface{})) != 4 {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+	if _, ok := got["proof_system"]; ok {
+		t.Error("Expected an empty proof system to be omitted")
+	}
+	if resp.AttesterID != 3 || resp.Expiry != 1700000000 || resp.Signature != "0xsig" || !resp.Success {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+// TestCreateAttestationCodedError tests that an apierror response keeps its code
+func TestCreateAttestationCodedError(t *testing.T) {
+	var got map[string]interface{}
+	srv := jsonServer(t, "/credential/attest", http.StatusBadRequest, map[string]interface{}{
+		"code":  "ERR_PROOF_SYSTEM_NOT_ACCEPTED",
+		"error": "proof system plonk is not accepted",
+	}, &got)
+	defer srv.Close()
+
+	_, err := NewAttesterClient(srv.URL, nil).CreateAttestation(context.Background(), &AttestationRequest{ProofSystem: "plonk"})
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "ERR_PROOF_SYSTEM_NOT_ACCEPTED" {
+		t.Fatalf("Expected *Error with the proof system code, got %v", err)
+	}
+	if got["proof_system"] != "plonk" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+}
+
+// TestRevokeError tests that an error response becomes an *Error and still fills the response
+func TestRevokeError(t *testing.T) {
+	var got map[string]interface{}
+	srv := jsonServer(t, "/credential/revoke", http.StatusBadRequest, map[string]interface{}{
+		"success": false,
+		"error":   "credential already revoked",
+	}, &got)
+	defer srv.Close()
+
+	resp, err := NewAttesterClient(srv.URL, nil).Revoke(context.Background(), &RevocationRequest{Commitment: "0xab", Reason: "fraud"})
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "" || apiErr.Message != "credential already revoked" {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+	if resp.Success || resp.Error != "credential already revoked" {
+		t.Errorf("Expected the error body to be decoded, got %+v", resp)
+	}
+	if got["commitment"] != "0xab" || got["reason"] != "fraud" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+}
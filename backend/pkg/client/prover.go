@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"noah-v2/backend/pkg/proofbundle"
+)
+
+// ProofRequest is the body of POST /proof/generate
+// Numbers are decimal strings, as the prover expects for field elements
+type ProofRequest struct {
+	Age          string `json:"age"`
+	Jurisdiction string `json:"jurisdiction"`
+	IsAccredited string `json:"is_accredited"`
+	IdentityData string `json:"identity_data"`
+	Nonce        string `json:"nonce"`
+
+	// Optional; the prover builds them from its jurisdiction list when omitted
+	MerklePath   []string `json:"merkle_path,omitempty"`
+	MerkleHelper []string `json:"merkle_helper,omitempty"`
+
+	MinAge               string `json:"min_age"`
+	JurisdictionRoot     string `json:"jurisdiction_root,omitempty"`
+	RequireAccreditation string `json:"require_accreditation"`
+	Commitment           string `json:"commitment"`
+	RelyingPartyID       string `json:"relying_party_id,omitempty"`
+
+	Format string `json:"format,omitempty"`
+}
+
+// ProofResponse is the prover's proof and public inputs
+type ProofResponse struct {
+	Proof          string              `json:"proof"`
+	ProofFormat    string              `json:"proof_format"`
+	PublicInputs   []string            `json:"public_inputs"`
+	Commitment     string              `json:"commitment"`
+	CircuitVersion string              `json:"circuit_version,omitempty"`
+	Bundle         *proofbundle.Bundle `json:"bundle,omitempty"`
+	Warning        string              `json:"warning,omitempty"`
+	Success        bool                `json:"success"`
+	Error          string              `json:"error,omitempty"`
+}
+
+// PublicWitness holds the KYC circuit's public inputs as decimal strings
+type PublicWitness struct {
+	MinAge               string `json:"MinAge"`
+	JurisdictionRoot     string `json:"JurisdictionRoot"`
+	RequireAccreditation string `json:"RequireAccreditation"`
+	Commitment           string `json:"Commitment"`
+	RelyingPartyID       string `json:"RelyingPartyID"`
+}
+
+// VerifyProofRequest is the body of POST /proof/verify-witness
+type VerifyProofRequest struct {
+	Proof         string        `json:"proof"`
+	Format        string        `json:"format,omitempty"`
+	PublicWitness PublicWitness `json:"public_witness"`
+	// Bundle replaces Proof, Format and PublicWitness
+	Bundle *proofbundle.Bundle `json:"bundle,omitempty"`
+}
+
+// VerifyProofResponse reports whether the proof verified
+type VerifyProofResponse struct {
+	Valid   bool   `json:"valid"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ProverClient calls the prover service
+type ProverClient struct {
+	base
+}
+
+// NewProverClient creates a client for the prover at baseURL; a nil httpClient uses
+// a default client that propagates trace context
+func NewProverClient(baseURL string, httpClient *http.Client) *ProverClient {
+	return &ProverClient{base: newBase(baseURL, httpClient)}
+}
+
+// GenerateProof generates a KYC proof
+func (c *ProverClient) GenerateProof(ctx context.Context, req *ProofRequest) (*ProofResponse, error) {
+	var resp ProofResponse
+	err := c.do(ctx, http.MethodPost, "/proof/generate", req, &resp)
+	return &resp, err
+}
+
+// VerifyProof verifies a proof against named public inputs; a proof that does not
+// verify is reported by Valid, not as an error
+func (c *ProverClient) VerifyProof(ctx context.Context, req *VerifyProofRequest) (*VerifyProofResponse, error) {
+	var resp VerifyProofResponse
+	err := c.do(ctx, http.MethodPost, "/proof/verify-witness", req, &resp)
+	return &resp, err
+}
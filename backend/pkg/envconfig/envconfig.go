@@ -0,0 +1,152 @@
+// Package envconfig reads service settings from environment variables
+// Typed values go through a Parser, which records an error for each malformed value and
+// falls back to the default, so a service can report every bad setting at startup
+// Redacted and RedactedURL hide secret settings when the configuration is logged
+package envconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Get returns the value of key, or defaultValue if it is unset or empty
+func Get(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetAllowEmpty reads key like Get, but keeps a value explicitly set to empty
+func GetAllowEmpty(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetList reads a comma-separated list, trimming entries and dropping empty ones
+func GetList(key string, defaultValue []string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	if len(result) == 0 {
+		return defaultValue
+	}
+	return result
+}
+
+// Parser reads typed environment values, recording a parse error for each malformed one
+// The zero value is ready to use
+type Parser struct {
+	errs []error
+}
+
+// invalid records a malformed value; the caller falls back to the default
+func (p *Parser) invalid(key, value, kind string) {
+	p.errs = append(p.errs, fmt.Errorf("%s: %q is not a valid %s", key, value, kind))
+}
+
+// Err returns every malformed value read so far, or nil
+func (p *Parser) Err() error {
+	return errors.Join(p.errs...)
+}
+
+// Uint reads an unsigned integer
+func (p *Parser) Uint(key string, defaultValue uint) uint {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		p.invalid(key, value, "unsigned integer")
+		return defaultValue
+	}
+	return uint(result)
+}
+
+// Int reads an integer
+func (p *Parser) Int(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		p.invalid(key, value, "integer")
+		return defaultValue
+	}
+	return result
+}
+
+// Bool reads a boolean in any form strconv.ParseBool accepts
+func (p *Parser) Bool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		p.invalid(key, value, "boolean")
+		return defaultValue
+	}
+	return result
+}
+
+// Duration reads a duration such as "30s" or "5m"
+func (p *Parser) Duration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		p.invalid(key, value, "duration")
+		return defaultValue
+	}
+	return result
+}
+
+// Floats reads a comma-separated list of numbers
+func (p *Parser) Floats(key string, defaultValue []float64) []float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var result []float64
+	for _, item := range strings.Split(value, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			p.invalid(key, value, "list of numbers")
+			return defaultValue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// Redacted hides a secret while still showing whether it is set
+func Redacted(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// RedactedURL hides any password embedded in a URL; an unparseable URL is hidden whole
+func RedactedURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Redacted(raw)
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,67 @@
+package envconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParserCollectsMalformedValues tests that malformed values fall back to their default
+// and are all reported by Err, naming their keys
+func TestParserCollectsMalformedValues(t *testing.T) {
+	t.Setenv("TEST_INT", "seven")
+	t.Setenv("TEST_BOOL", "yes please")
+	t.Setenv("TEST_DURATION", "30s")
+	t.Setenv("TEST_FLOATS", "0.1, 1,10")
+
+	var env Parser
+	if got := env.Int("TEST_INT", 3); got != 3 {
+		t.Errorf("Expected a malformed value to fall back to the default, got %d", got)
+	}
+	env.Bool("TEST_BOOL", false)
+	if got := env.Duration("TEST_DURATION", time.Second); got != 30*time.Second {
+		t.Errorf("Expected 30s, got %s", got)
+	}
+	if got := env.Floats("TEST_FLOATS", nil); !reflect.DeepEqual(got, []float64{0.1, 1, 10}) {
+		t.Errorf("Expected [0.1 1 10], got %v", got)
+	}
+	if got := env.Uint("TEST_UNSET", 5); got != 5 {
+		t.Errorf("Expected an unset value to use the default, got %d", got)
+	}
+
+	err := env.Err()
+	if err == nil {
+		t.Fatal("Expected the malformed values to be reported")
+	}
+	for _, key := range []string{"TEST_INT", "TEST_BOOL"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected the error to name %s, got %v", key, err)
+		}
+	}
+	if strings.Contains(err.Error(), "TEST_DURATION") {
+		t.Errorf("Expected well-formed values not to be reported, got %v", err)
+	}
+}
+
+// TestGetVariants tests how Get, GetAllowEmpty and GetList treat unset and empty values
+func TestGetVariants(t *testing.T) {
+	t.Setenv("TEST_EMPTY", "")
+	t.Setenv("TEST_LIST", " a, ,b ")
+
+	if got := Get("TEST_EMPTY", "default"); got != "default" {
+		t.Errorf("Expected Get to replace an empty value, got %q", got)
+	}
+	if got := GetAllowEmpty("TEST_EMPTY", "default"); got != "" {
+		t.Errorf("Expected GetAllowEmpty to keep an empty value, got %q", got)
+	}
+	if got := GetAllowEmpty("TEST_UNSET", "default"); got != "default" {
+		t.Errorf("Expected GetAllowEmpty to default an unset value, got %q", got)
+	}
+	if got := GetList("TEST_LIST", nil); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], got %v", got)
+	}
+	if got := GetList("TEST_EMPTY", []string{"x"}); !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("Expected the default list, got %v", got)
+	}
+}
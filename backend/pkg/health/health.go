@@ -2,6 +2,8 @@ package health
 
 import (
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -33,11 +35,48 @@ type CheckResult struct {
 // Checker is a function that performs a health check
 type Checker func() CheckResult
 
+// DefaultCheckTimeout bounds each checker when Config.CheckTimeout is not set
+const DefaultCheckTimeout = 5 * time.Second
+
 // Config holds health check configuration
 type Config struct {
 	ServiceName string
 	Version     string
 	Checks      map[string]Checker
+	// CheckTimeout bounds every checker (DefaultCheckTimeout when zero); a checker that
+	// has not returned in time is reported unhealthy
+	CheckTimeout time.Duration
+	// Timeouts overrides CheckTimeout for individual checkers by name
+	Timeouts map[string]time.Duration
+}
+
+// timeout returns the time allowed for the named checker
+func (cfg Config) timeout(name string) time.Duration {
+	if d, ok := cfg.Timeouts[name]; ok && d > 0 {
+		return d
+	}
+	if cfg.CheckTimeout > 0 {
+		return cfg.CheckTimeout
+	}
+	return DefaultCheckTimeout
+}
+
+// runCheck runs a checker in its own goroutine so a hung dependency cannot hang the
+// health endpoint; the goroutine of a timed-out checker finishes in the background
+func runCheck(checker Checker, timeout time.Duration) CheckResult {
+	done := make(chan CheckResult, 1)
+	go func() {
+		done <- checker()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		return CheckResult{Status: "unhealthy", Message: "check timed out after " + timeout.String()}
+	}
 }
 
 // Handler returns a gin handler for health checks
@@ -51,18 +90,35 @@ func Handler(cfg Config) gin.HandlerFunc {
 			Checks:  make(map[string]CheckResult),
 		}
 
-		// Run all health checks
-		allHealthy := true
+		// Run all health checks concurrently, each bounded by its timeout
+		var mu sync.Mutex
+		var wg sync.WaitGroup
 		for name, checker := range cfg.Checks {
-			result := checker()
-			status.Checks[name] = result
-			if result.Status != "healthy" {
-				allHealthy = false
+			wg.Add(1)
+			go func(name string, checker Checker) {
+				defer wg.Done()
+				result := runCheck(checker, cfg.timeout(name))
+				mu.Lock()
+				status.Checks[name] = result
+				mu.Unlock()
+			}(name, checker)
+		}
+		wg.Wait()
+
+		// A degraded check still serves traffic; any other non-healthy check does not
+		for _, result := range status.Checks {
+			switch result.Status {
+			case "healthy":
+			case "degraded":
+				if status.Status == "healthy" {
+					status.Status = "degraded"
+				}
+			default:
+				status.Status = "unhealthy"
 			}
 		}
 
-		if !allHealthy {
-			status.Status = "unhealthy"
+		if status.Status == "unhealthy" {
 			c.JSON(http.StatusServiceUnavailable, status)
 			return
 		}
@@ -71,9 +127,35 @@ func Handler(cfg Config) gin.HandlerFunc {
 	}
 }
 
-// ReadinessHandler returns a simple readiness check
-func ReadinessHandler() gin.HandlerFunc {
+// Readiness is a flag shared between a service's startup code and its readiness check
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// NewReadiness creates a readiness flag that starts out not ready
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// SetReady marks the service as ready (or not) to receive traffic
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady reports whether the service is ready to receive traffic
+func (r *Readiness) IsReady() bool {
+	return r.ready.Load()
+}
+
+// ReadinessHandler returns a readiness check that reports 503 until the flag is set
+func ReadinessHandler(readiness *Readiness) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !readiness.IsReady() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "not ready",
+			})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"status": "ready",
 		})
@@ -0,0 +1,86 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestHandlerCheckTimeout tests that a checker slower than its timeout is reported
+// unhealthy promptly while the other checkers still report their own results
+func TestHandlerCheckTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+	defer close(release)
+
+	router := gin.New()
+	router.GET("/health", Handler(Config{
+		ServiceName:  "test",
+		CheckTimeout: time.Second,
+		Timeouts:     map[string]time.Duration{"slow": 50 * time.Millisecond},
+		Checks: map[string]Checker{
+			"slow": func() CheckResult {
+				<-release
+				return CheckResult{Status: "healthy"}
+			},
+			"fast": func() CheckResult {
+				return CheckResult{Status: "healthy"}
+			},
+		},
+	}))
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected a response soon after the 50ms timeout, took %s", elapsed)
+	}
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if slow := status.Checks["slow"]; slow.Status != "unhealthy" || !strings.Contains(slow.Message, "timed out") {
+		t.Errorf("Expected the slow check to time out, got %+v", slow)
+	}
+	if fast := status.Checks["fast"]; fast.Status != "healthy" {
+		t.Errorf("Expected the fast check to stay healthy, got %+v", fast)
+	}
+}
+
+// TestHandlerDegraded tests that a degraded check reports a degraded status with 200,
+// and that an unhealthy check still takes precedence with 503
+func TestHandlerDegraded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(checks map[string]Checker) (int, Status) {
+		router := gin.New()
+		router.GET("/health", Handler(Config{ServiceName: "test", Checks: checks}))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var status Status
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatalf("Failed to decode status: %v", err)
+		}
+		return rec.Code, status
+	}
+	degraded := func() CheckResult { return CheckResult{Status: "degraded", Message: "slow upstream"} }
+	unhealthy := func() CheckResult { return CheckResult{Status: "unhealthy"} }
+
+	code, status := serve(map[string]Checker{"upstream": degraded})
+	if code != http.StatusOK || status.Status != "degraded" {
+		t.Errorf("Expected 200 degraded, got %d %s", code, status.Status)
+	}
+
+	code, status = serve(map[string]Checker{"upstream": degraded, "signer": unhealthy})
+	if code != http.StatusServiceUnavailable || status.Status != "unhealthy" {
+		t.Errorf("Expected 503 unhealthy, got %d %s", code, status.Status)
+	}
+}
@@ -0,0 +1,118 @@
+package keymanifest
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CurrentVersion is the manifest format version written by New
+const CurrentVersion = 1
+
+// Manifest describes a distributed set of proving/verifying keys
+// It is written next to the verifying key so consumers can check provenance
+type Manifest struct {
+	Version          int    `json:"version"`
+	CircuitHash      string `json:"circuit_hash"`       // SHA256 of the serialized constraint system
+	VerifyingKeyHash string `json:"verifying_key_hash"` // SHA256 of the serialized verifying key
+	CreatedAt        int64  `json:"created_at"`
+	ProofSystem      string `json:"proof_system"`
+	Curve            string `json:"curve"`
+}
+
+// ManifestPath returns the manifest location for a verifying key path
+func ManifestPath(verifyingKeyPath string) string {
+	return verifyingKeyPath + ".manifest.json"
+}
+
+// SignaturePath returns the detached signature location for a verifying key path
+func SignaturePath(verifyingKeyPath string) string {
+	return verifyingKeyPath + ".sig"
+}
+
+// CircuitHash returns the hash identifying a serialized circuit (its version)
+func CircuitHash(circuitBytes []byte) string {
+	return hashHex(circuitBytes)
+}
+
+// New creates a manifest for the given serialized circuit and verifying key
+func New(circuitBytes, verifyingKeyBytes []byte, proofSystem, curve string) *Manifest {
+	return &Manifest{
+		Version:          CurrentVersion,
+		CircuitHash:      CircuitHash(circuitBytes),
+		VerifyingKeyHash: hashHex(verifyingKeyBytes),
+		CreatedAt:        time.Now().Unix(),
+		ProofSystem:      proofSystem,
+		Curve:            curve,
+	}
+}
+
+// Write saves the manifest as indented JSON
+func Write(path string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Read loads a manifest from disk
+func Read(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > CurrentVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	return &m, nil
+}
+
+// VerifyKey checks that the verifying key bytes match the manifest
+func (m *Manifest) VerifyKey(verifyingKeyBytes []byte) error {
+	if got := hashHex(verifyingKeyBytes); got != m.VerifyingKeyHash {
+		return fmt.Errorf("verifying key hash mismatch: manifest %s, got %s", m.VerifyingKeyHash, got)
+	}
+	return nil
+}
+
+// Sign produces a hex-encoded ASN.1 ECDSA signature over the SHA256 of the verifying key
+func Sign(privateKey *ecdsa.PrivateKey, verifyingKeyBytes []byte) (string, error) {
+	digest := sha256.Sum256(verifyingKeyBytes)
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign verifying key: %w", err)
+	}
+	return hex.EncodeToString(sig), nil
+}
+
+// VerifySignature checks a detached signature produced by Sign
+func VerifySignature(publicKey *ecdsa.PublicKey, verifyingKeyBytes []byte, signatureHex string) error {
+	sig, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("invalid signature hex: %w", err)
+	}
+	digest := sha256.Sum256(verifyingKeyBytes)
+	if !ecdsa.VerifyASN1(publicKey, digest[:], sig) {
+		return fmt.Errorf("verifying key signature is invalid")
+	}
+	return nil
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
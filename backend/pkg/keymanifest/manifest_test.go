@@ -0,0 +1,55 @@
+package keymanifest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verifying.key.manifest.json")
+	vk := []byte("verifying-key-bytes")
+
+	m := New([]byte("circuit-bytes"), vk, "groth16", "bn254")
+	if err := Write(path, m); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	loaded, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if *loaded != *m {
+		t.Errorf("Expected %+v, got %+v", *m, *loaded)
+	}
+	if err := loaded.VerifyKey(vk); err != nil {
+		t.Errorf("Expected verifying key to match manifest: %v", err)
+	}
+}
+
+func TestManifestRejectsTamperedKey(t *testing.T) {
+	vk := []byte("verifying-key-bytes")
+	tampered := []byte("verifying-key-bytez")
+
+	m := New([]byte("circuit-bytes"), vk, "groth16", "bn254")
+	if err := m.VerifyKey(tampered); err == nil {
+		t.Error("Expected error for tampered verifying key, got nil")
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	sig, err := Sign(privateKey, vk)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := VerifySignature(&privateKey.PublicKey, vk, sig); err != nil {
+		t.Errorf("Expected valid signature: %v", err)
+	}
+	if err := VerifySignature(&privateKey.PublicKey, tampered, sig); err == nil {
+		t.Error("Expected signature check to fail for tampered verifying key, got nil")
+	}
+}
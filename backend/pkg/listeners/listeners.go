@@ -0,0 +1,34 @@
+// Package listeners checks the ports a service listens on before it binds any of them
+package listeners
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Listener is a port a service listens on, named by the setting it comes from
+type Listener struct {
+	Name string // Setting the port is configured by, e.g. PROVER_PORT
+	Port string // Empty means the listener is disabled
+}
+
+// Validate returns an error naming the settings involved if any enabled listener's port
+// is not a valid TCP port or is shared with another listener, so a collision fails at
+// startup rather than when the second listener binds
+func Validate(listeners ...Listener) error {
+	seen := make(map[int]string, len(listeners))
+	for _, l := range listeners {
+		if l.Port == "" {
+			continue
+		}
+		port, err := strconv.Atoi(l.Port)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%s %q is not a valid port (expected 1-65535)", l.Name, l.Port)
+		}
+		if other, exists := seen[port]; exists {
+			return fmt.Errorf("%s and %s both use port %d; each listener needs its own port", other, l.Name, port)
+		}
+		seen[port] = l.Name
+	}
+	return nil
+}
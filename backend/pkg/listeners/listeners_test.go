@@ -0,0 +1,37 @@
+package listeners
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidate tests that colliding and invalid ports are reported with their settings
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		listeners []Listener
+		wantErr   string
+	}{
+		{"distinct", []Listener{{"ATTESTER_PORT", "8081"}, {"METRICS_PORT", "9091"}}, ""},
+		{"disabled listener", []Listener{{"ATTESTER_PORT", "8081"}, {"METRICS_PORT", ""}}, ""},
+		{"collision", []Listener{{"ATTESTER_PORT", "8081"}, {"METRICS_PORT", "8081"}}, "ATTESTER_PORT and METRICS_PORT both use port 8081"},
+		{"collision with leading zero", []Listener{{"PROVER_PORT", "8080"}, {"METRICS_PORT", "08080"}}, "both use port 8080"},
+		{"not a number", []Listener{{"PROVER_PORT", "http"}}, "PROVER_PORT \"http\" is not a valid port"},
+		{"out of range", []Listener{{"METRICS_PORT", "70000"}}, "not a valid port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.listeners...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
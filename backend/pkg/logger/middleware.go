@@ -1,14 +1,22 @@
 package logger
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
 	"time"
 
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/tracing"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // GinLogger returns a gin middleware for logging HTTP requests
-func GinLogger() gin.HandlerFunc {
+// Requests slower than slowThreshold are logged at Warn with slow set, whatever their
+// status short of a server error; 0 disables the check
+func GinLogger(slowThreshold time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -34,14 +42,26 @@ func GinLogger() gin.HandlerFunc {
 			zap.String("user_agent", c.Request.UserAgent()),
 		}
 
+		// Tie the log line to the request's trace when tracing is enabled
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+
 		// Add error if present
 		if len(c.Errors) > 0 {
 			fields = append(fields, zap.String("error", c.Errors.String()))
 		}
 
+		slow := slowThreshold > 0 && latency > slowThreshold
+		if slow {
+			fields = append(fields, zap.Bool("slow", true), zap.Duration("slow_threshold", slowThreshold))
+		}
+
 		// Log based on status code
 		if statusCode >= 500 {
 			Error("Server error", fields...)
+		} else if slow {
+			Warn("Slow request", fields...)
 		} else if statusCode >= 400 {
 			Warn("Client error", fields...)
 		} else {
@@ -50,20 +70,67 @@ func GinLogger() gin.HandlerFunc {
 	}
 }
 
+// RequestIDHeader carries a caller-chosen request ID, echoed on internal errors
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds a caller-supplied request ID before it is logged
+const maxRequestIDLength = 128
+
 // GinRecovery returns a gin middleware for recovering from panics
+// The panic and its stack are logged with a request ID, which is returned in an
+// INTERNAL_ERROR body and the X-Request-ID header so support can find the log entry
 func GinRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				id := requestID(c)
 				Error("Panic recovered",
 					zap.Any("error", err),
+					zap.String("request_id", id),
 					zap.String("path", c.Request.URL.Path),
 					zap.String("method", c.Request.Method),
 					zap.Stack("stack"),
 				)
-				c.AbortWithStatus(500)
+				c.Header(RequestIDHeader, id)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, apierror.APIError{
+					Success:   false,
+					Code:      apierror.CodeInternal,
+					Error:     "Internal server error",
+					RequestID: id,
+				})
 			}
 		}()
 		c.Next()
 	}
 }
+
+// requestID returns the caller's X-Request-ID if it is a safe token, else the trace ID
+// when tracing is enabled, else a random ID
+func requestID(c *gin.Context) string {
+	if id := c.GetHeader(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+		return traceID
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether id is 1 to maxRequestIDLength letters, digits, '-', '_' or '.'
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeLogs replaces the global logger with one recording entries until the test ends
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	previous := Log
+	Log = zap.New(core)
+	t.Cleanup(func() { Log = previous })
+	return logs
+}
+
+func TestGinLoggerSlowRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logs := observeLogs(t)
+
+	router := gin.New()
+	router.Use(GinLogger(20 * time.Millisecond))
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(40 * time.Millisecond)
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/fast", "/slow"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(entries))
+	}
+	if fast := entries[0]; fast.Level != zapcore.InfoLevel || fast.ContextMap()["slow"] != nil {
+		t.Errorf("Expected the fast request at Info without slow, got %s %v", fast.Level, fast.ContextMap())
+	}
+	slow := entries[1]
+	if slow.Level != zapcore.WarnLevel || slow.Message != "Slow request" || slow.ContextMap()["slow"] != true {
+		t.Errorf("Expected a slow request warning, got %s %q %v", slow.Level, slow.Message, slow.ContextMap())
+	}
+	if slow.ContextMap()["status"] != int64(http.StatusNotFound) {
+		t.Errorf("Expected status 404 on the slow request, got %v", slow.ContextMap()["status"])
+	}
+}
+
+func TestGinLoggerSlowThresholdDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logs := observeLogs(t)
+
+	router := gin.New()
+	router.Use(GinLogger(0))
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(10 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if entries := logs.FilterField(zap.Bool("slow", true)).Len(); entries != 0 {
+		t.Errorf("Expected no slow warnings with the check disabled, got %d", entries)
+	}
+}
+
+func TestGinRecoveryStructuredError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logs := observeLogs(t)
+
+	router := gin.New()
+	router.Use(GinRecovery())
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	tests := []struct {
+		name   string
+		header string
+		wantID string
+	}{
+		{"generated ID", "", ""},
+		{"caller ID", "support-123.a_b", "support-123.a_b"},
+		{"unsafe caller ID replaced", "bad id\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("Expected status 500, got %d", rec.Code)
+			}
+			var body apierror.APIError
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Expected a JSON error body, got %q: %v", rec.Body.String(), err)
+			}
+			if body.Success || body.Code != apierror.CodeInternal || body.Error == "" {
+				t.Errorf("Expected an %s error body, got %+v", apierror.CodeInternal, body)
+			}
+			if body.RequestID == "" || body.RequestID == tt.header && tt.wantID == "" {
+				t.Errorf("Expected a fresh request ID, got %q", body.RequestID)
+			}
+			if tt.wantID != "" && body.RequestID != tt.wantID {
+				t.Errorf("Expected request ID %q, got %q", tt.wantID, body.RequestID)
+			}
+			if got := rec.Header().Get(RequestIDHeader); got != body.RequestID {
+				t.Errorf("Expected the %s header to match the body, got %q", RequestIDHeader, got)
+			}
+
+			// The log entry carries the same ID and the stack
+			entries := logs.TakeAll()
+			if len(entries) != 1 {
+				t.Fatalf("Expected 1 log entry, got %d", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["request_id"] != body.RequestID || fields["stack"] == nil {
+				t.Errorf("Expected the request ID and stack logged, got %v", fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestProofGenerationBuckets tests that observations land in the configured buckets
+func TestProofGenerationBuckets(t *testing.T) {
+	Initialize(Config{ServiceName: "buckets-test", ProofGenerationBuckets: []float64{1, 3, 8}})
+
+	for _, d := range []time.Duration{500 * time.Millisecond, 2 * time.Second, 2500 * time.Millisecond, 7 * time.Second, 40 * time.Second} {
+		RecordProofGeneration(d, true)
+	}
+
+	expected := `
+# HELP proof_generation_duration_seconds Proof generation duration in seconds
+# TYPE proof_generation_duration_seconds histogram
+proof_generation_duration_seconds_bucket{service="buckets-test",le="1"} 1
+proof_generation_duration_seconds_bucket{service="buckets-test",le="3"} 3
+proof_generation_duration_seconds_bucket{service="buckets-test",le="8"} 4
+proof_generation_duration_seconds_bucket{service="buckets-test",le="+Inf"} 5
+proof_generation_duration_seconds_sum{service="buckets-test"} 52
+proof_generation_duration_seconds_count{service="buckets-test"} 5
+`
+	if err := testutil.CollectAndCompare(active.proofGenerationDuration, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	// Reinitializing with defaults replaces the histogram rather than failing to register it
+	Initialize(Config{ServiceName: "buckets-test"})
+	RecordProofGeneration(4*time.Second, true)
+	if n := testutil.CollectAndCount(active.proofGenerationDuration); n != 1 {
+		t.Errorf("Expected one series after reinitializing, got %d", n)
+	}
+}
+
+// TestValidateBuckets tests rejection of empty and non-increasing bucket lists
+func TestValidateBuckets(t *testing.T) {
+	if err := ValidateBuckets(DefaultProofGenerationBuckets); err != nil {
+		t.Errorf("Expected default buckets to be valid: %v", err)
+	}
+	for _, buckets := range [][]float64{nil, {1, 1}, {5, 2}} {
+		if err := ValidateBuckets(buckets); err == nil {
+			t.Errorf("Expected %v to be rejected", buckets)
+		}
+	}
+}
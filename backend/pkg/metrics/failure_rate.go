@@ -0,0 +1,36 @@
+package metrics
+
+import "sync"
+
+// DefaultFailureRateAlpha weights each verification at 10%, so the rate reflects
+// roughly the last 10-20 verifications
+const DefaultFailureRateAlpha = 0.1
+
+// failureRate is an exponential moving average of failures (1) and successes (0)
+type failureRate struct {
+	mu    sync.Mutex
+	alpha float64
+	rate  float64
+}
+
+// observe folds one verification result into the average and returns the new rate
+func (f *failureRate) observe(success bool) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sample := 0.0
+	if !success {
+		sample = 1.0
+	}
+	alpha := f.alpha
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultFailureRateAlpha
+	}
+	f.rate = alpha*sample + (1-alpha)*f.rate
+	return f.rate
+}
+
+// recordVerificationResult updates the verification failure rate gauge
+func (m *metricSet) recordVerificationResult(service string, success bool) {
+	m.proofVerificationFailureRate.WithLabelValues(service).Set(m.failures.observe(success))
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestVerificationFailureRateRisesOnBurst tests that a burst of failures drives the gauge
+// above an alerting threshold and that successes bring it back down
+func TestVerificationFailureRateRisesOnBurst(t *testing.T) {
+	Initialize(Config{ServiceName: "failure-rate-test", FailureRateAlpha: 0.2})
+	gauge := active.proofVerificationFailureRate.WithLabelValues("failure-rate-test")
+
+	for i := 0; i < 20; i++ {
+		RecordProofVerification(time.Millisecond, true)
+	}
+	if rate := testutil.ToFloat64(gauge); rate != 0 {
+		t.Fatalf("Expected a zero failure rate after successes, got %f", rate)
+	}
+
+	const threshold = 0.5
+	for i := 0; i < 5; i++ {
+		RecordProofVerification(time.Millisecond, false)
+	}
+	burst := testutil.ToFloat64(gauge)
+	if burst <= threshold {
+		t.Errorf("Expected failure rate above %.2f after a burst, got %f", threshold, burst)
+	}
+
+	for i := 0; i < 10; i++ {
+		RecordProofVerification(time.Millisecond, true)
+	}
+	if rate := testutil.ToFloat64(gauge); rate >= burst || rate > threshold {
+		t.Errorf("Expected failure rate to decay below %.2f after successes, got %f", threshold, rate)
+	}
+}
@@ -1,108 +1,246 @@
 package metrics
 
 import (
+	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
+// metricSet holds every metric, so Initialize can register a complete set into a fresh
+// registry instead of the global one, which would panic on duplicate registration
+type metricSet struct {
+	registry *prometheus.Registry
+
 	// HTTP metrics
-	httpRequestsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"service", "method", "path", "status"},
-	)
-
-	httpRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "HTTP request latency in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"service", "method", "path", "status"},
-	)
-
-	httpRequestsInFlight = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "http_requests_in_flight",
-			Help: "Current number of HTTP requests being processed",
-		},
-		[]string{"service"},
-	)
+	httpRequestsTotal    *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	httpRequestsInFlight *prometheus.GaugeVec
 
 	// Proof generation metrics
-	proofGenerationTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "proof_generation_total",
-			Help: "Total number of proof generation attempts",
-		},
-		[]string{"service", "status"},
-	)
-
-	proofGenerationDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "proof_generation_duration_seconds",
-			Help:    "Proof generation duration in seconds",
-			Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
-		},
-		[]string{"service"},
-	)
+	proofGenerationTotal    *prometheus.CounterVec
+	proofGenerationDuration *prometheus.HistogramVec
 
 	// Proof verification metrics
-	proofVerificationTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "proof_verification_total",
-			Help: "Total number of proof verification attempts",
-		},
-		[]string{"service", "status"},
-	)
-
-	proofVerificationDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "proof_verification_duration_seconds",
-			Help:    "Proof verification duration in seconds",
-			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2},
-		},
-		[]string{"service"},
-	)
+	proofVerificationTotal       *prometheus.CounterVec
+	proofVerificationDuration    *prometheus.HistogramVec
+	proofVerificationFailureRate *prometheus.GaugeVec
+	proofVerificationQueueDepth  *prometheus.GaugeVec
+	failures                     *failureRate
+
+	// Proof job queue metrics
+	proofJobQueueDepth      *prometheus.GaugeVec
+	proofJobRejectionsTotal *prometheus.CounterVec
 
 	// Circuit metrics
-	circuitInitialized = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "circuit_initialized",
-			Help: "Whether the circuit is initialized (1) or not (0)",
-		},
-		[]string{"service"},
-	)
-)
+	circuitInitialized *prometheus.GaugeVec
+}
+
+// newMetricSet creates every metric and registers it in registry
+func newMetricSet(registry *prometheus.Registry, buckets []float64, failureRateAlpha float64) *metricSet {
+	m := &metricSet{
+		registry: registry,
+		failures: &failureRate{alpha: failureRateAlpha},
+		httpRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Total number of HTTP requests",
+			},
+			[]string{"service", "method", "path", "status"},
+		),
+		httpRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "HTTP request latency in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"service", "method", "path", "status"},
+		),
+		httpRequestsInFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "http_requests_in_flight",
+				Help: "Current number of HTTP requests being processed",
+			},
+			[]string{"service"},
+		),
+		proofGenerationTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "proof_generation_total",
+				Help: "Total number of proof generation attempts",
+			},
+			[]string{"service", "status"},
+		),
+		proofGenerationDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "proof_generation_duration_seconds",
+				Help:    "Proof generation duration in seconds",
+				Buckets: buckets,
+			},
+			[]string{"service"},
+		),
+		proofVerificationTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "proof_verification_total",
+				Help: "Total number of proof verification attempts",
+			},
+			[]string{"service", "status"},
+		),
+		proofVerificationDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "proof_verification_duration_seconds",
+				Help:    "Proof verification duration in seconds",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2},
+			},
+			[]string{"service"},
+		),
+		proofVerificationFailureRate: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "proof_verification_failure_rate",
+				Help: "Exponential moving average of the proof verification failure ratio (0-1)",
+			},
+			[]string{"service"},
+		),
+		proofVerificationQueueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "proof_verification_queue_depth",
+				Help: "Number of proof verifications waiting for a free verification slot",
+			},
+			[]string{"service"},
+		),
+		proofJobQueueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "proof_job_queue_depth",
+				Help: "Number of proof jobs waiting to be proved",
+			},
+			[]string{"service"},
+		),
+		proofJobRejectionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "proof_job_rejections_total",
+				Help: "Total number of proof jobs refused because the queue was full",
+			},
+			[]string{"service"},
+		),
+		circuitInitialized: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "circuit_initialized",
+				Help: "Whether the circuit is initialized (1) or not (0)",
+			},
+			[]string{"service"},
+		),
+	}
+	registry.MustRegister(m.all()...)
+	return m
+}
+
+// all lists the collectors for registration
+func (m *metricSet) all() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.httpRequestsTotal,
+		m.httpRequestDuration,
+		m.httpRequestsInFlight,
+		m.proofGenerationTotal,
+		m.proofGenerationDuration,
+		m.proofVerificationTotal,
+		m.proofVerificationDuration,
+		m.proofVerificationFailureRate,
+		m.proofVerificationQueueDepth,
+		m.proofJobQueueDepth,
+		m.proofJobRejectionsTotal,
+		m.circuitInitialized,
+	}
+}
+
+// unregister removes the collectors from their registry
+func (m *metricSet) unregister() {
+	for _, collector := range m.all() {
+		m.registry.Unregister(collector)
+	}
+}
+
+// DefaultProofGenerationBuckets resolve the common 1-10s proving range, widening
+// roughly as a Fibonacci sequence beyond it
+var DefaultProofGenerationBuckets = []float64{0.5, 1, 2, 3, 5, 8, 13, 21, 34}
 
 // Config holds metrics configuration
 type Config struct {
 	ServiceName string
+	// ProofGenerationBuckets are the proof_generation_duration_seconds bucket upper bounds
+	// in seconds, strictly increasing; nil uses DefaultProofGenerationBuckets
+	ProofGenerationBuckets []float64
+	// FailureRateAlpha is the smoothing factor of proof_verification_failure_rate
+	// (0-1, higher reacts faster); 0 uses DefaultFailureRateAlpha
+	FailureRateAlpha float64
+	// Registry receives every collector and is served by Handler; nil creates a new
+	// registry with the Go runtime and process collectors the global one has
+	Registry *prometheus.Registry
 }
 
-var config Config
+var (
+	mu     sync.RWMutex
+	config Config
+	// active is replaced by Initialize; until then metrics are recorded but not served
+	active = newMetricSet(prometheus.NewRegistry(), DefaultProofGenerationBuckets, 0)
+)
 
-// Initialize sets up metrics with service name
+// Initialize sets up metrics with service name, registering them in cfg.Registry
+// Calling it again replaces the metrics, so each call may use a fresh registry, or the
+// same one, without a duplicate registration panic
 func Initialize(cfg Config) {
+	registry := cfg.Registry
+	if registry == nil {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	buckets := cfg.ProofGenerationBuckets
+	if buckets == nil {
+		buckets = DefaultProofGenerationBuckets
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if active.registry == registry {
+		active.unregister()
+	}
+	active = newMetricSet(registry, buckets, cfg.FailureRateAlpha)
 	config = cfg
 }
 
+// current returns the active metrics and the service name to label them with
+func current() (*metricSet, string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active, config.ServiceName
+}
+
+// ValidateBuckets returns an error unless buckets are non-empty and strictly increasing
+func ValidateBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return fmt.Errorf("at least one histogram bucket is required")
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("histogram buckets must be strictly increasing, got %v after %v", buckets[i], buckets[i-1])
+		}
+	}
+	return nil
+}
+
 // HTTPMiddleware returns a gin middleware for collecting HTTP metrics
 func HTTPMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
+		m, service := current()
 
 		// Increment in-flight requests
-		httpRequestsInFlight.WithLabelValues(config.ServiceName).Inc()
-		defer httpRequestsInFlight.WithLabelValues(config.ServiceName).Dec()
+		inFlight := m.httpRequestsInFlight.WithLabelValues(service)
+		inFlight.Inc()
+		defer inFlight.Dec()
 
 		// Process request
 		c.Next()
@@ -116,15 +254,15 @@ func HTTPMiddleware() gin.HandlerFunc {
 			path = c.Request.URL.Path
 		}
 
-		httpRequestsTotal.WithLabelValues(
-			config.ServiceName,
+		m.httpRequestsTotal.WithLabelValues(
+			service,
 			method,
 			path,
 			http.StatusText(status),
 		).Inc()
 
-		httpRequestDuration.WithLabelValues(
-			config.ServiceName,
+		m.httpRequestDuration.WithLabelValues(
+			service,
 			method,
 			path,
 			http.StatusText(status),
@@ -139,8 +277,9 @@ func RecordProofGeneration(duration time.Duration, success bool) {
 		status = "failure"
 	}
 
-	proofGenerationTotal.WithLabelValues(config.ServiceName, status).Inc()
-	proofGenerationDuration.WithLabelValues(config.ServiceName).Observe(duration.Seconds())
+	m, service := current()
+	m.proofGenerationTotal.WithLabelValues(service, status).Inc()
+	m.proofGenerationDuration.WithLabelValues(service).Observe(duration.Seconds())
 }
 
 // RecordProofVerification records proof verification metrics
@@ -150,8 +289,28 @@ func RecordProofVerification(duration time.Duration, success bool) {
 		status = "failure"
 	}
 
-	proofVerificationTotal.WithLabelValues(config.ServiceName, status).Inc()
-	proofVerificationDuration.WithLabelValues(config.ServiceName).Observe(duration.Seconds())
+	m, service := current()
+	m.proofVerificationTotal.WithLabelValues(service, status).Inc()
+	m.proofVerificationDuration.WithLabelValues(service).Observe(duration.Seconds())
+	m.recordVerificationResult(service, success)
+}
+
+// AddProofVerificationQueueDepth adjusts the number of verifications waiting for a slot by delta
+func AddProofVerificationQueueDepth(delta int) {
+	m, service := current()
+	m.proofVerificationQueueDepth.WithLabelValues(service).Add(float64(delta))
+}
+
+// SetProofJobQueueDepth records the number of queued proof jobs
+func SetProofJobQueueDepth(depth int) {
+	m, service := current()
+	m.proofJobQueueDepth.WithLabelValues(service).Set(float64(depth))
+}
+
+// RecordProofJobRejected counts a proof job refused because the queue was full
+func RecordProofJobRejected() {
+	m, service := current()
+	m.proofJobRejectionsTotal.WithLabelValues(service).Inc()
 }
 
 // SetCircuitInitialized sets the circuit initialization status
@@ -160,10 +319,27 @@ func SetCircuitInitialized(initialized bool) {
 	if initialized {
 		value = 1.0
 	}
-	circuitInitialized.WithLabelValues(config.ServiceName).Set(value)
+	m, service := current()
+	m.circuitInitialized.WithLabelValues(service).Set(value)
 }
 
-// Handler returns the prometheus HTTP handler
+// Handler returns the prometheus HTTP handler, serving the registry of the latest Initialize
 func Handler() http.Handler {
-	return promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m, _ := current()
+		promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves Handler at /metrics on addr, for a metrics listener separate from
+// the service's own port
+func ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
 }
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestInitializeWithFreshRegistries tests that initializing twice, as repeated test
+// setups do, registers into each registry without a duplicate registration panic
+func TestInitializeWithFreshRegistries(t *testing.T) {
+	first := prometheus.NewRegistry()
+	Initialize(Config{ServiceName: "registry-test", Registry: first})
+	SetCircuitInitialized(true)
+
+	second := prometheus.NewRegistry()
+	Initialize(Config{ServiceName: "registry-test", Registry: second})
+	RecordProofJobRejected()
+
+	if n, err := testutil.GatherAndCount(first, "circuit_initialized"); err != nil || n != 1 {
+		t.Errorf("Expected the first registry to keep its series, got %d: %v", n, err)
+	}
+	if n, err := testutil.GatherAndCount(second, "circuit_initialized", "proof_job_rejections_total"); err != nil || n != 1 {
+		t.Errorf("Expected only the rejection series in the second registry, got %d: %v", n, err)
+	}
+
+	// Reusing a registry replaces the previous metrics instead of panicking
+	Initialize(Config{ServiceName: "registry-test", Registry: second})
+	if n, err := testutil.GatherAndCount(second, "proof_job_rejections_total"); err != nil || n != 0 {
+		t.Errorf("Expected reinitialized metrics to start empty, got %d: %v", n, err)
+	}
+
+	// The handler serves the registry of the latest Initialize
+	RecordProofJobRejected()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `proof_job_rejections_total{service="registry-test"} 1`) {
+		t.Errorf("Expected the handler to serve the active registry, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"noah-v2/backend/pkg/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the header carrying the admin API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKey rejects requests whose X-API-Key header does not match key
+func APIKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(APIKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid or missing API key")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCORSOrigins are the local frontend origins allowed when none are configured
+var DefaultCORSOrigins = []string{"http://localhost:5173", "http://localhost:5174", "http://localhost:3000"}
+
+// ValidateCORSOrigins checks origins can be served by the CORS middleware: each must be an
+// http(s) origin or "*", and a wildcard is refused when credentials are allowed, since
+// browsers reject that combination and reflecting every origin instead would let any
+// site make credentialed requests
+func ValidateCORSOrigins(origins []string, allowCredentials bool) error {
+	if len(origins) == 0 {
+		return fmt.Errorf("no allowed origins")
+	}
+	for _, origin := range origins {
+		if strings.Contains(origin, "*") {
+			if allowCredentials {
+				return fmt.Errorf("wildcard origin %q cannot be combined with credentials; list the allowed origins explicitly", origin)
+			}
+			if origin != "*" {
+				return fmt.Errorf("invalid origin %q: only a bare \"*\" wildcard is supported", origin)
+			}
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("invalid origin %q: must start with http:// or https://", origin)
+		}
+	}
+	return nil
+}
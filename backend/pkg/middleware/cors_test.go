@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateCORSOrigins tests that a wildcard origin is refused with credentials and
+// explicit origins are accepted
+func TestValidateCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		errMsg      string
+	}{
+		{"defaults", DefaultCORSOrigins, true, ""},
+		{"wildcard without credentials", []string{"*"}, false, ""},
+		{"wildcard with credentials", []string{"https://app.example.com", "*"}, true, "cannot be combined with credentials"},
+		{"subdomain wildcard with credentials", []string{"https://*.example.com"}, true, "cannot be combined with credentials"},
+		{"missing scheme", []string{"app.example.com"}, true, "http:// or https://"},
+		{"none", nil, true, "no allowed origins"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCORSOrigins(tt.origins, tt.credentials)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Expected origins to be accepted, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
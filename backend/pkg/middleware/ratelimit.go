@@ -1,28 +1,68 @@
 package middleware
 
 import (
-	"github.com/gin-gonic/gin"
-	
+	"container/list"
 	"net/http"
 	"sync"
 	"time"
 
+	"noah-v2/backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"golang.org/x/time/rate"
-	
 )
 
+// DefaultMaxTrackedIPs is the default number of per-IP limiters kept in memory
+const DefaultMaxTrackedIPs = 10000
+
+// MinBurst is the smallest burst a limiter is created with; each request takes one
+// token, so a burst of 0 would deny every request
+const MinBurst = 1
+
 // RateLimiter implements per-IP rate limiting
+// Limiters are kept in a bounded LRU so a flood of distinct IPs cannot grow memory without limit
 type RateLimiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
+	limiters map[string]*list.Element
+	lru      *list.List // front is most recently used
+	capacity int
+	mu       sync.Mutex
 	rate     rate.Limit
 	burst    int
 }
 
-// NewRateLimiter creates a new rate limiter
+// limiterEntry is the value stored in the LRU list
+type limiterEntry struct {
+	ip      string
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter creates a new rate limiter tracking up to DefaultMaxTrackedIPs IPs
+// Each IP may make burst requests at once and requestsPerSecond on average after that
 func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	return NewBoundedRateLimiter(requestsPerSecond, burst, DefaultMaxTrackedIPs)
+}
+
+// NewBoundedRateLimiter creates a new rate limiter that tracks at most capacity IPs,
+// evicting the least recently used limiter when full
+// A burst below MinBurst is raised to MinBurst with a warning
+func NewBoundedRateLimiter(requestsPerSecond float64, burst int, capacity int) *RateLimiter {
+	if capacity <= 0 {
+		capacity = DefaultMaxTrackedIPs
+	}
+	if burst < MinBurst {
+		if logger.Log != nil {
+			logger.Warn("Rate limiter burst below minimum, using minimum",
+				zap.Int("burst", burst),
+				zap.Int("min_burst", MinBurst),
+			)
+		}
+		burst = MinBurst
+	}
 	return &RateLimiter{
-		limiters: make(map[string]*rate.Limiter),
+		limiters: make(map[string]*list.Element),
+		lru:      list.New(),
+		capacity: capacity,
 		rate:     rate.Limit(requestsPerSecond),
 		burst:    burst,
 	}
@@ -33,15 +73,77 @@ func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	limiter, exists := rl.limiters[ip]
-	if !exists {
-		limiter = rate.NewLimiter(rl.rate, rl.burst)
-		rl.limiters[ip] = limiter
+	if elem, exists := rl.limiters[ip]; exists {
+		rl.lru.MoveToFront(elem)
+		return elem.Value.(*limiterEntry).limiter
+	}
+
+	// Evict the least recently used limiter when at capacity
+	if rl.lru.Len() >= rl.capacity {
+		oldest := rl.lru.Back()
+		rl.lru.Remove(oldest)
+		delete(rl.limiters, oldest.Value.(*limiterEntry).ip)
 	}
 
+	limiter := rate.NewLimiter(rl.rate, rl.burst)
+	rl.limiters[ip] = rl.lru.PushFront(&limiterEntry{ip: ip, limiter: limiter})
+
 	return limiter
 }
 
+// Size returns the number of IPs currently tracked
+func (rl *RateLimiter) Size() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.lru.Len()
+}
+
+// Tokens returns the remaining tokens for an IP and whether the IP is tracked
+// It does not create a limiter or change the IP's LRU position
+func (rl *RateLimiter) Tokens(ip string) (float64, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	elem, exists := rl.limiters[ip]
+	if !exists {
+		return 0, false
+	}
+	return elem.Value.(*limiterEntry).limiter.Tokens(), true
+}
+
+// AdminHandler returns a handler reporting limiter state; ?ip= adds that IP's remaining tokens
+func (rl *RateLimiter) AdminHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response := gin.H{
+			"tracked_ips": rl.Size(),
+			"capacity":    rl.capacity,
+			"rate":        float64(rl.rate),
+			"burst":       rl.burst,
+		}
+
+		if ip := c.Query("ip"); ip != "" {
+			tokens, tracked := rl.Tokens(ip)
+			if !tracked {
+				// An untracked IP starts with a full bucket
+				tokens = float64(rl.burst)
+			}
+			response["ip"] = ip
+			response["tracked"] = tracked
+			response["tokens"] = tokens
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// reset drops all tracked limiters
+func (rl *RateLimiter) reset() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limiters = make(map[string]*list.Element)
+	rl.lru.Init()
+}
+
 // Middleware returns a gin middleware for rate limiting
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	// Cleanup old limiters periodically
@@ -50,11 +152,9 @@ func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 		defer ticker.Stop()
 
 		for range ticker.C {
-			rl.mu.Lock()
 			// Simple cleanup: remove all limiters
 			// In production, you might want more sophisticated cleanup
-			rl.limiters = make(map[string]*rate.Limiter)
-			rl.mu.Unlock()
+			rl.reset()
 		}
 	}()
 
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRateLimiterBoundedLRU tests that tracked IPs stay bounded and recent IPs survive eviction
+func TestRateLimiterBoundedLRU(t *testing.T) {
+	rl := NewBoundedRateLimiter(10, 5, 3)
+
+	recent := rl.getLimiter("10.0.0.1")
+	for i := 0; i < 10; i++ {
+		rl.getLimiter(fmt.Sprintf("192.168.0.%d", i))
+		// Keep 10.0.0.1 as the most recently used entry
+		if got := rl.getLimiter("10.0.0.1"); got != recent {
+			t.Fatalf("Expected recently used IP to keep its limiter after %d inserts", i+1)
+		}
+		if size := rl.Size(); size > 3 {
+			t.Fatalf("Expected at most 3 tracked IPs, got %d", size)
+		}
+	}
+
+	if size := rl.Size(); size != 3 {
+		t.Errorf("Expected 3 tracked IPs, got %d", size)
+	}
+	if _, ok := rl.limiters["192.168.0.0"]; ok {
+		t.Error("Expected least recently used IP to be evicted")
+	}
+	if _, ok := rl.limiters["192.168.0.9"]; !ok {
+		t.Error("Expected most recently inserted IP to be tracked")
+	}
+}
+
+// TestRateLimiterAdminHandler tests that the admin endpoint reports reduced tokens after requests
+func TestRateLimiterAdminHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl := NewBoundedRateLimiter(0.001, 10, 100)
+
+	router := gin.New()
+	admin := router.Group("/admin", APIKey("secret"))
+	admin.GET("/ratelimit", rl.AdminHandler())
+	limited := router.Group("/", rl.Middleware())
+	limited.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "10.1.2.3:4000"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Missing or wrong key is rejected
+	for _, key := range []string{"", "wrong"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin/ratelimit", nil)
+		req.Header.Set(APIKeyHeader, key)
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for key %q, got %d", key, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/ratelimit?ip=10.1.2.3", nil)
+	req.Header.Set(APIKeyHeader, "secret")
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		TrackedIPs int     `json:"tracked_ips"`
+		Tracked    bool    `json:"tracked"`
+		Tokens     float64 `json:"tokens"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if body.TrackedIPs != 1 || !body.Tracked {
+		t.Errorf("Expected the IP to be the only tracked IP, got %+v", body)
+	}
+	if body.Tokens > 6.1 || body.Tokens < 5.9 {
+		t.Errorf("Expected about 6 tokens left after 4 requests, got %f", body.Tokens)
+	}
+}
+
+// TestRateLimiterZeroBurst tests that a burst of 0 is raised to MinBurst instead of denying every request
+func TestRateLimiterZeroBurst(t *testing.T) {
+	for _, burst := range []int{0, -3} {
+		rl := NewRateLimiter(1, burst)
+		if rl.burst != MinBurst {
+			t.Errorf("Expected burst %d for %d, got %d", MinBurst, burst, rl.burst)
+		}
+		limiter := rl.getLimiter("10.0.0.1")
+		if !limiter.Allow() {
+			t.Errorf("Expected the first request to be allowed with burst %d", burst)
+		}
+		if limiter.Allow() {
+			t.Errorf("Expected the burst of %d to be spent after one request", MinBurst)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"mime"
 	"net/http"
 
+	"noah-v2/backend/pkg/apierror"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -31,17 +34,17 @@ func Security() gin.HandlerFunc {
 	}
 }
 
-// ValidateContentType ensures requests have valid Content-Type
+// ValidateContentType rejects POST and PUT bodies that are not JSON with a 415
+// Parameters such as charset are allowed, and an omitted Content-Type is treated as JSON
 func ValidateContentType() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Method == http.MethodPost || c.Request.Method == http.MethodPut {
-			contentType := c.GetHeader("Content-Type")
-			if contentType != "application/json" && contentType != "" {
-				c.JSON(http.StatusUnsupportedMediaType, gin.H{
-					"error": "Content-Type must be application/json",
-				})
-				c.Abort()
-				return
+			if contentType := c.GetHeader("Content-Type"); contentType != "" {
+				mediaType, _, err := mime.ParseMediaType(contentType)
+				if err != nil || mediaType != gin.MIMEJSON {
+					apierror.Abort(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMediaType, "Content-Type must be application/json")
+					return
+				}
 			}
 		}
 		c.Next()
@@ -0,0 +1,79 @@
+// Package proofbundle defines a self-describing proof document carrying everything a
+// verifier needs: the proof, its public inputs in circuit order, and the circuit and
+// proof system they belong to
+package proofbundle
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the bundle format version this package reads and writes
+const Version = 1
+
+// ProofSystemGroth16 is the proof system of proofs from the KYC circuits
+const ProofSystemGroth16 = "groth16"
+
+// Bundle is a proof with its public inputs and metadata
+type Bundle struct {
+	Version int `json:"version"`
+	// CircuitHash identifies the circuit (and so the verifying key); empty uses the verifier's default
+	CircuitHash  string   `json:"circuit_hash,omitempty"`
+	ProofSystem  string   `json:"proof_system"`
+	Proof        []byte   `json:"proof"`         // Serialized proof, base64 in JSON
+	PublicInputs []string `json:"public_inputs"` // Hex public inputs in circuit order
+}
+
+// New creates a bundle of the current version
+func New(circuitHash, proofSystem string, proof []byte, publicInputs []string) *Bundle {
+	return &Bundle{
+		Version:      Version,
+		CircuitHash:  circuitHash,
+		ProofSystem:  proofSystem,
+		Proof:        proof,
+		PublicInputs: publicInputs,
+	}
+}
+
+// Validate checks the bundle is of a supported version and has a proof and public inputs
+func (b *Bundle) Validate() error {
+	if b.Version != Version {
+		return fmt.Errorf("unsupported proof bundle version %d (expected %d)", b.Version, Version)
+	}
+	if b.ProofSystem == "" {
+		return fmt.Errorf("proof bundle has no proof_system")
+	}
+	if len(b.Proof) == 0 {
+		return fmt.Errorf("proof bundle has no proof")
+	}
+	if len(b.PublicInputs) == 0 {
+		return fmt.Errorf("proof bundle has no public_inputs")
+	}
+	return nil
+}
+
+// EncodedProof returns the proof base64-encoded, as the proof fields of requests carry it
+func (b *Bundle) EncodedProof() string {
+	return base64.StdEncoding.EncodeToString(b.Proof)
+}
+
+// Marshal validates the bundle and encodes it as JSON
+func Marshal(b *Bundle) ([]byte, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(b)
+}
+
+// Unmarshal decodes a JSON bundle and validates it
+func Unmarshal(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse proof bundle: %w", err)
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
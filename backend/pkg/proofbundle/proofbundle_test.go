@@ -0,0 +1,57 @@
+package proofbundle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	b := New("abc123", ProofSystemGroth16, []byte{0x01, 0x02, 0xff}, []string{"12", "0a", "00"})
+
+	data, err := Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"proof":"AQL/"`) {
+		t.Errorf("Expected base64 proof in %s", data)
+	}
+
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, b) {
+		t.Errorf("Expected %+v, got %+v", b, decoded)
+	}
+	if decoded.EncodedProof() != "AQL/" {
+		t.Errorf("Expected encoded proof AQL/, got %s", decoded.EncodedProof())
+	}
+}
+
+func TestBundleValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		json   string
+		errMsg string
+	}{
+		{"unknown version", `{"version":2,"proof_system":"groth16","proof":"AQ==","public_inputs":["01"]}`, "unsupported proof bundle version"},
+		{"no proof system", `{"version":1,"proof":"AQ==","public_inputs":["01"]}`, "no proof_system"},
+		{"no proof", `{"version":1,"proof_system":"groth16","public_inputs":["01"]}`, "no proof"},
+		{"no public inputs", `{"version":1,"proof_system":"groth16","proof":"AQ=="}`, "no public_inputs"},
+		{"malformed", `{"version":"1"}`, "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	if _, err := Marshal(&Bundle{Version: Version}); err == nil {
+		t.Error("Expected Marshal to reject an incomplete bundle")
+	}
+}
@@ -0,0 +1,45 @@
+package proofbundle
+
+import (
+	"noah-v2/backend/pkg/protoresp"
+)
+
+// Bundle field numbers in backend/proto/noah.proto
+const (
+	fieldVersion      = 1
+	fieldCircuitHash  = 2
+	fieldProofSystem  = 3
+	fieldProof        = 4
+	fieldPublicInputs = 5
+)
+
+// MarshalProto encodes the bundle as the noah.v1.ProofBundle message
+func (b *Bundle) MarshalProto() []byte {
+	var e protoresp.Encoder
+	e.Uint64(fieldVersion, uint64(b.Version))
+	e.String(fieldCircuitHash, b.CircuitHash)
+	e.String(fieldProofSystem, b.ProofSystem)
+	e.String(fieldProof, string(b.Proof))
+	e.Strings(fieldPublicInputs, b.PublicInputs)
+	return e.Bytes()
+}
+
+// UnmarshalProto decodes a noah.v1.ProofBundle message into b
+func (b *Bundle) UnmarshalProto(data []byte) error {
+	*b = Bundle{}
+	return protoresp.Decode(data, func(f protoresp.Field) error {
+		switch f.Number {
+		case fieldVersion:
+			b.Version = int(f.Varint)
+		case fieldCircuitHash:
+			b.CircuitHash = string(f.Bytes)
+		case fieldProofSystem:
+			b.ProofSystem = string(f.Bytes)
+		case fieldProof:
+			b.Proof = append([]byte{}, f.Bytes...)
+		case fieldPublicInputs:
+			b.PublicInputs = append(b.PublicInputs, string(f.Bytes))
+		}
+		return nil
+	})
+}
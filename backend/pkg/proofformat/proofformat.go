@@ -0,0 +1,58 @@
+package proofformat
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Supported proof transport encodings
+const (
+	Base64 = "base64"
+	Hex    = "hex"
+)
+
+// Normalize returns the canonical format name, defaulting to base64 when empty
+func Normalize(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", Base64:
+		return Base64, nil
+	case Hex:
+		return Hex, nil
+	default:
+		return "", fmt.Errorf("unsupported proof format %q (expected %s or %s)", format, Base64, Hex)
+	}
+}
+
+// Encode serializes proof bytes in the given format
+func Encode(data []byte, format string) (string, error) {
+	format, err := Normalize(format)
+	if err != nil {
+		return "", err
+	}
+	if format == Hex {
+		return hex.EncodeToString(data), nil
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Decode parses an encoded proof, failing if the content does not match the declared format
+func Decode(encoded string, format string) ([]byte, error) {
+	format, err := Normalize(format)
+	if err != nil {
+		return nil, err
+	}
+	if format == Hex {
+		data, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("proof is not valid hex: %w", err)
+		}
+		return data, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("proof is not valid base64: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,139 @@
+// Package protoresp encodes and decodes the protobuf responses described in
+// backend/proto/noah.proto, without generated code, and picks protobuf or JSON per request
+package protoresp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MIMEType is the Accept value that selects protobuf responses
+const MIMEType = "application/x-protobuf"
+
+// Message is a response with a protobuf encoding matching backend/proto/noah.proto
+type Message interface {
+	MarshalProto() []byte
+}
+
+// WantsProtobuf reports whether the request's Accept header prefers protobuf to JSON
+// A missing or wildcard Accept header keeps JSON, the default
+func WantsProtobuf(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, MIMEType) == MIMEType
+}
+
+// OK writes a 200 response as protobuf when the client asks for it and as JSON otherwise
+func OK(c *gin.Context, body Message) {
+	c.Header("Vary", "Accept")
+	if WantsProtobuf(c) {
+		c.Data(http.StatusOK, MIMEType, body.MarshalProto())
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// Encoder appends proto3 fields, omitting zero values as proto3 does
+type Encoder struct {
+	buf []byte
+}
+
+// String appends a string field
+func (e *Encoder) String(num protowire.Number, v string) {
+	if v == "" {
+		return
+	}
+	e.buf = protowire.AppendTag(e.buf, num, protowire.BytesType)
+	e.buf = protowire.AppendString(e.buf, v)
+}
+
+// Strings appends a repeated string field, keeping empty elements
+func (e *Encoder) Strings(num protowire.Number, vs []string) {
+	for _, v := range vs {
+		e.buf = protowire.AppendTag(e.buf, num, protowire.BytesType)
+		e.buf = protowire.AppendString(e.buf, v)
+	}
+}
+
+// Uint64 appends a uint64 (or uint32) field
+func (e *Encoder) Uint64(num protowire.Number, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.buf = protowire.AppendTag(e.buf, num, protowire.VarintType)
+	e.buf = protowire.AppendVarint(e.buf, v)
+}
+
+// OptionalUint64 appends a proto3 optional uint64 field, which is written even when zero
+func (e *Encoder) OptionalUint64(num protowire.Number, v *uint64) {
+	if v == nil {
+		return
+	}
+	e.buf = protowire.AppendTag(e.buf, num, protowire.VarintType)
+	e.buf = protowire.AppendVarint(e.buf, *v)
+}
+
+// Bool appends a bool field
+func (e *Encoder) Bool(num protowire.Number, v bool) {
+	if !v {
+		return
+	}
+	e.buf = protowire.AppendTag(e.buf, num, protowire.VarintType)
+	e.buf = protowire.AppendVarint(e.buf, protowire.EncodeBool(v))
+}
+
+// Message appends an embedded message field; nil is omitted
+func (e *Encoder) Message(num protowire.Number, m Message) {
+	if m == nil {
+		return
+	}
+	e.buf = protowire.AppendTag(e.buf, num, protowire.BytesType)
+	e.buf = protowire.AppendBytes(e.buf, m.MarshalProto())
+}
+
+// Bytes returns the encoded message
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// Field is one decoded field: Varint for varint fields, Bytes for length-delimited ones
+type Field struct {
+	Number protowire.Number
+	Type   protowire.Type
+	Varint uint64
+	Bytes  []byte
+}
+
+// Decode calls fn for each field of an encoded message, skipping fixed-width fields,
+// which these messages do not use
+func Decode(b []byte, fn func(Field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		field := Field{Number: num, Type: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.Varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			field.Bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if typ == protowire.VarintType || typ == protowire.BytesType {
+			if err := fn(field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
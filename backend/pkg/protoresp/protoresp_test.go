@@ -0,0 +1,63 @@
+package protoresp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"noah-v2/backend/pkg/proofbundle"
+	"noah-v2/backend/pkg/protoresp"
+	"noah-v2/backend/pkg/protoresp/prototest"
+)
+
+const schemaPath = "../../proto/noah.proto"
+
+// TestBundleRoundTrip tests that a bundle with every field set encodes as the
+// noah.v1.ProofBundle in noah.proto and decodes back unchanged
+func TestBundleRoundTrip(t *testing.T) {
+	fd := prototest.Schema(t, schemaPath)
+	bundle := proofbundle.New("circuit-hash", "groth16", []byte{0x01, 0x02}, []string{"0x01", "", "0x03"})
+
+	data := bundle.MarshalProto()
+	msg := prototest.RoundTrip(t, fd, "ProofBundle", data)
+	if unset := prototest.Unset(msg); len(unset) > 0 {
+		t.Errorf("Expected every field on the wire, missing %v", unset)
+	}
+
+	var decoded proofbundle.Bundle
+	if err := decoded.UnmarshalProto(data); err != nil {
+		t.Fatalf("Failed to decode bundle: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, bundle) {
+		t.Errorf("Expected %+v, got %+v", bundle, &decoded)
+	}
+}
+
+// TestEncoderOmitsZeroValues tests that zero scalars are left off the wire as proto3
+// does, while a proto3 optional field set to zero is still written
+func TestEncoderOmitsZeroValues(t *testing.T) {
+	fd := prototest.Schema(t, schemaPath)
+
+	var zero uint64
+	var e protoresp.Encoder
+	e.String(1, "")
+	e.String(2, "s")
+	e.OptionalUint64(3, &zero)
+
+	msg := prototest.RoundTrip(t, fd, "SignatureComponents", e.Bytes())
+	got := prototest.Unset(msg)
+	if want := []string{"r"}; len(got) != 1 || string(got[0]) != want[0] {
+		t.Errorf("Expected only %v unset, got %v", want, got)
+	}
+}
+
+// TestDecodeRejectsTruncated tests that a message cut off inside a field is an error
+func TestDecodeRejectsTruncated(t *testing.T) {
+	var e protoresp.Encoder
+	e.String(1, "value")
+	data := e.Bytes()
+
+	err := protoresp.Decode(data[:len(data)-1], func(protoresp.Field) error { return nil })
+	if err == nil {
+		t.Error("Expected an error for a truncated message")
+	}
+}
@@ -0,0 +1,176 @@
+// Package prototest checks hand-written protobuf encodings against backend/proto/noah.proto.
+// It compiles the schema at test time with the protobuf runtime, so a field number or
+// type that drifts from the .proto file fails the test
+package prototest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	packageLine = regexp.MustCompile(`^package\s+([\w.]+)\s*;$`)
+	messageLine = regexp.MustCompile(`^message\s+(\w+)\s*\{$`)
+	fieldLine   = regexp.MustCompile(`^(?:(repeated|optional)\s+)?([\w.]+)\s+(\w+)\s*=\s*(\d+)\s*;$`)
+)
+
+// scalarTypes are the field types noah.proto uses; any other type names a message
+var scalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+	"bool":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"uint32": descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"uint64": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+}
+
+// Schema compiles the proto3 file at path. Only the subset noah.proto uses is
+// understood (top-level messages with scalar, message, repeated and optional fields);
+// anything else fails the test rather than being skipped
+func Schema(t testing.TB, path string) protoreflect.FileDescriptor {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	file, err := parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	file.Name = proto.String(path)
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatalf("Invalid schema %s: %v", path, err)
+	}
+	return fd
+}
+
+func parse(data []byte) (*descriptorpb.FileDescriptorProto, error) {
+	file := &descriptorpb.FileDescriptorProto{Syntax: proto.String("proto3")}
+	var message *descriptorpb.DescriptorProto
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case line == `syntax = "proto3";`:
+		case message == nil && packageLine.MatchString(line):
+			file.Package = proto.String(packageLine.FindStringSubmatch(line)[1])
+		case message == nil && messageLine.MatchString(line):
+			message = &descriptorpb.DescriptorProto{Name: proto.String(messageLine.FindStringSubmatch(line)[1])}
+		case message != nil && line == "}":
+			file.MessageType = append(file.MessageType, message)
+			message = nil
+		case message != nil && fieldLine.MatchString(line):
+			m := fieldLine.FindStringSubmatch(line)
+			number, err := strconv.ParseInt(m[4], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid field number: %w", n, err)
+			}
+			field := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(m[3]),
+				JsonName: proto.String(m[3]),
+				Number:   proto.Int32(int32(number)),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}
+			if typ, ok := scalarTypes[m[2]]; ok {
+				field.Type = typ.Enum()
+			} else {
+				field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				field.TypeName = proto.String("." + file.GetPackage() + "." + m[2])
+			}
+			switch m[1] {
+			case "repeated":
+				field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			case "optional":
+				// proto3 optional fields sit alone in a synthetic oneof
+				field.Proto3Optional = proto.Bool(true)
+				field.OneofIndex = proto.Int32(int32(len(message.OneofDecl)))
+				message.OneofDecl = append(message.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + m[3])})
+			}
+			message.Field = append(message.Field, field)
+		default:
+			return nil, fmt.Errorf("line %d: unsupported syntax %q", n, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if message != nil {
+		return nil, fmt.Errorf("message %s is not closed", message.GetName())
+	}
+	return file, nil
+}
+
+// RoundTrip decodes data as the named message of fd and fails the test unless every
+// field is known to the schema and re-encoding gives back the same bytes. It returns
+// the decoded message so callers can check which fields were set
+func RoundTrip(t testing.TB, fd protoreflect.FileDescriptor, name protoreflect.Name, data []byte) protoreflect.Message {
+	t.Helper()
+	desc := fd.Messages().ByName(name)
+	if desc == nil {
+		t.Fatalf("Message %s is not in %s", name, fd.Path())
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		t.Fatalf("Failed to decode %s: %v", name, err)
+	}
+	checkKnown(t, msg)
+
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to re-encode %s: %v", name, err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("Re-encoding %s changed the bytes\ngot:  %x\nwant: %x", name, encoded, data)
+	}
+	return msg
+}
+
+// checkKnown fails the test when msg, or a message inside it, carries fields the
+// schema does not declare or declares with another wire type
+func checkKnown(t testing.TB, msg protoreflect.Message) {
+	t.Helper()
+	if unknown := msg.GetUnknown(); len(unknown) > 0 {
+		t.Errorf("%s has fields not in the schema: %x", msg.Descriptor().FullName(), []byte(unknown))
+	}
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil {
+			return true
+		}
+		if fd.IsList() {
+			for i := 0; i < v.List().Len(); i++ {
+				checkKnown(t, v.List().Get(i).Message())
+			}
+		} else {
+			checkKnown(t, v.Message())
+		}
+		return true
+	})
+}
+
+// Unset returns the fields of msg's type that are not set in msg, so a test that
+// fills every Go field can check each one reached the wire
+func Unset(msg protoreflect.Message) []protoreflect.Name {
+	var names []protoreflect.Name
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if !msg.Has(fields.Get(i)) {
+			names = append(names, fields.Get(i).Name())
+		}
+	}
+	return names
+}
@@ -0,0 +1,61 @@
+// Package tlsconfig builds the TLS settings the services listen with
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultMinVersion is the oldest protocol version accepted unless configured otherwise
+const DefaultMinVersion = "1.2"
+
+// CipherSuites are the TLS 1.2 suites offered: ECDHE key exchange with AEAD ciphers only
+// TLS 1.3 suites are not configurable in crypto/tls and are all secure
+var CipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseVersion converts "1.2" or "TLS1.2" to a crypto/tls version constant
+func ParseVersion(version string) (uint16, error) {
+	v := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(version)), "TLS")
+	if parsed, ok := versions[strings.TrimSpace(v)]; ok {
+		return parsed, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q (expected 1.0, 1.1, 1.2 or 1.3)", version)
+}
+
+// New returns a server TLS config refusing protocol versions below minVersion
+func New(minVersion uint16) *tls.Config {
+	return &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: CipherSuites,
+	}
+}
+
+// ListenAndServe serves handler over TLS on addr with the certificate in certFile and keyFile
+func ListenAndServe(addr string, handler http.Handler, certFile, keyFile, minVersion string) error {
+	version, err := ParseVersion(minVersion)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: New(version),
+	}
+	return server.ListenAndServeTLS(certFile, keyFile)
+}
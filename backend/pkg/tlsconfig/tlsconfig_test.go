@@ -0,0 +1,68 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// get requests the server's root with a client limited to the given TLS versions
+func get(srv *httptest.Server, minVersion, maxVersion uint16) error {
+	transport := srv.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.MinVersion = minVersion
+	transport.TLSClientConfig.MaxVersion = maxVersion
+	resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// TestMinVersionRefusesOlderClients tests that clients below the minimum version fail the handshake
+func TestMinVersionRefusesOlderClients(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		minVersion string
+		client     uint16
+		wantErr    bool
+	}{
+		{"tls1.1 client refused by default", DefaultMinVersion, tls.VersionTLS11, true},
+		{"tls1.0 client refused by default", DefaultMinVersion, tls.VersionTLS10, true},
+		{"tls1.2 client accepted by default", DefaultMinVersion, tls.VersionTLS12, false},
+		{"tls1.3 client accepted by default", DefaultMinVersion, tls.VersionTLS13, false},
+		{"tls1.2 client refused with 1.3 minimum", "1.3", tls.VersionTLS12, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := ParseVersion(tt.minVersion)
+			if err != nil {
+				t.Fatalf("ParseVersion failed: %v", err)
+			}
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			srv.TLS = New(version)
+			srv.StartTLS()
+			defer srv.Close()
+
+			err = get(srv, tls.VersionTLS10, tt.client)
+			if tt.wantErr && err == nil {
+				t.Error("Expected the handshake to be refused")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected the client to connect, got %v", err)
+			}
+		})
+	}
+}
+
+// TestParseVersion tests accepted spellings and rejection of unknown versions
+func TestParseVersion(t *testing.T) {
+	for input, want := range map[string]uint16{"1.2": tls.VersionTLS12, "TLS1.3": tls.VersionTLS13, " tls 1.1 ": tls.VersionTLS11} {
+		if got, err := ParseVersion(input); err != nil || got != want {
+			t.Errorf("ParseVersion(%q) = %x, %v; want %x", input, got, err, want)
+		}
+	}
+	if _, err := ParseVersion("1.4"); err == nil {
+		t.Error("Expected an unknown version to be rejected")
+	}
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareSpanPerRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	install(provider)
+	defer provider.Shutdown(context.Background())
+
+	// The handler calls a downstream service through the traced transport
+	var downstreamTraceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	var handlerTraceID string
+	router := gin.New()
+	router.Use(Middleware("test-service"))
+	router.GET("/ping", func(c *gin.Context) {
+		handlerTraceID = TraceID(c.Request.Context())
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, downstream.URL, nil)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Each request yields a server span and a client span for the downstream call
+	var server int
+	for _, span := range exporter.GetSpans() {
+		if span.SpanKind == trace.SpanKindServer {
+			server++
+		}
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("Expected span %s in trace %s, got %s", span.Name, traceID, span.SpanContext.TraceID())
+		}
+	}
+	if server != 2 {
+		t.Errorf("Expected 2 request spans, got %d of %d spans", server, len(exporter.GetSpans()))
+	}
+	if handlerTraceID != traceID {
+		t.Errorf("Expected handler trace ID %s, got %q", traceID, handlerTraceID)
+	}
+	if len(downstreamTraceparent) < 36 || downstreamTraceparent[3:35] != traceID {
+		t.Errorf("Expected trace context propagated downstream, got %q", downstreamTraceparent)
+	}
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "test-service", "")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Expected a no-op shutdown, got %v", err)
+	}
+	if id := TraceID(context.Background()); id != "" {
+		t.Errorf("Expected no trace ID outside a span, got %s", id)
+	}
+}
@@ -1,51 +1,78 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
+	"strconv"
+	"time"
 
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/backend/pkg/protoresp"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark/frontend"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // API handles HTTP requests for proof generation
 type API struct {
 	circuitManager *CircuitManager
+	readiness      *health.Readiness // Set once the circuit is compiled and keys are loaded
+	jobs           *JobQueue         // Asynchronous proof generation behind /proof/jobs
 }
 
-// NewAPI creates a new API handler
-func NewAPI() *API {
+// NewAPI creates a new API handler from a config main has validated
+func NewAPI(config *Config) *API {
+	circuitManager := NewCircuitManager(config)
+	jobs := NewJobQueue(circuitManager.config.ProofJobQueueSize, circuitManager.GenerateProof)
+	jobs.Start()
 	return &API{
-		circuitManager: NewCircuitManager(),
+		circuitManager: circuitManager,
+		readiness:      health.NewReadiness(),
+		jobs:           jobs,
 	}
 }
 
-// Initialize initializes the circuit manager
+// Initialize initializes the circuit manager and marks the service ready
+// With SELF_TEST_ON_START, a known witness must also prove and verify first
 func (api *API) Initialize() error {
-	return api.circuitManager.Initialize()
+	if err := api.circuitManager.Initialize(); err != nil {
+		return err
+	}
+	if api.circuitManager.config.SelfTestOnStart {
+		elapsed, err := api.circuitManager.SelfTest()
+		if err != nil {
+			logger.Error("Startup self-test failed", zap.Duration("duration", elapsed), zap.Error(err))
+			return err
+		}
+		logger.Info("Startup self-test passed", zap.Duration("duration", elapsed))
+	}
+	api.readiness.SetReady(true)
+	return nil
 }
 
 // GenerateProof handles proof generation requests
 func (api *API) GenerateProof(c *gin.Context) {
-	var req ProofRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ProofResponse{
-			Success: false,
-			Error:   "Invalid request: " + err.Error(),
-		})
+	req, ok := api.bindProofRequest(c)
+	if !ok {
 		return
 	}
 
-	// Validate request
-	if err := validateProofRequest(&req); err != nil {
+	// Generate proof
+	response, err := api.circuitManager.GenerateProof(req)
+	if errors.Is(err, ErrCommitmentMismatch) {
 		c.JSON(http.StatusBadRequest, ProofResponse{
 			Success: false,
-			Error:   "Validation failed: " + err.Error(),
+			Error:   response.Error,
 		})
 		return
 	}
-
-	// Generate proof
-	response, err := api.circuitManager.GenerateProof(&req)
 	if err != nil {
 		// Log the error for debugging
 		fmt.Printf("ERROR: GenerateProof failed: %v\n", err)
@@ -72,7 +99,202 @@ func (api *API) GenerateProof(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	protoresp.OK(c, response)
+}
+
+// SubmitProofJob queues a proof request and returns its job ID without waiting for the proof
+// A full queue is refused with 503 and ERR_QUEUE_FULL, asking the client to retry later
+func (api *API) SubmitProofJob(c *gin.Context) {
+	req, ok := api.bindProofRequest(c)
+	if !ok {
+		return
+	}
+
+	id, err := api.jobs.Submit(req)
+	if errors.Is(err, ErrQueueFull) {
+		c.Header("Retry-After", strconv.Itoa(jobRetryAfterSeconds))
+		apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeQueueFull,
+			fmt.Sprintf("Proof job queue is full (%d waiting), retry later", api.jobs.Depth()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ProofJob{
+			Status: JobStatusFailed,
+			Error:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ProofJob{
+		ID:     id,
+		Status: JobStatusQueued,
+	})
+}
+
+// GetProofJob reports a proof job's status and, once done, its proof
+func (api *API) GetProofJob(c *gin.Context) {
+	job, ok := api.jobs.Get(c.Param("id"))
+	if !ok {
+		apierror.Abort(c, http.StatusNotFound, apierror.CodeNotFound, "Proof job "+c.Param("id")+" not found")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// bindProofRequest decodes and validates a proof request, writing the error response
+// and returning false when the request cannot be proved
+func (api *API) bindProofRequest(c *gin.Context) (*ProofRequest, bool) {
+	if !api.readiness.IsReady() {
+		c.JSON(http.StatusServiceUnavailable, ProofResponse{
+			Success: false,
+			Error:   "Prover is still initializing",
+		})
+		return nil, false
+	}
+
+	var req ProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ProofResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	// The proof format may be given in the body or as a query parameter
+	if req.Format == "" {
+		req.Format = c.Query("format")
+	}
+
+	if err := applyAccreditationDefault(&req, api.circuitManager.config); err != nil {
+		c.JSON(http.StatusBadRequest, ProofResponse{
+			Success: false,
+			Error:   "Validation failed: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	// Validate request
+	if err := validateProofRequest(&req, api.circuitManager.config.MaxAge); err != nil {
+		if errors.Is(err, ErrMerkleDepthMismatch) {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeMerkleDepthMismatch, "Validation failed: "+err.Error())
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, ProofResponse{
+			Success: false,
+			Error:   "Validation failed: " + err.Error(),
+		})
+		return nil, false
+	}
+	if err := validateDateFreshness(&req, time.Now(), api.circuitManager.config.AllowedClockSkew); err != nil {
+		c.JSON(http.StatusBadRequest, ProofResponse{
+			Success: false,
+			Error:   "Validation failed: " + err.Error(),
+		})
+		return nil, false
+	}
+	if config := api.circuitManager.config; config.StrictInputEntropy {
+		if err := validateInputEntropy(&req, config.MinInputBits); err != nil {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeLowEntropyInput, err.Error())
+			return nil, false
+		}
+	}
+
+	return &req, true
+}
+
+// VerifyWitness verifies a proof against public inputs given by name rather than as
+// the ordered hex array, for integrators that build the circuit struct themselves
+func (api *API) VerifyWitness(c *gin.Context) {
+	if !api.readiness.IsReady() {
+		c.JSON(http.StatusServiceUnavailable, VerifyWitnessResponse{
+			Success: false,
+			Error:   "Prover is still initializing",
+		})
+		return
+	}
+
+	var req VerifyWitnessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, VerifyWitnessResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Bundle != nil {
+		if err := req.applyBundle(api.circuitManager.version); err != nil {
+			c.JSON(http.StatusBadRequest, VerifyWitnessResponse{
+				Success: false,
+				Error:   "Invalid bundle: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	witness, err := req.PublicWitness.circuit()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, VerifyWitnessResponse{
+			Success: false,
+			Error:   "Validation failed: " + err.Error(),
+		})
+		return
+	}
+
+	proof, err := decodeProof(req.Proof, req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, VerifyWitnessResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// A proof that does not verify is a valid answer, not a request error
+	if err := api.circuitManager.VerifyProof(proof, witness); err != nil {
+		c.JSON(http.StatusOK, VerifyWitnessResponse{
+			Valid:   false,
+			Success: true,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyWitnessResponse{
+		Valid:   true,
+		Success: true,
+	})
+}
+
+// circuit builds the public-only circuit assignment, requiring every public input
+func (w *PublicWitness) circuit() (*circuit.KYCCircuit, error) {
+	// An omitted RelyingPartyID means an unbound proof, as in ProofRequest
+	if w.RelyingPartyID.Int == nil {
+		w.RelyingPartyID.Int = new(big.Int)
+	}
+	inputs := []struct {
+		name  string
+		value *big.Int
+	}{
+		{"MinAge", w.MinAge.Int},
+		{"JurisdictionRoot", w.JurisdictionRoot.Int},
+		{"RequireAccreditation", w.RequireAccreditation.Int},
+		{"Commitment", w.Commitment.Int},
+		{"RelyingPartyID", w.RelyingPartyID.Int},
+	}
+	for _, input := range inputs {
+		if err := validateFieldElement(input.name, input.value); err != nil {
+			return nil, err
+		}
+	}
+	return &circuit.KYCCircuit{
+		MinAge:               w.MinAge.Int,
+		JurisdictionRoot:     w.JurisdictionRoot.Int,
+		RequireAccreditation: w.RequireAccreditation.Int,
+		Commitment:           w.Commitment.Int,
+		RelyingPartyID:       w.RelyingPartyID.Int,
+	}, nil
 }
 
 // HealthCheck returns service health status
@@ -83,29 +305,164 @@ func (api *API) HealthCheck(c *gin.Context) {
 	})
 }
 
+// ErrMerkleDepthMismatch is returned when a Merkle path or its helper bits do not have
+// one entry per level of the compiled tree
+var ErrMerkleDepthMismatch = errors.New("merkle depth mismatch")
+
+// validateMerkleProof checks a Merkle path and its direction bits have the circuit depth
+// name prefixes the field names in errors ("merkle" gives merkle_path and merkle_helper)
+func validateMerkleProof(name string, path, helper []frontend.Variable) error {
+	if len(path) != merkleDepth {
+		return fmt.Errorf("%w: %s_path must have %d entries, got %d", ErrMerkleDepthMismatch, name, merkleDepth, len(path))
+	}
+	if len(helper) != len(path) {
+		return fmt.Errorf("%w: %s_helper must have the same length as %s_path (%d), got %d", ErrMerkleDepthMismatch, name, name, len(path), len(helper))
+	}
+	for i, v := range path {
+		if v == nil {
+			return fmt.Errorf("%s_path[%d] cannot be empty", name, i)
+		}
+	}
+	for i, v := range helper {
+		if !isBit(v) {
+			return fmt.Errorf("%s_helper[%d] must be 0 or 1, got %v", name, i, v)
+		}
+	}
+	return nil
+}
+
+// isBit reports whether a decoded JSON value is 0 or 1
+func isBit(v frontend.Variable) bool {
+	switch b := v.(type) {
+	case int:
+		return b == 0 || b == 1
+	case float64:
+		return b == 0 || b == 1
+	case string:
+		return b == "0" || b == "1"
+	case *big.Int:
+		return b != nil && (b.Sign() == 0 || b.Cmp(big.NewInt(1)) == 0)
+	default:
+		return false
+	}
+}
+
+// applyAccreditationDefault fills in an omitted require_accreditation from
+// DEFAULT_REQUIRE_ACCREDITATION, or rejects the request under STRICT_REQUIRE_ACCREDITATION
+// An explicit 0 (or null) is kept: only a missing field counts as omitted
+func applyAccreditationDefault(req *ProofRequest, config *Config) error {
+	if req.RequireAccreditation.Int != nil {
+		return nil
+	}
+	if config.StrictRequireAccreditation {
+		return fmt.Errorf("require_accreditation is required")
+	}
+	value := big.NewInt(0)
+	if config.DefaultRequireAccreditation {
+		value = big.NewInt(1)
+	}
+	req.RequireAccreditation = BigIntString{value}
+	return nil
+}
+
+// validateInputEntropy rejects a nonce or identity data shorter than minBits
+// Small values keep the commitment MiMC(identity_data, nonce) from hiding them
+func validateInputEntropy(req *ProofRequest, minBits int) error {
+	inputs := []struct {
+		name  string
+		value *big.Int
+	}{
+		{"nonce", req.Nonce.Int},
+		{"identity_data", req.IdentityData.Int},
+	}
+	// An attribute tree root is a hash, so only a client-chosen identity_data is checked
+	if req.Attributes != nil {
+		inputs = inputs[:1]
+	}
+	for _, input := range inputs {
+		if bits := input.value.BitLen(); bits < minBits {
+			return fmt.Errorf("%s has %d bits, at least %d are required", input.name, bits, minBits)
+		}
+	}
+	return nil
+}
+
 // validateProofRequest validates the proof request
-func validateProofRequest(req *ProofRequest) error {
-	if req.Age.Int == nil || req.Age.Sign() < 0 {
-		return fmt.Errorf("invalid age")
+// ageLimit is the circuit's compiled age limit (0 means circuit.MaxAge)
+func validateProofRequest(req *ProofRequest, ageLimit int) error {
+	maxAge := big.NewInt(int64(circuit.EffectiveAgeLimit(ageLimit)))
+	// With a birthdate the age is computed from it
+	if req.Birthdate == nil && (req.Age.Int == nil || req.Age.Sign() < 0 || req.Age.Cmp(maxAge) > 0) {
+		return fmt.Errorf("invalid age: must be between 0 and %s", maxAge)
 	}
-	if req.Jurisdiction.Int == nil {
-		return fmt.Errorf("invalid jurisdiction")
+	if err := validateFieldElement("jurisdiction", req.Jurisdiction.Int); err != nil {
+		return fmt.Errorf("invalid jurisdiction: %w", err)
 	}
-	if req.IdentityData.Int == nil {
-		return fmt.Errorf("invalid identity data")
+	// With attributes the identity data is their tree root
+	if req.Attributes == nil {
+		if err := validateFieldElement("identity_data", req.IdentityData.Int); err != nil {
+			return fmt.Errorf("invalid identity data: %w", err)
+		}
 	}
-	if req.Nonce.Int == nil {
-		return fmt.Errorf("invalid nonce")
+	if err := validateFieldElement("nonce", req.Nonce.Int); err != nil {
+		return fmt.Errorf("invalid nonce: %w", err)
 	}
-	if req.MinAge.Int == nil || req.MinAge.Sign() < 0 {
-		return fmt.Errorf("invalid min_age")
+	if req.RelyingPartyID.Int != nil {
+		if err := validateFieldElement("relying_party_id", req.RelyingPartyID.Int); err != nil {
+			return fmt.Errorf("invalid relying party ID: %w", err)
+		}
 	}
-	if req.JurisdictionRoot.Int == nil {
-		return fmt.Errorf("jurisdiction_root cannot be empty")
+	if req.MinAge.Int == nil || req.MinAge.Sign() < 0 || req.MinAge.Cmp(maxAge) > 0 {
+		return fmt.Errorf("invalid min_age: must be between 0 and %s", maxAge)
+	}
+	// Without a Merkle proof the path and root are taken from the server's jurisdiction list
+	if len(req.MerklePath) > 0 || len(req.MerkleHelper) > 0 {
+		if err := validateMerkleProof("merkle", req.MerklePath, req.MerkleHelper); err != nil {
+			return err
+		}
+		if req.JurisdictionRoot.Int == nil {
+			return fmt.Errorf("jurisdiction_root cannot be empty")
+		}
 	}
 	// Commitment can be empty (will be computed internally)
 	if req.Commitment.Int == nil {
 		return fmt.Errorf("invalid commitment")
 	}
+	if _, err := proofformat.Normalize(req.Format); err != nil {
+		return err
+	}
+	if req.Denylist != nil {
+		if err := validateDenylistProof(req.Denylist); err != nil {
+			return err
+		}
+	}
+	if req.Birthdate != nil {
+		if req.Denylist != nil {
+			return fmt.Errorf("birthdate and denylist proofs cannot be combined")
+		}
+		if err := validateBirthdate(req.Birthdate); err != nil {
+			return err
+		}
+		age := circuit.BirthdateAge(req.Birthdate.BirthdateDays.Int64(), req.Birthdate.ReferenceDateDays.Int64())
+		if big.NewInt(age).Cmp(maxAge) > 0 {
+			return fmt.Errorf("age from birthdate exceeds %s years", maxAge)
+		}
+	}
+	if req.Expiry != nil {
+		if req.Denylist != nil || req.Birthdate != nil {
+			return fmt.Errorf("expiry proofs cannot be combined with denylist or birthdate proofs")
+		}
+		if err := validateExpiry(req.Expiry, req.IdentityData.Int); err != nil {
+			return err
+		}
+	}
+	if req.Attributes != nil {
+		if req.Denylist != nil || req.Birthdate != nil || req.Expiry != nil {
+			return fmt.Errorf("attribute proofs cannot be combined with denylist, birthdate or expiry proofs")
+		}
+		if err := validateAttributes(req.Attributes); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/apispec"
+	"noah-v2/backend/pkg/health"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+)
+
+// TestUnknownRouteAndWrongMethod tests the JSON error bodies for 404 and 405
+func TestUnknownRouteAndWrongMethod(t *testing.T) {
+	config := testConfig(t)
+	router := setupRouter(NewAPI(config), config)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{http.MethodGet, "/does-not-exist", http.StatusNotFound, apierror.CodeNotFound},
+		{http.MethodGet, "/proof/generate", http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rec.Code)
+		}
+		var body apierror.APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: invalid JSON body %q: %v", tt.method, tt.path, rec.Body.String(), err)
+		}
+		if body.Code != tt.code || body.Success {
+			t.Errorf("%s %s: expected code %s, got %+v", tt.method, tt.path, tt.code, body)
+		}
+	}
+}
+
+// TestGenerateProofRejectsNonJSON tests that a form-encoded body gets a 415 before binding,
+// while JSON with a charset parameter is let through
+func TestGenerateProofRejectsNonJSON(t *testing.T) {
+	config := testConfig(t)
+	router := setupRouter(NewAPI(config), config)
+
+	req := httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader("age=25&min_age=18"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected status 415, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body apierror.APIError
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid JSON body %q: %v", rec.Body.String(), err)
+	}
+	if body.Code != apierror.CodeUnsupportedMediaType {
+		t.Errorf("Expected code %s, got %+v", apierror.CodeUnsupportedMediaType, body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code == http.StatusUnsupportedMediaType {
+		t.Errorf("Expected JSON with a charset to be accepted, got 415")
+	}
+}
+
+// TestReadinessReflectsInitialization tests that readiness is 503 until the circuit is initialized
+func TestReadinessReflectsInitialization(t *testing.T) {
+	api := &API{
+		circuitManager: &CircuitManager{config: newTestCircuitManager(t).config},
+		readiness:      health.NewReadiness(),
+	}
+	router := setupRouter(api, testConfig(t))
+
+	status := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := status("/health/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before initialization, got %d", code)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader("{}")))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected proof generation to return 503 before initialization, got %d", rec.Code)
+	}
+
+	if err := api.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	for _, path := range []string{"/health/ready", "/ready"} {
+		if code := status(path); code != http.StatusOK {
+			t.Errorf("Expected 200 from %s after initialization, got %d", path, code)
+		}
+	}
+}
+
+// TestOpenAPIDocumentsEveryRoute tests that the served document describes every registered route
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	config := testConfig(t)
+	config.AdminAPIKey = "test-admin-key"
+	router := setupRouter(NewAPI(config), config)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var doc apispec.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+	if missing := doc.Missing(router.Routes()); len(missing) > 0 {
+		t.Errorf("Routes missing from OpenAPI document: %v", missing)
+	}
+}
+
+// TestValidateProofRequestMerkleShape tests validation of the jurisdiction + Merkle path request shape
+func TestValidateProofRequestMerkleShape(t *testing.T) {
+	// A request decoded from JSON, as the handler sees it
+	valid := newTestProofRequest()
+	body, err := json.Marshal(valid)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	var decoded ProofRequest
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if err := validateProofRequest(&decoded, 0); err != nil {
+		t.Errorf("Expected decoded request to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(req *ProofRequest)
+		wantErr string
+	}{
+		{"valid", func(req *ProofRequest) {}, ""},
+		{"path from jurisdiction list", func(req *ProofRequest) {
+			req.MerklePath, req.MerkleHelper = nil, nil
+		}, ""},
+		{"path and helper too short", func(req *ProofRequest) {
+			req.MerklePath = req.MerklePath[:testMerkleDepth-1]
+			req.MerkleHelper = req.MerkleHelper[:testMerkleDepth-1]
+		}, "merkle_path must have 20 entries, got 19"},
+		{"helper shorter than path", func(req *ProofRequest) {
+			req.MerkleHelper = req.MerkleHelper[:testMerkleDepth-1]
+		}, "merkle_helper must have the same length"},
+		{"helper without path", func(req *ProofRequest) {
+			req.MerklePath = nil
+		}, "merkle_path must have"},
+		{"non-boolean helper bit", func(req *ProofRequest) {
+			req.MerkleHelper[3] = 2
+		}, "merkle_helper[3] must be 0 or 1"},
+		{"negative jurisdiction", func(req *ProofRequest) {
+			req.Jurisdiction = BigIntString{big.NewInt(-1)}
+		}, "invalid jurisdiction"},
+		{"identity data above field", func(req *ProofRequest) {
+			req.IdentityData = BigIntString{new(big.Int).Add(ecc.BN254.ScalarField(), big.NewInt(1))}
+		}, "invalid identity data"},
+		{"age above circuit maximum", func(req *ProofRequest) {
+			req.Age = BigIntString{big.NewInt(circuit.MaxAge + 1)}
+		}, "invalid age"},
+		{"min_age above circuit maximum", func(req *ProofRequest) {
+			req.MinAge = BigIntString{big.NewInt(circuit.MaxAge + 1)}
+		}, "invalid min_age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestProofRequest()
+			tt.modify(req)
+			err := validateProofRequest(req, 0)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid request, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// A configured CIRCUIT_MAX_AGE moves the bound
+	req := newTestProofRequest()
+	req.Age = BigIntString{big.NewInt(circuit.MaxAge + 1)}
+	if err := validateProofRequest(req, circuit.MaxAge+10); err != nil {
+		t.Errorf("Expected age %d to be valid under a raised limit, got %v", circuit.MaxAge+1, err)
+	}
+}
+
+// TestGenerateProofMerkleDepthMismatch tests that paths not matching the circuit depth are
+// refused with ERR_MERKLE_DEPTH_MISMATCH and the expected and actual lengths
+func TestGenerateProofMerkleDepthMismatch(t *testing.T) {
+	api := &API{circuitManager: &CircuitManager{config: &Config{}}, readiness: health.NewReadiness()}
+	api.readiness.SetReady(true)
+	router := setupRouter(api, testConfig(t))
+
+	tests := []struct {
+		name       string
+		pathLen    int
+		helperLen  int
+		wantDetail string
+	}{
+		{"too short", testMerkleDepth - 1, testMerkleDepth - 1, "merkle_path must have 20 entries, got 19"},
+		{"too long", testMerkleDepth + 1, testMerkleDepth + 1, "merkle_path must have 20 entries, got 21"},
+		{"helper length differs", testMerkleDepth, testMerkleDepth - 2, "as merkle_path (20), got 18"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proofReq := newTestProofRequest()
+			path := make([]frontend.Variable, tt.pathLen)
+			helper := make([]frontend.Variable, tt.helperLen)
+			for i := range path {
+				path[i] = "0"
+			}
+			for i := range helper {
+				helper[i] = "0"
+			}
+			proofReq.MerklePath, proofReq.MerkleHelper = path, helper
+			body, err := json.Marshal(proofReq)
+			if err != nil {
+				t.Fatalf("Failed to encode request: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var apiErr apierror.APIError
+			if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("Failed to decode error: %v", err)
+			}
+			if apiErr.Code != apierror.CodeMerkleDepthMismatch || !strings.Contains(apiErr.Error, tt.wantDetail) {
+				t.Errorf("Expected %s mentioning %q, got %+v", apierror.CodeMerkleDepthMismatch, tt.wantDetail, apiErr)
+			}
+		})
+	}
+}
+
+// TestGenerateProofInputEntropy tests that a tiny nonce is rejected only in strict mode
+func TestGenerateProofInputEntropy(t *testing.T) {
+	shared := newTestCircuitManager(t)
+	strictConfig := *shared.config
+	strictConfig.StrictInputEntropy = true
+	strictConfig.MinInputBits = 64
+
+	newAPI := func(cm *CircuitManager) *API {
+		api := &API{circuitManager: cm, readiness: health.NewReadiness()}
+		api.readiness.SetReady(true)
+		return api
+	}
+
+	// newTestProofRequest uses a 17-bit nonce and 14-bit identity data
+	proofReq := newTestProofRequest()
+	for i := range proofReq.MerklePath {
+		// Send path entries as decimal strings; JSON numbers decode to float64, which gnark rejects
+		proofReq.MerklePath[i] = fmt.Sprint(proofReq.MerklePath[i])
+		proofReq.MerkleHelper[i] = fmt.Sprint(proofReq.MerkleHelper[i])
+	}
+	body, err := json.Marshal(proofReq)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	post := func(api *API) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		setupRouter(api, testConfig(t)).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(newAPI(&CircuitManager{config: &strictConfig}))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 in strict mode, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var apiErr apierror.APIError
+	if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	if apiErr.Code != apierror.CodeLowEntropyInput || !strings.Contains(apiErr.Error, "nonce") {
+		t.Errorf("Expected %s for the nonce, got %+v", apierror.CodeLowEntropyInput, apiErr)
+	}
+
+	rec = post(newAPI(shared))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the check off, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestRequireAccreditationDefault tests present and missing require_accreditation under
+// strict and lenient modes
+func TestRequireAccreditationDefault(t *testing.T) {
+	proofReq := newTestProofRequest()
+	for i := range proofReq.MerklePath {
+		proofReq.MerklePath[i] = fmt.Sprint(proofReq.MerklePath[i])
+		proofReq.MerkleHelper[i] = fmt.Sprint(proofReq.MerkleHelper[i])
+	}
+	encode := func(value interface{}) []byte {
+		body, err := json.Marshal(proofReq)
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		delete(fields, "require_accreditation")
+		if value != nil {
+			fields["require_accreditation"] = value
+		}
+		body, _ = json.Marshal(fields)
+		return body
+	}
+
+	tests := []struct {
+		name    string
+		body    []byte
+		config  Config
+		want    int64
+		wantErr bool
+	}{
+		{"lenient present zero", encode("0"), Config{DefaultRequireAccreditation: true}, 0, false},
+		{"lenient present one", encode("1"), Config{}, 1, false},
+		{"lenient missing", encode(nil), Config{}, 0, false},
+		{"lenient missing with default", encode(nil), Config{DefaultRequireAccreditation: true}, 1, false},
+		{"strict present zero", encode("0"), Config{StrictRequireAccreditation: true}, 0, false},
+		{"strict present one", encode("1"), Config{StrictRequireAccreditation: true}, 1, false},
+		{"strict missing", encode(nil), Config{StrictRequireAccreditation: true, DefaultRequireAccreditation: true}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req ProofRequest
+			if err := json.Unmarshal(tt.body, &req); err != nil {
+				t.Fatalf("Failed to decode request: %v", err)
+			}
+			err := applyAccreditationDefault(&req, &tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected a missing require_accreditation to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if req.RequireAccreditation.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("Expected require_accreditation %d, got %s", tt.want, req.RequireAccreditation.String())
+			}
+		})
+	}
+
+	// Through the API, strict mode answers 400 before proving
+	strictConfig := *newTestCircuitManager(t).config
+	strictConfig.StrictRequireAccreditation = true
+	api := &API{circuitManager: &CircuitManager{config: &strictConfig}, readiness: health.NewReadiness()}
+	api.readiness.SetReady(true)
+	rec := httptest.NewRecorder()
+	httpReq := httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader(string(encode(nil))))
+	httpReq.Header.Set("Content-Type", "application/json")
+	setupRouter(api, testConfig(t)).ServeHTTP(rec, httpReq)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "require_accreditation") {
+		t.Errorf("Expected 400 for a missing require_accreditation, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestVerifyWitnessMatchesHexInputs tests that named public inputs verify exactly as the
+// ordered hex public inputs do for the same proof
+func TestVerifyWitnessMatchesHexInputs(t *testing.T) {
+	cm := newTestCircuitManager(t)
+	resp, err := cm.GenerateProof(newTestProofRequest())
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	api := &API{circuitManager: cm, readiness: health.NewReadiness()}
+	api.readiness.SetReady(true)
+	router := setupRouter(api, testConfig(t))
+
+	names := []string{"MinAge", "JurisdictionRoot", "RequireAccreditation", "Commitment", "RelyingPartyID"}
+	tests := []struct {
+		name   string
+		inputs []string
+	}{
+		{"valid", resp.PublicInputs},
+		{"tampered commitment", []string{resp.PublicInputs[0], resp.PublicInputs[1], resp.PublicInputs[2], "01", resp.PublicInputs[4]}},
+	}
+
+	for _, tt := range tests {
+		values := make([]*big.Int, len(names))
+		witness := make(map[string]string, len(names))
+		for i, name := range names {
+			v, ok := new(big.Int).SetString(tt.inputs[i], 16)
+			if !ok {
+				t.Fatalf("%s: invalid hex public input %q", tt.name, tt.inputs[i])
+			}
+			values[i] = v
+			witness[name] = v.String()
+		}
+
+		hexErr := cm.VerifyEncodedProof(resp.Proof, resp.ProofFormat, &circuit.KYCCircuit{
+			MinAge:               values[0],
+			JurisdictionRoot:     values[1],
+			RequireAccreditation: values[2],
+			Commitment:           values[3],
+			RelyingPartyID:       values[4],
+		})
+
+		body, err := json.Marshal(map[string]interface{}{
+			"proof":          resp.Proof,
+			"format":         resp.ProofFormat,
+			"public_witness": witness,
+		})
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/proof/verify-witness", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.name, rec.Code, rec.Body.String())
+		}
+		var got VerifyWitnessResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.name, err)
+		}
+
+		if got.Valid != (hexErr == nil) {
+			t.Errorf("%s: named witness valid=%v, hex inputs error %v", tt.name, got.Valid, hexErr)
+		}
+		if want := tt.name == "valid"; got.Valid != want {
+			t.Errorf("%s: expected valid=%v, got %+v", tt.name, want, got)
+		}
+	}
+
+	// Every public input is required
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/proof/verify-witness",
+		strings.NewReader(`{"proof":"`+resp.Proof+`","public_witness":{"MinAge":"18"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "JurisdictionRoot is required") {
+		t.Errorf("Expected 400 for a missing public input, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// attributeCircuit returns the compiled KYC + attributes variant, loading or generating its keys
+func (cm *CircuitManager) attributeCircuit() (*circuitVariant, error) {
+	return cm.loadVariant(cm.attributes, "attributes", &circuit.KYCAttributeCircuit{
+		KYCCircuit: circuit.KYCCircuit{
+			MerklePath:   make([]frontend.Variable, merkleDepth),
+			MerkleHelper: make([]frontend.Variable, merkleDepth),
+			AgeLimit:     cm.config.MaxAge,
+		},
+		AttributePath:   make([]frontend.Variable, circuit.AttributeTreeDepth),
+		AttributeHelper: make([]frontend.Variable, circuit.AttributeTreeDepth),
+	}, cm.config.AttributesProvingKeyPath, cm.config.AttributesVerifyingKeyPath)
+}
+
+// attributeTree builds the MiMC tree over the request's attributes, laid out like the
+// jurisdiction tree: leaves are MiMC(value) and empty leaves hash to zero
+func attributeTree(input *AttributeInput) (*JurisdictionTree, error) {
+	values := make([]*big.Int, len(input.Values))
+	for i, v := range input.Values {
+		values[i] = v.Int
+	}
+	return NewJurisdictionTree(values, circuit.AttributeTreeDepth)
+}
+
+// fillAttributeRoot sets identity_data to the root of the request's attribute tree, so the
+// commitment binds the whole attribute set
+func fillAttributeRoot(req *ProofRequest) (*JurisdictionTree, error) {
+	tree, err := attributeTree(req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	req.IdentityData = BigIntString{tree.Root()}
+	return tree, nil
+}
+
+// attributeAssignment extends a KYC witness with the Merkle proof of the disclosed attribute
+func attributeAssignment(kyc *circuit.KYCCircuit, input *AttributeInput, tree *JurisdictionTree) *circuit.KYCAttributeCircuit {
+	path, helper := tree.proofAt(input.Disclose)
+	return &circuit.KYCAttributeCircuit{
+		KYCCircuit:      *kyc,
+		AttributePath:   path,
+		AttributeHelper: helper,
+		AttributeIndex:  input.Disclose,
+		AttributeValue:  input.Values[input.Disclose].Int,
+	}
+}
+
+// validateAttributes checks every attribute is a field element, the set fits the
+// attribute tree and the disclosed index is within it
+func validateAttributes(input *AttributeInput) error {
+	if len(input.Values) == 0 {
+		return fmt.Errorf("attributes values cannot be empty")
+	}
+	if max := 1 << circuit.AttributeTreeDepth; len(input.Values) > max {
+		return fmt.Errorf("attributes has %d values, at most %d are supported", len(input.Values), max)
+	}
+	for i, v := range input.Values {
+		if err := validateFieldElement(fmt.Sprintf("attributes value %d", i), v.Int); err != nil {
+			return err
+		}
+	}
+	if input.Disclose < 0 || input.Disclose >= len(input.Values) {
+		return fmt.Errorf("attributes disclose index %d is out of range for %d values", input.Disclose, len(input.Values))
+	}
+	return nil
+}
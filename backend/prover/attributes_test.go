@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// newTestAttributeRequest discloses the jurisdiction from a set of three attributes
+func newTestAttributeRequest() *ProofRequest {
+	req := newTestProofRequest()
+	req.IdentityData = BigIntString{}
+	req.Attributes = &AttributeInput{
+		Values: []BigIntString{
+			{big.NewInt(25)},        // age
+			{big.NewInt(840)},       // jurisdiction
+			{big.NewInt(987654321)}, // document number
+		},
+		Disclose: 1,
+	}
+	return req
+}
+
+// TestGenerateProofWithAttributes tests that a proof over a committed attribute set
+// verifies with the disclosed attribute as public input, and fails for another value
+func TestGenerateProofWithAttributes(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	req := newTestAttributeRequest()
+	if err := validateProofRequest(req, 0); err != nil {
+		t.Fatalf("Expected valid request, got: %v", err)
+	}
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if len(resp.PublicInputs) != 7 || resp.PublicInputs[5] != "01" || resp.PublicInputs[6] != "0348" {
+		t.Fatalf("Expected attribute index and value as sixth and seventh public inputs, got %v", resp.PublicInputs)
+	}
+	if resp.CircuitVersion != cm.attributes.version {
+		t.Errorf("Expected attributes circuit version %s, got %s", cm.attributes.version, resp.CircuitVersion)
+	}
+
+	// The commitment binds the attribute tree root in place of identity data
+	tree, err := attributeTree(req.Attributes)
+	if err != nil {
+		t.Fatalf("Failed to build attribute tree: %v", err)
+	}
+	want := testMiMC(tree.Root(), req.Nonce.Int)
+	if commitment, ok := new(big.Int).SetString(resp.Commitment, 16); !ok || commitment.Cmp(want) != 0 {
+		t.Errorf("Expected commitment %x over the attribute root, got %s", want, resp.Commitment)
+	}
+
+	proofBytes, err := proofformat.Decode(resp.Proof, resp.ProofFormat)
+	if err != nil {
+		t.Fatalf("Failed to decode proof: %v", err)
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		t.Fatalf("Failed to deserialize proof: %v", err)
+	}
+	verify := func(value int64) error {
+		publicWitness, err := frontend.NewWitness(&circuit.KYCAttributeCircuit{
+			KYCCircuit:     *publicWitnessFor(t, req, resp),
+			AttributeIndex: 1,
+			AttributeValue: value,
+		}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+		if err != nil {
+			t.Fatalf("Failed to create public witness: %v", err)
+		}
+		return groth16.Verify(proof, cm.attributes.vk, publicWitness)
+	}
+	if err := verify(840); err != nil {
+		t.Errorf("Expected attribute proof to verify, got: %v", err)
+	}
+	if err := verify(124); err == nil {
+		t.Error("Expected verification with another attribute value to fail")
+	}
+}
+
+// TestValidateProofRequestAttributes tests the attribute request checks
+func TestValidateProofRequestAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ProofRequest)
+		errMsg string
+	}{
+		{"no values", func(r *ProofRequest) { r.Attributes.Values = nil }, "cannot be empty"},
+		{"too many values", func(r *ProofRequest) {
+			r.Attributes.Values = make([]BigIntString, 1<<circuit.AttributeTreeDepth+1)
+		}, "at most"},
+		{"missing value", func(r *ProofRequest) { r.Attributes.Values[2] = BigIntString{} }, "value 2 is required"},
+		{"disclose out of range", func(r *ProofRequest) { r.Attributes.Disclose = 3 }, "out of range"},
+		{"combined with birthdate", func(r *ProofRequest) {
+			r.Birthdate = newTestBirthdateRequest(big.NewInt(20000)).Birthdate
+		}, "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestAttributeRequest()
+			tt.modify(req)
+			err := validateProofRequest(req, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
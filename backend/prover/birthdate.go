@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// maxBirthdateAge is the largest age the birthdate circuit can compute
+const maxBirthdateAge = 255
+
+// birthdateCircuit returns the compiled KYC + birthdate variant, loading or generating its keys
+func (cm *CircuitManager) birthdateCircuit() (*circuitVariant, error) {
+	return cm.loadVariant(cm.birthdate, "birthdate", &circuit.KYCBirthdateCircuit{
+		KYCCircuit: circuit.KYCCircuit{
+			MerklePath:   make([]frontend.Variable, merkleDepth),
+			MerkleHelper: make([]frontend.Variable, merkleDepth),
+			AgeLimit:     cm.config.MaxAge,
+		},
+	}, cm.config.BirthdateProvingKeyPath, cm.config.BirthdateVerifyingKeyPath)
+}
+
+// birthdateAssignment extends a KYC witness with the request's birthdate and reference date
+func birthdateAssignment(kyc *circuit.KYCCircuit, input *BirthdateInput) *circuit.KYCBirthdateCircuit {
+	return &circuit.KYCBirthdateCircuit{
+		KYCCircuit:        *kyc,
+		BirthdateDays:     input.BirthdateDays.Int,
+		ReferenceDateDays: input.ReferenceDateDays.Int,
+	}
+}
+
+// validateBirthdate checks both dates are set, the reference date is not before the
+// birthdate or the epoch, and the resulting age is within the circuit's range
+func validateBirthdate(input *BirthdateInput) error {
+	birthdate, reference := input.BirthdateDays.Int, input.ReferenceDateDays.Int
+	if birthdate == nil || reference == nil {
+		return fmt.Errorf("birthdate_days and reference_date_days are required")
+	}
+	// Day counts beyond ±2^31 are millions of years away
+	if !birthdate.IsInt64() || birthdate.Int64() < math.MinInt32 || birthdate.Int64() > math.MaxInt32 {
+		return fmt.Errorf("birthdate_days is out of range")
+	}
+	if !reference.IsInt64() || reference.Sign() < 0 || reference.Int64() > math.MaxInt32 {
+		return fmt.Errorf("reference_date_days is out of range")
+	}
+	age := circuit.BirthdateAge(birthdate.Int64(), reference.Int64())
+	if age < 0 {
+		return fmt.Errorf("reference_date_days is before birthdate_days")
+	}
+	if age > maxBirthdateAge {
+		return fmt.Errorf("age from birthdate exceeds %d years", maxBirthdateAge)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// epochDays returns the days since the Unix epoch of a calendar date
+func epochDays(year int, month time.Month, day int) *big.Int {
+	return big.NewInt(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// newTestBirthdateRequest proves min_age 18 for someone born 2000-06-15 on the reference date
+func newTestBirthdateRequest(reference *big.Int) *ProofRequest {
+	req := newTestProofRequest()
+	req.Age = BigIntString{}
+	req.Birthdate = &BirthdateInput{
+		BirthdateDays:     BigIntString{epochDays(2000, time.June, 15)},
+		ReferenceDateDays: BigIntString{reference},
+	}
+	return req
+}
+
+// TestGenerateProofWithBirthdate tests that a birthdate proof verifies on the 18th birthday
+// with the reference date as sixth public input, and cannot be made one day earlier
+func TestGenerateProofWithBirthdate(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	req := newTestBirthdateRequest(epochDays(2018, time.June, 15))
+	if err := validateProofRequest(req, 0); err != nil {
+		t.Fatalf("Expected valid request, got: %v", err)
+	}
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if len(resp.PublicInputs) != 6 {
+		t.Fatalf("Expected 6 public inputs, got %d", len(resp.PublicInputs))
+	}
+	if resp.CircuitVersion != cm.birthdate.version {
+		t.Errorf("Expected birthdate circuit version %s, got %s", cm.birthdate.version, resp.CircuitVersion)
+	}
+
+	proofBytes, err := proofformat.Decode(resp.Proof, resp.ProofFormat)
+	if err != nil {
+		t.Fatalf("Failed to decode proof: %v", err)
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		t.Fatalf("Failed to deserialize proof: %v", err)
+	}
+	publicWitness, err := frontend.NewWitness(&circuit.KYCBirthdateCircuit{
+		KYCCircuit:        *publicWitnessFor(t, req, resp),
+		ReferenceDateDays: req.Birthdate.ReferenceDateDays.Int,
+	}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatalf("Failed to create public witness: %v", err)
+	}
+	if err := groth16.Verify(proof, cm.birthdate.vk, publicWitness); err != nil {
+		t.Errorf("Expected birthdate proof to verify, got: %v", err)
+	}
+
+	// One day short of 18
+	short := newTestBirthdateRequest(epochDays(2018, time.June, 14))
+	if _, err := cm.GenerateProof(short); err == nil {
+		t.Error("Expected error one day before the 18th birthday, got nil")
+	}
+}
+
+// TestValidateProofRequestBirthdate tests the birthdate request checks
+func TestValidateProofRequestBirthdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ProofRequest)
+		errMsg string
+	}{
+		{"missing reference", func(r *ProofRequest) { r.Birthdate.ReferenceDateDays = BigIntString{} }, "required"},
+		{"reference before birthdate", func(r *ProofRequest) {
+			r.Birthdate.ReferenceDateDays = BigIntString{epochDays(1999, time.January, 1)}
+		}, "before"},
+		{"negative reference", func(r *ProofRequest) { r.Birthdate.ReferenceDateDays = BigIntString{big.NewInt(-1)} }, "out of range"},
+		{"combined with denylist", func(r *ProofRequest) { r.Denylist = newTestDenylistProof(t, []int64{100}, 0) }, "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestBirthdateRequest(epochDays(2024, time.January, 1))
+			tt.modify(req)
+			err := validateProofRequest(req, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	// Birthdates before the epoch are negative
+	req := newTestBirthdateRequest(epochDays(2024, time.January, 1))
+	req.Birthdate.BirthdateDays = BigIntString{epochDays(1950, time.March, 3)}
+	if err := validateProofRequest(req, 0); err != nil {
+		t.Errorf("Expected a pre-epoch birthdate to be valid, got: %v", err)
+	}
+}
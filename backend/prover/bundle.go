@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"noah-v2/backend/pkg/proofbundle"
+	"noah-v2/backend/pkg/proofformat"
+)
+
+// baseInputCount is the number of public inputs of the base KYC circuit
+const baseInputCount = 5
+
+// applyBundle fills the proof and public witness of the request from its bundle, which
+// must be a groth16 proof for the base circuit with version circuitVersion
+func (r *VerifyWitnessRequest) applyBundle(circuitVersion string) error {
+	b := r.Bundle
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if r.Proof != "" {
+		return fmt.Errorf("bundle cannot be combined with proof")
+	}
+	if !strings.EqualFold(b.ProofSystem, proofbundle.ProofSystemGroth16) {
+		return fmt.Errorf("unsupported proof system %q", b.ProofSystem)
+	}
+	if b.CircuitHash != "" && b.CircuitHash != circuitVersion {
+		return fmt.Errorf("bundle is for circuit %s, this prover verifies %s", b.CircuitHash, circuitVersion)
+	}
+	if len(b.PublicInputs) != baseInputCount {
+		return fmt.Errorf("bundle has %d public inputs, expected %d", len(b.PublicInputs), baseInputCount)
+	}
+
+	values := make([]BigIntString, baseInputCount)
+	for i, input := range b.PublicInputs {
+		v, ok := new(big.Int).SetString(strings.TrimPrefix(input, "0x"), 16)
+		if !ok {
+			return fmt.Errorf("public input %d is not valid hex: %q", i, input)
+		}
+		values[i] = BigIntString{v}
+	}
+	r.Proof = b.EncodedProof()
+	r.Format = proofformat.Base64
+	r.PublicWitness = PublicWitness{
+		MinAge:               values[0],
+		JurisdictionRoot:     values[1],
+		RequireAccreditation: values[2],
+		Commitment:           values[3],
+		RelyingPartyID:       values[4],
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/proofbundle"
+)
+
+// TestProofBundleRoundTrip tests that the bundle of a generated proof survives
+// marshalling and verifies through /proof/verify-witness on its own
+func TestProofBundleRoundTrip(t *testing.T) {
+	cm := newTestCircuitManager(t)
+	resp, err := cm.GenerateProof(newTestProofRequest())
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if resp.Bundle == nil || resp.Bundle.CircuitHash != cm.version || resp.Bundle.EncodedProof() != resp.Proof {
+		t.Fatalf("Expected a bundle of the base circuit proof, got %+v", resp.Bundle)
+	}
+	data, err := proofbundle.Marshal(resp.Bundle)
+	if err != nil {
+		t.Fatalf("Failed to marshal bundle: %v", err)
+	}
+	bundle, err := proofbundle.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal bundle: %v", err)
+	}
+
+	api := &API{circuitManager: cm, readiness: health.NewReadiness()}
+	api.readiness.SetReady(true)
+	router := setupRouter(api, testConfig(t))
+	verify := func(req VerifyWitnessRequest) (int, VerifyWitnessResponse) {
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		httpReq := httptest.NewRequest(http.MethodPost, "/proof/verify-witness", bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, httpReq)
+		var got VerifyWitnessResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		return rec.Code, got
+	}
+
+	if code, got := verify(VerifyWitnessRequest{Bundle: bundle}); code != http.StatusOK || !got.Valid {
+		t.Errorf("Expected bundle to verify, got %d: %+v", code, got)
+	}
+
+	tampered := *bundle
+	tampered.PublicInputs = append([]string{"15"}, bundle.PublicInputs[1:]...)
+	if code, got := verify(VerifyWitnessRequest{Bundle: &tampered}); code != http.StatusOK || got.Valid {
+		t.Errorf("Expected tampered bundle to be invalid, got %d: %+v", code, got)
+	}
+
+	otherCircuit := *bundle
+	otherCircuit.CircuitHash = "0000"
+	if code, got := verify(VerifyWitnessRequest{Bundle: &otherCircuit}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for another circuit, got %d: %+v", code, got)
+	}
+	if code, got := verify(VerifyWitnessRequest{Proof: resp.Proof, Bundle: bundle}); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bundle combined with a proof, got %d: %+v", code, got)
+	}
+}
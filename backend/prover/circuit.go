@@ -2,45 +2,106 @@ package main
 
 import (
 	"bytes"
-	"encoding/base64"
+	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"time"
 
+	"noah-v2/backend/pkg/keymanifest"
+	"noah-v2/backend/pkg/metrics"
+	"noah-v2/backend/pkg/proofbundle"
+	"noah-v2/backend/pkg/proofformat"
 	"noah-v2/circuit"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// ErrCommitmentMismatch is returned in strict mode when the request's commitment
+// differs from the one computed from its identity data and nonce
+var ErrCommitmentMismatch = errors.New("commitment mismatch")
+
+// merkleDepth is the jurisdiction Merkle tree depth the circuit is compiled for
+// Depth 20 supports up to 2^20 = 1M jurisdictions
+const merkleDepth = 20
+
 // CircuitManager handles circuit compilation and proof generation
 type CircuitManager struct {
-	ccs         constraint.ConstraintSystem
-	pk          groth16.ProvingKey
-	vk          groth16.VerifyingKey
-	initialized bool
-	config      *Config
+	ccs               constraint.ConstraintSystem
+	version           string // Circuit hash, matching the verifying key manifest
+	pk                groth16.ProvingKey
+	vk                groth16.VerifyingKey
+	initialized       bool
+	config            *Config
+	jurisdictionTrees *JurisdictionTreeCache
+	jurisdictionList  *JurisdictionListSource // Set when the list is loaded from JURISDICTION_LIST_URL
+	denylist          *circuitVariant         // KYC + denylist circuit, compiled on first use
+	birthdate         *circuitVariant         // KYC + birthdate circuit, compiled on first use
+	expiry            *circuitVariant         // KYC + expiry circuit, compiled on first use
+	attributes        *circuitVariant         // KYC + attributes circuit, compiled on first use
+	proverOpts        []backend.ProverOption  // Solver settings passed to every groth16.Prove call
+	witnessBuffers    *witnessPool            // Scratch Merkle slices for server-built jurisdiction proofs
 }
 
-// NewCircuitManager creates a new circuit manager
-func NewCircuitManager() *CircuitManager {
+// NewCircuitManager creates a new circuit manager from a config main has validated
+func NewCircuitManager(config *Config) *CircuitManager {
 	return &CircuitManager{
-		initialized: false,
-		config:      LoadConfig(),
+		initialized:       false,
+		config:            config,
+		jurisdictionTrees: NewJurisdictionTreeCache(merkleDepth),
+		witnessBuffers:    newWitnessPool(merkleDepth),
+		denylist:          &circuitVariant{},
+		birthdate:         &circuitVariant{},
+		expiry:            &circuitVariant{},
+		attributes:        &circuitVariant{},
+		proverOpts:        proverOptions(config),
 	}
 }
 
 // Initialize compiles the circuit and loads/generates keys
 func (cm *CircuitManager) Initialize() error {
+	if cm.jurisdictionTrees == nil {
+		cm.jurisdictionTrees = NewJurisdictionTreeCache(merkleDepth)
+	}
+	if cm.witnessBuffers == nil {
+		cm.witnessBuffers = newWitnessPool(merkleDepth)
+	}
+	if cm.denylist == nil {
+		cm.denylist = &circuitVariant{}
+	}
+	if cm.birthdate == nil {
+		cm.birthdate = &circuitVariant{}
+	}
+	if cm.expiry == nil {
+		cm.expiry = &circuitVariant{}
+	}
+	if cm.attributes == nil {
+		cm.attributes = &circuitVariant{}
+	}
+
+	// Load the jurisdiction list from its URL before serving, then keep it fresh
+	if cm.config.JurisdictionListURL != "" && cm.jurisdictionList == nil {
+		source := NewJurisdictionListSource(cm.config.JurisdictionListURL, cm.config.JurisdictionListPath, cm.jurisdictionTrees)
+		if _, err := source.Refresh(context.Background()); err != nil {
+			return fmt.Errorf("failed to load jurisdiction list: %w", err)
+		}
+		if cm.config.JurisdictionListRefresh > 0 {
+			source.Start(context.Background(), cm.config.JurisdictionListRefresh)
+		}
+		cm.jurisdictionList = source
+	}
+
 	// Compile the circuit
 	// Note: gnark requires fixed-size arrays for compilation
-	// We use Merkle proofs for jurisdiction verification (depth 20 supports up to 2^20 = 1M jurisdictions)
-	merkleDepth := 20
-
+	// We use Merkle proofs for jurisdiction verification (see merkleDepth)
 	kycCircuit := &circuit.KYCCircuit{
 		// Private inputs
 		Age:          0,
@@ -56,6 +117,9 @@ func (cm *CircuitManager) Initialize() error {
 		JurisdictionRoot:     0,
 		RequireAccreditation: 0,
 		Commitment:           0,
+		RelyingPartyID:       0,
+		// Compile-time bound on Age
+		AgeLimit: cm.config.MaxAge,
 	}
 
 	// Get the scalar field for BN254 curve (used by Groth16)
@@ -66,6 +130,9 @@ func (cm *CircuitManager) Initialize() error {
 	if err != nil {
 		return fmt.Errorf("failed to compile circuit: %w", err)
 	}
+	if cm.version, err = circuitVersion(cm.ccs); err != nil {
+		return err
+	}
 
 	// Try to load keys from files, generate if they don't exist
 	if err := cm.loadKeys(); err != nil {
@@ -92,39 +159,49 @@ func (cm *CircuitManager) Initialize() error {
 
 // loadKeys loads proving and verifying keys from files
 func (cm *CircuitManager) loadKeys() error {
+	pk, vk, err := loadKeyPair(cm.config.ProvingKeyPath, cm.config.VerifyingKeyPath)
+	if err != nil {
+		return err
+	}
+	cm.pk, cm.vk = pk, vk
+	return nil
+}
+
+// loadKeyPair reads a proving and verifying key pair from files
+func loadKeyPair(provingKeyPath, verifyingKeyPath string) (groth16.ProvingKey, groth16.VerifyingKey, error) {
 	// Check if key files exist
-	if _, err := os.Stat(cm.config.ProvingKeyPath); os.IsNotExist(err) {
-		return fmt.Errorf("proving key file does not exist")
+	if _, err := os.Stat(provingKeyPath); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("proving key file does not exist")
 	}
-	if _, err := os.Stat(cm.config.VerifyingKeyPath); os.IsNotExist(err) {
-		return fmt.Errorf("verifying key file does not exist")
+	if _, err := os.Stat(verifyingKeyPath); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("verifying key file does not exist")
 	}
 
 	// Load proving key
-	pkFile, err := os.Open(cm.config.ProvingKeyPath)
+	pkFile, err := os.Open(provingKeyPath)
 	if err != nil {
-		return fmt.Errorf("failed to open proving key file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open proving key file: %w", err)
 	}
 	defer pkFile.Close()
 
-	cm.pk = groth16.NewProvingKey(ecc.BN254)
-	if _, err := cm.pk.ReadFrom(pkFile); err != nil {
-		return fmt.Errorf("failed to read proving key: %w", err)
+	pk := groth16.NewProvingKey(ecc.BN254)
+	if _, err := pk.ReadFrom(pkFile); err != nil {
+		return nil, nil, fmt.Errorf("failed to read proving key: %w", err)
 	}
 
 	// Load verifying key
-	vkFile, err := os.Open(cm.config.VerifyingKeyPath)
+	vkFile, err := os.Open(verifyingKeyPath)
 	if err != nil {
-		return fmt.Errorf("failed to open verifying key file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open verifying key file: %w", err)
 	}
 	defer vkFile.Close()
 
-	cm.vk = groth16.NewVerifyingKey(ecc.BN254)
-	if _, err := cm.vk.ReadFrom(vkFile); err != nil {
-		return fmt.Errorf("failed to read verifying key: %w", err)
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(vkFile); err != nil {
+		return nil, nil, fmt.Errorf("failed to read verifying key: %w", err)
 	}
 
-	return nil
+	return pk, vk, nil
 }
 
 // GenerateProof generates a Groth16 proof for the given witness
@@ -137,6 +214,33 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 
 	// Create witness from request
 	// The circuit now uses Merkle proofs for jurisdiction verification
+	// If the client did not send a Merkle proof, build it from the configured list
+	// The path is built into pooled buffers, released (and cleared from req) on return
+	if len(req.MerklePath) == 0 {
+		buffers, err := cm.fillJurisdictionProof(req)
+		if err != nil {
+			return &ProofResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		defer func() {
+			req.MerklePath, req.MerkleHelper = nil, nil
+			cm.witnessBuffers.put(buffers)
+		}()
+	}
+
+	// With attributes the identity data is the root of their tree
+	var attributes *JurisdictionTree
+	if req.Attributes != nil {
+		var err error
+		if attributes, err = fillAttributeRoot(req); err != nil {
+			return &ProofResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+	}
 
 	// Compute the commitment from identity data and nonce (matches circuit logic)
 	// The circuit computes: MiMC(IdentityData || Nonce)
@@ -148,9 +252,29 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 		}, err
 	}
 
+	// A provided non-zero commitment that disagrees is replaced, with a warning, or rejected in strict mode
+	var warning string
+	if req.Commitment.Int != nil && req.Commitment.Sign() != 0 && req.Commitment.Cmp(computedCommitment) != 0 {
+		mismatch := fmt.Sprintf("provided commitment %s does not match commitment %s computed from identity_data and nonce",
+			req.Commitment.Text(16), computedCommitment.Text(16))
+		if cm.config.StrictCommitment {
+			return &ProofResponse{
+				Success: false,
+				Error:   mismatch,
+			}, fmt.Errorf("%w: %s", ErrCommitmentMismatch, mismatch)
+		}
+		warning = mismatch + "; the computed commitment was used"
+	}
+
+	// With a birthdate the age is derived from it; the circuit checks the derivation
+	age := req.Age.Int
+	if req.Birthdate != nil {
+		age = big.NewInt(circuit.BirthdateAge(req.Birthdate.BirthdateDays.Int64(), req.Birthdate.ReferenceDateDays.Int64()))
+	}
+
 	witnessData := &circuit.KYCCircuit{
 		// Private inputs
-		Age:          req.Age.Int,
+		Age:          age,
 		Jurisdiction: req.Jurisdiction.Int,
 		IsAccredited: req.IsAccredited.Int,
 		IdentityData: req.IdentityData.Int,
@@ -163,11 +287,63 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 		JurisdictionRoot:     req.JurisdictionRoot.Int,
 		RequireAccreditation: req.RequireAccreditation.Int,
 		Commitment:           computedCommitment, // Use computed commitment
+		RelyingPartyID:       req.relyingParty(),
+	}
+
+	// A denylist proof switches to the KYC + denylist circuit variant
+	var assignment frontend.Circuit = witnessData
+	ccs, pk, version := cm.ccs, cm.pk, cm.version
+	if req.Denylist != nil {
+		variant, err := cm.denylistCircuit()
+		if err != nil {
+			return &ProofResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		assignment = denylistAssignment(witnessData, req.Denylist)
+		ccs, pk, version = variant.ccs, variant.pk, variant.version
+	}
+	// A birthdate switches to the KYC + birthdate circuit variant
+	if req.Birthdate != nil {
+		variant, err := cm.birthdateCircuit()
+		if err != nil {
+			return &ProofResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		assignment = birthdateAssignment(witnessData, req.Birthdate)
+		ccs, pk, version = variant.ccs, variant.pk, variant.version
+	}
+	// An expiry switches to the KYC + expiry circuit variant
+	if req.Expiry != nil {
+		variant, err := cm.expiryCircuit()
+		if err != nil {
+			return &ProofResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		assignment = expiryAssignment(witnessData, req.Expiry)
+		ccs, pk, version = variant.ccs, variant.pk, variant.version
+	}
+	// Attributes switch to the KYC + attributes circuit variant
+	if req.Attributes != nil {
+		variant, err := cm.attributeCircuit()
+		if err != nil {
+			return &ProofResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		assignment = attributeAssignment(witnessData, req.Attributes, attributes)
+		ccs, pk, version = variant.ccs, variant.pk, variant.version
 	}
 
 	// Create full witness (with both private and public inputs)
 	field := ecc.BN254.ScalarField()
-	witnessFull, err := frontend.NewWitness(witnessData, field)
+	witnessFull, err := frontend.NewWitness(assignment, field)
 	if err != nil {
 		return &ProofResponse{
 			Success: false,
@@ -176,7 +352,7 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 	}
 
 	// Generate proof
-	proof, err := groth16.Prove(cm.ccs, cm.pk, witnessFull)
+	proof, err := cm.prove(ccs, pk, witnessFull)
 	if err != nil {
 		return &ProofResponse{
 			Success: false,
@@ -192,8 +368,21 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 			Error:   fmt.Sprintf("proof serialization failed: %v", err),
 		}, err
 	}
-	// Encode to base64 for JSON transport
-	proofBytes := base64.StdEncoding.EncodeToString(proofBuf.Bytes())
+	// Encode for JSON transport (base64 unless hex was requested)
+	proofFormat, err := proofformat.Normalize(req.Format)
+	if err != nil {
+		return &ProofResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+	proofBytes, err := proofformat.Encode(proofBuf.Bytes(), proofFormat)
+	if err != nil {
+		return &ProofResponse{
+			Success: false,
+			Error:   fmt.Sprintf("proof encoding failed: %v", err),
+		}, err
+	}
 
 	// Extract public witness for public inputs
 	publicWitness, err := witnessFull.Public()
@@ -205,7 +394,7 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 	}
 
 	// Extract public inputs from witness in the correct order
-	// New optimized circuit public inputs: MinAge, JurisdictionRoot, RequireAccreditation, Commitment
+	// New optimized circuit public inputs: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID
 	publicInputs := make([]string, 0)
 
 	// padHex ensures hex string is even length by padding with leading zero if needed
@@ -238,6 +427,31 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 	commitmentHex := padHex(computedCommitment.Text(16))
 	publicInputs = append(publicInputs, commitmentHex)
 
+	// Add RelyingPartyID
+	publicInputs = append(publicInputs, padHex(req.relyingParty().Text(16)))
+
+	// Add DenylistRoot (denylist variant only)
+	if req.Denylist != nil {
+		publicInputs = append(publicInputs, padHex(req.Denylist.Root.Int.Text(16)))
+	}
+
+	// Add ReferenceDateDays (birthdate variant only)
+	if req.Birthdate != nil {
+		publicInputs = append(publicInputs, padHex(req.Birthdate.ReferenceDateDays.Int.Text(16)))
+	}
+
+	// Add CurrentDays (expiry variant only)
+	if req.Expiry != nil {
+		publicInputs = append(publicInputs, padHex(req.Expiry.CurrentDays.Int.Text(16)))
+	}
+
+	// Add AttributeIndex and AttributeValue (attributes variant only)
+	if req.Attributes != nil {
+		publicInputs = append(publicInputs,
+			padHex(big.NewInt(int64(req.Attributes.Disclose)).Text(16)),
+			padHex(req.Attributes.Values[req.Attributes.Disclose].Text(16)))
+	}
+
 	// #region agent log
 	logEntry2 := fmt.Sprintf(`{"sessionId":"debug-session","runId":"run1","hypothesisId":"A","location":"circuit.go:278","message":"Final public inputs (optimized)","data":{"totalCount":%d,"minAge":"%s","jurisdictionRoot":"%s","requireAccred":"%s","commitment":"%s"},"timestamp":%d}`+"\n", len(publicInputs), minAgeHex, jurisdictionRootHex, requireAccredHex, commitmentHex, time.Now().UnixMilli())
 	logFile.WriteString(logEntry2)
@@ -248,13 +462,61 @@ func (cm *CircuitManager) GenerateProof(req *ProofRequest) (*ProofResponse, erro
 
 	// padHex ensures hex string is even length (defined earlier in function)
 	return &ProofResponse{
-		Proof:        proofBytes, // Base64 encoded binary proof
-		PublicInputs: publicInputs,
-		Commitment:   padHex(computedCommitment.Text(16)), // Use computed commitment
-		Success:      true,
+		Proof:          proofBytes, // Encoded binary proof
+		ProofFormat:    proofFormat,
+		PublicInputs:   publicInputs,
+		Commitment:     padHex(computedCommitment.Text(16)), // Use computed commitment
+		CircuitVersion: version,
+		Bundle:         proofbundle.New(version, proofbundle.ProofSystemGroth16, proofBuf.Bytes(), publicInputs),
+		Warning:        warning,
+		Success:        true,
 	}, nil
 }
 
+// fillJurisdictionProof sets the Merkle path, helper and root for the request's
+// jurisdiction from the configured jurisdiction list (server-side path); the path and
+// helper live in the returned pooled buffers until they are put back
+func (cm *CircuitManager) fillJurisdictionProof(req *ProofRequest) (*witnessBuffers, error) {
+	tree, err := cm.jurisdictionTree()
+	if err != nil {
+		return nil, err
+	}
+
+	root := tree.Root()
+	if req.JurisdictionRoot.Int != nil && req.JurisdictionRoot.Cmp(root) != 0 {
+		return nil, fmt.Errorf("jurisdiction_root does not match the configured jurisdiction list")
+	}
+
+	buffers := cm.witnessBuffers.get(tree.depth)
+	if err := tree.ProofInto(req.Jurisdiction.Int, buffers.path, buffers.helper); err != nil {
+		cm.witnessBuffers.put(buffers)
+		return nil, err
+	}
+
+	req.MerklePath = buffers.path
+	req.MerkleHelper = buffers.helper
+	req.JurisdictionRoot = BigIntString{root}
+	return buffers, nil
+}
+
+// jurisdictionTree returns the tree of the configured jurisdiction list (URL or file)
+func (cm *CircuitManager) jurisdictionTree() (*JurisdictionTree, error) {
+	if cm.jurisdictionList != nil {
+		if tree := cm.jurisdictionList.Tree(); tree != nil {
+			return tree, nil
+		}
+	}
+	if cm.config.JurisdictionListPath == "" {
+		return nil, fmt.Errorf("merkle_path is required when no jurisdiction list is configured")
+	}
+
+	tree, err := cm.jurisdictionTrees.FromFile(cm.config.JurisdictionListPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jurisdiction tree: %w", err)
+	}
+	return tree, nil
+}
+
 // VerifyProof verifies a proof using the stored verifying key
 // This is a helper that takes the public witness directly (from frontend.NewWitness().Public())
 func (cm *CircuitManager) VerifyProof(proof groth16.Proof, publicWitnessData *circuit.KYCCircuit) error {
@@ -275,29 +537,46 @@ func (cm *CircuitManager) VerifyProof(proof groth16.Proof, publicWitnessData *ci
 		return fmt.Errorf("failed to extract public witness: %w", err)
 	}
 
-	return groth16.Verify(proof, cm.vk, pubWitness)
+	start := time.Now()
+	err = groth16.Verify(proof, cm.vk, pubWitness)
+	metrics.RecordProofVerification(time.Since(start), err == nil)
+	return err
 }
 
 // VerifyProofFromBase64 verifies a proof from a base64-encoded string
 // publicWitnessData should be the circuit struct with only public fields set
 func (cm *CircuitManager) VerifyProofFromBase64(proofBase64 string, publicWitnessData *circuit.KYCCircuit) error {
+	return cm.VerifyEncodedProof(proofBase64, proofformat.Base64, publicWitnessData)
+}
+
+// VerifyEncodedProof verifies a proof encoded in the given format ("base64" or "hex")
+// publicWitnessData should be the circuit struct with only public fields set
+func (cm *CircuitManager) VerifyEncodedProof(encodedProof, format string, publicWitnessData *circuit.KYCCircuit) error {
 	if !cm.initialized {
 		return fmt.Errorf("circuit manager not initialized")
 	}
 
-	// Decode base64 proof
-	proofBytes, err := base64.StdEncoding.DecodeString(proofBase64)
+	proof, err := decodeProof(encodedProof, format)
+	if err != nil {
+		return err
+	}
+	return cm.VerifyProof(proof, publicWitnessData)
+}
+
+// decodeProof decodes and deserializes a proof encoded as "base64" or "hex"
+func decodeProof(encodedProof, format string) (groth16.Proof, error) {
+	// Decode proof, checking the content matches the declared format
+	proofBytes, err := proofformat.Decode(encodedProof, format)
 	if err != nil {
-		return fmt.Errorf("failed to decode proof: %w", err)
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
 	}
 
 	// Deserialize proof
 	proof := groth16.NewProof(ecc.BN254)
 	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
-		return fmt.Errorf("failed to deserialize proof: %w", err)
+		return nil, fmt.Errorf("failed to deserialize proof: %w", err)
 	}
-
-	return cm.VerifyProof(proof, publicWitnessData)
+	return proof, nil
 }
 
 // SaveKeys saves proving and verifying keys to files
@@ -305,7 +584,11 @@ func (cm *CircuitManager) SaveKeys(provingKeyPath, verifyingKeyPath string) erro
 	if !cm.initialized {
 		return fmt.Errorf("circuit manager not initialized")
 	}
+	return cm.saveKeyPair(cm.ccs, cm.pk, cm.vk, provingKeyPath, verifyingKeyPath)
+}
 
+// saveKeyPair writes a key pair and the verifying key's manifest for the given circuit
+func (cm *CircuitManager) saveKeyPair(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, vk groth16.VerifyingKey, provingKeyPath, verifyingKeyPath string) error {
 	// Create directories if they don't exist
 	keyDir := filepath.Dir(provingKeyPath)
 	if err := os.MkdirAll(keyDir, 0755); err != nil {
@@ -319,20 +602,62 @@ func (cm *CircuitManager) SaveKeys(provingKeyPath, verifyingKeyPath string) erro
 	}
 	defer pkFile.Close()
 
-	if _, err := cm.pk.WriteTo(pkFile); err != nil {
+	if _, err := pk.WriteTo(pkFile); err != nil {
 		return fmt.Errorf("failed to write proving key: %w", err)
 	}
 
-	// Save verifying key (can be world-readable as it's public)
-	vkFile, err := os.OpenFile(verifyingKeyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to create verifying key file: %w", err)
+	// Serialize verifying key once so the manifest hashes exactly what is written
+	var vkBuf bytes.Buffer
+	if _, err := vk.WriteTo(&vkBuf); err != nil {
+		return fmt.Errorf("failed to serialize verifying key: %w", err)
 	}
-	defer vkFile.Close()
 
-	if _, err := cm.vk.WriteTo(vkFile); err != nil {
+	// Save verifying key (can be world-readable as it's public)
+	if err := os.WriteFile(verifyingKeyPath, vkBuf.Bytes(), 0644); err != nil {
 		return fmt.Errorf("failed to write verifying key: %w", err)
 	}
 
+	return cm.writeKeyManifest(ccs, verifyingKeyPath, vkBuf.Bytes())
+}
+
+// circuitVersion returns the hash identifying a compiled circuit
+// It equals the circuit_hash in the manifest written with the circuit's verifying key
+func circuitVersion(ccs constraint.ConstraintSystem) (string, error) {
+	var ccsBuf bytes.Buffer
+	if _, err := ccs.WriteTo(&ccsBuf); err != nil {
+		return "", fmt.Errorf("failed to serialize circuit: %w", err)
+	}
+	return keymanifest.CircuitHash(ccsBuf.Bytes()), nil
+}
+
+// writeKeyManifest writes the provenance manifest next to the verifying key
+// and, if a signing key is configured, a detached signature over the key bytes
+func (cm *CircuitManager) writeKeyManifest(ccs constraint.ConstraintSystem, verifyingKeyPath string, vkBytes []byte) error {
+	var ccsBuf bytes.Buffer
+	if _, err := ccs.WriteTo(&ccsBuf); err != nil {
+		return fmt.Errorf("failed to serialize circuit: %w", err)
+	}
+
+	manifest := keymanifest.New(ccsBuf.Bytes(), vkBytes, "groth16", ecc.BN254.String())
+	if err := keymanifest.Write(keymanifest.ManifestPath(verifyingKeyPath), manifest); err != nil {
+		return err
+	}
+
+	if cm.config.ManifestSigningKey == "" {
+		return nil
+	}
+
+	signingKey, err := crypto.HexToECDSA(cm.config.ManifestSigningKey)
+	if err != nil {
+		return fmt.Errorf("invalid manifest signing key: %w", err)
+	}
+	signature, err := keymanifest.Sign(signingKey, vkBytes)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keymanifest.SignaturePath(verifyingKeyPath), []byte(signature), 0644); err != nil {
+		return fmt.Errorf("failed to write verifying key signature: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/gin-gonic/gin"
+)
+
+const testMerkleDepth = 20
+
+var (
+	testKeyDir      string
+	testManagerOnce sync.Once
+	testManager     *CircuitManager
+	testManagerErr  error
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := logger.Initialize(logger.Config{Level: "error", Service: "prover-test"}); err != nil {
+		panic(err)
+	}
+
+	dir, err := os.MkdirTemp("", "prover-keys-")
+	if err != nil {
+		panic(err)
+	}
+	testKeyDir = dir
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestCircuitManager returns a circuit manager with freshly generated keys
+// Setup is expensive, so the manager is shared by all tests in the package
+func newTestCircuitManager(t testing.TB) *CircuitManager {
+	t.Helper()
+	testManagerOnce.Do(func() {
+		testManager = &CircuitManager{
+			config: &Config{
+				ProvingKeyPath:             filepath.Join(testKeyDir, "proving.key"),
+				VerifyingKeyPath:           filepath.Join(testKeyDir, "verifying.key"),
+				DenylistProvingKeyPath:     filepath.Join(testKeyDir, "denylist_proving.key"),
+				DenylistVerifyingKeyPath:   filepath.Join(testKeyDir, "denylist_verifying.key"),
+				BirthdateProvingKeyPath:    filepath.Join(testKeyDir, "birthdate_proving.key"),
+				BirthdateVerifyingKeyPath:  filepath.Join(testKeyDir, "birthdate_verifying.key"),
+				ExpiryProvingKeyPath:       filepath.Join(testKeyDir, "expiry_proving.key"),
+				ExpiryVerifyingKeyPath:     filepath.Join(testKeyDir, "expiry_verifying.key"),
+				AttributesProvingKeyPath:   filepath.Join(testKeyDir, "attributes_proving.key"),
+				AttributesVerifyingKeyPath: filepath.Join(testKeyDir, "attributes_verifying.key"),
+			},
+		}
+		testManagerErr = testManager.Initialize()
+	})
+	if testManagerErr != nil {
+		t.Fatalf("Failed to initialize circuit manager: %v", testManagerErr)
+	}
+	return testManager
+}
+
+// testMiMC hashes the given field elements with MiMC as the circuit does
+func testMiMC(values ...*big.Int) *big.Int {
+	h := hash.MIMC_BN254.New()
+	for _, v := range values {
+		var e fr.Element
+		e.SetBigInt(v)
+		b := e.Bytes()
+		h.Write(b[:])
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// newTestProofRequest builds a valid request proving membership of jurisdiction 1
+// at index 0 of a depth-20 tree whose siblings are all zero
+func newTestProofRequest() *ProofRequest {
+	jurisdiction := big.NewInt(1)
+
+	path := make([]frontend.Variable, testMerkleDepth)
+	helper := make([]frontend.Variable, testMerkleDepth)
+	node := testMiMC(jurisdiction)
+	for i := 0; i < testMerkleDepth; i++ {
+		path[i] = big.NewInt(0)
+		helper[i] = 0
+		node = testMiMC(node, big.NewInt(0))
+	}
+
+	return &ProofRequest{
+		Age:                  BigIntString{big.NewInt(25)},
+		Jurisdiction:         BigIntString{jurisdiction},
+		IsAccredited:         BigIntString{big.NewInt(1)},
+		IdentityData:         BigIntString{big.NewInt(12345)},
+		Nonce:                BigIntString{big.NewInt(67890)},
+		MerklePath:           path,
+		MerkleHelper:         helper,
+		MinAge:               BigIntString{big.NewInt(18)},
+		JurisdictionRoot:     BigIntString{node},
+		RequireAccreditation: BigIntString{big.NewInt(1)},
+		Commitment:           BigIntString{big.NewInt(0)},
+	}
+}
+
+// publicWitnessFor builds the public-only circuit assignment from a proof response
+func publicWitnessFor(t *testing.T, req *ProofRequest, resp *ProofResponse) *circuit.KYCCircuit {
+	t.Helper()
+	commitment, ok := new(big.Int).SetString(resp.Commitment, 16)
+	if !ok {
+		t.Fatalf("Invalid commitment in response: %s", resp.Commitment)
+	}
+	return &circuit.KYCCircuit{
+		MinAge:               req.MinAge.Int,
+		JurisdictionRoot:     req.JurisdictionRoot.Int,
+		RequireAccreditation: req.RequireAccreditation.Int,
+		Commitment:           commitment,
+		RelyingPartyID:       req.relyingParty(),
+	}
+}
+
+// TestGenerateProofHexRoundTrip tests that a hex-encoded proof verifies and is rejected as base64
+func TestGenerateProofHexRoundTrip(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	req := newTestProofRequest()
+	req.Format = proofformat.Hex
+
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if resp.ProofFormat != proofformat.Hex {
+		t.Errorf("Expected proof format %q, got %q", proofformat.Hex, resp.ProofFormat)
+	}
+
+	publicWitness := publicWitnessFor(t, req, resp)
+	if err := cm.VerifyEncodedProof(resp.Proof, proofformat.Hex, publicWitness); err != nil {
+		t.Errorf("Expected hex proof to verify, got: %v", err)
+	}
+
+	if err := cm.VerifyEncodedProof(resp.Proof, proofformat.Base64, publicWitness); err == nil {
+		t.Error("Expected error when hex proof is declared as base64, got nil")
+	}
+}
+
+// TestGenerateProofCommitmentMismatch tests matching, lenient and strict handling of a provided commitment
+func TestGenerateProofCommitmentMismatch(t *testing.T) {
+	cm := newTestCircuitManager(t)
+	t.Cleanup(func() { cm.config.StrictCommitment = false })
+	computed := testMiMC(big.NewInt(12345), big.NewInt(67890))
+
+	tests := []struct {
+		name        string
+		commitment  *big.Int
+		strict      bool
+		wantErr     bool
+		wantWarning bool
+	}{
+		{"matching", computed, true, false, false},
+		{"zero means compute", big.NewInt(0), true, false, false},
+		{"mismatch lenient", big.NewInt(42), false, false, true},
+		{"mismatch strict", big.NewInt(42), true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm.config.StrictCommitment = tt.strict
+			req := newTestProofRequest()
+			req.Commitment = BigIntString{tt.commitment}
+
+			resp, err := cm.GenerateProof(req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCommitmentMismatch) {
+					t.Fatalf("Expected ErrCommitmentMismatch, got %v", err)
+				}
+				if resp.Success || !strings.Contains(resp.Error, "does not match") {
+					t.Errorf("Expected mismatch error response, got %+v", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to generate proof: %v", err)
+			}
+			if got := resp.Warning != ""; got != tt.wantWarning {
+				t.Errorf("Expected warning=%v, got %q", tt.wantWarning, resp.Warning)
+			}
+			if got, ok := new(big.Int).SetString(resp.Commitment, 16); !ok || got.Cmp(computed) != 0 {
+				t.Errorf("Expected computed commitment %x, got %s", computed, resp.Commitment)
+			}
+		})
+	}
+}
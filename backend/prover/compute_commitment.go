@@ -1,35 +1,57 @@
 package main
 
 import (
+	"fmt"
 	"math/big"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/hash"
 )
 
+// validateFieldElement checks that v is a canonical BN254 scalar field element, in [0, r)
+// Larger values would be reduced modulo r by the circuit but not by byte-level MiMC,
+// so the two would silently disagree
+func validateFieldElement(name string, v *big.Int) error {
+	if v == nil {
+		return fmt.Errorf("%s is required", name)
+	}
+	if v.Sign() < 0 || v.Cmp(ecc.BN254.ScalarField()) >= 0 {
+		return fmt.Errorf("%s is not a field element: must be between 0 and the BN254 scalar field modulus", name)
+	}
+	return nil
+}
+
 // computeCommitment computes the MiMC hash of identity data and nonce
 // This matches the circuit's commitment computation: MiMC(IdentityData || Nonce)
 // MiMC expects field elements (32 bytes for BN254), so we need to pad the input
 func computeCommitment(identityData, nonce *big.Int) (*big.Int, error) {
+	if err := validateFieldElement("identity_data", identityData); err != nil {
+		return nil, err
+	}
+	if err := validateFieldElement("nonce", nonce); err != nil {
+		return nil, err
+	}
+
 	mimc := hash.MIMC_BN254.New()
-	
+
 	// MiMC expects field elements (32 bytes for BN254)
 	// Pad identity data to 32 bytes
 	identityBytes := make([]byte, 32)
 	identityDataBytes := identityData.Bytes()
 	copy(identityBytes[32-len(identityDataBytes):], identityDataBytes)
 	mimc.Write(identityBytes)
-	
+
 	// Pad nonce to 32 bytes
 	nonceBytes := make([]byte, 32)
 	nonceDataBytes := nonce.Bytes()
 	copy(nonceBytes[32-len(nonceDataBytes):], nonceDataBytes)
 	mimc.Write(nonceBytes)
-	
+
 	// Compute hash
 	hashBytes := mimc.Sum(nil)
-	
+
 	// Convert to big.Int
 	commitment := new(big.Int).SetBytes(hashBytes)
-	
+
 	return commitment, nil
 }
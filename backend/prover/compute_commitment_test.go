@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// TestComputeCommitmentFieldRange tests that inputs must be canonical field elements,
+// so an over-large value cannot produce a commitment the circuit disagrees with
+func TestComputeCommitmentFieldRange(t *testing.T) {
+	modulus := ecc.BN254.ScalarField()
+	largest := new(big.Int).Sub(modulus, big.NewInt(1))
+
+	commitment, err := computeCommitment(largest, big.NewInt(7))
+	if err != nil {
+		t.Fatalf("Expected an in-field value to be accepted, got %v", err)
+	}
+	if want := testMiMC(largest, big.NewInt(7)); commitment.Cmp(want) != 0 {
+		t.Errorf("Expected commitment %s, got %s", want, commitment)
+	}
+
+	tests := []struct {
+		name                string
+		identityData, nonce *big.Int
+		wantErr             string
+	}{
+		{"identity data equal to modulus", modulus, big.NewInt(7), "identity_data is not a field element"},
+		{"nonce above modulus", big.NewInt(7), new(big.Int).Add(modulus, big.NewInt(5)), "nonce is not a field element"},
+		{"negative nonce", big.NewInt(7), big.NewInt(-1), "nonce is not a field element"},
+		{"missing identity data", nil, big.NewInt(7), "identity_data is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := computeCommitment(tt.identityData, tt.nonce)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
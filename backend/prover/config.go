@@ -1,31 +1,185 @@
 package main
 
 import (
-	"os"
+	"time"
+
+	"noah-v2/backend/pkg/envconfig"
+	"noah-v2/backend/pkg/listeners"
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/metrics"
+	"noah-v2/backend/pkg/middleware"
+	"noah-v2/backend/pkg/tlsconfig"
+	"noah-v2/circuit"
+
+	"go.uber.org/zap"
 )
 
 // Config holds the prover service configuration
 type Config struct {
-	Port            string
-	CircuitPath     string
-	ProvingKeyPath  string
-	VerifyingKeyPath string
+	Port string
+	// MetricsPort also serves /metrics on its own listener; empty serves it on Port only
+	MetricsPort        string
+	CircuitPath        string
+	ProvingKeyPath     string
+	VerifyingKeyPath   string
+	ManifestSigningKey string // Optional hex secp256k1 key used to sign the verifying key
+	// Keys for the KYC + denylist circuit variant, generated on first use
+	DenylistProvingKeyPath   string
+	DenylistVerifyingKeyPath string
+	// Keys for the KYC + birthdate circuit variant, generated on first use
+	BirthdateProvingKeyPath   string
+	BirthdateVerifyingKeyPath string
+	// Keys for the KYC + expiry circuit variant, generated on first use
+	ExpiryProvingKeyPath   string
+	ExpiryVerifyingKeyPath string
+	// Keys for the KYC + attributes circuit variant, generated on first use
+	AttributesProvingKeyPath   string
+	AttributesVerifyingKeyPath string
+	// JurisdictionListPath is an optional JSON array of allowed jurisdiction codes
+	// used to build Merkle proofs for requests that omit merkle_path
+	JurisdictionListPath string
+	// JurisdictionListURL optionally serves the same JSON array over HTTP(S); when set it
+	// takes precedence and JurisdictionListPath is only a fallback if the first fetch fails
+	JurisdictionListURL     string
+	JurisdictionListRefresh time.Duration // Re-fetch interval for JurisdictionListURL (0 disables)
+	RateLimitMaxIPs         int           // Maximum number of per-IP rate limiters kept in memory
+	CORSAllowedOrigins      []string      // Origins browsers may call the API from, with credentials
+	AdminAPIKey             string        // Enables /admin endpoints behind the X-API-Key header when set
+	ProveNbCPU              int           // Maximum CPUs used for proving (0 uses all CPUs)
+	ProveSolverLog          bool          // Whether the constraint solver logs circuit debug output
+	// StrictCommitment rejects requests whose commitment differs from the one computed
+	// from identity_data and nonce; otherwise the response carries a warning
+	StrictCommitment bool
+	// StrictInputEntropy rejects nonce and identity_data values shorter than MinInputBits,
+	// which would let the commitment be brute-forced
+	StrictInputEntropy bool
+	MinInputBits       int
+	// MaxAge is the largest provable age, compiled into every KYC circuit; the attester
+	// must use the same value (0 means circuit.MaxAge)
+	MaxAge int
+	// DefaultRequireAccreditation is used for requests that omit require_accreditation;
+	// StrictRequireAccreditation rejects such requests instead
+	DefaultRequireAccreditation bool
+	StrictRequireAccreditation  bool
+	// TLSCertFile and TLSKeyFile serve HTTPS when both are set
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSMinVersion string // Oldest TLS version accepted: 1.0, 1.1, 1.2 or 1.3
+	// SelfTestOnStart proves and verifies a known witness before serving, so a proving key
+	// that does not match the verifying key or circuit fails startup instead of requests
+	SelfTestOnStart bool
+	// ProofJobQueueSize bounds the proof jobs waiting in /proof/jobs; further submissions get 503
+	ProofJobQueueSize int
+	// ProofDurationBuckets are the proof_generation_duration_seconds bucket bounds in seconds
+	ProofDurationBuckets []float64
+	// AllowedClockSkew is how far a request's date may be from ours and still count as
+	// today, absorbing client clock drift around midnight
+	AllowedClockSkew time.Duration
+	// SlowRequestThreshold logs requests taking longer at Warn (0 disables)
+	SlowRequestThreshold time.Duration
+	// OTLPEndpoint is the OTLP/HTTP collector base URL spans are exported to (empty disables tracing)
+	OTLPEndpoint string
 }
 
 // LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
-	return &Config{
-		Port:            getEnv("PROVER_PORT", "8080"),
-		CircuitPath:     getEnv("CIRCUIT_PATH", "./circuit"),
-		ProvingKeyPath:  getEnv("PROVING_KEY_PATH", "./keys/proving.key"),
-		VerifyingKeyPath: getEnv("VERIFYING_KEY_PATH", "./keys/verifying.key"),
+// Malformed numeric, boolean or duration values are reported, one error per key, rather
+// than silently replaced by their defaults; the returned config still holds the defaults
+func LoadConfig() (*Config, error) {
+	var env envconfig.Parser
+	config := &Config{
+		Port:                        envconfig.Get("PROVER_PORT", "8080"),
+		MetricsPort:                 envconfig.Get("METRICS_PORT", ""),
+		CircuitPath:                 envconfig.Get("CIRCUIT_PATH", "./circuit"),
+		ProvingKeyPath:              envconfig.Get("PROVING_KEY_PATH", "./keys/proving.key"),
+		VerifyingKeyPath:            envconfig.Get("VERIFYING_KEY_PATH", "./keys/verifying.key"),
+		ManifestSigningKey:          envconfig.Get("MANIFEST_SIGNING_KEY", ""),
+		DenylistProvingKeyPath:      envconfig.Get("DENYLIST_PROVING_KEY_PATH", "./keys/denylist_proving.key"),
+		DenylistVerifyingKeyPath:    envconfig.Get("DENYLIST_VERIFYING_KEY_PATH", "./keys/denylist_verifying.key"),
+		BirthdateProvingKeyPath:     envconfig.Get("BIRTHDATE_PROVING_KEY_PATH", "./keys/birthdate_proving.key"),
+		BirthdateVerifyingKeyPath:   envconfig.Get("BIRTHDATE_VERIFYING_KEY_PATH", "./keys/birthdate_verifying.key"),
+		ExpiryProvingKeyPath:        envconfig.Get("EXPIRY_PROVING_KEY_PATH", "./keys/expiry_proving.key"),
+		ExpiryVerifyingKeyPath:      envconfig.Get("EXPIRY_VERIFYING_KEY_PATH", "./keys/expiry_verifying.key"),
+		AttributesProvingKeyPath:    envconfig.Get("ATTRIBUTES_PROVING_KEY_PATH", "./keys/attributes_proving.key"),
+		AttributesVerifyingKeyPath:  envconfig.Get("ATTRIBUTES_VERIFYING_KEY_PATH", "./keys/attributes_verifying.key"),
+		JurisdictionListPath:        envconfig.Get("JURISDICTION_LIST_PATH", ""),
+		JurisdictionListURL:         envconfig.Get("JURISDICTION_LIST_URL", ""),
+		JurisdictionListRefresh:     env.Duration("JURISDICTION_LIST_REFRESH", 0),
+		AdminAPIKey:                 envconfig.Get("ADMIN_API_KEY", ""),
+		RateLimitMaxIPs:             env.Int("RATE_LIMIT_MAX_IPS", middleware.DefaultMaxTrackedIPs),
+		CORSAllowedOrigins:          envconfig.GetList("CORS_ALLOWED_ORIGINS", middleware.DefaultCORSOrigins),
+		ProveNbCPU:                  env.Int("PROVE_NB_CPU", 0),
+		ProveSolverLog:              env.Bool("PROVE_SOLVER_LOG", true),
+		StrictCommitment:            env.Bool("STRICT_COMMITMENT_CHECK", false),
+		StrictInputEntropy:          env.Bool("STRICT_INPUT_ENTROPY", false),
+		MinInputBits:                env.Int("MIN_INPUT_ENTROPY_BITS", 128),
+		MaxAge:                      env.Int("CIRCUIT_MAX_AGE", circuit.MaxAge),
+		DefaultRequireAccreditation: env.Bool("DEFAULT_REQUIRE_ACCREDITATION", false),
+		StrictRequireAccreditation:  env.Bool("STRICT_REQUIRE_ACCREDITATION", false),
+		TLSCertFile:                 envconfig.Get("TLS_CERT_FILE", ""),
+		TLSKeyFile:                  envconfig.Get("TLS_KEY_FILE", ""),
+		TLSMinVersion:               envconfig.Get("TLS_MIN_VERSION", tlsconfig.DefaultMinVersion),
+		SelfTestOnStart:             env.Bool("SELF_TEST_ON_START", false),
+		ProofJobQueueSize:           env.Int("PROOF_JOB_QUEUE_SIZE", 16),
+		ProofDurationBuckets:        env.Floats("PROOF_DURATION_BUCKETS", metrics.DefaultProofGenerationBuckets),
+		AllowedClockSkew:            env.Duration("ALLOWED_CLOCK_SKEW", 5*time.Minute),
+		SlowRequestThreshold:        env.Duration("SLOW_REQUEST_THRESHOLD", 0),
+		OTLPEndpoint:                envconfig.Get("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
 	}
+	return config, env.Err()
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Listeners returns the ports the service listens on, by setting
+func (c *Config) Listeners() []listeners.Listener {
+	return []listeners.Listener{
+		{Name: "PROVER_PORT", Port: c.Port},
+		{Name: "METRICS_PORT", Port: c.MetricsPort},
 	}
-	return defaultValue
 }
 
+// LogSafe logs the effective configuration with secrets redacted
+func (c *Config) LogSafe() {
+	logger.Info("Effective configuration", c.LogFields()...)
+}
+
+// LogFields returns every configuration value as a log field, with secrets redacted
+func (c *Config) LogFields() []zap.Field {
+	return []zap.Field{
+		zap.String("port", c.Port),
+		zap.String("metrics_port", c.MetricsPort),
+		zap.String("circuit_path", c.CircuitPath),
+		zap.String("proving_key_path", c.ProvingKeyPath),
+		zap.String("verifying_key_path", c.VerifyingKeyPath),
+		zap.String("manifest_signing_key", envconfig.Redacted(c.ManifestSigningKey)),
+		zap.String("denylist_proving_key_path", c.DenylistProvingKeyPath),
+		zap.String("denylist_verifying_key_path", c.DenylistVerifyingKeyPath),
+		zap.String("birthdate_proving_key_path", c.BirthdateProvingKeyPath),
+		zap.String("birthdate_verifying_key_path", c.BirthdateVerifyingKeyPath),
+		zap.String("expiry_proving_key_path", c.ExpiryProvingKeyPath),
+		zap.String("expiry_verifying_key_path", c.ExpiryVerifyingKeyPath),
+		zap.String("attributes_proving_key_path", c.AttributesProvingKeyPath),
+		zap.String("attributes_verifying_key_path", c.AttributesVerifyingKeyPath),
+		zap.String("jurisdiction_list_path", c.JurisdictionListPath),
+		zap.String("jurisdiction_list_url", envconfig.RedactedURL(c.JurisdictionListURL)),
+		zap.Duration("jurisdiction_list_refresh", c.JurisdictionListRefresh),
+		zap.Int("rate_limit_max_ips", c.RateLimitMaxIPs),
+		zap.Strings("cors_allowed_origins", c.CORSAllowedOrigins),
+		zap.String("admin_api_key", envconfig.Redacted(c.AdminAPIKey)),
+		zap.Int("prove_nb_cpu", c.ProveNbCPU),
+		zap.Bool("prove_solver_log", c.ProveSolverLog),
+		zap.Bool("strict_commitment", c.StrictCommitment),
+		zap.Bool("strict_input_entropy", c.StrictInputEntropy),
+		zap.Int("min_input_bits", c.MinInputBits),
+		zap.Int("max_age", c.MaxAge),
+		zap.Bool("default_require_accreditation", c.DefaultRequireAccreditation),
+		zap.Bool("strict_require_accreditation", c.StrictRequireAccreditation),
+		zap.String("tls_cert_file", c.TLSCertFile),
+		zap.String("tls_key_file", c.TLSKeyFile),
+		zap.String("tls_min_version", c.TLSMinVersion),
+		zap.Bool("self_test_on_start", c.SelfTestOnStart),
+		zap.Int("proof_job_queue_size", c.ProofJobQueueSize),
+		zap.Float64s("proof_duration_buckets", c.ProofDurationBuckets),
+		zap.Duration("allowed_clock_skew", c.AllowedClockSkew),
+		zap.Duration("slow_request_threshold", c.SlowRequestThreshold),
+		zap.String("otlp_endpoint", envconfig.RedactedURL(c.OTLPEndpoint)),
+	}
+}
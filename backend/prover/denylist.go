@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// denylistCircuit returns the compiled KYC + denylist variant, loading or generating its keys
+func (cm *CircuitManager) denylistCircuit() (*circuitVariant, error) {
+	return cm.loadVariant(cm.denylist, "denylist", &circuit.KYCDenylistCircuit{
+		KYCCircuit: circuit.KYCCircuit{
+			MerklePath:   make([]frontend.Variable, merkleDepth),
+			MerkleHelper: make([]frontend.Variable, merkleDepth),
+			AgeLimit:     cm.config.MaxAge,
+		},
+		DenylistLowPath:    make([]frontend.Variable, merkleDepth),
+		DenylistLowHelper:  make([]frontend.Variable, merkleDepth),
+		DenylistHighPath:   make([]frontend.Variable, merkleDepth),
+		DenylistHighHelper: make([]frontend.Variable, merkleDepth),
+	}, cm.config.DenylistProvingKeyPath, cm.config.DenylistVerifyingKeyPath)
+}
+
+// denylistAssignment extends a KYC witness with the request's denylist non-membership proof
+func denylistAssignment(kyc *circuit.KYCCircuit, proof *DenylistProof) *circuit.KYCDenylistCircuit {
+	return &circuit.KYCDenylistCircuit{
+		KYCCircuit:         *kyc,
+		DenylistLow:        proof.Low.Int,
+		DenylistHigh:       proof.High.Int,
+		DenylistLowPath:    proof.LowPath,
+		DenylistLowHelper:  proof.LowHelper,
+		DenylistHighPath:   proof.HighPath,
+		DenylistHighHelper: proof.HighHelper,
+		DenylistRoot:       proof.Root.Int,
+	}
+}
+
+// validateDenylistProof checks a denylist proof has every field at the compiled depth
+func validateDenylistProof(proof *DenylistProof) error {
+	if proof.Root.Int == nil {
+		return fmt.Errorf("denylist root cannot be empty")
+	}
+	if proof.Low.Int == nil || proof.High.Int == nil {
+		return fmt.Errorf("denylist low and high leaves are required")
+	}
+	if err := validateMerkleProof("denylist low", proof.LowPath, proof.LowHelper); err != nil {
+		return err
+	}
+	return validateMerkleProof("denylist high", proof.HighPath, proof.HighHelper)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// newTestDenylistProof builds a denylist proof over the sorted tree [0, codes..., 2^62]
+// using the leaves at lowIndex and lowIndex+1
+func newTestDenylistProof(t *testing.T, codes []int64, lowIndex int) *DenylistProof {
+	t.Helper()
+	leaves := []*big.Int{big.NewInt(0)}
+	for _, code := range codes {
+		leaves = append(leaves, big.NewInt(code))
+	}
+	leaves = append(leaves, new(big.Int).Lsh(big.NewInt(1), 62))
+
+	tree, err := NewJurisdictionTree(leaves, testMerkleDepth)
+	if err != nil {
+		t.Fatalf("Failed to build denylist tree: %v", err)
+	}
+	low, high := leaves[lowIndex], leaves[lowIndex+1]
+	lowPath, lowHelper, err := tree.Proof(low)
+	if err != nil {
+		t.Fatalf("Failed to build low leaf proof: %v", err)
+	}
+	highPath, highHelper, err := tree.Proof(high)
+	if err != nil {
+		t.Fatalf("Failed to build high leaf proof: %v", err)
+	}
+
+	return &DenylistProof{
+		Root:       BigIntString{tree.Root()},
+		Low:        BigIntString{low},
+		High:       BigIntString{high},
+		LowPath:    lowPath,
+		LowHelper:  lowHelper,
+		HighPath:   highPath,
+		HighHelper: highHelper,
+	}
+}
+
+// TestGenerateProofWithDenylist tests that a jurisdiction outside the denylist proves
+// with the denylist root as sixth public input
+func TestGenerateProofWithDenylist(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	// Jurisdiction 1 falls between the lower sentinel and 100
+	req := newTestProofRequest()
+	req.Denylist = newTestDenylistProof(t, []int64{100, 200}, 0)
+	if err := validateProofRequest(req, 0); err != nil {
+		t.Fatalf("Expected valid request, got: %v", err)
+	}
+
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if len(resp.PublicInputs) != 6 {
+		t.Fatalf("Expected 6 public inputs, got %d", len(resp.PublicInputs))
+	}
+
+	proofBytes, err := proofformat.Decode(resp.Proof, resp.ProofFormat)
+	if err != nil {
+		t.Fatalf("Failed to decode proof: %v", err)
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		t.Fatalf("Failed to deserialize proof: %v", err)
+	}
+
+	kyc := publicWitnessFor(t, req, resp)
+	publicWitness, err := frontend.NewWitness(&circuit.KYCDenylistCircuit{
+		KYCCircuit:   *kyc,
+		DenylistRoot: req.Denylist.Root.Int,
+	}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatalf("Failed to create public witness: %v", err)
+	}
+	if err := groth16.Verify(proof, cm.denylist.vk, publicWitness); err != nil {
+		t.Errorf("Expected denylist proof to verify, got: %v", err)
+	}
+}
+
+// TestGenerateProofRejectsDenylistedJurisdiction tests that a denylisted jurisdiction cannot prove
+func TestGenerateProofRejectsDenylistedJurisdiction(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	// Jurisdiction 1 is itself a denylist leaf, so leaves 0 and 1 do not surround it
+	req := newTestProofRequest()
+	req.Denylist = newTestDenylistProof(t, []int64{1, 100}, 0)
+
+	if _, err := cm.GenerateProof(req); err == nil {
+		t.Error("Expected error for denylisted jurisdiction, got nil")
+	}
+}
+
+// TestValidateProofRequestDenylistDepth tests that denylist paths must match the circuit depth
+func TestValidateProofRequestDenylistDepth(t *testing.T) {
+	req := newTestProofRequest()
+	req.Denylist = newTestDenylistProof(t, []int64{100}, 0)
+	req.Denylist.HighHelper = req.Denylist.HighHelper[:1]
+
+	if err := validateProofRequest(req, 0); err == nil {
+		t.Error("Expected error for short denylist helper, got nil")
+	}
+}
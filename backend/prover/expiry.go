@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// expiryCircuit returns the compiled KYC + expiry variant, loading or generating its keys
+func (cm *CircuitManager) expiryCircuit() (*circuitVariant, error) {
+	return cm.loadVariant(cm.expiry, "expiry", &circuit.KYCExpiryCircuit{
+		KYCCircuit: circuit.KYCCircuit{
+			MerklePath:   make([]frontend.Variable, merkleDepth),
+			MerkleHelper: make([]frontend.Variable, merkleDepth),
+			AgeLimit:     cm.config.MaxAge,
+		},
+	}, cm.config.ExpiryProvingKeyPath, cm.config.ExpiryVerifyingKeyPath)
+}
+
+// expiryAssignment extends a KYC witness with the request's expiry and current date
+func expiryAssignment(kyc *circuit.KYCCircuit, input *ExpiryInput) *circuit.KYCExpiryCircuit {
+	return &circuit.KYCExpiryCircuit{
+		KYCCircuit:     *kyc,
+		CredentialData: input.CredentialData.Int,
+		ExpiryDays:     input.ExpiryDays.Int,
+		CurrentDays:    input.CurrentDays.Int,
+	}
+}
+
+// expiryIdentityData returns MiMC(credentialData, expiryDays), the identity data of a
+// credential whose expiry is committed (see circuit.ExpiryIdentityData)
+func expiryIdentityData(credentialData, expiryDays *big.Int) *big.Int {
+	return mimcHash(credentialData, expiryDays)
+}
+
+// validateExpiry checks both dates are set and within the circuit's range, that
+// identityData commits to the expiry and that the credential has not expired, none of
+// which the circuit could prove otherwise
+func validateExpiry(input *ExpiryInput, identityData *big.Int) error {
+	expiry, current := input.ExpiryDays.Int, input.CurrentDays.Int
+	if expiry == nil || current == nil {
+		return fmt.Errorf("expiry_days and current_days are required")
+	}
+	if err := validateFieldElement("credential_data", input.CredentialData.Int); err != nil {
+		return err
+	}
+	if expiry.Sign() < 0 || expiry.Cmp(big.NewInt(math.MaxUint32)) > 0 {
+		return fmt.Errorf("expiry_days is out of range")
+	}
+	if current.Sign() < 0 || current.Cmp(big.NewInt(math.MaxUint32)) > 0 {
+		return fmt.Errorf("current_days is out of range")
+	}
+	if identityData != nil && expiryIdentityData(input.CredentialData.Int, expiry).Cmp(identityData) != 0 {
+		return fmt.Errorf("identity_data does not commit to expiry_days: it must be MiMC(credential_data, expiry_days)")
+	}
+	if expiry.Cmp(current) < 0 {
+		return fmt.Errorf("credential expired: expiry_days is before current_days")
+	}
+	return nil
+}
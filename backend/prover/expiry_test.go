@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/keymanifest"
+	"noah-v2/backend/pkg/proofformat"
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+)
+
+// newTestExpiryRequest proves a credential expiring on 2025-01-01 is valid on current;
+// identity_data commits to the expiry, as an issuer binding it computes
+func newTestExpiryRequest(current *big.Int) *ProofRequest {
+	req := newTestProofRequest()
+	expiry := epochDays(2025, time.January, 1)
+	req.Expiry = &ExpiryInput{
+		CredentialData: req.IdentityData,
+		ExpiryDays:     BigIntString{expiry},
+		CurrentDays:    BigIntString{current},
+	}
+	req.IdentityData = BigIntString{expiryIdentityData(req.Expiry.CredentialData.Int, expiry)}
+	return req
+}
+
+// TestGenerateProofWithExpiry tests that an expiry proof verifies on the expiry day with
+// the current date as sixth public input, and cannot be made the day after
+func TestGenerateProofWithExpiry(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	req := newTestExpiryRequest(epochDays(2025, time.January, 1))
+	if err := validateProofRequest(req, 0); err != nil {
+		t.Fatalf("Expected valid request, got: %v", err)
+	}
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if len(resp.PublicInputs) != 6 {
+		t.Fatalf("Expected 6 public inputs, got %d", len(resp.PublicInputs))
+	}
+	if resp.CircuitVersion != cm.expiry.version {
+		t.Errorf("Expected expiry circuit version %s, got %s", cm.expiry.version, resp.CircuitVersion)
+	}
+
+	proofBytes, err := proofformat.Decode(resp.Proof, resp.ProofFormat)
+	if err != nil {
+		t.Fatalf("Failed to decode proof: %v", err)
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofBytes)); err != nil {
+		t.Fatalf("Failed to deserialize proof: %v", err)
+	}
+	publicWitness, err := frontend.NewWitness(&circuit.KYCExpiryCircuit{
+		KYCCircuit:  *publicWitnessFor(t, req, resp),
+		CurrentDays: req.Expiry.CurrentDays.Int,
+	}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatalf("Failed to create public witness: %v", err)
+	}
+	if err := groth16.Verify(proof, cm.expiry.vk, publicWitness); err != nil {
+		t.Errorf("Expected expiry proof to verify, got: %v", err)
+	}
+
+	// The day after expiry, bypassing request validation
+	expired := newTestExpiryRequest(epochDays(2025, time.January, 2))
+	if _, err := cm.GenerateProof(expired); err == nil {
+		t.Error("Expected error the day after expiry, got nil")
+	}
+
+	// A later expiry than the committed one, bypassing request validation
+	forged := newTestExpiryRequest(epochDays(2025, time.January, 2))
+	forged.Expiry.ExpiryDays = BigIntString{epochDays(2030, time.January, 1)}
+	if _, err := cm.GenerateProof(forged); err == nil {
+		t.Error("Expected error for a forged expiry, got nil")
+	}
+}
+
+// TestValidateProofRequestExpiry tests the expiry request checks
+func TestValidateProofRequestExpiry(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ProofRequest)
+		errMsg string
+	}{
+		{"missing current date", func(r *ProofRequest) { r.Expiry.CurrentDays = BigIntString{} }, "required"},
+		{"expired", func(r *ProofRequest) {
+			r.Expiry.CurrentDays = BigIntString{epochDays(2025, time.January, 2)}
+		}, "expired"},
+		{"negative expiry", func(r *ProofRequest) { r.Expiry.ExpiryDays = BigIntString{big.NewInt(-1)} }, "out of range"},
+		{"missing credential data", func(r *ProofRequest) { r.Expiry.CredentialData = BigIntString{} }, "credential_data is required"},
+		{"forged expiry", func(r *ProofRequest) {
+			r.Expiry.ExpiryDays = BigIntString{epochDays(2030, time.January, 1)}
+		}, "does not commit to expiry_days"},
+		{"combined with birthdate", func(r *ProofRequest) {
+			r.Birthdate = newTestBirthdateRequest(epochDays(2024, time.January, 1)).Birthdate
+		}, "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestExpiryRequest(epochDays(2024, time.January, 1))
+			tt.modify(req)
+			err := validateProofRequest(req, 0)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+// TestVariantRegeneratesStaleKeys tests that keys whose manifest names another circuit,
+// such as expiry keys set up before the expiry was committed, are regenerated
+func TestVariantRegeneratesStaleKeys(t *testing.T) {
+	dir := t.TempDir()
+	pkPath, vkPath := filepath.Join(dir, "proving.key"), filepath.Join(dir, "verifying.key")
+	cm := &CircuitManager{config: &Config{}}
+
+	v, err := cm.loadVariant(&circuitVariant{}, "test", &circuit.ExpiryCircuit{}, pkPath, vkPath)
+	if err != nil {
+		t.Fatalf("Failed to load variant: %v", err)
+	}
+	manifestPath := keymanifest.ManifestPath(vkPath)
+	manifest, err := keymanifest.Read(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	manifest.CircuitHash = "stale"
+	if err := keymanifest.Write(manifestPath, manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	if _, err := cm.loadVariant(&circuitVariant{}, "test", &circuit.ExpiryCircuit{}, pkPath, vkPath); err != nil {
+		t.Fatalf("Failed to reload variant: %v", err)
+	}
+	manifest, err = keymanifest.Read(manifestPath)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if manifest.CircuitHash != v.version {
+		t.Errorf("Expected regenerated keys for circuit %s, manifest names %s", v.version, manifest.CircuitHash)
+	}
+}
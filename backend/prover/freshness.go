@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// day is the length of the day counts dates are given in
+const day = 24 * time.Hour
+
+// validateClockSkew rejects a negative ALLOWED_CLOCK_SKEW
+func validateClockSkew(skew time.Duration) error {
+	if skew < 0 {
+		return fmt.Errorf("must not be negative, got %s", skew)
+	}
+	return nil
+}
+
+// validateDateFreshness checks the dates a proof is evaluated on, which become public
+// inputs, are today's UTC date: a date counts as today while now is within skew of it,
+// so a client whose clock has just passed midnight ahead of ours is not rejected
+func validateDateFreshness(req *ProofRequest, now time.Time, skew time.Duration) error {
+	if req.Birthdate != nil {
+		if err := checkDateFresh("reference_date_days", req.Birthdate.ReferenceDateDays.Int, now, skew); err != nil {
+			return err
+		}
+	}
+	if req.Expiry != nil {
+		if err := checkDateFresh("current_days", req.Expiry.CurrentDays.Int, now, skew); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDateFresh checks the UTC day days covers overlaps [now-skew, now+skew]
+func checkDateFresh(name string, days *big.Int, now time.Time, skew time.Duration) error {
+	start := time.Unix(days.Int64()*int64(day/time.Second), 0)
+	if now.Add(skew).Before(start) {
+		return fmt.Errorf("%s is in the future (beyond the allowed clock skew of %s)", name, skew)
+	}
+	if !now.Add(-skew).Before(start.Add(day)) {
+		return fmt.Errorf("%s is stale: it must be the current date", name)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestValidateDateFreshness tests that request dates must be today's, with the clock skew
+// stretching today at both ends
+func TestValidateDateFreshness(t *testing.T) {
+	const skew = 5 * time.Minute
+	today := epochDays(2025, time.March, 10)
+	tomorrow := new(big.Int).Add(today, big.NewInt(1))
+	yesterday := new(big.Int).Sub(today, big.NewInt(1))
+	noon := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+	beforeMidnight := time.Date(2025, time.March, 10, 23, 58, 0, 0, time.UTC)
+	afterMidnight := time.Date(2025, time.March, 10, 0, 2, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		current *big.Int
+		now     time.Time
+		errMsg  string
+	}{
+		{"today", today, noon, ""},
+		{"tomorrow within skew", tomorrow, beforeMidnight, ""},
+		{"yesterday within skew", yesterday, afterMidnight, ""},
+		{"tomorrow beyond skew", tomorrow, noon, "in the future"},
+		{"stale", yesterday, noon, "stale"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestExpiryRequest(tt.current)
+			err := validateDateFreshness(req, tt.now, skew)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Expected the date to be accepted, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	// The birthdate reference date is checked the same way
+	req := newTestBirthdateRequest(tomorrow)
+	if err := validateDateFreshness(req, noon, skew); err == nil || !strings.Contains(err.Error(), "reference_date_days") {
+		t.Errorf("Expected a future reference date to be rejected, got %v", err)
+	}
+
+	// Requests without dates have nothing to check
+	if err := validateDateFreshness(newTestProofRequest(), noon, 0); err != nil {
+		t.Errorf("Expected no error without dates, got: %v", err)
+	}
+}
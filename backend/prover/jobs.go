@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// ErrQueueFull is returned when a proof job is submitted while the queue is at capacity
+var ErrQueueFull = errors.New("proof job queue is full")
+
+// Proof job states
+const (
+	JobStatusQueued  = "queued"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// jobRetryAfterSeconds is the Retry-After sent with ERR_QUEUE_FULL, about one proof's time
+const jobRetryAfterSeconds = 5
+
+// jobRetention is how long a finished job's result stays available
+const jobRetention = 15 * time.Minute
+
+// ProofJob is the state of an asynchronous proof generation, as returned by /proof/jobs/:id
+type ProofJob struct {
+	ID     string         `json:"id"`
+	Status string         `json:"status"`
+	Result *ProofResponse `json:"result,omitempty"`
+	Error  string         `json:"error,omitempty"`
+
+	request    *ProofRequest
+	finishedAt time.Time
+}
+
+// JobQueue runs proof requests in the background on a bounded queue, so a burst of
+// submissions is refused with ErrQueueFull instead of growing memory without limit
+type JobQueue struct {
+	queue   chan *ProofJob
+	prove   func(*ProofRequest) (*ProofResponse, error)
+	mu      sync.Mutex
+	jobs    map[string]*ProofJob
+	started sync.Once
+}
+
+// NewJobQueue creates a queue holding at most size jobs waiting to be proved
+func NewJobQueue(size int, prove func(*ProofRequest) (*ProofResponse, error)) *JobQueue {
+	if size < 1 {
+		size = 1
+	}
+	return &JobQueue{
+		queue: make(chan *ProofJob, size),
+		prove: prove,
+		jobs:  make(map[string]*ProofJob),
+	}
+}
+
+// Start launches the worker; proving already uses every CPU, so jobs run one at a time
+func (q *JobQueue) Start() {
+	q.started.Do(func() {
+		go q.work()
+	})
+}
+
+// Submit queues a proof request and returns the new job's ID, or ErrQueueFull
+func (q *JobQueue) Submit(req *ProofRequest) (string, error) {
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	job := &ProofJob{ID: id, Status: JobStatusQueued, request: req}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pruneLocked(time.Now())
+
+	select {
+	case q.queue <- job:
+	default:
+		metrics.RecordProofJobRejected()
+		return "", ErrQueueFull
+	}
+	q.jobs[id] = job
+	metrics.SetProofJobQueueDepth(len(q.queue))
+	return id, nil
+}
+
+// Get returns a snapshot of the job with the given ID; expired jobs are pruned first,
+// so a finished job is not served past jobRetention
+func (q *JobQueue) Get(id string) (ProofJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pruneLocked(time.Now())
+	job, ok := q.jobs[id]
+	if !ok {
+		return ProofJob{}, false
+	}
+	return *job, true
+}
+
+// Depth returns the number of jobs waiting to be proved
+func (q *JobQueue) Depth() int {
+	return len(q.queue)
+}
+
+func (q *JobQueue) work() {
+	for job := range q.queue {
+		q.mu.Lock()
+		job.Status = JobStatusRunning
+		metrics.SetProofJobQueueDepth(len(q.queue))
+		q.mu.Unlock()
+
+		response, err := q.proveJob(job)
+
+		q.mu.Lock()
+		job.request = nil
+		job.finishedAt = time.Now()
+		if err != nil || response == nil || !response.Success {
+			job.Status = JobStatusFailed
+			job.Error = jobError(response, err)
+		} else {
+			job.Status = JobStatusDone
+			job.Result = response
+		}
+		q.pruneLocked(job.finishedAt)
+		q.mu.Unlock()
+	}
+}
+
+// proveJob runs one job, turning a panic into an error so the job is marked failed and
+// the worker goes on to the next one; the panic itself is only logged
+func (q *JobQueue) proveJob(job *ProofJob) (response *ProofResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Proof job panicked",
+				zap.Any("error", r),
+				zap.String("job_id", job.ID),
+				zap.Stack("stack"),
+			)
+			response, err = nil, errors.New("internal error during proof generation")
+		}
+	}()
+	return q.prove(job.request)
+}
+
+// jobError prefers the response's error, which GenerateProof words for clients
+func jobError(response *ProofResponse, err error) string {
+	if response != nil && response.Error != "" {
+		return response.Error
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return "proof generation failed"
+}
+
+// pruneLocked drops finished jobs older than jobRetention; q.mu must be held
+func (q *JobQueue) pruneLocked(now time.Time) {
+	for id, job := range q.jobs {
+		if !job.finishedAt.IsZero() && now.Sub(job.finishedAt) > jobRetention {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// newJobID returns a random 128-bit job ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
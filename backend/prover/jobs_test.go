@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/health"
+)
+
+// newJobTestAPI creates a ready API whose proof jobs run prove instead of the circuit
+func newJobTestAPI(t *testing.T, queueSize int, prove func(*ProofRequest) (*ProofResponse, error)) (*API, http.Handler) {
+	t.Helper()
+	api := &API{
+		circuitManager: &CircuitManager{config: &Config{}},
+		readiness:      health.NewReadiness(),
+		jobs:           NewJobQueue(queueSize, prove),
+	}
+	api.readiness.SetReady(true)
+	return api, setupRouter(api, testConfig(t))
+}
+
+// postProofJob submits the test proof request to /proof/jobs
+func postProofJob(t *testing.T, router http.Handler) *httptest.ResponseRecorder {
+	t.Helper()
+	proofReq := newTestProofRequest()
+	for i := range proofReq.MerklePath {
+		// Decimal strings, since JSON numbers decode to float64
+		proofReq.MerklePath[i] = fmt.Sprint(proofReq.MerklePath[i])
+		proofReq.MerkleHelper[i] = fmt.Sprint(proofReq.MerkleHelper[i])
+	}
+	body, err := json.Marshal(proofReq)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/proof/jobs", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestProofJobQueueFull tests that submissions beyond the queue size are refused with 503
+func TestProofJobQueueFull(t *testing.T) {
+	// The worker is never started, so queued jobs stay queued
+	_, router := newJobTestAPI(t, 2, func(*ProofRequest) (*ProofResponse, error) {
+		t.Error("Expected no job to be proved")
+		return nil, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if rec := postProofJob(t, router); rec.Code != http.StatusAccepted {
+			t.Fatalf("Expected job %d to be accepted, got %d: %s", i, rec.Code, rec.Body.String())
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := postProofJob(t, router)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected 503 once the queue is full, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header")
+		}
+		var apiErr apierror.APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+			t.Fatalf("Failed to decode error: %v", err)
+		}
+		if apiErr.Code != apierror.CodeQueueFull {
+			t.Errorf("Expected %s, got %+v", apierror.CodeQueueFull, apiErr)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, metric := range []string{"proof_job_queue_depth", "proof_job_rejections_total"} {
+		if !strings.Contains(rec.Body.String(), metric) {
+			t.Errorf("Expected %s in /metrics", metric)
+		}
+	}
+}
+
+// TestProofJobLifecycle tests that a submitted job can be polled until its proof is ready
+func TestProofJobLifecycle(t *testing.T) {
+	api, router := newJobTestAPI(t, 1, func(req *ProofRequest) (*ProofResponse, error) {
+		return &ProofResponse{Proof: "proof-for-" + req.Nonce.String(), Success: true}, nil
+	})
+	api.jobs.Start()
+
+	rec := postProofJob(t, router)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job ProofJob
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil || job.ID == "" {
+		t.Fatalf("Expected a job ID, got %s (%v)", rec.Body.String(), err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != JobStatusDone {
+		if time.Now().After(deadline) {
+			t.Fatalf("Job did not finish, last status %q", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/proof/jobs/"+job.ID, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 polling the job, got %d", rec.Code)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+	}
+	if job.Result == nil || job.Result.Proof != "proof-for-"+newTestProofRequest().Nonce.String() {
+		t.Errorf("Expected the job's proof, got %+v", job.Result)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/proof/jobs/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", rec.Code)
+	}
+}
+
+// TestProofJobPanicFailsJob tests that a panicking proof marks its job failed and the
+// worker keeps serving later jobs
+func TestProofJobPanicFailsJob(t *testing.T) {
+	calls := 0
+	q := NewJobQueue(2, func(*ProofRequest) (*ProofResponse, error) {
+		calls++
+		if calls == 1 {
+			panic("constraint system corrupted")
+		}
+		return &ProofResponse{Success: true}, nil
+	})
+	first, _ := q.Submit(newTestProofRequest())
+	second, _ := q.Submit(newTestProofRequest())
+	q.Start()
+
+	wait := func(id string) ProofJob {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			job, _ := q.Get(id)
+			if job.Status == JobStatusDone || job.Status == JobStatusFailed {
+				return job
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Job %s did not finish, last status %q", id, job.Status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if job := wait(first); job.Status != JobStatusFailed || strings.Contains(job.Error, "corrupted") {
+		t.Errorf("Expected the panicking job to fail without exposing the panic, got %+v", job)
+	}
+	if job := wait(second); job.Status != JobStatusDone {
+		t.Errorf("Expected the next job to succeed, got %+v", job)
+	}
+}
+
+// TestProofJobPrunedOnRead tests that a finished job past its retention is not served
+func TestProofJobPrunedOnRead(t *testing.T) {
+	q := NewJobQueue(1, nil)
+	q.jobs["old"] = &ProofJob{ID: "old", Status: JobStatusDone, finishedAt: time.Now().Add(-jobRetention - time.Second)}
+	q.jobs["recent"] = &ProofJob{ID: "recent", Status: JobStatusDone, finishedAt: time.Now()}
+
+	if _, ok := q.Get("old"); ok {
+		t.Error("Expected the expired job to be pruned")
+	}
+	if _, ok := q.Get("recent"); !ok {
+		t.Error("Expected the recent job to be kept")
+	}
+}
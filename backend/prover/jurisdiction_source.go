@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"noah-v2/backend/pkg/logger"
+	"noah-v2/backend/pkg/tracing"
+
+	"go.uber.org/zap"
+)
+
+// JurisdictionListSource keeps the jurisdiction tree built from a list served over HTTP(S)
+// If the URL cannot be fetched before any list was loaded, the local file is used instead
+type JurisdictionListSource struct {
+	url          string
+	fallbackPath string
+	client       *http.Client
+	cache        *JurisdictionTreeCache
+
+	mu   sync.RWMutex
+	tree *JurisdictionTree
+}
+
+// NewJurisdictionListSource creates a source for the list at url, falling back to fallbackPath ("" for none)
+func NewJurisdictionListSource(url, fallbackPath string, cache *JurisdictionTreeCache) *JurisdictionListSource {
+	return &JurisdictionListSource{
+		url:          url,
+		fallbackPath: fallbackPath,
+		client:       &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(nil)},
+		cache:        cache,
+	}
+}
+
+// Tree returns the current tree, or nil if no list has been loaded yet
+func (s *JurisdictionListSource) Tree() *JurisdictionTree {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree
+}
+
+// Refresh fetches the list and swaps in its tree, reporting whether the root changed
+func (s *JurisdictionListSource) Refresh(ctx context.Context) (bool, error) {
+	tree, err := s.fetch(ctx)
+	if err != nil {
+		// Keep serving the last good list; only fall back to the file on first load
+		if s.Tree() != nil || s.fallbackPath == "" {
+			return false, err
+		}
+		tree, err = s.cache.FromFile(s.fallbackPath)
+		if err != nil {
+			return false, fmt.Errorf("failed to load jurisdiction list from URL and fallback file: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := s.tree == nil || s.tree.Root().Cmp(tree.Root()) != 0
+	s.tree = tree
+	return changed, nil
+}
+
+// Start refreshes the list every interval until ctx is cancelled
+func (s *JurisdictionListSource) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changed, err := s.Refresh(ctx)
+				if err != nil {
+					logger.Warn("Failed to refresh jurisdiction list", zap.String("url", s.url), zap.Error(err))
+					continue
+				}
+				if changed {
+					logger.Info("Jurisdiction list updated", zap.String("root", s.Tree().Root().String()))
+				}
+			}
+		}
+	}()
+}
+
+// fetch downloads and parses the list, building (or reusing) its tree
+func (s *JurisdictionListSource) fetch(ctx context.Context) (*JurisdictionTree, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jurisdiction list URL: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jurisdiction list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jurisdiction list: unexpected status %d", resp.StatusCode)
+	}
+
+	codes, err := decodeJurisdictionList(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return s.cache.Get(codes)
+}
+
+// decodeJurisdictionList streams a JSON array of jurisdiction codes (numbers or decimal strings)
+// Entries must be field elements; duplicates are dropped keeping the first occurrence
+func decodeJurisdictionList(r io.Reader) ([]*big.Int, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("failed to parse jurisdiction list: expected a JSON array")
+	}
+
+	seen := make(map[string]bool)
+	codes := make([]*big.Int, 0)
+	for i := 0; dec.More(); i++ {
+		var entry BigIntString
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("failed to parse jurisdiction list entry %d: %w", i, err)
+		}
+		if err := validateFieldElement(fmt.Sprintf("jurisdiction list entry %d", i), entry.Int); err != nil {
+			return nil, err
+		}
+		if seen[entry.String()] {
+			continue
+		}
+		seen[entry.String()] = true
+		codes = append(codes, entry.Int)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse jurisdiction list: %w", err)
+	}
+	return codes, nil
+}
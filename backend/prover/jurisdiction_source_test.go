@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newJurisdictionListServer serves the current list body; set it with the returned function
+func newJurisdictionListServer(t *testing.T, body string) (*httptest.Server, func(string)) {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if body == "" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, func(b string) {
+		mu.Lock()
+		defer mu.Unlock()
+		body = b
+	}
+}
+
+// TestJurisdictionListSourceRefresh tests loading from a URL and detecting a changed list
+func TestJurisdictionListSourceRefresh(t *testing.T) {
+	server, setBody := newJurisdictionListServer(t, `[1, 2, "840"]`)
+	source := NewJurisdictionListSource(server.URL, "", NewJurisdictionTreeCache(merkleDepth))
+
+	changed, err := source.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to load jurisdiction list: %v", err)
+	}
+	if !changed {
+		t.Error("Expected first load to report a change")
+	}
+	first := source.Tree().Root()
+
+	if _, _, err := source.Tree().Proof(big.NewInt(840)); err != nil {
+		t.Errorf("Expected 840 to be in the list: %v", err)
+	}
+
+	// An unchanged list keeps the root
+	changed, err = source.Refresh(context.Background())
+	if err != nil || changed {
+		t.Errorf("Expected unchanged list, got changed=%v err=%v", changed, err)
+	}
+
+	// A changed list yields a new root
+	setBody(`[1, 2, "840", 276]`)
+	changed, err = source.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to refresh jurisdiction list: %v", err)
+	}
+	if !changed || source.Tree().Root().Cmp(first) == 0 {
+		t.Error("Expected refresh to detect the changed list with a new root")
+	}
+
+	// A failing refresh keeps serving the last good list
+	setBody("")
+	if _, err := source.Refresh(context.Background()); err == nil {
+		t.Error("Expected error when the list URL fails")
+	}
+	if _, _, err := source.Tree().Proof(big.NewInt(276)); err != nil {
+		t.Errorf("Expected the last good list to remain loaded: %v", err)
+	}
+}
+
+// TestJurisdictionListSourceFallback tests that the local file is used when the URL fails on first load
+func TestJurisdictionListSourceFallback(t *testing.T) {
+	server, _ := newJurisdictionListServer(t, "")
+	listPath := filepath.Join(t.TempDir(), "jurisdictions.json")
+	if err := os.WriteFile(listPath, []byte(`[7, 9]`), 0644); err != nil {
+		t.Fatalf("Failed to write jurisdiction list: %v", err)
+	}
+
+	source := NewJurisdictionListSource(server.URL, listPath, NewJurisdictionTreeCache(merkleDepth))
+	if _, err := source.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected fallback file to load, got: %v", err)
+	}
+	if _, _, err := source.Tree().Proof(big.NewInt(9)); err != nil {
+		t.Errorf("Expected 9 from the fallback file: %v", err)
+	}
+}
+
+// TestDecodeJurisdictionList tests validation and deduplication of list entries
+func TestDecodeJurisdictionList(t *testing.T) {
+	codes, err := decodeJurisdictionList(strings.NewReader(`[3, "1", 3, "1", 2]`))
+	if err != nil {
+		t.Fatalf("Failed to decode list: %v", err)
+	}
+	want := []int64{3, 1, 2}
+	if len(codes) != len(want) {
+		t.Fatalf("Expected %d deduplicated codes, got %d", len(want), len(codes))
+	}
+	for i, code := range codes {
+		if code.Int64() != want[i] {
+			t.Errorf("Expected code %d at %d, got %s", want[i], i, code)
+		}
+	}
+
+	invalid := []string{
+		`{"codes": [1]}`,
+		`[1, -2]`,
+		`[1, "not-a-number"]`,
+		`["21888242871839275222246405745257275088548364400416034343698204186575808495617"]`, // field modulus
+	}
+	for _, body := range invalid {
+		if _, err := decodeJurisdictionList(strings.NewReader(body)); err == nil {
+			t.Errorf("Expected error for %s, got nil", body)
+		}
+	}
+}
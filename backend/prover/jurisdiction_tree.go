@@ -0,0 +1,260 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+)
+
+// JurisdictionTree is a sparse MiMC Merkle tree of allowed jurisdiction codes
+// laid out the way circuit.KYCCircuit verifies it: leaves are MiMC(code),
+// parents are MiMC(left || right) and empty subtrees hash to zero
+type JurisdictionTree struct {
+	depth  int
+	index  map[string]int // jurisdiction code (decimal) -> leaf index
+	levels [][]*big.Int   // levels[0] are leaf hashes, levels[depth] is the root
+	zeros  []*big.Int     // zeros[i] is the hash of an empty subtree at level i
+}
+
+// BuildJurisdictionTree builds the tree of a jurisdiction list in canonical form: the
+// codes are sorted ascending and duplicates dropped before hashing, so the root depends
+// only on the set of codes, not on their order or repetition
+func BuildJurisdictionTree(codes []*big.Int, depth int) (*JurisdictionTree, error) {
+	return NewJurisdictionTree(canonicalCodes(codes), depth)
+}
+
+// canonicalCodes returns a sorted copy of codes without duplicates
+func canonicalCodes(codes []*big.Int) []*big.Int {
+	sorted := slices.Clone(codes)
+	slices.SortFunc(sorted, (*big.Int).Cmp)
+	return slices.CompactFunc(sorted, func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+}
+
+// NewJurisdictionTree builds a tree of the given depth from codes in the given order;
+// leaf i is codes[i], which attribute trees rely on (see BuildJurisdictionTree for lists)
+func NewJurisdictionTree(codes []*big.Int, depth int) (*JurisdictionTree, error) {
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("jurisdiction list is empty")
+	}
+	if len(codes) > 1<<depth {
+		return nil, fmt.Errorf("jurisdiction list has %d entries, tree of depth %d holds at most %d", len(codes), depth, 1<<depth)
+	}
+
+	tree := &JurisdictionTree{
+		depth:  depth,
+		index:  make(map[string]int, len(codes)),
+		levels: make([][]*big.Int, depth+1),
+		zeros:  make([]*big.Int, depth+1),
+	}
+
+	tree.zeros[0] = big.NewInt(0)
+	for i := 0; i < depth; i++ {
+		tree.zeros[i+1] = mimcHash(tree.zeros[i], tree.zeros[i])
+	}
+
+	leaves := make([]*big.Int, len(codes))
+	for i, code := range codes {
+		leaves[i] = mimcHash(code)
+		tree.index[code.String()] = i
+	}
+	tree.levels[0] = leaves
+
+	for level := 0; level < depth; level++ {
+		current := tree.levels[level]
+		next := make([]*big.Int, (len(current)+1)/2)
+		for i := range next {
+			next[i] = mimcHash(current[2*i], tree.node(level, 2*i+1))
+		}
+		tree.levels[level+1] = next
+	}
+
+	return tree, nil
+}
+
+// node returns the hash at the given level and index, using the empty-subtree hash if unset
+func (t *JurisdictionTree) node(level, i int) *big.Int {
+	if i < len(t.levels[level]) {
+		return t.levels[level][i]
+	}
+	return t.zeros[level]
+}
+
+// Root returns the Merkle root
+func (t *JurisdictionTree) Root() *big.Int {
+	return t.levels[t.depth][0]
+}
+
+// Proof returns the circuit MerklePath and MerkleHelper for a jurisdiction code
+func (t *JurisdictionTree) Proof(code *big.Int) ([]frontend.Variable, []frontend.Variable, error) {
+	index, ok := t.index[code.String()]
+	if !ok {
+		return nil, nil, fmt.Errorf("jurisdiction %s is not in the allowed list", code.String())
+	}
+	path, helper := t.proofAt(index)
+	return path, helper, nil
+}
+
+// ProofInto writes the circuit MerklePath and MerkleHelper for a jurisdiction code into
+// path and helper, which must have the tree's depth
+func (t *JurisdictionTree) ProofInto(code *big.Int, path, helper []frontend.Variable) error {
+	index, ok := t.index[code.String()]
+	if !ok {
+		return fmt.Errorf("jurisdiction %s is not in the allowed list", code.String())
+	}
+	if len(path) != t.depth || len(helper) != t.depth {
+		return fmt.Errorf("merkle buffers have length %d and %d, tree depth is %d", len(path), len(helper), t.depth)
+	}
+	t.proofInto(index, path, helper)
+	return nil
+}
+
+// proofAt returns the circuit path and helper bits for the leaf at index
+func (t *JurisdictionTree) proofAt(index int) ([]frontend.Variable, []frontend.Variable) {
+	path := make([]frontend.Variable, t.depth)
+	helper := make([]frontend.Variable, t.depth)
+	t.proofInto(index, path, helper)
+	return path, helper
+}
+
+// proofInto fills path and helper with the path and helper bits for the leaf at index
+func (t *JurisdictionTree) proofInto(index int, path, helper []frontend.Variable) {
+	for level := 0; level < t.depth; level++ {
+		path[level] = t.node(level, index^1)
+		helper[level] = index & 1
+		index >>= 1
+	}
+}
+
+// mimcHash hashes field elements with MiMC, matching the circuit's hash
+func mimcHash(values ...*big.Int) *big.Int {
+	h := hash.MIMC_BN254.New()
+	for _, v := range values {
+		var e fr.Element
+		e.SetBigInt(v)
+		b := e.Bytes()
+		h.Write(b[:])
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// JurisdictionTreeCache reuses constructed trees keyed by a hash of their leaf set
+type JurisdictionTreeCache struct {
+	mu     sync.Mutex
+	depth  int
+	trees  map[string]*JurisdictionTree
+	files  map[string]cachedListFile
+	builds int
+}
+
+// cachedListFile remembers which tree a list file produced at a given modification time
+type cachedListFile struct {
+	modTime time.Time
+	key     string
+}
+
+// NewJurisdictionTreeCache creates an empty cache for trees of the given depth
+func NewJurisdictionTreeCache(depth int) *JurisdictionTreeCache {
+	return &JurisdictionTreeCache{
+		depth: depth,
+		trees: make(map[string]*JurisdictionTree),
+		files: make(map[string]cachedListFile),
+	}
+}
+
+// Get returns the canonical tree for the given codes (see BuildJurisdictionTree),
+// building it only on a cache miss; permutations of a list share one tree
+func (c *JurisdictionTreeCache) Get(codes []*big.Int) (*JurisdictionTree, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, tree, err := c.get(codes)
+	return tree, err
+}
+
+func (c *JurisdictionTreeCache) get(codes []*big.Int) (string, *JurisdictionTree, error) {
+	codes = canonicalCodes(codes)
+	key := leafSetKey(codes)
+	if tree, ok := c.trees[key]; ok {
+		return key, tree, nil
+	}
+
+	tree, err := NewJurisdictionTree(codes, c.depth)
+	if err != nil {
+		return "", nil, err
+	}
+	c.trees[key] = tree
+	c.builds++
+	return key, tree, nil
+}
+
+// FromFile returns the tree for a JSON list file, reloading it when its mtime changes
+func (c *JurisdictionTreeCache) FromFile(path string) (*JurisdictionTree, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat jurisdiction list: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.files[path]
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		if tree, ok := c.trees[cached.key]; ok {
+			return tree, nil
+		}
+	}
+
+	codes, err := readJurisdictionList(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, tree, err := c.get(codes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The file changed, drop the tree built from its previous contents
+	if ok && cached.key != key {
+		delete(c.trees, cached.key)
+	}
+	c.files[path] = cachedListFile{modTime: info.ModTime(), key: key}
+
+	return tree, nil
+}
+
+// Builds returns how many trees have been constructed
+func (c *JurisdictionTreeCache) Builds() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.builds
+}
+
+// readJurisdictionList reads a JSON array of jurisdiction codes from a file
+func readJurisdictionList(path string) ([]*big.Int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jurisdiction list: %w", err)
+	}
+	defer f.Close()
+
+	return decodeJurisdictionList(f)
+}
+
+// leafSetKey hashes the ordered leaf set to identify a tree
+func leafSetKey(codes []*big.Int) string {
+	h := sha256.New()
+	for _, code := range codes {
+		h.Write([]byte(code.String()))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
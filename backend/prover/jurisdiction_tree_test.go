@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestJurisdictionTreeCacheReusesTree tests that repeated lookups reuse the tree until the list file changes
+func TestJurisdictionTreeCacheReusesTree(t *testing.T) {
+	listPath := filepath.Join(t.TempDir(), "jurisdictions.json")
+	if err := os.WriteFile(listPath, []byte(`[1, 2, 3, "840"]`), 0644); err != nil {
+		t.Fatalf("Failed to write jurisdiction list: %v", err)
+	}
+
+	cache := NewJurisdictionTreeCache(merkleDepth)
+	first, err := cache.FromFile(listPath)
+	if err != nil {
+		t.Fatalf("Failed to load tree: %v", err)
+	}
+	second, err := cache.FromFile(listPath)
+	if err != nil {
+		t.Fatalf("Failed to load tree: %v", err)
+	}
+	if first != second || cache.Builds() != 1 {
+		t.Errorf("Expected second lookup to hit the cache, got %d builds", cache.Builds())
+	}
+
+	// Same leaf set passed directly hits the same entry
+	if _, err := cache.Get([]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(840)}); err != nil {
+		t.Fatalf("Failed to get tree: %v", err)
+	}
+	if cache.Builds() != 1 {
+		t.Errorf("Expected identical leaf set to hit the cache, got %d builds", cache.Builds())
+	}
+
+	// Changing the file invalidates the cached tree
+	if err := os.WriteFile(listPath, []byte(`[1, 2]`), 0644); err != nil {
+		t.Fatalf("Failed to rewrite jurisdiction list: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(listPath, later, later); err != nil {
+		t.Fatalf("Failed to update mtime: %v", err)
+	}
+	third, err := cache.FromFile(listPath)
+	if err != nil {
+		t.Fatalf("Failed to reload tree: %v", err)
+	}
+	if cache.Builds() != 2 || third.Root().Cmp(first.Root()) == 0 {
+		t.Errorf("Expected changed list to rebuild the tree with a new root, got %d builds", cache.Builds())
+	}
+}
+
+// TestGenerateProofFromJurisdictionList tests proving with a server-built Merkle proof
+func TestGenerateProofFromJurisdictionList(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	listPath := filepath.Join(t.TempDir(), "jurisdictions.json")
+	if err := os.WriteFile(listPath, []byte(`[4, 7, 1]`), 0644); err != nil {
+		t.Fatalf("Failed to write jurisdiction list: %v", err)
+	}
+	cm.config.JurisdictionListPath = listPath
+	t.Cleanup(func() { cm.config.JurisdictionListPath = "" })
+
+	req := newTestProofRequest()
+	req.MerklePath = nil
+	req.MerkleHelper = nil
+	req.JurisdictionRoot = BigIntString{}
+
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		t.Fatalf("Failed to generate proof: %v", err)
+	}
+	if err := cm.VerifyEncodedProof(resp.Proof, resp.ProofFormat, publicWitnessFor(t, req, resp)); err != nil {
+		t.Errorf("Expected proof to verify, got: %v", err)
+	}
+
+	req = newTestProofRequest()
+	req.MerklePath = nil
+	req.MerkleHelper = nil
+	req.JurisdictionRoot = BigIntString{}
+	req.Jurisdiction = BigIntString{big.NewInt(99)}
+	if _, err := cm.GenerateProof(req); err == nil {
+		t.Error("Expected error for jurisdiction outside the list, got nil")
+	}
+}
+
+// TestBuildJurisdictionTreeCanonical tests that the root depends only on the set of codes
+func TestBuildJurisdictionTreeCanonical(t *testing.T) {
+	codes := func(values ...int64) []*big.Int {
+		out := make([]*big.Int, len(values))
+		for i, v := range values {
+			out[i] = big.NewInt(v)
+		}
+		return out
+	}
+	build := func(list []*big.Int) *JurisdictionTree {
+		tree, err := BuildJurisdictionTree(list, merkleDepth)
+		if err != nil {
+			t.Fatalf("Failed to build tree: %v", err)
+		}
+		return tree
+	}
+
+	want := build(codes(1, 276, 840)).Root()
+	for _, list := range [][]*big.Int{
+		codes(840, 1, 276),
+		codes(276, 840, 1),
+		codes(1, 840, 276, 840, 1),
+		codes(276, 276, 276, 1, 840),
+	} {
+		tree := build(list)
+		if tree.Root().Cmp(want) != 0 {
+			t.Errorf("Expected %v to have the root of its sorted set", list)
+		}
+		if _, _, err := tree.Proof(big.NewInt(276)); err != nil {
+			t.Errorf("Expected a proof for 276 in %v, got %v", list, err)
+		}
+	}
+
+	// Order-preserving construction still differs, as attribute trees require
+	ordered, err := NewJurisdictionTree(codes(840, 1, 276), merkleDepth)
+	if err != nil {
+		t.Fatalf("Failed to build tree: %v", err)
+	}
+	if ordered.Root().Cmp(want) == 0 {
+		t.Error("Expected NewJurisdictionTree to keep the given leaf order")
+	}
+
+	// The cache shares one tree between permutations
+	cache := NewJurisdictionTreeCache(merkleDepth)
+	first, _ := cache.Get(codes(840, 1, 276))
+	second, _ := cache.Get(codes(1, 1, 276, 840))
+	if first != second || cache.Builds() != 1 || first.Root().Cmp(want) != 0 {
+		t.Errorf("Expected permutations to share one canonical tree, got %d builds", cache.Builds())
+	}
+}
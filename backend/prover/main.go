@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/apispec"
 	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/listeners"
 	"noah-v2/backend/pkg/logger"
 	"noah-v2/backend/pkg/metrics"
 	"noah-v2/backend/pkg/middleware"
+	"noah-v2/backend/pkg/tlsconfig"
+	"noah-v2/backend/pkg/tracing"
+	"noah-v2/circuit"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -28,43 +35,120 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Load configuration
+	config, err := LoadConfig()
+	if err != nil {
+		logger.Fatal("Invalid configuration", zap.Error(err))
+	}
+	config.LogSafe()
+	if err := middleware.ValidateCORSOrigins(config.CORSAllowedOrigins, corsAllowCredentials); err != nil {
+		logger.Fatal("Invalid CORS_ALLOWED_ORIGINS", zap.Error(err))
+	}
+	if err := listeners.Validate(config.Listeners()...); err != nil {
+		logger.Fatal("Conflicting listener ports", zap.Error(err))
+	}
+
+	// Trace requests when an OTLP collector is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), "prover", config.OTLPEndpoint)
+	if err != nil {
+		logger.Fatal("Failed to set up tracing", zap.Error(err))
+	}
+	defer shutdownTracing(context.Background())
+
 	// Initialize metrics
+	if err := circuit.ValidateAgeLimit(config.MaxAge); err != nil {
+		logger.Fatal("Invalid CIRCUIT_MAX_AGE", zap.Error(err))
+	}
+	if err := metrics.ValidateBuckets(config.ProofDurationBuckets); err != nil {
+		logger.Fatal("Invalid PROOF_DURATION_BUCKETS", zap.Error(err))
+	}
+	if err := validateClockSkew(config.AllowedClockSkew); err != nil {
+		logger.Fatal("Invalid ALLOWED_CLOCK_SKEW", zap.Error(err))
+	}
 	metrics.Initialize(metrics.Config{
-		ServiceName: "prover",
+		ServiceName:            "prover",
+		ProofGenerationBuckets: config.ProofDurationBuckets,
 	})
 
-	// Load configuration
-	config := LoadConfig()
+	// Bound proving parallelism on shared hosts
+	if config.ProveNbCPU > 0 {
+		previous := limitCPUs(config.ProveNbCPU)
+		logger.Info("Limited proving CPUs", zap.Int("cpus", config.ProveNbCPU), zap.Int("available", previous))
+	}
 
 	// Create API
-	api := NewAPI()
+	api := NewAPI(config)
 
-	// Initialize circuit manager
-	if err := api.Initialize(); err != nil {
-		logger.Fatal("Failed to initialize circuit manager", zap.Error(err))
+	// Setup routes
+	router := setupRouter(api, config)
+
+	// Initialize circuit manager in the background; /health/ready reports 503 until done
+	go func() {
+		if err := api.Initialize(); err != nil {
+			logger.Fatal("Failed to initialize circuit manager", zap.Error(err))
+		}
+		metrics.SetCircuitInitialized(true)
+		logger.Info("Circuit initialized, prover ready")
+	}()
+
+	// Serve metrics on their own listener when configured
+	if config.MetricsPort != "" {
+		logger.Info("Starting metrics server", zap.String("metrics_port", config.MetricsPort))
+		go func() {
+			if err := metrics.ListenAndServe(":" + config.MetricsPort); err != nil {
+				logger.Fatal("Failed to start metrics server", zap.Error(err))
+			}
+		}()
 	}
-	metrics.SetCircuitInitialized(true)
 
-	// Setup routes
+	// Start server
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+			logger.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		logger.Info("Starting prover service with TLS",
+			zap.String("port", config.Port),
+			zap.String("tls_min_version", config.TLSMinVersion),
+		)
+		if err := tlsconfig.ListenAndServe(":"+config.Port, router, config.TLSCertFile, config.TLSKeyFile, config.TLSMinVersion); err != nil {
+			logger.Fatal("Failed to start server", zap.Error(err))
+		}
+		return
+	}
+	logger.Info("Starting prover service", zap.String("port", config.Port))
+	if err := router.Run(":" + config.Port); err != nil {
+		logger.Fatal("Failed to start server", zap.Error(err))
+	}
+}
+
+// corsAllowCredentials lets browsers send cookies and auth headers cross-origin, which
+// rules out a wildcard in CORS_ALLOWED_ORIGINS
+const corsAllowCredentials = true
+
+// setupRouter creates the gin engine with middleware and all prover routes
+func setupRouter(api *API, config *Config) *gin.Engine {
 	router := gin.New()
+	router.HandleMethodNotAllowed = true
 
 	// Add standard middleware
-	router.Use(logger.GinLogger())
+	router.Use(tracing.Middleware("prover"))
+	router.Use(logger.GinLogger(config.SlowRequestThreshold))
 	router.Use(logger.GinRecovery())
 	router.Use(middleware.Security())
+	router.Use(middleware.ValidateContentType()) // Only checks POST and PUT bodies
 	router.Use(metrics.HTTPMiddleware())
 
 	// Rate limiting
-	limiter := middleware.NewRateLimiter(50, 10) // Proving is expensive, lower limit
+	limiter := middleware.NewBoundedRateLimiter(50, 10, config.RateLimitMaxIPs) // Proving is expensive, lower limit
 	router.Use(limiter.Middleware())
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174", "http://localhost:3000"},
+		AllowOrigins:     config.CORSAllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
+		AllowCredentials: corsAllowCredentials,
 	}))
 
 	// Health check
@@ -79,18 +163,31 @@ func main() {
 		},
 	}
 	router.GET("/health", health.Handler(healthConfig))
-	router.GET("/health/ready", health.ReadinessHandler())
+	router.GET("/health/ready", health.ReadinessHandler(api.readiness))
+	router.GET("/ready", health.ReadinessHandler(api.readiness))
 	router.GET("/health/live", health.LivenessHandler())
 
 	// Proof generation
 	router.POST("/proof/generate", api.GenerateProof)
+	router.POST("/proof/verify-witness", api.VerifyWitness)
+	router.POST("/proof/jobs", api.SubmitProofJob)
+	router.GET("/proof/jobs/:id", api.GetProofJob)
 
-	// Metrics
+	// Metrics, also served on their own METRICS_PORT listener when one is set
 	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	// Start server
-	logger.Info("Starting prover service", zap.String("port", config.Port))
-	if err := router.Run(":" + config.Port); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+	// API description
+	router.GET("/openapi.json", apispec.Handler(openAPISpec()))
+
+	// Admin endpoints, only registered when an API key is configured
+	if config.AdminAPIKey != "" {
+		admin := router.Group("/admin", middleware.APIKey(config.AdminAPIKey))
+		admin.GET("/ratelimit", limiter.AdminHandler())
 	}
+
+	// Consistent JSON errors for unknown routes and wrong methods
+	router.NoRoute(apierror.NotFoundHandler())
+	router.NoMethod(apierror.MethodNotAllowedHandler())
+
+	return router
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+
+	"noah-v2/backend/pkg/apierror"
+	"noah-v2/backend/pkg/apispec"
+	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/middleware"
+)
+
+// readinessBody is the body of /health/ready and /ready
+type readinessBody struct {
+	Status string `json:"status"`
+}
+
+// openAPISpec describes the prover routes registered by setupRouter
+// Schemas are derived from the request and response types, so keep the route list in step with setupRouter
+func openAPISpec() *apispec.Document {
+	doc := apispec.New("Noah Prover", "1.0.0", "Generates Groth16 zero-knowledge proofs for KYC credentials")
+
+	doc.Add(http.MethodPost, "/proof/generate", &apispec.Operation{
+		Summary:     "Generate a KYC proof",
+		RequestBody: apispec.JSONBody(ProofRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Proof generated", ProofResponse{}),
+			"400": apispec.JSONResponse("Invalid request", ProofResponse{}),
+			"429": apispec.JSONResponse("Rate limit exceeded", map[string]interface{}{}),
+			"500": apispec.JSONResponse("Proof generation failed", ProofResponse{}),
+			"503": apispec.JSONResponse("Circuit still initializing", ProofResponse{}),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/proof/verify-witness", &apispec.Operation{
+		Summary:     "Verify a proof against named public inputs",
+		RequestBody: apispec.JSONBody(VerifyWitnessRequest{}),
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Verification result", VerifyWitnessResponse{}),
+			"400": apispec.JSONResponse("Invalid request or undecodable proof", VerifyWitnessResponse{}),
+			"503": apispec.JSONResponse("Circuit still initializing", VerifyWitnessResponse{}),
+		},
+	})
+
+	doc.Add(http.MethodPost, "/proof/jobs", &apispec.Operation{
+		Summary:     "Queue a KYC proof for background generation",
+		RequestBody: apispec.JSONBody(ProofRequest{}),
+		Responses: map[string]apispec.Response{
+			"202": apispec.JSONResponse("Job queued; poll /proof/jobs/{id}", ProofJob{}),
+			"400": apispec.JSONResponse("Invalid request", ProofResponse{}),
+			"503": apispec.JSONResponse("Circuit still initializing, or queue full (ERR_QUEUE_FULL, with Retry-After)", apierror.APIError{}),
+		},
+	})
+	doc.Add(http.MethodGet, "/proof/jobs/:id", &apispec.Operation{
+		Summary:    "Status of a queued proof job, with the proof once done",
+		Parameters: []apispec.Parameter{apispec.PathParam("id", "Job ID returned by POST /proof/jobs")},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Job status: queued, running, done or failed", ProofJob{}),
+			"404": apispec.JSONResponse("Unknown or expired job", apierror.APIError{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/health", &apispec.Operation{
+		Summary:   "Detailed health status",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Health status", health.Status{})},
+	})
+	for _, path := range []string{"/health/ready", "/ready"} {
+		doc.Add(http.MethodGet, path, &apispec.Operation{
+			Summary: "Readiness probe",
+			Responses: map[string]apispec.Response{
+				"200": apispec.JSONResponse("Circuit compiled and keys loaded", readinessBody{}),
+				"503": apispec.JSONResponse("Still initializing", readinessBody{}),
+			},
+		})
+	}
+	doc.Add(http.MethodGet, "/health/live", &apispec.Operation{
+		Summary:   "Liveness probe",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("Alive", readinessBody{})},
+	})
+
+	doc.Add(http.MethodGet, "/metrics", &apispec.Operation{
+		Summary:   "Prometheus metrics",
+		Responses: map[string]apispec.Response{"200": apispec.TextResponse("Metrics in Prometheus text format")},
+	})
+
+	doc.Add(http.MethodGet, "/admin/ratelimit", &apispec.Operation{
+		Summary: "Rate limiter state (only registered when ADMIN_API_KEY is set)",
+		Parameters: []apispec.Parameter{
+			apispec.HeaderParam(middleware.APIKeyHeader, "Admin API key"),
+			apispec.QueryParam("ip", "Report the remaining tokens for this IP", false),
+		},
+		Responses: map[string]apispec.Response{
+			"200": apispec.JSONResponse("Rate limiter state", map[string]interface{}{}),
+			"401": apispec.JSONResponse("Missing or invalid API key", apierror.APIError{}),
+		},
+	})
+
+	doc.Add(http.MethodGet, "/openapi.json", &apispec.Operation{
+		Summary:   "This OpenAPI document",
+		Responses: map[string]apispec.Response{"200": apispec.JSONResponse("OpenAPI 3 document", map[string]interface{}{})},
+	})
+
+	return doc
+}
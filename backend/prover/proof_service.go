@@ -6,9 +6,9 @@ type ProofService struct {
 }
 
 // NewProofService creates a new proof service
-func NewProofService() *ProofService {
+func NewProofService(config *Config) *ProofService {
 	return &ProofService{
-		circuitManager: NewCircuitManager(),
+		circuitManager: NewCircuitManager(config),
 	}
 }
 
@@ -21,4 +21,3 @@ func (ps *ProofService) Initialize() error {
 func (ps *ProofService) GenerateProof(req *ProofRequest) (*ProofResponse, error) {
 	return ps.circuitManager.GenerateProof(req)
 }
-
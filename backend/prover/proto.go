@@ -0,0 +1,65 @@
+package main
+
+import (
+	"noah-v2/backend/pkg/proofbundle"
+	"noah-v2/backend/pkg/protoresp"
+)
+
+// ProofResponse field numbers in backend/proto/noah.proto
+const (
+	proofFieldProof          = 1
+	proofFieldProofFormat    = 2
+	proofFieldPublicInputs   = 3
+	proofFieldCommitment     = 4
+	proofFieldCircuitVersion = 5
+	proofFieldWarning        = 6
+	proofFieldSuccess        = 7
+	proofFieldError          = 8
+	proofFieldBundle         = 9
+)
+
+// MarshalProto encodes the response as the noah.v1.ProofResponse message
+func (r *ProofResponse) MarshalProto() []byte {
+	var e protoresp.Encoder
+	e.String(proofFieldProof, r.Proof)
+	e.String(proofFieldProofFormat, r.ProofFormat)
+	e.Strings(proofFieldPublicInputs, r.PublicInputs)
+	e.String(proofFieldCommitment, r.Commitment)
+	e.String(proofFieldCircuitVersion, r.CircuitVersion)
+	e.String(proofFieldWarning, r.Warning)
+	e.Bool(proofFieldSuccess, r.Success)
+	e.String(proofFieldError, r.Error)
+	if r.Bundle != nil {
+		e.Message(proofFieldBundle, r.Bundle)
+	}
+	return e.Bytes()
+}
+
+// UnmarshalProto decodes a noah.v1.ProofResponse message into r
+func (r *ProofResponse) UnmarshalProto(b []byte) error {
+	*r = ProofResponse{}
+	return protoresp.Decode(b, func(f protoresp.Field) error {
+		switch f.Number {
+		case proofFieldProof:
+			r.Proof = string(f.Bytes)
+		case proofFieldProofFormat:
+			r.ProofFormat = string(f.Bytes)
+		case proofFieldPublicInputs:
+			r.PublicInputs = append(r.PublicInputs, string(f.Bytes))
+		case proofFieldCommitment:
+			r.Commitment = string(f.Bytes)
+		case proofFieldCircuitVersion:
+			r.CircuitVersion = string(f.Bytes)
+		case proofFieldWarning:
+			r.Warning = string(f.Bytes)
+		case proofFieldSuccess:
+			r.Success = f.Varint != 0
+		case proofFieldError:
+			r.Error = string(f.Bytes)
+		case proofFieldBundle:
+			r.Bundle = &proofbundle.Bundle{}
+			return r.Bundle.UnmarshalProto(f.Bytes)
+		}
+		return nil
+	})
+}
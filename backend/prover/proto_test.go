@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"noah-v2/backend/pkg/health"
+	"noah-v2/backend/pkg/proofbundle"
+	"noah-v2/backend/pkg/protoresp"
+	"noah-v2/backend/pkg/protoresp/prototest"
+)
+
+// TestGenerateProofProtobuf tests that a protobuf response decodes to the same ProofResponse
+// as the JSON one for the same proof; seeded randomness makes the two proofs identical
+func TestGenerateProofProtobuf(t *testing.T) {
+	cm := newTestCircuitManager(t)
+	api := &API{circuitManager: cm, readiness: health.NewReadiness()}
+	api.readiness.SetReady(true)
+	router := setupRouter(api, testConfig(t))
+
+	proofReq := newTestProofRequest()
+	for i := range proofReq.MerklePath {
+		// Decimal strings, since JSON numbers decode to float64
+		proofReq.MerklePath[i] = fmt.Sprint(proofReq.MerklePath[i])
+		proofReq.MerkleHelper[i] = fmt.Sprint(proofReq.MerkleHelper[i])
+	}
+	body, err := json.Marshal(proofReq)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	post := func(accept string) *httptest.ResponseRecorder {
+		// Restarting the seeded stream makes every proof identical
+		seedRandomness(t, "protobuf-test")
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/proof/generate", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for Accept %q, got %d: %s", accept, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	rec := post("")
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected JSON by default, got %q", ct)
+	}
+	var fromJSON ProofResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("Failed to decode JSON response: %v", err)
+	}
+
+	rec = post(protoresp.MIMEType)
+	if ct := rec.Header().Get("Content-Type"); ct != protoresp.MIMEType {
+		t.Errorf("Expected %s, got %q", protoresp.MIMEType, ct)
+	}
+	var fromProto ProofResponse
+	if err := fromProto.UnmarshalProto(rec.Body.Bytes()); err != nil {
+		t.Fatalf("Failed to decode protobuf response: %v", err)
+	}
+
+	if !fromJSON.Success || fromJSON.Proof == "" {
+		t.Fatalf("Expected a successful proof, got %+v", fromJSON)
+	}
+	if !reflect.DeepEqual(fromJSON, fromProto) {
+		t.Errorf("Expected equal responses\njson:  %+v\nproto: %+v", fromJSON, fromProto)
+	}
+}
+
+// TestProofResponseMatchesSchema tests that a ProofResponse with every field set encodes
+// as the noah.v1.ProofResponse in backend/proto/noah.proto
+func TestProofResponseMatchesSchema(t *testing.T) {
+	fd := prototest.Schema(t, "../proto/noah.proto")
+	resp := ProofResponse{
+		Proof:          "proof",
+		ProofFormat:    "base64",
+		PublicInputs:   []string{"0x01", "0x02"},
+		Commitment:     "0x03",
+		CircuitVersion: "circuit",
+		Bundle:         proofbundle.New("circuit", "groth16", []byte{0x04}, []string{"0x01"}),
+		Warning:        "warning",
+		Success:        true,
+		Error:          "error",
+	}
+
+	msg := prototest.RoundTrip(t, fd, "ProofResponse", resp.MarshalProto())
+	if unset := prototest.Unset(msg); len(unset) > 0 {
+		t.Errorf("Expected every field on the wire, missing %v", unset)
+	}
+}
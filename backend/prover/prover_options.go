@@ -0,0 +1,43 @@
+package main
+
+import (
+	"runtime"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/rs/zerolog"
+)
+
+// proverOptions builds the gnark prover options from the configuration
+func proverOptions(config *Config) []backend.ProverOption {
+	var solverOpts []solver.Option
+	if !config.ProveSolverLog {
+		// Drop api.Println output from the circuit while solving
+		solverOpts = append(solverOpts, solver.WithLogger(zerolog.Nop()))
+	}
+	if len(solverOpts) == 0 {
+		return nil
+	}
+	return []backend.ProverOption{backend.WithSolverOptions(solverOpts...)}
+}
+
+// limitCPUs caps the number of CPUs executing Go code at once and returns the previous cap
+// gnark sizes its solver and multi-exponentiation worker pools from runtime.NumCPU and has
+// no per-call CPU option in this version, so GOMAXPROCS is what bounds proving parallelism
+// n <= 0 leaves the current setting unchanged
+func limitCPUs(n int) int {
+	if n <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return runtime.GOMAXPROCS(n)
+}
+
+// prove runs groth16.Prove with the configured prover options
+// gnark draws the Groth16 blinding factors from crypto/rand.Reader, which is left alone:
+// replacing it would hand the same predictable stream to every other reader in the process
+func (cm *CircuitManager) prove(ccs constraint.ConstraintSystem, pk groth16.ProvingKey, fullWitness witness.Witness) (groth16.Proof, error) {
+	return groth16.Prove(ccs, pk, fullWitness, cm.proverOpts...)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	mathrand "math/rand/v2"
+	"runtime"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+)
+
+// TestProverOptions tests that solver settings are turned into prover options
+func TestProverOptions(t *testing.T) {
+	if opts := proverOptions(&Config{ProveSolverLog: true}); len(opts) != 0 {
+		t.Errorf("Expected no options with default settings, got %d", len(opts))
+	}
+
+	opts := proverOptions(&Config{ProveSolverLog: false})
+	proverConfig, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		t.Fatalf("Failed to apply prover options: %v", err)
+	}
+	if len(proverConfig.SolverOpts) != 1 {
+		t.Errorf("Expected one solver option, got %d", len(proverConfig.SolverOpts))
+	}
+}
+
+// TestLimitCPUs tests that the CPU cap is applied and can be restored
+func TestLimitCPUs(t *testing.T) {
+	previous := limitCPUs(1)
+	defer limitCPUs(previous)
+
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("Expected GOMAXPROCS 1, got %d", got)
+	}
+	if got := limitCPUs(0); got != 1 {
+		t.Errorf("Expected 0 to leave the cap unchanged, got %d", got)
+	}
+}
+
+// seedRandomness makes proofs deterministic until the test ends by replacing
+// crypto/rand.Reader, which gnark draws the Groth16 blinding from, with a ChaCha8 stream
+// keyed by seed; calling it again restarts the stream. Tests using it must not run in parallel
+func seedRandomness(t testing.TB, seed string) {
+	t.Helper()
+	previous := rand.Reader
+	rand.Reader = mathrand.NewChaCha8(sha256.Sum256([]byte(seed)))
+	t.Cleanup(func() { rand.Reader = previous })
+}
+
+// TestSeededProvingRandomness tests that seeded randomness makes proofs of the same
+// witness byte-identical, while proofs are randomized otherwise
+func TestSeededProvingRandomness(t *testing.T) {
+	cm := newTestCircuitManager(t)
+	proofOf := func() string {
+		t.Helper()
+		resp, err := cm.GenerateProof(newTestProofRequest())
+		if err != nil {
+			t.Fatalf("Failed to generate proof: %v", err)
+		}
+		return resp.Proof
+	}
+
+	if proofOf() == proofOf() {
+		t.Error("Expected unseeded proofs to differ")
+	}
+
+	seedRandomness(t, "snapshot-seed")
+	first := proofOf()
+	seedRandomness(t, "snapshot-seed")
+	if second := proofOf(); second != first {
+		t.Error("Expected identical proofs for the same seed and witness")
+	}
+	seedRandomness(t, "other-seed")
+	if other := proofOf(); other == first {
+		t.Error("Expected a different seed to give a different proof")
+	}
+}
+
+// BenchmarkGenerateProofCPUs compares proving time on one CPU and on all CPUs
+func BenchmarkGenerateProofCPUs(b *testing.B) {
+	cm := newTestCircuitManager(b)
+	req := newTestProofRequest()
+
+	cpuCounts := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		cpuCounts = append(cpuCounts, n)
+	}
+	for _, cpus := range cpuCounts {
+		b.Run(fmt.Sprintf("cpus=%d", cpus), func(b *testing.B) {
+			previous := limitCPUs(cpus)
+			defer limitCPUs(previous)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := cm.GenerateProof(req); err != nil {
+					b.Fatalf("Failed to generate proof: %v", err)
+				}
+			}
+		})
+	}
+}
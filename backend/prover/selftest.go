@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"noah-v2/circuit"
+)
+
+// ErrSelfTestFailed is returned when the startup self-test proof cannot be generated or
+// does not verify, which means the keys do not match each other or the circuit
+var ErrSelfTestFailed = errors.New("startup self-test failed")
+
+// selfTestRequest builds the fixed witness proved by the startup self-test: an accredited
+// 30-year-old in jurisdiction 1 of a one-leaf tree, with small in-field identity data and nonce
+func selfTestRequest() (*ProofRequest, error) {
+	jurisdiction := big.NewInt(1)
+	tree, err := NewJurisdictionTree([]*big.Int{jurisdiction}, merkleDepth)
+	if err != nil {
+		return nil, err
+	}
+	path, helper, err := tree.Proof(jurisdiction)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProofRequest{
+		Age:                  BigIntString{big.NewInt(30)},
+		Jurisdiction:         BigIntString{jurisdiction},
+		IsAccredited:         BigIntString{big.NewInt(1)},
+		IdentityData:         BigIntString{big.NewInt(1001)},
+		Nonce:                BigIntString{big.NewInt(2002)},
+		MerklePath:           path,
+		MerkleHelper:         helper,
+		MinAge:               BigIntString{big.NewInt(18)},
+		JurisdictionRoot:     BigIntString{tree.Root()},
+		RequireAccreditation: BigIntString{big.NewInt(1)},
+		Commitment:           BigIntString{big.NewInt(0)},
+	}, nil
+}
+
+// SelfTest proves the fixed self-test witness and verifies the proof with the loaded
+// verifying key, returning how long both took
+func (cm *CircuitManager) SelfTest() (time.Duration, error) {
+	start := time.Now()
+
+	req, err := selfTestRequest()
+	if err != nil {
+		return time.Since(start), fmt.Errorf("%w: %w", ErrSelfTestFailed, err)
+	}
+	resp, err := cm.GenerateProof(req)
+	if err != nil {
+		return time.Since(start), fmt.Errorf("%w: proof generation: %w", ErrSelfTestFailed, err)
+	}
+	commitment, ok := new(big.Int).SetString(resp.Commitment, 16)
+	if !ok {
+		return time.Since(start), fmt.Errorf("%w: invalid commitment %q", ErrSelfTestFailed, resp.Commitment)
+	}
+
+	err = cm.VerifyEncodedProof(resp.Proof, resp.ProofFormat, &circuit.KYCCircuit{
+		MinAge:               req.MinAge.Int,
+		JurisdictionRoot:     req.JurisdictionRoot.Int,
+		RequireAccreditation: req.RequireAccreditation.Int,
+		Commitment:           commitment,
+		RelyingPartyID:       req.relyingParty(),
+	})
+	if err != nil {
+		return time.Since(start), fmt.Errorf("%w: proof does not verify with the loaded verifying key: %w", ErrSelfTestFailed, err)
+	}
+	return time.Since(start), nil
+}
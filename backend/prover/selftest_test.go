@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"noah-v2/backend/pkg/health"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// TestSelfTestDetectsMismatchedKeys tests that the self-test passes with matching keys and
+// that a verifying key from another setup fails it, and with it startup
+func TestSelfTestDetectsMismatchedKeys(t *testing.T) {
+	shared := newTestCircuitManager(t)
+	if _, err := shared.SelfTest(); err != nil {
+		t.Fatalf("Expected the self-test to pass with matching keys: %v", err)
+	}
+
+	// A second setup of the same circuit gives a valid but unrelated verifying key
+	_, otherVK, err := groth16.Setup(shared.ccs)
+	if err != nil {
+		t.Fatalf("Failed to set up keys: %v", err)
+	}
+	mismatched := *shared
+	mismatched.vk = otherVK
+	if _, err := mismatched.SelfTest(); !errors.Is(err, ErrSelfTestFailed) {
+		t.Errorf("Expected ErrSelfTestFailed with a mismatched verifying key, got %v", err)
+	}
+
+	dir := t.TempDir()
+	config := &Config{
+		ProvingKeyPath:   filepath.Join(dir, "proving.key"),
+		VerifyingKeyPath: filepath.Join(dir, "verifying.key"),
+		SelfTestOnStart:  true,
+	}
+	if err := shared.saveKeyPair(shared.ccs, shared.pk, otherVK, config.ProvingKeyPath, config.VerifyingKeyPath); err != nil {
+		t.Fatalf("Failed to write keys: %v", err)
+	}
+	api := &API{circuitManager: &CircuitManager{config: config}, readiness: health.NewReadiness()}
+	if err := api.Initialize(); !errors.Is(err, ErrSelfTestFailed) {
+		t.Errorf("Expected startup to fail the self-test, got %v", err)
+	}
+	if api.readiness.IsReady() {
+		t.Error("Expected the prover not to report ready after a failed self-test")
+	}
+}
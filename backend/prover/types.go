@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strconv"
 	"strings"
 
+	"noah-v2/backend/pkg/proofbundle"
+
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -43,11 +46,45 @@ func (b *BigIntString) UnmarshalJSON(data []byte) error {
 			b.Int = &standardBigInt
 			return nil
 		}
+		// Exponent or decimal notation, as JS clients produce for large numbers (1e18)
+		if exact, isNumber, err := parseExactInteger(str); isNumber {
+			if err != nil {
+				return err
+			}
+			b.Int = exact
+			return nil
+		}
 		return fmt.Errorf("cannot parse %q as big.Int", str)
 	}
 	return nil
 }
 
+// maxDecimalExponent bounds the exponent accepted by parseExactInteger
+const maxDecimalExponent = 100
+
+// parseExactInteger parses decimal or exponent notation such as 1e18 or 1.5e2 exactly,
+// reporting whether str was a number at all and erroring if its value is fractional
+func parseExactInteger(str string) (*big.Int, bool, error) {
+	// Field elements have at most 78 digits; refuse exponents that would expand without bound
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return nil, false, nil
+		}
+		if exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return nil, true, fmt.Errorf("cannot parse %q as big.Int: exponent out of range", str)
+		}
+	}
+	r, ok := new(big.Rat).SetString(str)
+	if !ok || strings.ContainsAny(str, "/") {
+		return nil, false, nil
+	}
+	if !r.IsInt() {
+		return nil, true, fmt.Errorf("cannot parse %q as big.Int: not an integer", str)
+	}
+	return new(big.Int).Set(r.Num()), true, nil
+}
+
 // MarshalJSON implements json.Marshaler
 func (b BigIntString) MarshalJSON() ([]byte, error) {
 	if b.Int == nil {
@@ -74,18 +111,115 @@ type ProofRequest struct {
 	JurisdictionRoot     BigIntString `json:"jurisdiction_root"`
 	RequireAccreditation BigIntString `json:"require_accreditation"`
 	Commitment           BigIntString `json:"commitment"`
+	// RelyingPartyID binds the proof to the verifier it is for, so the attester can refuse
+	// it from anyone else; omitted or 0 leaves the proof unbound
+	RelyingPartyID BigIntString `json:"relying_party_id"`
+
+	// Format selects the proof encoding in the response: "base64" (default) or "hex"
+	Format string `json:"format,omitempty"`
+
+	// Denylist optionally proves the jurisdiction is NOT in a denylist tree
+	// When set, the proof has a sixth public input: the denylist root
+	Denylist *DenylistProof `json:"denylist,omitempty"`
+
+	// Birthdate optionally proves age from a birthdate, computed in-circuit; age is then
+	// ignored, min_age is in years and the proof has a sixth public input: the reference date
+	Birthdate *BirthdateInput `json:"birthdate,omitempty"`
+
+	// Expiry optionally proves the credential has not expired; the proof then has a
+	// sixth public input: the current date
+	Expiry *ExpiryInput `json:"expiry,omitempty"`
+
+	// Attributes optionally proves over a committed attribute set: identity_data is then
+	// ignored and replaced by the root of the attribute tree, and the proof has two more
+	// public inputs: the disclosed attribute's index and value
+	Attributes *AttributeInput `json:"attributes,omitempty"`
+}
+
+// AttributeInput is a credential's attribute set and the one attribute to disclose
+type AttributeInput struct {
+	Values   []BigIntString `json:"values"`   // Private: every attribute, in tree order
+	Disclose int            `json:"disclose"` // Index of the attribute revealed as a public input
+}
+
+// BirthdateInput holds dates as days since 1970-01-01 (negative before it)
+type BirthdateInput struct {
+	BirthdateDays     BigIntString `json:"birthdate_days"`      // Private
+	ReferenceDateDays BigIntString `json:"reference_date_days"` // Public: the date the age is evaluated on
+}
+
+// ExpiryInput holds dates as days since 1970-01-01. The expiry is committed:
+// identity_data must be MiMC(credential_data, expiry_days), as the issuer computed it
+type ExpiryInput struct {
+	CredentialData BigIntString `json:"credential_data"` // Private: identity data hashed with the expiry
+	ExpiryDays     BigIntString `json:"expiry_days"`     // Private: last day the credential is valid
+	CurrentDays    BigIntString `json:"current_days"`    // Public: the date expiry is checked against
+}
+
+// DenylistProof is a non-membership proof against a sorted denylist Merkle tree:
+// Low and High are adjacent leaves with Low < jurisdiction < High
+type DenylistProof struct {
+	Root       BigIntString        `json:"root"`
+	Low        BigIntString        `json:"low"`
+	High       BigIntString        `json:"high"`
+	LowPath    []frontend.Variable `json:"low_path"`
+	LowHelper  []frontend.Variable `json:"low_helper"`
+	HighPath   []frontend.Variable `json:"high_path"`
+	HighHelper []frontend.Variable `json:"high_helper"`
+}
+
+// PublicWitness holds the KYC circuit's public inputs by their circuit field names,
+// so a serialized circuit.KYCCircuit can be sent as is (private fields are ignored)
+type PublicWitness struct {
+	MinAge               BigIntString `json:"MinAge"`
+	JurisdictionRoot     BigIntString `json:"JurisdictionRoot"`
+	RequireAccreditation BigIntString `json:"RequireAccreditation"`
+	Commitment           BigIntString `json:"Commitment"`
+	RelyingPartyID       BigIntString `json:"RelyingPartyID"`
+}
+
+// VerifyWitnessRequest is a proof to verify against an explicit public witness
+type VerifyWitnessRequest struct {
+	Proof         string        `json:"proof"`
+	Format        string        `json:"format,omitempty"` // Proof encoding: "base64" (default) or "hex"
+	PublicWitness PublicWitness `json:"public_witness"`
+	// Bundle replaces proof, format and public_witness with a proof bundle
+	Bundle *proofbundle.Bundle `json:"bundle,omitempty"`
+}
+
+// VerifyWitnessResponse reports whether the proof verified
+type VerifyWitnessResponse struct {
+	Valid   bool   `json:"valid"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
 }
 
 // ProofResponse represents the generated proof and public inputs
 type ProofResponse struct {
 	Proof        string   `json:"proof"`         // Serialized proof
+	ProofFormat  string   `json:"proof_format"`  // Encoding of Proof: "base64" or "hex"
 	PublicInputs []string `json:"public_inputs"` // Public inputs as hex strings
 	Commitment   string   `json:"commitment"`    // Commitment hash
-	Success      bool     `json:"success"`
-	Error        string   `json:"error,omitempty"`
+	// CircuitVersion identifies the circuit (and so the verifying key) the proof is for
+	CircuitVersion string `json:"circuit_version,omitempty"`
+	// Bundle holds the proof, public inputs and circuit version as one document, ready to
+	// pass to /proof/verify-witness or the attester
+	Bundle *proofbundle.Bundle `json:"bundle,omitempty"`
+	// Warning reports a non-fatal problem, e.g. a provided commitment that was replaced
+	Warning string `json:"warning,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
 }
 
 // CircuitConfig holds circuit configuration
 type CircuitConfig struct {
 	MaxJurisdictions int `json:"max_jurisdictions"`
 }
+
+// relyingParty returns the relying party ID the proof is bound to, 0 when none was sent
+func (r *ProofRequest) relyingParty() *big.Int {
+	if r.RelyingPartyID.Int == nil {
+		return big.NewInt(0)
+	}
+	return r.RelyingPartyID.Int
+}
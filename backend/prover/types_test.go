@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestBigIntStringUnmarshal tests decimal strings and exponent notation, exact or fractional
+func TestBigIntStringUnmarshal(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    string
+		wantErr bool
+	}{
+		{`"1000000000000000000"`, "1000000000000000000", false},
+		{`1e18`, "1000000000000000000", false},
+		{`"1e18"`, "1000000000000000000", false},
+		{`1.5e2`, "150", false},
+		{`1E+3`, "1000", false},
+		{`2.0`, "2", false},
+		{`1.5`, "", true},
+		{`1e-3`, "", true},
+		{`"abc"`, "", true},
+		{`"1/2"`, "", true},
+		{`1e1000000000`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var b BigIntString
+			err := json.Unmarshal([]byte(tt.json), &b)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %s", b.Int)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := b.Int.String(); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
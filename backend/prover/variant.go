@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"noah-v2/backend/pkg/keymanifest"
+	"noah-v2/backend/pkg/logger"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"go.uber.org/zap"
+)
+
+// circuitVariant holds a compiled KYC circuit variant and its keys
+// Variants are compiled lazily on the first request that needs them
+type circuitVariant struct {
+	mu          sync.Mutex
+	ccs         constraint.ConstraintSystem
+	version     string
+	pk          groth16.ProvingKey
+	vk          groth16.VerifyingKey
+	initialized bool
+}
+
+// loadVariant compiles the named variant's circuit once, loading or generating its keys
+func (cm *CircuitManager) loadVariant(v *circuitVariant, name string, c frontend.Circuit, pkPath, vkPath string) (*circuitVariant, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.initialized {
+		return v, nil
+	}
+
+	var err error
+	v.ccs, err = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile %s circuit: %w", name, err)
+	}
+	if v.version, err = circuitVersion(v.ccs); err != nil {
+		return nil, err
+	}
+
+	v.pk, v.vk, err = loadKeyPair(pkPath, vkPath)
+	if err == nil && !keysMatchCircuit(vkPath, v.version) {
+		// Keys set up for an earlier shape of the circuit would prove nothing it accepts
+		logger.Warn("Regenerating keys of a changed circuit", zap.String("variant", name), zap.String("circuit_version", v.version))
+		err = fmt.Errorf("keys do not match the %s circuit", name)
+	}
+	if err != nil {
+		// Keys don't exist or failed to load, generate new ones
+		v.pk, v.vk, err = groth16.Setup(v.ccs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup %s keys: %w", name, err)
+		}
+		if err := cm.saveKeyPair(v.ccs, v.pk, v.vk, pkPath, vkPath); err != nil {
+			return nil, fmt.Errorf("failed to save generated %s keys: %w", name, err)
+		}
+	}
+
+	v.initialized = true
+	return v, nil
+}
+
+// keysMatchCircuit reports whether the manifest next to vkPath names the given circuit
+// version; keys without a manifest are assumed to match
+func keysMatchCircuit(vkPath, version string) bool {
+	manifest, err := keymanifest.Read(keymanifest.ManifestPath(vkPath))
+	if err != nil {
+		return true
+	}
+	return manifest.CircuitHash == version
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// witnessBuffers are scratch Merkle path and helper slices for building one witness
+type witnessBuffers struct {
+	path   []frontend.Variable
+	helper []frontend.Variable
+}
+
+// witnessPool reuses witness scratch buffers across GenerateProof calls, so batch
+// proving does not allocate fresh Merkle slices per request
+type witnessPool struct {
+	depth int
+	pool  sync.Pool
+}
+
+// newWitnessPool creates a pool of buffers pre-sized for trees of the given depth
+func newWitnessPool(depth int) *witnessPool {
+	p := &witnessPool{depth: depth}
+	p.pool.New = func() any {
+		return &witnessBuffers{
+			path:   make([]frontend.Variable, depth),
+			helper: make([]frontend.Variable, depth),
+		}
+	}
+	return p
+}
+
+// get returns buffers of length depth, allocating new ones if pooled buffers are too short
+func (p *witnessPool) get(depth int) *witnessBuffers {
+	if depth > p.depth {
+		return &witnessBuffers{
+			path:   make([]frontend.Variable, depth),
+			helper: make([]frontend.Variable, depth),
+		}
+	}
+	b := p.pool.Get().(*witnessBuffers)
+	b.path, b.helper = b.path[:depth], b.helper[:depth]
+	return b
+}
+
+// put clears b, so pooled buffers hold no tree nodes, and returns it to the pool
+func (p *witnessPool) put(b *witnessBuffers) {
+	if cap(b.path) < p.depth || cap(b.helper) < p.depth {
+		return
+	}
+	b.path, b.helper = b.path[:p.depth], b.helper[:p.depth]
+	clear(b.path)
+	clear(b.helper)
+	p.pool.Put(b)
+}
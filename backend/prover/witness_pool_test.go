@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerateProofPooledWitness tests that proofs built from reused witness buffers verify
+func TestGenerateProofPooledWitness(t *testing.T) {
+	cm := newTestCircuitManager(t)
+
+	listPath := filepath.Join(t.TempDir(), "jurisdictions.json")
+	if err := os.WriteFile(listPath, []byte(`[4, 7, 1]`), 0644); err != nil {
+		t.Fatalf("Failed to write jurisdiction list: %v", err)
+	}
+	cm.config.JurisdictionListPath = listPath
+	t.Cleanup(func() { cm.config.JurisdictionListPath = "" })
+
+	// The second and third proofs reuse the buffers the earlier ones released
+	for _, jurisdiction := range []int64{1, 4, 1} {
+		req := newTestProofRequest()
+		req.MerklePath = nil
+		req.MerkleHelper = nil
+		req.JurisdictionRoot = BigIntString{}
+		req.Jurisdiction = BigIntString{big.NewInt(jurisdiction)}
+
+		resp, err := cm.GenerateProof(req)
+		if err != nil {
+			t.Fatalf("Failed to generate proof for jurisdiction %d: %v", jurisdiction, err)
+		}
+		if err := cm.VerifyEncodedProof(resp.Proof, resp.ProofFormat, publicWitnessFor(t, req, resp)); err != nil {
+			t.Errorf("Expected proof for jurisdiction %d to verify, got: %v", jurisdiction, err)
+		}
+		if req.MerklePath != nil || req.MerkleHelper != nil {
+			t.Error("Expected the pooled Merkle buffers to be detached from the request")
+		}
+	}
+
+	pool := newWitnessPool(2)
+	b := pool.get(2)
+	b.path[0], b.helper[0] = big.NewInt(5), 1
+	pool.put(b)
+	if b.path[0] != nil || b.helper[0] != nil {
+		t.Error("Expected released buffers to be cleared")
+	}
+}
+
+// TestWitnessPoolAllocations tests that pooled witness buffers allocate less than fresh ones
+func TestWitnessPoolAllocations(t *testing.T) {
+	tree, err := NewJurisdictionTree([]*big.Int{big.NewInt(1), big.NewInt(2)}, merkleDepth)
+	if err != nil {
+		t.Fatalf("Failed to build tree: %v", err)
+	}
+	code := big.NewInt(2)
+	pool := newWitnessPool(merkleDepth)
+
+	fresh := testing.AllocsPerRun(100, func() {
+		if _, _, err := tree.Proof(code); err != nil {
+			t.Fatal(err)
+		}
+	})
+	pooled := testing.AllocsPerRun(100, func() {
+		b := pool.get(merkleDepth)
+		if err := tree.ProofInto(code, b.path, b.helper); err != nil {
+			t.Fatal(err)
+		}
+		pool.put(b)
+	})
+	if pooled >= fresh {
+		t.Errorf("Expected pooled buffers to allocate less than fresh ones, got %.1f and %.1f per proof", pooled, fresh)
+	}
+}
+
+// BenchmarkWitnessBuffers compares allocations per witness path with fresh and pooled buffers
+func BenchmarkWitnessBuffers(b *testing.B) {
+	tree, err := NewJurisdictionTree([]*big.Int{big.NewInt(1), big.NewInt(2)}, merkleDepth)
+	if err != nil {
+		b.Fatalf("Failed to build tree: %v", err)
+	}
+	code := big.NewInt(2)
+
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := tree.Proof(code); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		pool := newWitnessPool(merkleDepth)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buffers := pool.get(merkleDepth)
+			if err := tree.ProofInto(code, buffers.path, buffers.helper); err != nil {
+				b.Fatal(err)
+			}
+			pool.put(buffers)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package circuit
+
+import (
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// AttestationSignatureCircuit verifies an attester's EdDSA signature over a commitment,
+// so a proof can show a commitment was attested without revealing the signature
+// Signatures are over BabyJubJub (gnark's BN254 twisted Edwards curve) with MiMC
+// hashing, as made by the attester's eddsa-babyjubjub scheme
+type AttestationSignatureCircuit struct {
+	// Private inputs (witness)
+	Signature eddsa.Signature `gnark:",secret"`
+
+	// Public inputs
+	PublicKey  eddsa.PublicKey   `gnark:",public"`
+	Commitment frontend.Variable `gnark:",public"` // Commitment hash reduced into the field
+}
+
+// Define declares the circuit constraints
+func (circuit *AttestationSignatureCircuit) Define(api frontend.API) error {
+	return VerifyAttestationSignature(api, circuit.PublicKey, circuit.Signature, circuit.Commitment)
+}
+
+// VerifyAttestationSignature constrains signature to be publicKey's EdDSA signature
+// over message, for circuits that consume attestations
+func VerifyAttestationSignature(api frontend.API, publicKey eddsa.PublicKey, signature eddsa.Signature, message frontend.Variable) error {
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BN254)
+	if err != nil {
+		return err
+	}
+	hFunc, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	return eddsa.Verify(curve, signature, message, publicKey, &hFunc)
+}
@@ -0,0 +1,47 @@
+package circuit_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttestationSignatureCircuit(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	key, err := eddsa.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	var commitment fr.Element
+	commitment.SetUint64(123456789)
+	message := commitment.Bytes()
+	sig, err := key.Sign(message[:], mimc.NewMiMC())
+	assert.NoError(t, err)
+
+	assign := func(commitment uint64, sig []byte) *circuit.AttestationSignatureCircuit {
+		assignment := &circuit.AttestationSignatureCircuit{Commitment: commitment}
+		assignment.PublicKey.Assign(tedwards.BN254, key.Public().Bytes())
+		assignment.Signature.Assign(tedwards.BN254, sig)
+		return assignment
+	}
+
+	assert.NoError(t, test.IsSolved(&circuit.AttestationSignatureCircuit{}, assign(123456789, sig), field))
+
+	// The signature does not cover another commitment
+	assert.Error(t, test.IsSolved(&circuit.AttestationSignatureCircuit{}, assign(123456790, sig), field))
+
+	// Nor does another key's signature verify against this public key
+	other, err := eddsa.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	forged, err := other.Sign(message[:], mimc.NewMiMC())
+	assert.NoError(t, err)
+	assert.Error(t, test.IsSolved(&circuit.AttestationSignatureCircuit{}, assign(123456789, forged), field))
+}
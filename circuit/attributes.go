@@ -0,0 +1,48 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// AttributeTreeDepth is the depth of a credential's attribute tree, which holds up to
+// 2^AttributeTreeDepth attributes
+const AttributeTreeDepth = 4
+
+// AttributeCircuit proves knowledge of a committed attribute set and discloses one
+// attribute from it. The set is a MiMC Merkle tree of attribute values (empty leaves are
+// zero), and the commitment is MiMC(AttributeRoot || Nonce), so the root takes the place
+// of a single IdentityData value
+type AttributeCircuit struct {
+	// Private inputs
+	AttributeRoot   frontend.Variable   `gnark:",secret"` // Root of the attribute tree
+	Nonce           frontend.Variable   `gnark:",secret"`
+	AttributePath   []frontend.Variable `gnark:",secret"`
+	AttributeHelper []frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	Commitment     frontend.Variable `gnark:",public"`
+	AttributeIndex frontend.Variable `gnark:",public"` // Position of the disclosed attribute
+	AttributeValue frontend.Variable `gnark:",public"` // Value of the disclosed attribute
+}
+
+// Define declares the circuit constraints
+func (circuit *AttributeCircuit) Define(api frontend.API) error {
+	commitment, err := CreateCommitment(api, circuit.AttributeRoot, circuit.Nonce)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(circuit.Commitment, commitment)
+
+	return AttributeCheck(api, circuit.AttributeRoot, circuit.AttributeIndex, circuit.AttributeValue,
+		circuit.AttributePath, circuit.AttributeHelper)
+}
+
+// AttributeCheck asserts value is the attribute at index in the attribute tree with the given root
+func AttributeCheck(api frontend.API, root, index, value frontend.Variable, path, helper []frontend.Variable) error {
+	leafIndex, err := verifyMembership(api, value, path, helper, root)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(leafIndex, index)
+	return nil
+}
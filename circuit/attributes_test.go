@@ -0,0 +1,104 @@
+package circuit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// attributeTree is a depth-2 attribute tree: age, jurisdiction, accreditation and a
+// document number
+type attributeTree struct {
+	values []int64
+	leaves [][]byte
+	level1 [2][]byte
+	root   fr.Element
+}
+
+func elementBytes(v int64) []byte {
+	b := new(fr.Element).SetInt64(v).Bytes()
+	return b[:]
+}
+
+func newAttributeTree() *attributeTree {
+	t := &attributeTree{values: []int64{30, 840, 1, 987654321}}
+	for _, v := range t.values {
+		t.leaves = append(t.leaves, mimcBytes(elementBytes(v)))
+	}
+	t.level1[0] = mimcBytes(t.leaves[0], t.leaves[1])
+	t.level1[1] = mimcBytes(t.leaves[2], t.leaves[3])
+	t.root.SetBytes(mimcBytes(t.level1[0], t.level1[1]))
+	return t
+}
+
+// assignment discloses the attribute at index with the given claimed value
+func (t *attributeTree) assignment(index int, value int64) *AttributeCircuit {
+	var leafSibling, nodeSibling, commitment fr.Element
+	leafSibling.SetBytes(t.leaves[index^1])
+	nodeSibling.SetBytes(t.level1[(index>>1)^1])
+	root := t.root.Bytes()
+	commitment.SetBytes(mimcBytes(root[:], elementBytes(67890)))
+
+	return &AttributeCircuit{
+		AttributeRoot:   t.root,
+		Nonce:           67890,
+		AttributePath:   []frontend.Variable{leafSibling, nodeSibling},
+		AttributeHelper: []frontend.Variable{index & 1, (index >> 1) & 1},
+		Commitment:      commitment,
+		AttributeIndex:  index,
+		AttributeValue:  value,
+	}
+}
+
+func newAttributeCircuit(depth int) *AttributeCircuit {
+	return &AttributeCircuit{
+		AttributePath:   make([]frontend.Variable, depth),
+		AttributeHelper: make([]frontend.Variable, depth),
+	}
+}
+
+func TestAttributeCircuitDisclosesOneAttribute(t *testing.T) {
+	tree := newAttributeTree()
+	field := ecc.BN254.ScalarField()
+
+	// Each attribute can be disclosed on its own
+	for i, v := range tree.values {
+		assert.NoError(t, test.IsSolved(newAttributeCircuit(2), tree.assignment(i, v), field))
+	}
+
+	// A value that is not in the set, or claimed at another position, is rejected
+	assert.Error(t, test.IsSolved(newAttributeCircuit(2), tree.assignment(1, 124), field))
+	wrongIndex := tree.assignment(1, 840)
+	wrongIndex.AttributeIndex = 0
+	assert.Error(t, test.IsSolved(newAttributeCircuit(2), wrongIndex, field))
+
+	// The attribute must come from the set the commitment binds
+	otherRoot := tree.assignment(0, 30)
+	otherRoot.AttributeRoot = big.NewInt(1)
+	assert.Error(t, test.IsSolved(newAttributeCircuit(2), otherRoot, field))
+}
+
+func TestKYCAttributeCircuitPublicInputs(t *testing.T) {
+	depth := 2
+	circuit := &KYCAttributeCircuit{
+		KYCCircuit: KYCCircuit{
+			MerklePath:   make([]frontend.Variable, depth),
+			MerkleHelper: make([]frontend.Variable, depth),
+		},
+		AttributePath:   make([]frontend.Variable, AttributeTreeDepth),
+		AttributeHelper: make([]frontend.Variable, AttributeTreeDepth),
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+	assert.NoError(t, err)
+
+	// MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID,
+	// AttributeIndex, AttributeValue (+1 constant wire)
+	assert.Equal(t, 8, ccs.GetNbPublicVariables())
+}
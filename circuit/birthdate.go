@@ -0,0 +1,154 @@
+package circuit
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Dates are days since the Unix epoch (1970-01-01), negative before it
+// Internally they are converted to March-based years (Howard Hinnant's civil_from_days),
+// where Feb 29 is the last day of the year, so a month/day has the same day-of-year
+// in every year and birthdays compare by (year, day-of-year)
+
+// epochShiftDays is the number of days from 0000-03-01 to 1970-01-01
+const epochShiftDays = 719468
+
+// daysPerEra is the length of the 400-year Gregorian cycle
+const daysPerEra = 146097
+
+// ageBits bounds the computed age below 256 years
+const ageBits = 8
+
+func init() {
+	solver.RegisterHint(divModHint)
+}
+
+// BirthdateAgeCircuit verifies that the person born on BirthdateDays is at least
+// MinAgeYears old on ReferenceDateDays without revealing the birthdate
+// A Feb 29 birthday is reached on Mar 1 in non-leap years
+type BirthdateAgeCircuit struct {
+	// Private inputs (witness)
+	BirthdateDays frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	ReferenceDateDays frontend.Variable `gnark:",public"`
+	MinAgeYears       frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit constraints
+func (circuit *BirthdateAgeCircuit) Define(api frontend.API) error {
+	age, err := AgeFromBirthdate(api, circuit.BirthdateDays, circuit.ReferenceDateDays)
+	if err != nil {
+		return err
+	}
+	api.AssertIsLessOrEqual(circuit.MinAgeYears, age)
+	return nil
+}
+
+// AgeFromBirthdate returns the age in whole years on referenceDays of someone born on
+// birthdateDays, and fails if the reference date precedes the birthdate
+func AgeFromBirthdate(api frontend.API, birthdateDays, referenceDays frontend.Variable) (frontend.Variable, error) {
+	birthKey, err := dateKey(api, birthdateDays)
+	if err != nil {
+		return nil, err
+	}
+	referenceKey, err := dateKey(api, referenceDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are year*366 + day-of-year with day-of-year in [0, 365], so the whole
+	// number of 366s between them is the year difference, less one before the birthday
+	age, _, err := divMod(api, api.Sub(referenceKey, birthKey), 366, ageBits)
+	return age, err
+}
+
+// dateKey maps days since the epoch to year*366 + day-of-year in March-based years
+func dateKey(api frontend.API, days frontend.Variable) (frontend.Variable, error) {
+	z := api.Add(days, epochShiftDays)
+	era, doe, err := divMod(api, z, daysPerEra, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	// Year of era: (doe - doe/1460 + doe/36524 - doe/146096) / 365, in [0, 399]
+	div1460, _, err := divMod(api, doe, 1460, 7)
+	if err != nil {
+		return nil, err
+	}
+	div36524, _, err := divMod(api, doe, 36524, 3)
+	if err != nil {
+		return nil, err
+	}
+	div146096, _, err := divMod(api, doe, 146096, 1)
+	if err != nil {
+		return nil, err
+	}
+	yoe, _, err := divMod(api, api.Add(api.Sub(doe, div1460), api.Sub(div36524, div146096)), 365, 9)
+	if err != nil {
+		return nil, err
+	}
+
+	// Day of year: doe - (365*yoe + yoe/4 - yoe/100), in [0, 365]
+	div4, _, err := divMod(api, yoe, 4, 7)
+	if err != nil {
+		return nil, err
+	}
+	div100, _, err := divMod(api, yoe, 100, 3)
+	if err != nil {
+		return nil, err
+	}
+	doy := api.Sub(doe, api.Sub(api.Add(api.Mul(yoe, 365), div4), div100))
+
+	year := api.Add(yoe, api.Mul(era, 400))
+	return api.Add(api.Mul(year, 366), doy), nil
+}
+
+// divMod returns q, r with a = q*divisor + r, 0 <= r < divisor and q < 2^qBits
+// The bounds make the decomposition unique, so a must be a non-negative integer
+// below divisor*2^qBits
+func divMod(api frontend.API, a frontend.Variable, divisor int64, qBits int) (frontend.Variable, frontend.Variable, error) {
+	out, err := api.Compiler().NewHint(divModHint, 2, a, divisor)
+	if err != nil {
+		return nil, nil, err
+	}
+	q, r := out[0], out[1]
+
+	api.ToBinary(q, qBits)
+	rBits := big.NewInt(divisor - 1).BitLen()
+	api.ToBinary(r, rBits)
+	api.ToBinary(api.Sub(divisor-1, r), rBits)
+	api.AssertIsEqual(a, api.Add(api.Mul(q, divisor), r))
+	return q, r, nil
+}
+
+// divModHint computes the quotient and remainder of inputs[0] by inputs[1]
+func divModHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].DivMod(inputs[0], inputs[1], outputs[1])
+	return nil
+}
+
+// BirthdateAge computes AgeFromBirthdate natively, for building witnesses
+// It is negative when the reference date precedes the birthdate
+func BirthdateAge(birthdateDays, referenceDays int64) int64 {
+	return floorDiv(nativeDateKey(referenceDays)-nativeDateKey(birthdateDays), 366)
+}
+
+func nativeDateKey(days int64) int64 {
+	z := days + epochShiftDays
+	era := floorDiv(z, daysPerEra)
+	doe := z - era*daysPerEra
+	yoe := (doe - doe/1460 + doe/36524 - doe/146096) / 365
+	doy := doe - (365*yoe + yoe/4 - yoe/100)
+	return (yoe+era*400)*366 + doy
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,87 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// days returns the days since the Unix epoch of a calendar date
+func days(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+func birthdateAssignment(birthdate, reference int64, minAge int) *BirthdateAgeCircuit {
+	return &BirthdateAgeCircuit{
+		BirthdateDays:     birthdate,
+		ReferenceDateDays: reference,
+		MinAgeYears:       minAge,
+	}
+}
+
+func TestBirthdateAgeCircuitThreshold(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	birthdate := days(2000, time.June, 15)
+
+	// Exactly 18 on the 18th birthday
+	err := test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(birthdate, days(2018, time.June, 15), 18), field)
+	assert.NoError(t, err)
+
+	// One day short of 18
+	err = test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(birthdate, days(2018, time.June, 14), 18), field)
+	assert.Error(t, err)
+}
+
+func TestBirthdateAgeCircuitEdgeDates(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+
+	// A Feb 29 birthday is reached on Mar 1 in non-leap years
+	leapling := days(2000, time.February, 29)
+	assert.Error(t, test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(leapling, days(2018, time.February, 28), 18), field))
+	assert.NoError(t, test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(leapling, days(2018, time.March, 1), 18), field))
+
+	// Birthdates before the epoch are negative day counts
+	early := days(1960, time.January, 1)
+	assert.NoError(t, test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(early, days(2024, time.January, 1), 64), field))
+	assert.Error(t, test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(early, days(2023, time.December, 31), 64), field))
+
+	// A reference date before the birthdate never proves an age, even zero
+	assert.Error(t, test.IsSolved(&BirthdateAgeCircuit{}, birthdateAssignment(days(2000, time.January, 2), days(2000, time.January, 1), 0), field))
+}
+
+func TestBirthdateAgeMatchesCalendar(t *testing.T) {
+	// Compare with calendar arithmetic over birthdates spanning leap and century years;
+	// month and day are compared directly since YearDay shifts after Feb in leap years
+	reference := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for birth := time.Date(1896, time.January, 1, 0, 0, 0, 0, time.UTC); birth.Before(reference); birth = birth.AddDate(0, 0, 37) {
+		want := reference.Year() - birth.Year()
+		if reference.Month() < birth.Month() || (reference.Month() == birth.Month() && reference.Day() < birth.Day()) {
+			want--
+		}
+		got := BirthdateAge(birth.Unix()/86400, reference.Unix()/86400)
+		if got != int64(want) {
+			t.Fatalf("Birthdate %s: expected age %d, got %d", birth.Format("2006-01-02"), want, got)
+		}
+	}
+}
+
+func TestKYCBirthdateCircuitPublicInputs(t *testing.T) {
+	depth := 2
+	circuit := &KYCBirthdateCircuit{
+		KYCCircuit: KYCCircuit{
+			MerklePath:   make([]frontend.Variable, depth),
+			MerkleHelper: make([]frontend.Variable, depth),
+		},
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+	assert.NoError(t, err)
+
+	// MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID, ReferenceDateDays (+1 constant wire)
+	assert.Equal(t, 7, ccs.GetNbPublicVariables())
+}
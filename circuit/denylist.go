@@ -0,0 +1,89 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/accumulator/merkle"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// DenylistCircuit verifies that a user's jurisdiction is NOT in a denylist
+// (e.g. sanctioned jurisdictions) without revealing the jurisdiction
+// The denylist is a Merkle tree of leaves sorted in ascending order, including
+// sentinel leaves below and above every valid code. Non-membership is proven by
+// exhibiting two adjacent leaves Low and High with Low < Jurisdiction < High.
+type DenylistCircuit struct {
+	// Private inputs
+	Jurisdiction frontend.Variable `gnark:",secret"`
+
+	// Adjacent denylist leaves surrounding the jurisdiction and their Merkle proofs
+	DenylistLow        frontend.Variable   `gnark:",secret"`
+	DenylistHigh       frontend.Variable   `gnark:",secret"`
+	DenylistLowPath    []frontend.Variable `gnark:",secret"`
+	DenylistLowHelper  []frontend.Variable `gnark:",secret"`
+	DenylistHighPath   []frontend.Variable `gnark:",secret"`
+	DenylistHighHelper []frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	DenylistRoot frontend.Variable `gnark:",public"` // Root of the sorted denylist tree
+}
+
+// Define declares the circuit constraints
+func (circuit *DenylistCircuit) Define(api frontend.API) error {
+	return DenylistCheck(api, circuit.Jurisdiction,
+		circuit.DenylistLow, circuit.DenylistLowPath, circuit.DenylistLowHelper,
+		circuit.DenylistHigh, circuit.DenylistHighPath, circuit.DenylistHighHelper,
+		circuit.DenylistRoot)
+}
+
+// DenylistCheck asserts that jurisdiction lies strictly between two adjacent leaves
+// of the sorted denylist tree with the given root, so it cannot be a leaf itself
+func DenylistCheck(api frontend.API, jurisdiction frontend.Variable,
+	low frontend.Variable, lowPath, lowHelper []frontend.Variable,
+	high frontend.Variable, highPath, highHelper []frontend.Variable,
+	root frontend.Variable) error {
+	lowIndex, err := verifyMembership(api, low, lowPath, lowHelper, root)
+	if err != nil {
+		return err
+	}
+	highIndex, err := verifyMembership(api, high, highPath, highHelper, root)
+	if err != nil {
+		return err
+	}
+
+	// The two leaves must be neighbours in the sorted tree
+	api.AssertIsEqual(highIndex, api.Add(lowIndex, 1))
+
+	// Low < Jurisdiction < High
+	api.AssertIsLessOrEqual(api.Add(low, 1), jurisdiction)
+	api.AssertIsLessOrEqual(api.Add(jurisdiction, 1), high)
+
+	return nil
+}
+
+// verifyMembership asserts leaf is in the MiMC Merkle tree with the given root
+// and returns its leaf index reconstructed from the helper bits (Little Endian)
+func verifyMembership(api frontend.API, leaf frontend.Variable, path, helper []frontend.Variable, root frontend.Variable) (frontend.Variable, error) {
+	mimcHash, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath := make([]frontend.Variable, len(path)+1)
+	fullPath[0] = leaf
+	copy(fullPath[1:], path)
+
+	leafIndex := frontend.Variable(0)
+	power := 1
+	for _, bit := range helper {
+		leafIndex = api.Add(leafIndex, api.Mul(bit, power))
+		power <<= 1
+	}
+
+	merkleProof := merkle.MerkleProof{
+		RootHash: root,
+		Path:     fullPath,
+	}
+	merkleProof.VerifyProof(api, &mimcHash, leafIndex)
+
+	return leafIndex, nil
+}
@@ -0,0 +1,135 @@
+package circuit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// denylistTree is a depth-2 sorted denylist: [0, 5, 10, 2^62]
+// 0 and 2^62 are sentinels so every allowed value has two neighbours
+type denylistTree struct {
+	leaves []*big.Int
+	level1 [2][]byte
+	root   fr.Element
+}
+
+func mimcBytes(inputs ...[]byte) []byte {
+	h := mimc.NewMiMC()
+	for _, in := range inputs {
+		h.Write(in)
+	}
+	return h.Sum(nil)
+}
+
+func newDenylistTree() *denylistTree {
+	t := &denylistTree{leaves: []*big.Int{
+		big.NewInt(0), big.NewInt(5), big.NewInt(10), new(big.Int).Lsh(big.NewInt(1), 62),
+	}}
+	hashed := make([][]byte, len(t.leaves))
+	for i, leaf := range t.leaves {
+		var e fr.Element
+		e.SetBigInt(leaf)
+		b := e.Bytes()
+		hashed[i] = mimcBytes(b[:])
+	}
+	t.level1[0] = mimcBytes(hashed[0], hashed[1])
+	t.level1[1] = mimcBytes(hashed[2], hashed[3])
+	t.root.SetBytes(mimcBytes(t.level1[0], t.level1[1]))
+	return t
+}
+
+// proof returns the sibling path and helper bits for the leaf at index
+func (t *denylistTree) proof(index int) ([]frontend.Variable, []frontend.Variable) {
+	var leafSibling fr.Element
+	sib := t.leaves[index^1]
+	var e fr.Element
+	e.SetBigInt(sib)
+	b := e.Bytes()
+	leafSibling.SetBytes(mimcBytes(b[:]))
+
+	var nodeSibling fr.Element
+	nodeSibling.SetBytes(t.level1[(index>>1)^1])
+
+	return []frontend.Variable{leafSibling, nodeSibling},
+		[]frontend.Variable{index & 1, (index >> 1) & 1}
+}
+
+func (t *denylistTree) assignment(jurisdiction, lowIndex, highIndex int) *DenylistCircuit {
+	lowPath, lowHelper := t.proof(lowIndex)
+	highPath, highHelper := t.proof(highIndex)
+	return &DenylistCircuit{
+		Jurisdiction:       jurisdiction,
+		DenylistLow:        t.leaves[lowIndex],
+		DenylistHigh:       t.leaves[highIndex],
+		DenylistLowPath:    lowPath,
+		DenylistLowHelper:  lowHelper,
+		DenylistHighPath:   highPath,
+		DenylistHighHelper: highHelper,
+		DenylistRoot:       t.root,
+	}
+}
+
+func newDenylistCircuit(depth int) *DenylistCircuit {
+	return &DenylistCircuit{
+		DenylistLowPath:    make([]frontend.Variable, depth),
+		DenylistLowHelper:  make([]frontend.Variable, depth),
+		DenylistHighPath:   make([]frontend.Variable, depth),
+		DenylistHighHelper: make([]frontend.Variable, depth),
+	}
+}
+
+func TestDenylistCircuitAcceptsValueOutsideDenylist(t *testing.T) {
+	tree := newDenylistTree()
+	field := ecc.BN254.ScalarField()
+
+	// 7 sits between leaves 5 (index 1) and 10 (index 2)
+	err := test.IsSolved(newDenylistCircuit(2), tree.assignment(7, 1, 2), field)
+	assert.NoError(t, err)
+
+	// 11 sits between 10 and the upper sentinel
+	err = test.IsSolved(newDenylistCircuit(2), tree.assignment(11, 2, 3), field)
+	assert.NoError(t, err)
+}
+
+func TestDenylistCircuitRejectsDenylistedValue(t *testing.T) {
+	tree := newDenylistTree()
+	field := ecc.BN254.ScalarField()
+
+	// 5 is itself a leaf, so no pair of neighbours strictly surrounds it
+	err := test.IsSolved(newDenylistCircuit(2), tree.assignment(5, 1, 2), field)
+	assert.Error(t, err)
+	err = test.IsSolved(newDenylistCircuit(2), tree.assignment(5, 0, 1), field)
+	assert.Error(t, err)
+
+	// Skipping over a leaf with non-adjacent neighbours is rejected
+	err = test.IsSolved(newDenylistCircuit(2), tree.assignment(5, 0, 2), field)
+	assert.Error(t, err)
+}
+
+func TestKYCDenylistCircuitPublicInputs(t *testing.T) {
+	depth := 2
+	circuit := &KYCDenylistCircuit{
+		KYCCircuit: KYCCircuit{
+			MerklePath:   make([]frontend.Variable, depth),
+			MerkleHelper: make([]frontend.Variable, depth),
+		},
+		DenylistLowPath:    make([]frontend.Variable, depth),
+		DenylistLowHelper:  make([]frontend.Variable, depth),
+		DenylistHighPath:   make([]frontend.Variable, depth),
+		DenylistHighHelper: make([]frontend.Variable, depth),
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+	assert.NoError(t, err)
+
+	// MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID, DenylistRoot (+1 constant wire)
+	assert.Equal(t, 7, ccs.GetNbPublicVariables())
+}
@@ -0,0 +1,33 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// dayBits bounds expiry and current dates below 2^32 days since the Unix epoch
+const dayBits = 32
+
+// ExpiryCircuit verifies that a credential expiring on ExpiryDays has not expired on
+// CurrentDays without revealing the expiry date
+// A credential is still valid on its expiry day
+type ExpiryCircuit struct {
+	// Private inputs (witness)
+	ExpiryDays frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	CurrentDays frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit constraints
+func (circuit *ExpiryCircuit) Define(api frontend.API) error {
+	AssertNotExpired(api, circuit.ExpiryDays, circuit.CurrentDays)
+	return nil
+}
+
+// AssertNotExpired constrains expiryDays >= currentDays, both days since the Unix epoch
+// Range-checking both keeps the comparison an ordering of dates rather than field elements
+func AssertNotExpired(api frontend.API, expiryDays, currentDays frontend.Variable) {
+	api.ToBinary(expiryDays, dayBits)
+	api.ToBinary(currentDays, dayBits)
+	api.AssertIsLessOrEqual(currentDays, expiryDays)
+}
@@ -0,0 +1,84 @@
+package circuit_test
+
+import (
+	"testing"
+	"time"
+
+	"noah-v2/circuit"
+	"noah-v2/circuit/testutil"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// epochDays returns the days since the Unix epoch of a calendar date
+func epochDays(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+func TestExpiryCircuit(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	today := epochDays(2024, time.March, 1)
+	solve := func(expiry, current int64) error {
+		return test.IsSolved(&circuit.ExpiryCircuit{}, &circuit.ExpiryCircuit{ExpiryDays: expiry, CurrentDays: current}, field)
+	}
+
+	// Expiring in the future, or today
+	assert.NoError(t, solve(epochDays(2025, time.March, 1), today))
+	assert.NoError(t, solve(today, today))
+
+	// Expired yesterday
+	assert.Error(t, solve(today-1, today))
+
+	// A date out of range cannot wrap around the field to look later
+	assert.Error(t, solve(-1, today))
+}
+
+func TestKYCExpiryCircuit(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	today := uint64(epochDays(2024, time.March, 1))
+	solve := func(assignment *circuit.KYCExpiryCircuit) error {
+		return test.IsSolved(newKYCExpiryCircuit(), assignment, field)
+	}
+	future, past := uint64(epochDays(2026, time.January, 1)), uint64(epochDays(2023, time.December, 31))
+
+	assert.NoError(t, solve(testutil.KYCExpiryAssignment(25, 18, future, today)))
+	assert.Error(t, solve(testutil.KYCExpiryAssignment(25, 18, past, today)))
+
+	// The KYC checks still apply to an unexpired credential
+	assert.Error(t, solve(testutil.KYCExpiryAssignment(17, 18, future, today)))
+}
+
+// TestKYCExpiryCircuitForgedExpiry tests that an expired credential cannot be proven
+// valid by claiming a later expiry than the one its commitment binds
+func TestKYCExpiryCircuitForgedExpiry(t *testing.T) {
+	field := ecc.BN254.ScalarField()
+	today := uint64(epochDays(2024, time.March, 1))
+	past, future := uint64(epochDays(2023, time.December, 31)), uint64(epochDays(2030, time.January, 1))
+
+	// Only the expiry witness is changed
+	forged := testutil.KYCExpiryAssignment(25, 18, past, today)
+	forged.ExpiryDays = future
+	assert.Error(t, test.IsSolved(newKYCExpiryCircuit(), forged, field))
+
+	// Rebinding IdentityData to the later date breaks the issued commitment instead
+	rebound := testutil.KYCExpiryAssignment(25, 18, future, today)
+	rebound.Commitment = testutil.KYCExpiryAssignment(25, 18, past, today).Commitment
+	assert.Error(t, test.IsSolved(newKYCExpiryCircuit(), rebound, field))
+}
+
+func TestKYCExpiryCircuitPublicInputs(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, newKYCExpiryCircuit())
+	assert.NoError(t, err)
+
+	// MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID, CurrentDays (+1 constant wire)
+	assert.Equal(t, 7, ccs.GetNbPublicVariables())
+}
+
+// newKYCExpiryCircuit returns the expiry variant sized like testutil.KYCCircuit
+func newKYCExpiryCircuit() *circuit.KYCExpiryCircuit {
+	return &circuit.KYCExpiryCircuit{KYCCircuit: *testutil.KYCCircuit()}
+}
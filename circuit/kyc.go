@@ -1,11 +1,37 @@
 package circuit
 
 import (
+	"fmt"
+
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/accumulator/merkle"
 	"github.com/consensys/gnark/std/hash/mimc"
 )
 
+// MaxAge is the largest Age the KYC circuit accepts unless compiled with another AgeLimit
+const MaxAge = 150
+
+// ageRangeBits bounds Age and MinAge before they are compared, so values near the field
+// modulus cannot wrap around the comparison
+const ageRangeBits = 16
+
+// ValidateAgeLimit returns an error unless limit is a usable KYCCircuit.AgeLimit:
+// 0 (MaxAge) or a positive age that fits the range check
+func ValidateAgeLimit(limit int) error {
+	if limit < 0 || limit >= 1<<ageRangeBits {
+		return fmt.Errorf("age limit %d out of range [0, %d)", limit, 1<<ageRangeBits)
+	}
+	return nil
+}
+
+// EffectiveAgeLimit returns the largest Age a circuit compiled with limit accepts
+func EffectiveAgeLimit(limit int) int {
+	if limit == 0 {
+		return MaxAge
+	}
+	return limit
+}
+
 // KYCCircuit is the main circuit that combines all KYC checks
 // It verifies age, jurisdiction, accreditation, and identity without revealing private data
 // Optimized: Uses Merkle Proofs for jurisdiction and direct assertions to reduce constraints
@@ -28,11 +54,27 @@ type KYCCircuit struct {
 	JurisdictionRoot     frontend.Variable `gnark:",public"` // Root of allowed jurisdictions tree
 	RequireAccreditation frontend.Variable `gnark:",public"` // 1 if accreditation required, 0 otherwise
 	Commitment           frontend.Variable `gnark:",public"`
+	// RelyingPartyID names the verifier the proof is for, so it cannot be replayed at
+	// another one; 0 leaves the proof unbound
+	RelyingPartyID frontend.Variable `gnark:",public"`
+
+	// AgeLimit is the largest Age the circuit accepts; 0 means MaxAge. It is fixed at
+	// compile time rather than a witness value, so the prover and verifier must compile
+	// with the same limit
+	AgeLimit int `gnark:"-"`
 }
 
 // Define declares the circuit constraints
 func (circuit *KYCCircuit) Define(api frontend.API) error {
 	// 1. Age Verify
+	// Range-check both ages first: each must fit in ageRangeBits and Age <= AgeLimit,
+	// so absurd ages are unprovable
+	if err := ValidateAgeLimit(circuit.AgeLimit); err != nil {
+		return err
+	}
+	api.ToBinary(circuit.Age, ageRangeBits)
+	api.ToBinary(circuit.MinAge, ageRangeBits)
+	api.AssertIsLessOrEqual(circuit.Age, EffectiveAgeLimit(circuit.AgeLimit))
 	// Constraint: Age >= MinAge
 	api.AssertIsLessOrEqual(circuit.MinAge, circuit.Age)
 
@@ -94,5 +136,14 @@ func (circuit *KYCCircuit) Define(api frontend.API) error {
 
 	api.AssertIsEqual(circuit.Commitment, computedCommitment)
 
+	// 5. Relying Party Binding
+	BindPublicInput(api, circuit.RelyingPartyID)
+
 	return nil
 }
+
+// BindPublicInput constrains a public input that no other constraint uses. Groth16 gives
+// an unconstrained input no weight in verification, so a proof would verify for any value
+func BindPublicInput(api frontend.API, v frontend.Variable) {
+	api.AssertIsEqual(api.Mul(v, v), api.Mul(v, v))
+}
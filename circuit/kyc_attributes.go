@@ -0,0 +1,34 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// KYCAttributeCircuit is the KYC circuit over a committed attribute set (see
+// AttributeCircuit): IdentityData is the root of the credential's attribute tree, and
+// one attribute is disclosed from it
+// Public inputs: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID,
+// AttributeIndex, AttributeValue
+type KYCAttributeCircuit struct {
+	KYCCircuit
+
+	// Merkle proof of the disclosed attribute (Private)
+	AttributePath   []frontend.Variable `gnark:",secret"`
+	AttributeHelper []frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	AttributeIndex frontend.Variable `gnark:",public"` // Position of the disclosed attribute
+	AttributeValue frontend.Variable `gnark:",public"` // Value of the disclosed attribute
+}
+
+// Define declares the circuit constraints
+func (circuit *KYCAttributeCircuit) Define(api frontend.API) error {
+	// 1-5. All KYC checks; the commitment binds IdentityData, here the attribute root
+	if err := circuit.KYCCircuit.Define(api); err != nil {
+		return err
+	}
+
+	// 6. The disclosed attribute is in the committed set
+	return AttributeCheck(api, circuit.IdentityData, circuit.AttributeIndex, circuit.AttributeValue,
+		circuit.AttributePath, circuit.AttributeHelper)
+}
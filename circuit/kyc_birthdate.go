@@ -0,0 +1,32 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// KYCBirthdateCircuit is the KYC circuit with Age computed in-circuit from a birthdate,
+// so clients cannot drift in how they compute age; MinAge is the minimum age in years
+// Public inputs: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID,
+// ReferenceDateDays
+type KYCBirthdateCircuit struct {
+	KYCCircuit
+
+	// Days since the Unix epoch (Private)
+	BirthdateDays frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	ReferenceDateDays frontend.Variable `gnark:",public"` // Date the age is evaluated on
+}
+
+// Define declares the circuit constraints
+func (circuit *KYCBirthdateCircuit) Define(api frontend.API) error {
+	// 0. Age is the whole years from the birthdate to the reference date
+	age, err := AgeFromBirthdate(api, circuit.BirthdateDays, circuit.ReferenceDateDays)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(circuit.Age, age)
+
+	// 1-4. All KYC checks (age, allowed jurisdiction, accreditation, commitment)
+	return circuit.KYCCircuit.Define(api)
+}
@@ -0,0 +1,37 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// KYCDenylistCircuit is the KYC circuit with an additional proof that the
+// jurisdiction is NOT in a denylist tree (see DenylistCircuit)
+// Public inputs: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID, DenylistRoot
+type KYCDenylistCircuit struct {
+	KYCCircuit
+
+	// Adjacent denylist leaves surrounding the jurisdiction (Private)
+	DenylistLow        frontend.Variable   `gnark:",secret"`
+	DenylistHigh       frontend.Variable   `gnark:",secret"`
+	DenylistLowPath    []frontend.Variable `gnark:",secret"`
+	DenylistLowHelper  []frontend.Variable `gnark:",secret"`
+	DenylistHighPath   []frontend.Variable `gnark:",secret"`
+	DenylistHighHelper []frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	DenylistRoot frontend.Variable `gnark:",public"` // Root of the sorted denylist tree
+}
+
+// Define declares the circuit constraints
+func (circuit *KYCDenylistCircuit) Define(api frontend.API) error {
+	// 1-4. All KYC checks (age, allowed jurisdiction, accreditation, commitment)
+	if err := circuit.KYCCircuit.Define(api); err != nil {
+		return err
+	}
+
+	// 5. Jurisdiction is not denylisted
+	return DenylistCheck(api, circuit.Jurisdiction,
+		circuit.DenylistLow, circuit.DenylistLowPath, circuit.DenylistLowHelper,
+		circuit.DenylistHigh, circuit.DenylistHighPath, circuit.DenylistHighHelper,
+		circuit.DenylistRoot)
+}
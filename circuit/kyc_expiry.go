@@ -0,0 +1,49 @@
+package circuit
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// KYCExpiryCircuit is the KYC circuit that also proves the credential has not expired
+// The expiry is bound into the commitment: IdentityData must equal
+// MiMC(CredentialData, ExpiryDays), so only the date the issuer committed to can be used
+// Public inputs: MinAge, JurisdictionRoot, RequireAccreditation, Commitment, RelyingPartyID,
+// CurrentDays
+type KYCExpiryCircuit struct {
+	KYCCircuit
+
+	// Identity data the issuer hashed together with the expiry into IdentityData (Private)
+	CredentialData frontend.Variable `gnark:",secret"`
+	// Days since the Unix epoch the credential expires on (Private)
+	ExpiryDays frontend.Variable `gnark:",secret"`
+
+	// Public inputs
+	CurrentDays frontend.Variable `gnark:",public"` // Date the expiry is checked against
+}
+
+// Define declares the circuit constraints
+func (circuit *KYCExpiryCircuit) Define(api frontend.API) error {
+	// 0. The expiry is the committed one, and the credential is valid on the current date
+	identity, err := ExpiryIdentityData(api, circuit.CredentialData, circuit.ExpiryDays)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(circuit.IdentityData, identity)
+	AssertNotExpired(api, circuit.ExpiryDays, circuit.CurrentDays)
+
+	// 1-4. All KYC checks (age, allowed jurisdiction, accreditation, commitment)
+	return circuit.KYCCircuit.Define(api)
+}
+
+// ExpiryIdentityData returns MiMC(credentialData, expiryDays), the IdentityData of a
+// credential whose expiry is committed
+func ExpiryIdentityData(api frontend.API, credentialData, expiryDays frontend.Variable) (frontend.Variable, error) {
+	mimcHash, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	mimcHash.Write(credentialData)
+	mimcHash.Write(expiryDays)
+	return mimcHash.Sum(), nil
+}
@@ -0,0 +1,193 @@
+// Package testutil provides a compiled KYC circuit, Groth16 keys and valid witnesses
+// for tests. Compilation and setup run once per test binary and are shared by every test
+package testutil
+
+import (
+	"sync"
+	"testing"
+
+	"noah-v2/circuit"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// MerkleDepth is the jurisdiction tree depth of the fixture circuit, the depth the
+// prover compiles with, so fixture proofs exercise the production constraint count
+const MerkleDepth = 20
+
+// Witness values of KYCAssignment
+const (
+	IdentityData   = 12345
+	Nonce          = 67890
+	RelyingPartyID = 7
+)
+
+// Keys is the compiled fixture circuit with its proving and verifying keys
+type Keys struct {
+	CCS constraint.ConstraintSystem
+	PK  groth16.ProvingKey
+	VK  groth16.VerifyingKey
+}
+
+var (
+	keysOnce sync.Once
+	keys     *Keys
+	keysErr  error
+)
+
+// KYCCircuit returns an unassigned KYC circuit of the fixture's shape, for compiling
+// or as the circuit of test.IsSolved
+func KYCCircuit() *circuit.KYCCircuit {
+	return &circuit.KYCCircuit{
+		MerklePath:   make([]frontend.Variable, MerkleDepth),
+		MerkleHelper: make([]frontend.Variable, MerkleDepth),
+	}
+}
+
+// Setup returns the fixture keys, compiling the circuit and running setup on first use
+func Setup(t testing.TB) *Keys {
+	t.Helper()
+	keysOnce.Do(func() {
+		keys, keysErr = setup()
+	})
+	if keysErr != nil {
+		t.Fatalf("Failed to set up KYC circuit: %v", keysErr)
+	}
+	return keys
+}
+
+func setup() (*Keys, error) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, KYCCircuit())
+	if err != nil {
+		return nil, err
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{CCS: ccs, PK: pk, VK: vk}, nil
+}
+
+// KYCAssignment returns a KYC witness with the given ages for jurisdiction 1, the first
+// leaf of the tree [1, 2, 3, 4] padded to MerkleDepth with empty subtrees, as the
+// prover's jurisdiction trees are. Accreditation is not required and the proof is bound
+// to RelyingPartyID; callers may change fields before proving
+func KYCAssignment(age, minAge frontend.Variable) *circuit.KYCCircuit {
+	//         Root
+	//          ...
+	//         H1234
+	//       /      \
+	//     H12      H34
+	//     / \      / \
+	//    1   2    3   4
+	leaves := make([]fr.Element, 4)
+	for i := range leaves {
+		leaves[i] = hashElements(element(uint64(i + 1)))
+	}
+	siblings := []fr.Element{leaves[1], hashElements(leaves[2], leaves[3])}
+
+	// Above the four leaves every sibling is an empty subtree: zero leaves hashed pairwise
+	var empty fr.Element
+	for level := 0; level < MerkleDepth; level++ {
+		if level >= len(siblings) {
+			siblings = append(siblings, empty)
+		}
+		empty = hashElements(empty, empty)
+	}
+
+	// Siblings of leaf 1 from the bottom up; helper bits 0 for index 0
+	path := make([]frontend.Variable, MerkleDepth)
+	helper := make([]frontend.Variable, MerkleDepth)
+	root := leaves[0]
+	for level, sibling := range siblings {
+		path[level] = sibling
+		helper[level] = 0
+		root = hashElements(root, sibling)
+	}
+
+	return &circuit.KYCCircuit{
+		Age:                  age,
+		Jurisdiction:         1,
+		IsAccredited:         0,
+		IdentityData:         IdentityData,
+		Nonce:                Nonce,
+		MerklePath:           path,
+		MerkleHelper:         helper,
+		MinAge:               minAge,
+		JurisdictionRoot:     root,
+		RequireAccreditation: 0,
+		Commitment:           Commitment(),
+		RelyingPartyID:       RelyingPartyID,
+	}
+}
+
+// KYCExpiryAssignment returns KYCAssignment extended with a committed expiry: IdentityData
+// becomes MiMC(IdentityData, expiryDays), as an issuer binding the expiry computes it,
+// and the commitment is recomputed over it
+func KYCExpiryAssignment(age, minAge frontend.Variable, expiryDays, currentDays uint64) *circuit.KYCExpiryCircuit {
+	kyc := KYCAssignment(age, minAge)
+	identity := hashElements(element(IdentityData), element(expiryDays))
+	kyc.IdentityData = identity
+	kyc.Commitment = hashElements(identity, element(Nonce))
+	return &circuit.KYCExpiryCircuit{
+		KYCCircuit:     *kyc,
+		CredentialData: IdentityData,
+		ExpiryDays:     expiryDays,
+		CurrentDays:    currentDays,
+	}
+}
+
+// Commitment returns MiMC(IdentityData, Nonce), the commitment of KYCAssignment
+func Commitment() fr.Element {
+	return hashElements(element(IdentityData), element(Nonce))
+}
+
+func element(v uint64) fr.Element {
+	var e fr.Element
+	e.SetUint64(v)
+	return e
+}
+
+// hashElements hashes field elements with MiMC, as the circuit does. It takes only
+// fr.Element so a value of another type cannot be hashed as zero by mistake
+func hashElements(values ...fr.Element) fr.Element {
+	h := mimc.NewMiMC()
+	for _, v := range values {
+		b := v.Bytes()
+		h.Write(b[:])
+	}
+	var sum fr.Element
+	sum.SetBytes(h.Sum(nil))
+	return sum
+}
+
+// Prove generates a proof of assignment with the fixture keys
+func Prove(t testing.TB, assignment *circuit.KYCCircuit) groth16.Proof {
+	t.Helper()
+	k := Setup(t)
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("Failed to create witness: %v", err)
+	}
+	proof, err := groth16.Prove(k.CCS, k.PK, witness)
+	if err != nil {
+		t.Fatalf("Failed to prove: %v", err)
+	}
+	return proof
+}
+
+// Verify checks proof against the public inputs of assignment with the fixture keys
+func Verify(t testing.TB, proof groth16.Proof, assignment *circuit.KYCCircuit) error {
+	t.Helper()
+	public, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatalf("Failed to create public witness: %v", err)
+	}
+	return groth16.Verify(proof, Setup(t).VK, public)
+}
@@ -0,0 +1,36 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSetupCached tests that setup runs once and its keys verify a fresh proof
+func TestSetupCached(t *testing.T) {
+	keys := Setup(t)
+	assert.Same(t, keys, Setup(t))
+
+	assignment := KYCAssignment(25, 18)
+	proof := Prove(t, assignment)
+	assert.NoError(t, Verify(t, proof, assignment))
+
+	// The proof does not verify for other public inputs
+	other := KYCAssignment(25, 21)
+	assert.Error(t, Verify(t, proof, other))
+}
+
+// TestKYCAssignmentDepth tests that the fixture path spans the full tree depth and that
+// every level of it is checked
+func TestKYCAssignmentDepth(t *testing.T) {
+	assignment := KYCAssignment(25, 18)
+	assert.Len(t, assignment.MerklePath, MerkleDepth)
+	assert.Len(t, assignment.MerkleHelper, MerkleDepth)
+	assert.NoError(t, test.IsSolved(KYCCircuit(), assignment, ecc.BN254.ScalarField()))
+
+	// Replacing the topmost empty-subtree sibling moves the root
+	assignment.MerklePath[MerkleDepth-1] = 0
+	assert.Error(t, test.IsSolved(KYCCircuit(), assignment, ecc.BN254.ScalarField()))
+}
@@ -1,19 +1,314 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
-// Integration tests would go here
-// These would test the full flow:
-// 1. Issue credential
-// 2. Generate proof
-// 3. Get attestation
-// 4. Register on-chain
-// 5. Verify status
+// The prover and attester are separate main modules, so they cannot be imported here
+// The test builds both binaries and runs them as child processes with generated keys,
+// exercising the real handlers and wiring over HTTP
+
+// testAttesterKey is a throwaway secp256k1 key used only by this test
+const testAttesterKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// service is a running backend binary
+type service struct {
+	baseURL string
+	cmd     *exec.Cmd
+	logs    *bytes.Buffer
+}
 
 func TestIntegrationFlow(t *testing.T) {
-	// Placeholder for integration tests
-	t.Skip("Integration tests to be implemented")
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available to build services")
+	}
+
+	dir := t.TempDir()
+	_, thisFile, _, _ := runtime.Caller(0)
+	backendDir := filepath.Join(filepath.Dir(thisFile), "..", "backend")
+
+	proverBin := buildService(t, filepath.Join(backendDir, "prover"), filepath.Join(dir, "prover"))
+	attesterBin := buildService(t, filepath.Join(backendDir, "attester"), filepath.Join(dir, "attester"))
+
+	keysDir := filepath.Join(dir, "keys")
+	listPath := filepath.Join(dir, "jurisdictions.json")
+	if err := os.WriteFile(listPath, []byte(`[840, 276, 250]`), 0644); err != nil {
+		t.Fatalf("Failed to write jurisdiction list: %v", err)
+	}
+	vkPath := filepath.Join(keysDir, "verifying.key")
+
+	// 1. Start the prover; it generates keys on first start and reports ready when done
+	prover := startService(t, proverBin, "PROVER_PORT", []string{
+		"PROVING_KEY_PATH=" + filepath.Join(keysDir, "proving.key"),
+		"VERIFYING_KEY_PATH=" + vkPath,
+		"DENYLIST_PROVING_KEY_PATH=" + filepath.Join(keysDir, "denylist_proving.key"),
+		"DENYLIST_VERIFYING_KEY_PATH=" + filepath.Join(keysDir, "denylist_verifying.key"),
+		"JURISDICTION_LIST_PATH=" + listPath,
+		"MANIFEST_SIGNING_KEY=" + testAttesterKey,
+	})
+	waitReady(t, prover, 3*time.Minute)
+
+	// 2. Start the attester against the prover's verifying key
+	attester := startService(t, attesterBin, "ATTESTER_PORT", []string{
+		"ATTESTER_PRIVATE_KEY=" + testAttesterKey,
+		"ATTESTER_ID=1",
+		"VERIFYING_KEY_PATH=" + vkPath,
+		"REQUIRE_KEY_MANIFEST=true",
+		"COMMITMENT_SCHEME=mimc",
+	})
+	waitReady(t, attester, time.Minute)
+
+	// 3. Issue a credential; the mimc scheme returns the private inputs behind its commitment
+	var issued struct {
+		Success    bool `json:"success"`
+		Credential struct {
+			Commitment  string `json:"commitment"`
+			ProofInputs struct {
+				IdentityData string `json:"identity_data"`
+				Nonce        string `json:"nonce"`
+			} `json:"proof_inputs"`
+		} `json:"credential"`
+	}
+	postJSON(t, attester, "/credential/issue", map[string]interface{}{
+		"user_id":    "integration-user",
+		"attributes": map[string]interface{}{"age": 30, "jurisdiction": 840},
+	}, http.StatusOK, &issued)
+	if !issued.Success || issued.Credential.Commitment == "" || issued.Credential.ProofInputs.IdentityData == "" {
+		t.Fatalf("Expected issued credential with commitment and proof inputs, got %+v", issued)
+	}
+
+	// 4. Generate a proof; the prover computes the commitment MiMC(identity_data, nonce)
+	// and builds the jurisdiction Merkle proof from its configured list
+	var proof struct {
+		Proof          string   `json:"proof"`
+		ProofFormat    string   `json:"proof_format"`
+		PublicInputs   []string `json:"public_inputs"`
+		Commitment     string   `json:"commitment"`
+		CircuitVersion string   `json:"circuit_version"`
+		Success        bool     `json:"success"`
+		Error          string   `json:"error"`
+	}
+	postJSON(t, prover, "/proof/generate", map[string]interface{}{
+		"age":                   "30",
+		"jurisdiction":          "840",
+		"is_accredited":         "1",
+		"identity_data":         issued.Credential.ProofInputs.IdentityData,
+		"nonce":                 issued.Credential.ProofInputs.Nonce,
+		"min_age":               "18",
+		"require_accreditation": "1",
+		"commitment":            "0",
+	}, http.StatusOK, &proof)
+	if !proof.Success || len(proof.PublicInputs) != 5 || proof.CircuitVersion == "" {
+		t.Fatalf("Expected versioned proof with 5 public inputs, got %+v", proof)
+	}
+	// Issued commitments carry a leading version byte (0x02 for mimc) before the hash
+	commitment := fmt.Sprintf("%064s", proof.Commitment)
+	versioned := issued.Credential.Commitment
+	if versioned != "02"+commitment {
+		t.Fatalf("Expected proof commitment %s to match issued commitment %s", commitment, versioned)
+	}
+
+	// 5. Submit the proof for attestation
+	var attestation struct {
+		Commitment string `json:"commitment"`
+		Signature  string `json:"signature"`
+		HashAlgo   string `json:"hash_algo"`
+		AttesterID uint   `json:"attester_id"`
+		Success    bool   `json:"success"`
+		Error      string `json:"error"`
+	}
+	postJSON(t, attester, "/credential/attest", map[string]interface{}{
+		"commitment":      versioned,
+		"proof":           proof.Proof,
+		"format":          proof.ProofFormat,
+		"public_inputs":   proof.PublicInputs,
+		"circuit_version": proof.CircuitVersion,
+		"user_id":         "integration-user",
+	}, http.StatusOK, &attestation)
+	if !attestation.Success || attestation.AttesterID != 1 {
+		t.Fatalf("Expected successful attestation from attester 1, got %+v", attestation)
+	}
+
+	// A proof for a different commitment must not be attested
+	tampered := append([]string{}, proof.PublicInputs...)
+	tampered[3] = "01"
+	var rejected struct {
+		Success bool `json:"success"`
+	}
+	postJSON(t, attester, "/credential/attest", map[string]interface{}{
+		"commitment":    commitment,
+		"proof":         proof.Proof,
+		"public_inputs": tampered,
+	}, http.StatusBadRequest, &rejected)
+	if rejected.Success {
+		t.Error("Expected attestation of tampered public inputs to fail")
+	}
+
+	// 6. Verify the returned signature against the attester's public key
+	var info struct {
+		PublicKey string `json:"public_key"`
+	}
+	getJSON(t, attester, "/info", &info)
+	if attestation.HashAlgo != "sha256" {
+		t.Fatalf("Expected default sha256 signing, got %q", attestation.HashAlgo)
+	}
+	valid, err := verifyCommitmentSignature(commitment, attestation.Signature, info.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to verify signature: %v", err)
+	}
+	if !valid {
+		t.Error("Expected attestation signature to verify against the attester public key")
+	}
+	otherCommitment := strings.Repeat("0", 63) + "1"
+	if valid, _ := verifyCommitmentSignature(otherCommitment, attestation.Signature, info.PublicKey); valid {
+		t.Error("Expected attestation signature not to verify for a different commitment")
+	}
+
+	// 7. Revoke the commitment and check the revocation root changes
+	var before, after struct {
+		Root  string `json:"root"`
+		Count int    `json:"count"`
+	}
+	getJSON(t, attester, "/revocation/root", &before)
+
+	var revoked struct {
+		Success bool `json:"success"`
+	}
+	postJSON(t, attester, "/credential/revoke", map[string]interface{}{
+		"commitment": commitment,
+		"reason":     "integration test",
+	}, http.StatusOK, &revoked)
+	if !revoked.Success {
+		t.Fatal("Expected revocation to succeed")
+	}
+
+	getJSON(t, attester, "/revocation/root", &after)
+	if after.Root == before.Root || after.Count != before.Count+1 {
+		t.Errorf("Expected revocation root to change, before %+v after %+v", before, after)
+	}
+
+	var status struct {
+		Revoked bool `json:"revoked"`
+	}
+	getJSON(t, attester, "/revocation/check?commitment="+url.QueryEscape(commitment), &status)
+	if !status.Revoked {
+		t.Error("Expected commitment to be reported as revoked")
+	}
+}
+
+// buildService compiles the main package in dir to out
+func buildService(t *testing.T, dir, out string) string {
+	t.Helper()
+	cmd := exec.Command("go", "build", "-o", out, ".")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build %s: %v\n%s", dir, err, output)
+	}
+	return out
+}
+
+// startService runs a service binary on a free port and stops it when the test ends
+func startService(t *testing.T, bin, portVar string, env []string) *service {
+	t.Helper()
+	port := freePort(t)
+
+	logs := &bytes.Buffer{}
+	cmd := exec.Command(bin)
+	cmd.Dir = filepath.Dir(bin)
+	cmd.Env = append(os.Environ(), append(env, portVar+"="+port, "LOG_LEVEL=error")...)
+	cmd.Stdout = logs
+	cmd.Stderr = logs
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start %s: %v", bin, err)
+	}
+
+	s := &service{baseURL: "http://127.0.0.1:" + port, cmd: cmd, logs: logs}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		if t.Failed() {
+			t.Logf("%s output:\n%s", filepath.Base(bin), logs.String())
+		}
+	})
+	return s
+}
+
+// waitReady polls /health/ready until the service reports ready
+func waitReady(t *testing.T, s *service, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(s.baseURL + "/health/ready")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	t.Fatalf("Service at %s not ready after %s:\n%s", s.baseURL, timeout, s.logs.String())
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer l.Close()
+	return fmt.Sprint(l.Addr().(*net.TCPAddr).Port)
+}
+
+// postJSON posts body and decodes the response into out; status 0 accepts any status
+func postJSON(t *testing.T, s *service, path string, body interface{}, status int, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+	resp, err := http.Post(s.baseURL+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	decodeResponse(t, "POST "+path, resp, status, out)
+}
+
+// getJSON fetches path and decodes a 200 response into out
+func getJSON(t *testing.T, s *service, path string, out interface{}) {
+	t.Helper()
+	resp, err := http.Get(s.baseURL + path)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	decodeResponse(t, "GET "+path, resp, http.StatusOK, out)
 }
 
+func decodeResponse(t *testing.T, name string, resp *http.Response, status int, out interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	if status != 0 && resp.StatusCode != status {
+		t.Fatalf("%s: expected status %d, got %d: %s", name, status, resp.StatusCode, strings.TrimSpace(buf.String()))
+	}
+	if err := json.Unmarshal(buf.Bytes(), out); err != nil {
+		t.Fatalf("%s: invalid JSON %q: %v", name, buf.String(), err)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// Minimal secp256k1 ECDSA verification so the integration test needs only the standard library
+
+var (
+	secpP, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secpN, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secpGx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	secpGy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+)
+
+// point is an affine curve point; nil x is the point at infinity
+type point struct{ x, y *big.Int }
+
+func (a point) add(b point) point {
+	if a.x == nil {
+		return b
+	}
+	if b.x == nil {
+		return a
+	}
+
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if new(big.Int).Add(a.y, b.y).Mod(new(big.Int).Add(a.y, b.y), secpP).Sign() == 0 {
+			return point{}
+		}
+		// lambda = 3x^2 / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	} else {
+		// lambda = (y2 - y1) / (x2 - x1)
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, secpP)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	}
+	lambda.Mod(lambda, secpP)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, secpP)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda).Sub(y, a.y).Mod(y, secpP)
+	return point{x, y}
+}
+
+func (a point) mul(k *big.Int) point {
+	result := point{}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = result.add(result)
+		if k.Bit(i) == 1 {
+			result = result.add(a)
+		}
+	}
+	return result
+}
+
+// decompress parses a 33-byte compressed public key
+func decompress(pub []byte) (point, error) {
+	if len(pub) != 33 || (pub[0] != 2 && pub[0] != 3) {
+		return point{}, fmt.Errorf("expected 33-byte compressed public key")
+	}
+	x := new(big.Int).SetBytes(pub[1:])
+	// y^2 = x^3 + 7, sqrt via y = rhs^((p+1)/4) since p = 3 mod 4
+	rhs := new(big.Int).Exp(x, big.NewInt(3), secpP)
+	rhs.Add(rhs, big.NewInt(7)).Mod(rhs, secpP)
+	exp := new(big.Int).Add(secpP, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(rhs, exp, secpP)
+	if new(big.Int).Exp(y, big.NewInt(2), secpP).Cmp(rhs) != 0 {
+		return point{}, fmt.Errorf("public key is not on the curve")
+	}
+	if y.Bit(0) != uint(pub[0]&1) {
+		y.Sub(secpP, y)
+	}
+	return point{x, y}, nil
+}
+
+// verifyCommitmentSignature checks a 64-byte r||s signature made directly over the 32-byte commitment
+func verifyCommitmentSignature(commitmentHex, signatureHex, publicKeyHex string) (bool, error) {
+	hash, err := hex.DecodeString(commitmentHex)
+	if err != nil {
+		return false, err
+	}
+	sig, err := hex.DecodeString(signatureHex)
+	if err != nil || len(sig) != 64 {
+		return false, fmt.Errorf("expected 64-byte hex signature")
+	}
+	pubBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return false, err
+	}
+	pub, err := decompress(pubBytes)
+	if err != nil {
+		return false, err
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(secpN) >= 0 || s.Cmp(secpN) >= 0 {
+		return false, nil
+	}
+
+	z := new(big.Int).SetBytes(hash)
+	w := new(big.Int).ModInverse(s, secpN)
+	u1 := new(big.Int).Mul(z, w)
+	u1.Mod(u1, secpN)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, secpN)
+
+	g := point{secpGx, secpGy}
+	R := g.mul(u1).add(pub.mul(u2))
+	if R.x == nil {
+		return false, nil
+	}
+	return new(big.Int).Mod(R.x, secpN).Cmp(r) == 0, nil
+}